@@ -15,6 +15,7 @@ import (
 
 	"github.com/0xDTC/0xGQLForge/internal/handler"
 	"github.com/0xDTC/0xGQLForge/internal/proxy"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
 	"github.com/0xDTC/0xGQLForge/internal/server"
 	"github.com/0xDTC/0xGQLForge/internal/storage"
 	"github.com/0xDTC/0xGQLForge/web"
@@ -25,6 +26,11 @@ func main() {
 	proxyAddr := flag.String("proxy", ":8888", "MITM proxy listen address")
 	dbPath := flag.String("db", "", "SQLite database path (default: ~/.gqlforge/gqlforge.db)")
 	autoProxy := flag.Bool("auto-proxy", false, "Start proxy automatically on launch")
+	reverseTarget := flag.String("reverse-target", "", "Also run a reverse proxy that forwards everything to this GraphQL URL")
+	reverseAddr := flag.String("reverse-addr", ":4000", "Reverse proxy listen address (with -reverse-target)")
+	reverseProject := flag.String("reverse-project", "", "Project ID the reverse proxy captures into")
+	retainUploads := flag.Int64("retain-uploads", 0, "Keep the raw body of GraphQL file upload requests up to this many bytes (0 keeps file metadata and hashes only)")
+	upstreamProxy := flag.String("upstream-proxy", "", "Chain outbound traffic through this proxy (http://, https://, socks5://; user:pass@ for auth)")
 	flag.Parse()
 
 	log.SetFlags(log.Ltime | log.Lshortfile)
@@ -51,9 +57,15 @@ func main() {
 	trafficRepo := storage.NewTrafficRepo(db)
 	analysisRepo := storage.NewAnalysisRepo(db)
 	projectRepo := storage.NewProjectRepo(db)
+	replayRepo := storage.NewReplayRepo(db)
+	rbacRepo := storage.NewRBACRepo(db)
+	wordlistRepo := storage.NewWordlistRepo(db)
+	injectionRepo := storage.NewInjectionRepo(db)
+	rewriteRepo := storage.NewRewriteRepo(db)
+	bundleRepo := storage.NewBundleRepo(db)
 
 	// Handlers
-	handlers := handler.NewHandlers(schemaRepo, trafficRepo, analysisRepo, projectRepo)
+	handlers := handler.NewHandlers(schemaRepo, trafficRepo, analysisRepo, projectRepo, replayRepo, rbacRepo, wordlistRepo, injectionRepo, rewriteRepo, bundleRepo)
 
 	// Certificate manager
 	certMgr, err := proxy.NewCertManager(configDir)
@@ -63,6 +75,15 @@ func main() {
 
 	// Proxy
 	p := proxy.NewProxy(*proxyAddr, certMgr, trafficRepo)
+	p.SetBundleRepo(bundleRepo)
+	p.SetRetainUploads(*retainUploads)
+	if *upstreamProxy != "" {
+		cfg := p.UpstreamConfig()
+		cfg.ProxyURL = *upstreamProxy
+		if err := p.SetUpstreamConfig(cfg); err != nil {
+			log.Fatalf("upstream proxy: %v", err)
+		}
+	}
 	handlers.SetProxyController(p)
 
 	if *autoProxy {
@@ -71,6 +92,12 @@ func main() {
 		}
 	}
 
+	if *reverseTarget != "" {
+		if _, err := p.StartListener(schema.ProxyListener{Kind: schema.ListenerReverse, Addr: *reverseAddr, Target: *reverseTarget, ProjectID: *reverseProject}); err != nil {
+			log.Fatalf("reverse proxy: %v", err)
+		}
+	}
+
 	// Server
 	srv, err := server.New(server.Config{
 		Addr:       *addr,
@@ -93,6 +120,7 @@ func main() {
 
 		fmt.Println("\nShutting down...")
 		p.Stop()
+		p.StopListeners()
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		if err := srv.Shutdown(ctx); err != nil {
 			log.Printf("shutdown error: %v", err)
@@ -111,7 +139,15 @@ func main() {
 `)
 	fmt.Printf("  Web UI:    http://localhost%s\n", *addr)
 	fmt.Printf("  Proxy:     %s\n", *proxyAddr)
+	for _, l := range p.Listeners() {
+		line := l.Addr
+		if l.Target != "" {
+			line += " -> " + l.Target
+		}
+		fmt.Printf("  %-10s %s\n", l.Kind+":", line)
+	}
 	fmt.Printf("  CA Cert:   %s\n", certMgr.CACertPath())
+	fmt.Printf("  Onboard:   http://%s (through the proxy)\n", proxy.OnboardingHost)
 	fmt.Printf("  Database:  %s\n", filepath.Join(configDir, "gqlforge.db"))
 	fmt.Println()
 
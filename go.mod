@@ -2,4 +2,9 @@ module github.com/0xDTC/0xGQLForge
 
 go 1.24.9
 
-require github.com/mattn/go-sqlite3 v1.14.34
+require (
+	github.com/mattn/go-sqlite3 v1.14.34
+	software.sslmate.com/src/go-pkcs12 v0.5.0
+)
+
+require golang.org/x/crypto v0.11.0 // indirect
@@ -0,0 +1,200 @@
+package analysis
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+)
+
+// ProbeAPQ checks how a target handles Automatic Persisted Queries: whether
+// APQ is enabled, and whether it accepts queries that were never registered
+// — with a made-up hash, with their real hash, or as plain query text. A
+// server that runs any of them is not enforcing an operation allowlist.
+// Every probe query only selects __typename under a fresh operation name, so
+// nothing is executed beyond the root type name.
+func ProbeAPQ(target Target, cfg upstream.Config) ([]schema.APQResult, error) {
+	if err := target.Validate(); err != nil {
+		return nil, err
+	}
+	client := newClient(cfg, 10*time.Second)
+
+	query := fmt.Sprintf("query GQLForgeAPQ%s { __typename }", randomHex(4))
+	sum := sha256.Sum256([]byte(query))
+	realHash := hex.EncodeToString(sum[:])
+	fakeHash := randomHex(32)
+	unknownHash := randomHex(32)
+
+	send := func(payload map[string]any) (int, string, apqReply, error) {
+		body, _ := json.Marshal(payload)
+		req, err := target.NewRequest(body)
+		if err != nil {
+			return 0, string(body), apqReply{}, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, string(body), apqReply{}, err
+		}
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return resp.StatusCode, string(body), parseAPQReply(respBody), nil
+	}
+	ext := func(hash string) map[string]any {
+		return map[string]any{"persistedQuery": map[string]any{"version": 1, "sha256Hash": hash}}
+	}
+
+	steps := []struct {
+		technique   string
+		description string
+		payload     map[string]any
+		judge       func(r apqReply) (bool, string)
+	}{
+		{
+			"apq_support",
+			"Send an unknown hash with no query text",
+			map[string]any{"extensions": ext(unknownHash)},
+			func(r apqReply) (bool, string) {
+				switch {
+				case r.has("PersistedQueryNotFound"):
+					return false, "APQ is enabled: the server asked for the query text"
+				case r.has("PersistedQueryNotSupported"):
+					return false, "APQ is disabled"
+				case r.ok:
+					return true, "the server answered an unknown hash with data"
+				}
+				return false, "no APQ-specific answer; the server may not support APQ"
+			},
+		},
+		{
+			"unregistered_made_up_hash",
+			"Send an unregistered query with a made-up hash",
+			map[string]any{"query": query, "extensions": ext(fakeHash)},
+			func(r apqReply) (bool, string) {
+				if r.ok {
+					return true, "the server ran an unregistered query without checking its hash; any query is accepted, and the made-up hash may now serve it to other clients"
+				}
+				if r.has("provided sha does not match") || r.has("hash") {
+					return false, "the server verifies hashes"
+				}
+				return false, "rejected"
+			},
+		},
+		{
+			"made_up_hash_replay",
+			"Send the made-up hash alone, after the previous step",
+			map[string]any{"extensions": ext(fakeHash)},
+			func(r apqReply) (bool, string) {
+				if r.ok {
+					return true, "the made-up hash was registered for the probe query: the persisted query cache can be poisoned"
+				}
+				return false, "the made-up hash was not registered"
+			},
+		},
+		{
+			"unregistered_real_hash",
+			"Send an unregistered query with its real SHA-256 hash",
+			map[string]any{"query": query, "extensions": ext(realHash)},
+			func(r apqReply) (bool, string) {
+				if r.ok {
+					return true, "the server registered and ran an arbitrary query: no operation allowlist is enforced"
+				}
+				return false, "rejected: registration looks restricted to an allowlist"
+			},
+		},
+		{
+			"real_hash_replay",
+			"Send the real hash alone, after the previous step",
+			map[string]any{"extensions": ext(realHash)},
+			func(r apqReply) (bool, string) {
+				if r.ok {
+					return true, "the arbitrary query stayed registered and can be run by hash"
+				}
+				return false, "the query was not kept"
+			},
+		},
+		{
+			"plain_query",
+			"Send the unregistered query as plain text without a hash",
+			map[string]any{"query": query},
+			func(r apqReply) (bool, string) {
+				if r.ok {
+					return true, "plain query text is accepted, so persisted queries are not used as an allowlist"
+				}
+				return false, "plain queries are rejected"
+			},
+		},
+	}
+
+	var results []schema.APQResult
+	for _, step := range steps {
+		status, payload, reply, err := send(step.payload)
+		result := schema.APQResult{
+			Technique:   step.technique,
+			Description: step.description,
+			Payload:     payload,
+			StatusCode:  status,
+		}
+		if err != nil {
+			result.Finding = fmt.Sprintf("request error: %v", err)
+		} else {
+			result.Vulnerable, result.Finding = step.judge(reply)
+			result.Response = truncate(reply.raw, 500)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// apqReply is a GraphQL response reduced to what the APQ probes judge on.
+type apqReply struct {
+	raw    string
+	ok     bool     // data is present and there are no errors
+	errors []string // error messages and extension codes
+}
+
+func (r apqReply) has(s string) bool {
+	s = strings.ToLower(s)
+	for _, e := range r.errors {
+		if strings.Contains(strings.ToLower(e), s) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseAPQReply(body []byte) apqReply {
+	reply := apqReply{raw: string(body)}
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message    string `json:"message"`
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return reply
+	}
+	for _, e := range resp.Errors {
+		reply.errors = append(reply.errors, e.Message, e.Extensions.Code)
+	}
+	data := strings.TrimSpace(string(resp.Data))
+	reply.ok = len(resp.Errors) == 0 && data != "" && data != "null"
+	return reply
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%0*x", n*2, time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
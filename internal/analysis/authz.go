@@ -0,0 +1,123 @@
+package analysis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Authorization outcomes of an operation replayed under some credentials.
+const (
+	OutcomeAllowed = "allowed" // data came back
+	OutcomeDenied  = "denied"  // 401/403 or an authorization error
+	OutcomeEmpty   = "empty"   // no error, but nothing was returned (e.g. not found)
+	OutcomeError   = "error"   // transport failure, server error, or unrelated GraphQL error
+)
+
+// authzErrorRe matches error messages and codes that servers use to refuse
+// access, as opposed to validation or resolver errors.
+var authzErrorRe = regexp.MustCompile(`(?i)unauthori[sz]ed|unauthenticated|forbidden|not authori[sz]ed|not allowed|permission|access denied|access is denied|insufficient (scope|privilege)|must be (logged|authenticated|signed)|login required|not logged in|invalid token|token (is )?(expired|invalid)|jwt`)
+
+// credentialHeaders identify the caller of a request.
+var credentialHeaders = []string{"Authorization", "Cookie"}
+
+// identityOf returns a short stable key for the caller identified by a set
+// of request headers. Requests without credentials share the key
+// "anonymous".
+func identityOf(headers map[string]string) string {
+	var parts []string
+	for _, name := range credentialHeaders {
+		for k, v := range headers {
+			if strings.EqualFold(k, name) && v != "" {
+				parts = append(parts, name+"="+v)
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return "anonymous"
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:4])
+}
+
+// identityOfTarget returns the identityOf key for the credentials t would
+// send.
+func identityOfTarget(t Target) string {
+	req, _ := http.NewRequest("POST", "http://identity.invalid/", nil)
+	t.Apply(req)
+	headers := make(map[string]string)
+	for _, name := range credentialHeaders {
+		if v := req.Header.Get(name); v != "" {
+			headers[name] = v
+		}
+	}
+	return identityOf(headers)
+}
+
+// withCredentials strips the captured caller's credentials from req and
+// applies creds in their place. creds.URL is ignored.
+func withCredentials(req *http.Request, creds Target) {
+	for _, name := range credentialHeaders {
+		req.Header.Del(name)
+	}
+	creds.Apply(req)
+}
+
+// classifyResponse decides whether a GraphQL response granted access.
+// evidence is a short excerpt explaining the decision.
+func classifyResponse(status int, body []byte) (outcome, evidence string) {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message    string         `json:"message"`
+			Extensions map[string]any `json:"extensions"`
+		} `json:"errors"`
+	}
+	parsed := json.Unmarshal(body, &resp) == nil
+
+	var authzMsgs, otherMsgs []string
+	for _, e := range resp.Errors {
+		code, _ := e.Extensions["code"].(string)
+		if authzErrorRe.MatchString(e.Message) || authzErrorRe.MatchString(code) {
+			authzMsgs = append(authzMsgs, e.Message)
+		} else {
+			otherMsgs = append(otherMsgs, e.Message)
+		}
+	}
+
+	hasData := parsed && dataPresent(resp.Data)
+	switch {
+	case hasData:
+		// Partial data next to an authorization error still leaked something.
+		return OutcomeAllowed, truncate(string(resp.Data), 300)
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return OutcomeDenied, truncate(string(body), 200)
+	case len(authzMsgs) > 0:
+		return OutcomeDenied, truncate(strings.Join(authzMsgs, "; "), 200)
+	case !parsed || status >= 500:
+		return OutcomeError, truncate(string(body), 200)
+	case len(otherMsgs) > 0:
+		return OutcomeError, truncate(strings.Join(otherMsgs, "; "), 200)
+	}
+	return OutcomeEmpty, truncate(string(resp.Data), 200)
+}
+
+// dataPresent reports whether a response's data object has at least one
+// root field holding something other than null or an empty list/object.
+func dataPresent(data json.RawMessage) bool {
+	var root map[string]json.RawMessage
+	if json.Unmarshal(data, &root) != nil {
+		return false
+	}
+	for _, v := range root {
+		switch strings.TrimSpace(string(v)) {
+		case "", "null", "[]", "{}":
+			continue
+		}
+		return true
+	}
+	return false
+}
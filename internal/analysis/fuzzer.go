@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
 )
 
 // DefaultFieldWordlist is a built-in wordlist for field fuzzing.
@@ -56,7 +57,7 @@ var DefaultFieldWordlist = []string{
 }
 
 // FuzzFields sends queries with wordlist field names to discover valid fields.
-func FuzzFields(targetURL string, typeName string, words []string) (schema.FuzzResult, error) {
+func FuzzFields(targetURL string, typeName string, words []string, cfg upstream.Config) (schema.FuzzResult, error) {
 	if targetURL == "" {
 		return schema.FuzzResult{}, fmt.Errorf("target URL is required")
 	}
@@ -68,7 +69,7 @@ func FuzzFields(targetURL string, typeName string, words []string) (schema.FuzzR
 		words = DefaultFieldWordlist
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := newClient(cfg, 10*time.Second)
 	result := schema.FuzzResult{
 		TypeName:     typeName,
 		TestedFields: words,
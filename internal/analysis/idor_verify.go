@@ -0,0 +1,469 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/0xDTC/0xGQLForge/internal/parser"
+	"github.com/0xDTC/0xGQLForge/internal/replay"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+)
+
+// IDOROptions configures VerifyIDOR.
+type IDOROptions struct {
+	// Traffic is the captured traffic to test, including response bodies.
+	Traffic []schema.CapturedRequest
+	// Attacker holds the second user's headers, cookies, and credentials.
+	// They replace the captured Authorization and Cookie headers; URL is
+	// ignored since every request goes to the URL it was captured from.
+	Attacker Target
+	// Client sends the requests; nil uses the default upstream client.
+	Client *http.Client
+	// IncludeMutations also replays mutations. Off by default because a
+	// swapped ID can modify another user's data for real.
+	IncludeMutations bool
+	// MaxSwaps caps how many IDs from other users' traffic are tried per
+	// variable. Zero means 3.
+	MaxSwaps int
+	// Neighbours is how far either side of a sequential integer ID to probe.
+	// Zero means 2.
+	Neighbours int
+	// MaxRequests caps the total number of requests sent. Zero means 200.
+	MaxRequests int
+}
+
+// IDORTest is one replay of a captured operation with an ID variable set to
+// TestedValue and the attacker's credentials.
+type IDORTest struct {
+	TrafficID     string `json:"trafficId"`
+	OperationName string `json:"operationName"`
+	Kind          string `json:"kind"`
+	Owner         string `json:"owner"`
+	Variable      string `json:"variable"`
+	Pattern       string `json:"pattern"`
+	OriginalValue string `json:"originalValue"`
+	TestedValue   string `json:"testedValue"`
+	Source        string `json:"source"` // "original", "other_user", or "neighbour"
+	StatusCode    int    `json:"statusCode,omitempty"`
+	Outcome       string `json:"outcome"`
+	Vulnerable    bool   `json:"vulnerable"`
+	// MatchesOriginal is set when an unmodified replay returned exactly the
+	// data the owner originally received.
+	MatchesOriginal bool   `json:"matchesOriginal,omitempty"`
+	Evidence        string `json:"evidence,omitempty"`
+}
+
+// IDORReport is the outcome of VerifyIDOR. Candidates lists only variables
+// with confirmed access, with ObservedValues holding the IDs that returned
+// data.
+type IDORReport struct {
+	Attacker         string                 `json:"attacker"`
+	Candidates       []schema.IDORCandidate `json:"candidates"`
+	Tests            []IDORTest             `json:"tests"`
+	Requests         int                    `json:"requests"`
+	SkippedMutations int                    `json:"skippedMutations,omitempty"`
+	Truncated        bool                   `json:"truncated,omitempty"`
+}
+
+// idVariable is an ID-looking value inside a request's variables.
+type idVariable struct {
+	path    []any  // map keys (string) and list indices (int) from the root
+	name    string // display path, e.g. "input.userId" or "ids[1]"
+	key     string // lowercased leaf key, used to pool values across requests
+	value   string
+	numeric bool
+	pattern string
+}
+
+type idorProbe struct {
+	value  string
+	source string
+}
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// VerifyIDOR replays captured operations whose variables contain IDs with a
+// second user's credentials. Each ID is tried as captured (when it belongs
+// to someone other than the attacker), swapped for IDs of the same variable
+// seen in other users' traffic, and, for sequential integers, swapped for
+// its neighbours. A test is vulnerable when the server returns data rather
+// than an authorization error.
+func VerifyIDOR(opts IDOROptions) (*IDORReport, error) {
+	if len(opts.Traffic) == 0 {
+		return nil, fmt.Errorf("no captured traffic to test")
+	}
+	if opts.Client == nil {
+		opts.Client = upstream.NewClient(upstream.DefaultConfig())
+	}
+	if opts.MaxSwaps <= 0 {
+		opts.MaxSwaps = 3
+	}
+	if opts.Neighbours <= 0 {
+		opts.Neighbours = 2
+	}
+	if opts.MaxRequests <= 0 {
+		opts.MaxRequests = 200
+	}
+
+	attacker := identityOfTarget(opts.Attacker)
+	report := &IDORReport{Attacker: attacker}
+
+	// Pool every ID value by variable key and by the identity that sent it.
+	type captured struct {
+		req   *schema.CapturedRequest
+		owner string
+		vars  []idVariable
+	}
+	var work []captured
+	pool := make(map[string]map[string][]string) // key → owner → values
+	var owners []string
+	seenOwner := make(map[string]bool)
+	for i := range opts.Traffic {
+		req := &opts.Traffic[i]
+		if req.Query == "" || strings.HasPrefix(req.Query, "# persisted query") {
+			continue
+		}
+		vars := collectIDVariables(req.Variables)
+		if len(vars) == 0 {
+			continue
+		}
+		owner := identityOf(req.Headers)
+		if !seenOwner[owner] {
+			seenOwner[owner] = true
+			owners = append(owners, owner)
+		}
+		for _, v := range vars {
+			if pool[v.key] == nil {
+				pool[v.key] = make(map[string][]string)
+			}
+			pool[v.key][owner] = appendUnique(pool[v.key][owner], v.value)
+		}
+		work = append(work, captured{req: req, owner: owner, vars: vars})
+	}
+
+	candidates := make(map[string]*schema.IDORCandidate)
+	var candidateOrder []string
+	tested := make(map[string]bool)
+
+	for _, w := range work {
+		kind := parser.OperationType(w.req.Query)
+		if kind == "mutation" && !opts.IncludeMutations {
+			report.SkippedMutations++
+			continue
+		}
+		opName := w.req.OperationName
+		if opName == "" {
+			opName = "anonymous"
+		}
+
+		for _, v := range w.vars {
+			for _, probe := range idorProbes(v, w.owner, attacker, owners, pool, opts) {
+				dedupeKey := w.req.Query + "\x00" + v.name + "\x00" + probe.value
+				if tested[dedupeKey] {
+					continue
+				}
+				if report.Requests >= opts.MaxRequests {
+					report.Truncated = true
+					break
+				}
+				tested[dedupeKey] = true
+
+				test := IDORTest{
+					TrafficID:     w.req.ID,
+					OperationName: opName,
+					Kind:          kind,
+					Owner:         w.owner,
+					Variable:      v.name,
+					Pattern:       v.pattern,
+					OriginalValue: v.value,
+					TestedValue:   probe.value,
+					Source:        probe.source,
+				}
+				report.Requests++
+				status, body, err := sendIDORProbe(opts, w.req, v, probe.value)
+				if err != nil {
+					test.Outcome = OutcomeError
+					test.Evidence = err.Error()
+					report.Tests = append(report.Tests, test)
+					continue
+				}
+				test.StatusCode = status
+				test.Outcome, test.Evidence = classifyResponse(status, body)
+				test.Vulnerable = test.Outcome == OutcomeAllowed
+				if test.Vulnerable && probe.source == "original" {
+					test.MatchesOriginal = sameData(body, w.req.ResponseBody)
+				}
+				report.Tests = append(report.Tests, test)
+
+				if !test.Vulnerable {
+					continue
+				}
+				ck := opName + "\x00" + v.name
+				c, ok := candidates[ck]
+				if !ok {
+					risk := "high"
+					if kind == "mutation" {
+						risk = "critical"
+					}
+					c = &schema.IDORCandidate{FieldName: opName, ArgName: v.name, Pattern: v.pattern, Risk: risk}
+					candidates[ck] = c
+					candidateOrder = append(candidateOrder, ck)
+				}
+				c.ObservedValues = appendUnique(c.ObservedValues, probe.value)
+			}
+		}
+	}
+
+	for _, ck := range candidateOrder {
+		report.Candidates = append(report.Candidates, *candidates[ck])
+	}
+	return report, nil
+}
+
+// idorProbes lists the values to try for v, in order: the captured value
+// itself when it isn't the attacker's, the same variable's values from other
+// users' traffic, then sequential neighbours.
+func idorProbes(v idVariable, owner, attacker string, owners []string, pool map[string]map[string][]string, opts IDOROptions) []idorProbe {
+	var probes []idorProbe
+	seen := map[string]bool{}
+	add := func(value, source string) {
+		if !seen[value] {
+			seen[value] = true
+			probes = append(probes, idorProbe{value: value, source: source})
+		}
+	}
+
+	if owner != attacker {
+		add(v.value, "original")
+	} else {
+		seen[v.value] = true // the attacker's own ID proves nothing
+	}
+
+	// The attacker's own IDs are never swapped in.
+	own := map[string]bool{}
+	for _, val := range pool[v.key][attacker] {
+		own[val] = true
+	}
+	swaps := 0
+	for _, o := range owners {
+		if o == attacker {
+			continue
+		}
+		for _, val := range pool[v.key][o] {
+			if swaps >= opts.MaxSwaps {
+				break
+			}
+			if own[val] || seen[val] || idValuePattern(val, v.numeric) != v.pattern {
+				continue
+			}
+			add(val, "other_user")
+			swaps++
+		}
+	}
+
+	if v.pattern == "sequential_int" {
+		if n, err := strconv.ParseInt(v.value, 10, 64); err == nil {
+			for d := int64(1); d <= int64(opts.Neighbours); d++ {
+				for _, c := range []int64{n - d, n + d} {
+					if c < 0 || own[strconv.FormatInt(c, 10)] {
+						continue
+					}
+					add(strconv.FormatInt(c, 10), "neighbour")
+				}
+			}
+		}
+	}
+	return probes
+}
+
+// sendIDORProbe replays req with v set to value and the attacker's
+// credentials, returning the status code and body.
+func sendIDORProbe(opts IDOROptions, req *schema.CapturedRequest, v idVariable, value string) (int, []byte, error) {
+	vars, err := decodeVariables(req.Variables)
+	if err != nil {
+		return 0, nil, err
+	}
+	var typed any = value
+	if v.numeric {
+		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+			typed = json.Number(value)
+		}
+	}
+	vars = setAtPath(vars, v.path, typed)
+	rawVars, err := json.Marshal(vars)
+	if err != nil {
+		return 0, nil, fmt.Errorf("encode variables: %w", err)
+	}
+
+	r := replay.FromCaptured(req)
+	r.Variables = rawVars
+	httpReq, err := r.Build()
+	if err != nil {
+		return 0, nil, err
+	}
+	withCredentials(httpReq, opts.Attacker)
+
+	resp, err := opts.Client.Do(httpReq)
+	if err != nil {
+		return 0, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20)) // 10MB limit
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("read response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+func decodeVariables(raw json.RawMessage) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("decode variables: %w", err)
+	}
+	return v, nil
+}
+
+// collectIDVariables finds ID-looking scalars in a variables object, in a
+// stable order.
+func collectIDVariables(raw json.RawMessage) []idVariable {
+	if len(raw) == 0 {
+		return nil
+	}
+	root, err := decodeVariables(raw)
+	if err != nil {
+		return nil
+	}
+	var out []idVariable
+	walkIDVariables(root, nil, "", "", false, &out)
+	return out
+}
+
+func walkIDVariables(node any, path []any, name, key string, idKey bool, out *[]idVariable) {
+	switch n := node.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childName := k
+			if name != "" {
+				childName = name + "." + k
+			}
+			walkIDVariables(n[k], appendPath(path, k), childName, strings.ToLower(k), isIDKey(k), out)
+		}
+	case []any:
+		for i, elem := range n {
+			walkIDVariables(elem, appendPath(path, i), fmt.Sprintf("%s[%d]", name, i), key, idKey, out)
+		}
+	case string:
+		if idKey && n != "" {
+			*out = append(*out, idVariable{path: path, name: name, key: key, value: n, pattern: idValuePattern(n, false)})
+		}
+	case json.Number:
+		if idKey {
+			*out = append(*out, idVariable{path: path, name: name, key: key, value: n.String(), numeric: true, pattern: idValuePattern(n.String(), true)})
+		}
+	}
+}
+
+func appendPath(path []any, elem any) []any {
+	out := make([]any, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, elem)
+}
+
+// isIDKey reports whether a variable name looks like it holds an ID.
+func isIDKey(name string) bool {
+	lower := strings.ToLower(name)
+	for _, p := range idorArgPatterns {
+		if lower == p {
+			return true
+		}
+	}
+	return lower == "ids" || lower == "uuid" ||
+		strings.HasSuffix(name, "Id") || strings.HasSuffix(name, "ID") ||
+		strings.HasSuffix(name, "Ids") || strings.HasSuffix(name, "IDs") ||
+		strings.HasSuffix(lower, "_id") || strings.HasSuffix(lower, "_ids") ||
+		strings.HasSuffix(lower, "uuid")
+}
+
+// idValuePattern classifies an ID value the same way detectIDPattern
+// classifies schema arguments, plus "encoded" for base64 global IDs such as
+// Relay's "User:42".
+func idValuePattern(value string, numeric bool) string {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return "sequential_int"
+	}
+	if numeric {
+		return "opaque_id"
+	}
+	if uuidRe.MatchString(value) {
+		return "uuid"
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(value); err == nil && len(decoded) > 2 && isPrintable(decoded) && bytes.ContainsRune(decoded, ':') {
+		return "encoded"
+	}
+	return "opaque_id"
+}
+
+func isPrintable(b []byte) bool {
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// setAtPath replaces the value at path inside a decoded JSON tree.
+func setAtPath(node any, path []any, value any) any {
+	if len(path) == 0 {
+		return value
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		if k, ok := path[0].(string); ok {
+			n[k] = setAtPath(n[k], path[1:], value)
+		}
+	case []any:
+		if i, ok := path[0].(int); ok && i < len(n) {
+			n[i] = setAtPath(n[i], path[1:], value)
+		}
+	}
+	return node
+}
+
+// sameData reports whether two GraphQL responses carry identical data.
+func sameData(a, b []byte) bool {
+	da, okA := canonicalData(a)
+	db, okB := canonicalData(b)
+	return okA && okB && da == db
+}
+
+func canonicalData(body []byte) (string, bool) {
+	var resp struct {
+		Data any `json:"data"`
+	}
+	if json.Unmarshal(body, &resp) != nil || resp.Data == nil {
+		return "", false
+	}
+	out, err := json.Marshal(resp.Data)
+	if err != nil {
+		return "", false
+	}
+	return string(out), true
+}
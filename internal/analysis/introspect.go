@@ -0,0 +1,357 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+)
+
+// introspectionShape selects which optional parts of the introspection query
+// to request. Newer fields make older servers reject the whole query, so
+// FetchIntrospection walks these from newest to oldest.
+type introspectionShape struct {
+	name                  string
+	schemaDescription     bool // __Schema.description (October 2021)
+	specifiedByURL        bool // __Type.specifiedByURL (October 2021)
+	directiveIsRepeatable bool // __Directive.isRepeatable (October 2021)
+	inputValueDeprecation bool // includeDeprecated on args/inputFields (October 2021)
+	directives            bool // __Schema.directives
+}
+
+var introspectionShapes = []introspectionShape{
+	{name: "october2021", schemaDescription: true, specifiedByURL: true, directiveIsRepeatable: true, inputValueDeprecation: true, directives: true},
+	{name: "june2018", directives: true},
+	{name: "legacy"},
+}
+
+// typeRefDepth is how many ofType levels the TypeRef fragment requests;
+// enough for wrappers such as [[[String!]!]!]!.
+const typeRefDepth = 10
+
+// IntrospectionQuery returns the complete October 2021 introspection query.
+func IntrospectionQuery() string {
+	return buildIntrospectionQuery(introspectionShapes[0])
+}
+
+func buildIntrospectionQuery(s introspectionShape) string {
+	inputDeprecated := ""
+	includeDeprecated := ""
+	if s.inputValueDeprecation {
+		inputDeprecated = "\n  isDeprecated\n  deprecationReason"
+		includeDeprecated = "(includeDeprecated: true)"
+	}
+
+	var b strings.Builder
+	b.WriteString("query IntrospectionQuery {\n  __schema {\n")
+	if s.schemaDescription {
+		b.WriteString("    description\n")
+	}
+	b.WriteString("    queryType { name }\n    mutationType { name }\n    subscriptionType { name }\n")
+	b.WriteString("    types { ...FullType }\n")
+	if s.directives {
+		b.WriteString("    directives {\n      name\n      description\n")
+		if s.directiveIsRepeatable {
+			b.WriteString("      isRepeatable\n")
+		}
+		fmt.Fprintf(&b, "      locations\n      args%s { ...InputValue }\n    }\n", includeDeprecated)
+	}
+	b.WriteString("  }\n}\n\n")
+
+	b.WriteString("fragment FullType on __Type {\n  kind\n  name\n  description\n")
+	if s.specifiedByURL {
+		b.WriteString("  specifiedByURL\n")
+	}
+	fmt.Fprintf(&b, `  fields(includeDeprecated: true) {
+    name
+    description
+    args%[1]s { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields%[1]s { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue%[2]s
+}
+
+`, includeDeprecated, inputDeprecated)
+
+	b.WriteString("fragment TypeRef on __Type {\n")
+	for i := 0; i < typeRefDepth; i++ {
+		indent := strings.Repeat("  ", i+1)
+		b.WriteString(indent + "kind\n" + indent + "name\n")
+		if i < typeRefDepth-1 {
+			b.WriteString(indent + "ofType {\n")
+		}
+	}
+	for i := typeRefDepth - 1; i > 0; i-- {
+		b.WriteString(strings.Repeat("  ", i) + "}\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// IntrospectionAttempt records one query shape tried against the target.
+type IntrospectionAttempt struct {
+	Shape      string `json:"shape"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// IntrospectionResult is the outcome of FetchIntrospection. Body holds the
+// raw response of the successful shape; Disabled is set when every shape was
+// answered without a __schema, which usually means introspection is off.
+// Technique names the bypass technique the schema was fetched with, if any.
+type IntrospectionResult struct {
+	Shape     string                 `json:"shape,omitempty"`
+	Technique string                 `json:"technique,omitempty"`
+	Body      []byte                 `json:"-"`
+	Disabled  bool                   `json:"disabled"`
+	Attempts  []IntrospectionAttempt `json:"attempts"`
+}
+
+// introspectionTransport sends the introspection query to a target. The
+// plain transport POSTs it as JSON; the others repeat a bypass technique.
+type introspectionTransport struct {
+	request func(t Target, query string) (*http.Request, error)
+	// unwrap turns the response into a plain GraphQL response; nil keeps it.
+	unwrap func(body []byte) []byte
+}
+
+var plainTransport = introspectionTransport{
+	request: func(t Target, query string) (*http.Request, error) {
+		return t.NewRequest(introspectionPayload(query))
+	},
+}
+
+// bypassTransports carry the full query the way the TryBypass technique of
+// the same name did. Techniques that only probe parts of the schema, or
+// that the plain query already covers, have none.
+var bypassTransports = map[string]introspectionTransport{
+	"get_introspection": {
+		request: func(t Target, query string) (*http.Request, error) {
+			u, err := url.Parse(t.URL)
+			if err != nil {
+				return nil, err
+			}
+			params := u.Query()
+			params.Set("query", query)
+			u.RawQuery = params.Encode()
+			req, err := http.NewRequest("GET", u.String(), nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Accept", "application/json")
+			t.Apply(req)
+			return req, nil
+		},
+	},
+	"alias_bypass": {
+		request: func(t Target, query string) (*http.Request, error) {
+			return t.NewRequest(introspectionPayload(strings.Replace(query, "__schema {", "s: __schema {", 1)))
+		},
+		unwrap: unaliasSchema,
+	},
+	"content_type_bypass": {
+		request: func(t Target, query string) (*http.Request, error) {
+			req, err := http.NewRequest("POST", t.URL, strings.NewReader(query))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", "application/graphql")
+			req.Header.Set("Accept", "application/json")
+			t.Apply(req)
+			return req, nil
+		},
+	},
+	"batch_bypass": {
+		request: func(t Target, query string) (*http.Request, error) {
+			return t.NewRequest(append(append([]byte("["), introspectionPayload(query)...), ']'))
+		},
+		unwrap: func(body []byte) []byte {
+			var batch []json.RawMessage
+			if json.Unmarshal(body, &batch) != nil || len(batch) == 0 {
+				return body
+			}
+			return batch[0]
+		},
+	},
+}
+
+func introspectionPayload(query string) []byte {
+	payload, _ := json.Marshal(map[string]string{
+		"query":         query,
+		"operationName": "IntrospectionQuery",
+	})
+	return payload
+}
+
+// unaliasSchema renames the aliased "s" field of an alias_bypass response
+// back to __schema.
+func unaliasSchema(body []byte) []byte {
+	var resp map[string]json.RawMessage
+	if json.Unmarshal(body, &resp) != nil {
+		return body
+	}
+	var data map[string]json.RawMessage
+	if json.Unmarshal(resp["data"], &data) != nil || data["s"] == nil {
+		return body
+	}
+	resp["data"], _ = json.Marshal(map[string]json.RawMessage{"__schema": data["s"]})
+	out, err := json.Marshal(resp)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// FetchIntrospection runs the introspection query against target, falling
+// back to older query shapes when the server rejects newer fields, whether
+// with GraphQL errors or with a non-GraphQL error page as some older gateways
+// and WAFs send. Transport failures are returned as errors, as are
+// non-GraphQL responses when every shape got one.
+func FetchIntrospection(target Target, cfg upstream.Config) (*IntrospectionResult, error) {
+	return fetchIntrospection(target, cfg, plainTransport)
+}
+
+// FetchIntrospectionBypass retries the full introspection query through each
+// successful bypass technique that can carry it, returning the first result
+// with a schema, or nil if none gets one.
+func FetchIntrospectionBypass(target Target, cfg upstream.Config, bypass []schema.BypassResult) *IntrospectionResult {
+	for _, b := range bypass {
+		transport, ok := bypassTransports[b.Technique]
+		if !b.Success || !ok {
+			continue
+		}
+		result, err := fetchIntrospection(target, cfg, transport)
+		if err == nil && result.Body != nil {
+			result.Technique = b.Technique
+			return result
+		}
+	}
+	return nil
+}
+
+func fetchIntrospection(target Target, cfg upstream.Config, transport introspectionTransport) (*IntrospectionResult, error) {
+	if err := target.Validate(); err != nil {
+		return nil, err
+	}
+	// Full introspection of a large schema can take a while to generate.
+	client := newClient(cfg, 30*time.Second)
+	result := &IntrospectionResult{}
+	// nonGraphQL is the error for the latest non-GraphQL response, returned
+	// only if no shape gets a GraphQL answer.
+	var nonGraphQL error
+	graphQLAnswered := false
+
+	for _, shape := range introspectionShapes {
+		attempt := IntrospectionAttempt{Shape: shape.name}
+		req, err := transport.request(target, buildIntrospectionQuery(shape))
+		if err != nil {
+			return nil, fmt.Errorf("build request: %w", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 50<<20)) // 50MB limit
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read response: %w", err)
+		}
+		attempt.StatusCode = resp.StatusCode
+		if transport.unwrap != nil {
+			body = transport.unwrap(bytes.TrimSpace(body))
+		}
+
+		var gqlResp struct {
+			Data *struct {
+				Schema json.RawMessage `json:"__schema"`
+			} `json:"data"`
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		if err := json.Unmarshal(body, &gqlResp); err != nil {
+			attempt.Error = "non-JSON response: " + truncate(string(body), 200)
+			result.Attempts = append(result.Attempts, attempt)
+			nonGraphQL = fmt.Errorf("target returned HTTP %d with a non-GraphQL response", resp.StatusCode)
+			continue
+		}
+
+		if gqlResp.Data == nil && len(gqlResp.Errors) == 0 && resp.StatusCode >= 400 {
+			attempt.Error = truncate(string(body), 200)
+			result.Attempts = append(result.Attempts, attempt)
+			nonGraphQL = fmt.Errorf("target returned HTTP %d", resp.StatusCode)
+			continue
+		}
+		graphQLAnswered = true
+
+		if gqlResp.Data != nil && len(gqlResp.Data.Schema) > 0 && string(gqlResp.Data.Schema) != "null" {
+			result.Attempts = append(result.Attempts, attempt)
+			result.Shape = shape.name
+			result.Body = body
+			return result, nil
+		}
+
+		var msgs []string
+		for _, e := range gqlResp.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		attempt.Error = strings.Join(msgs, "; ")
+		if attempt.Error == "" {
+			attempt.Error = "response contained no __schema"
+		}
+		result.Attempts = append(result.Attempts, attempt)
+
+		// An explicit refusal won't change with an older query shape.
+		if introspectionRefused(msgs) {
+			break
+		}
+	}
+
+	if !graphQLAnswered {
+		return result, nonGraphQL
+	}
+	result.Disabled = true
+	return result, nil
+}
+
+// introspectionRefused reports whether the server's errors say introspection
+// itself is blocked, as opposed to complaining about an unknown field.
+func introspectionRefused(msgs []string) bool {
+	for _, m := range msgs {
+		lower := strings.ToLower(m)
+		if !strings.Contains(lower, "introspection") {
+			continue
+		}
+		for _, hint := range []string{"disabled", "not allowed", "not permitted", "forbidden", "blocked", "is off"} {
+			if strings.Contains(lower, hint) {
+				return true
+			}
+		}
+	}
+	return false
+}
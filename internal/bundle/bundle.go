@@ -0,0 +1,275 @@
+// Package bundle extracts GraphQL documents and persisted query mappings
+// from JavaScript bundles: gql/graphql template literals and other
+// document strings, Relay operation params ({id, name, operationKind,
+// text}), Meta's *_facebookRelayOperation modules, and persisted query
+// manifests that map an ID or hash to query text.
+package bundle
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/parser"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// Operation kinds beyond the three operation types.
+const KindFragment = "fragment"
+
+// maxDocumentLen skips string literals too long to be a hand-written
+// document.
+const maxDocumentLen = 1 << 20
+
+var (
+	documentStart = regexp.MustCompile(`^\s*(query|mutation|subscription|fragment)\b`)
+	relayModule   = regexp.MustCompile(`__d\(\s*["'](\w+?)_facebookRelayOperation["'][^{]{0,200}\{\s*(?:["']use strict["'];?\s*)?\w+\.exports\s*=\s*["']([\w-]+)["']`)
+	numericID     = regexp.MustCompile(`^[0-9]{5,}$`)
+	hexHash       = regexp.MustCompile(`^[0-9a-fA-F]{32,}$`)
+)
+
+// Scan returns the operations and fragments found in src, in the order they
+// appear. Operations carry the fragments they spread, so their Text is a
+// complete document; DocID is set when the bundle maps a persisted query ID
+// to them. ID, ProjectID, Source, and FirstSeen are left to the caller.
+func Scan(src []byte) []schema.BundleOperation {
+	s := &scanner{
+		fragments: map[string]*parser.FragmentDefinition{},
+		kinds:     map[string]string{},
+	}
+	s.walk(lex(string(src)))
+	for _, m := range relayModule.FindAllStringSubmatch(string(src), -1) {
+		s.mappings = append(s.mappings, mapping{docID: m[2], name: m[1]})
+	}
+	return s.result()
+}
+
+type scanner struct {
+	docs      []*parser.OperationDefinition // operations in order of appearance
+	fragments map[string]*parser.FragmentDefinition
+	fragOrder []string
+	mappings  []mapping
+	kinds     map[string]string // operation name -> Relay operationKind
+}
+
+// mapping ties a persisted query ID to an operation name and/or text.
+type mapping struct {
+	docID string
+	name  string
+	kind  string
+	text  string
+}
+
+// walk finds document strings, and the object literals whose keys map an ID
+// to a document or name.
+func (s *scanner) walk(toks []token) {
+	var stack []map[string]string
+	for i, t := range toks {
+		switch {
+		case t.kind == tokPunct && t.text == "{":
+			stack = append(stack, map[string]string{})
+		case t.kind == tokPunct && t.text == "}":
+			if len(stack) > 0 {
+				s.object(stack[len(stack)-1])
+				stack = stack[:len(stack)-1]
+			}
+		case t.kind != tokPunct && i+2 < len(toks) && toks[i+1].text == ":" && toks[i+1].kind == tokPunct && len(stack) > 0:
+			if v := toks[i+2]; v.kind == tokString {
+				stack[len(stack)-1][t.text] = v.text
+			} else if v.kind == tokWord && (v.text == "null" || numericID.MatchString(v.text)) {
+				stack[len(stack)-1][t.text] = strings.TrimPrefix(v.text, "null")
+			}
+		}
+
+		if t.kind != tokString || !s.document(t.text) {
+			continue
+		}
+		// Manifests map an ID or hash straight to the text.
+		if i >= 2 && toks[i-1].kind == tokPunct && toks[i-1].text == ":" && toks[i-2].kind != tokPunct {
+			if key := toks[i-2].text; numericID.MatchString(key) || hexHash.MatchString(key) {
+				s.mappings = append(s.mappings, mapping{docID: key, text: t.text})
+			}
+		}
+	}
+}
+
+// object inspects a closed object literal for Relay operation params or a
+// persisted query manifest entry.
+func (s *scanner) object(obj map[string]string) {
+	id := firstOf(obj, "id", "docId", "doc_id", "queryId", "sha256Hash", "hash")
+	name := firstOf(obj, "name", "operationName")
+	kind := firstOf(obj, "operationKind", "type")
+	var text string
+	for _, key := range []string{"text", "body", "query", "document"} {
+		if v := obj[key]; isDocument(v) {
+			text = v
+			break
+		}
+	}
+	_, relay := obj["operationKind"]
+	if relay && name != "" && kind != "" {
+		s.kinds[name] = kind
+	}
+	// Relay params name the operation even when the text is stripped.
+	if id == "" || (text == "" && !(relay && name != "")) {
+		return
+	}
+	s.mappings = append(s.mappings, mapping{docID: id, name: name, kind: kind, text: text})
+}
+
+// document parses a document string and collects its definitions. It
+// reports whether text was a document.
+func (s *scanner) document(text string) bool {
+	if len(text) > maxDocumentLen || !documentStart.MatchString(text) {
+		return false
+	}
+	doc, err := parser.ParseDocument(text)
+	if err != nil {
+		return false
+	}
+	for _, def := range doc.Definitions {
+		switch d := def.(type) {
+		case *parser.OperationDefinition:
+			s.docs = append(s.docs, d)
+		case *parser.FragmentDefinition:
+			if _, ok := s.fragments[d.Name]; !ok {
+				s.fragments[d.Name] = d
+				s.fragOrder = append(s.fragOrder, d.Name)
+			}
+		}
+	}
+	return true
+}
+
+// result merges the documents and mappings into one entry per operation or
+// fragment.
+func (s *scanner) result() []schema.BundleOperation {
+	var out []schema.BundleOperation
+	byKey := map[string]int{}
+	add := func(op schema.BundleOperation) {
+		key := op.Kind + ":" + op.Name
+		if op.DocID != "" {
+			key = "id:" + op.DocID
+		} else if op.Name == "" {
+			key += ":" + op.Text
+		}
+		if i, ok := byKey[key]; ok {
+			if out[i].Text == "" {
+				out[i].Text = op.Text
+			}
+			return
+		}
+		byKey[key] = len(out)
+		out = append(out, op)
+	}
+
+	// Mapped operations first, so a document seen on its own joins the
+	// mapping for its name instead of becoming a second entry.
+	mapped := map[string]bool{} // kind:name and text of mapped operations
+	for _, m := range s.mappings {
+		op := schema.BundleOperation{DocID: m.docID, Name: m.name, Kind: m.kind}
+		if m.text != "" {
+			if doc, err := parser.ParseDocument(m.text); err == nil {
+				if def := parser.SelectOperation(doc, m.name); def != nil {
+					op.Name, op.Kind = def.Name, def.Operation
+					op.Text = s.complete(def)
+				}
+			}
+		}
+		if op.Kind == "" {
+			op.Kind = s.kinds[op.Name]
+		}
+		if op.Kind == "" {
+			op.Kind = kindFromName(op.Name)
+		}
+		if op.Name != "" {
+			mapped[op.Kind+":"+op.Name] = true
+		}
+		mapped[op.Text] = op.Text != ""
+		add(op)
+	}
+	for _, def := range s.docs {
+		text := s.complete(def)
+		if mapped[text] {
+			continue
+		}
+		if def.Name != "" && mapped[def.Operation+":"+def.Name] {
+			for i := range out {
+				if out[i].DocID != "" && out[i].Name == def.Name && out[i].Kind == def.Operation && out[i].Text == "" {
+					out[i].Text = text
+				}
+			}
+			continue
+		}
+		add(schema.BundleOperation{Kind: def.Operation, Name: def.Name, Text: text})
+	}
+	for _, name := range s.fragOrder {
+		frag := s.fragments[name]
+		add(schema.BundleOperation{
+			Kind: KindFragment,
+			Name: name,
+			Text: parser.Print(&parser.Document{Definitions: []parser.Definition{frag}}),
+		})
+	}
+	return out
+}
+
+// complete prints op followed by every fragment it spreads, directly or
+// through other fragments, that the bundle defines.
+func (s *scanner) complete(op *parser.OperationDefinition) string {
+	doc := &parser.Document{Definitions: []parser.Definition{op}}
+	seen := map[string]bool{}
+	var visit func(sels []parser.Selection)
+	visit = func(sels []parser.Selection) {
+		for _, sel := range sels {
+			switch v := sel.(type) {
+			case *parser.Field:
+				visit(v.SelectionSet)
+			case *parser.InlineFragment:
+				visit(v.SelectionSet)
+			case *parser.FragmentSpread:
+				frag, ok := s.fragments[v.Name]
+				if !ok || seen[v.Name] {
+					continue
+				}
+				seen[v.Name] = true
+				doc.Definitions = append(doc.Definitions, frag)
+				visit(frag.SelectionSet)
+			}
+		}
+	}
+	visit(op.SelectionSet)
+	return parser.Print(doc)
+}
+
+// isDocument reports whether s looks like and parses as a GraphQL
+// executable document.
+func isDocument(s string) bool {
+	if len(s) > maxDocumentLen || !documentStart.MatchString(s) {
+		return false
+	}
+	_, err := parser.ParseDocument(s)
+	return err == nil
+}
+
+// kindFromName guesses the operation type from Relay's naming convention
+// (FooQuery, FooMutation, FooSubscription).
+func kindFromName(name string) string {
+	switch {
+	case strings.HasSuffix(name, "Mutation"):
+		return "mutation"
+	case strings.HasSuffix(name, "Subscription"):
+		return "subscription"
+	case strings.HasSuffix(name, "Query"):
+		return "query"
+	}
+	return ""
+}
+
+func firstOf(obj map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := obj[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package bundle
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// found is the part of a BundleOperation the tests compare.
+type found struct {
+	Kind, Name, DocID string
+}
+
+func summarize(ops []schema.BundleOperation) []found {
+	out := make([]found, len(ops))
+	for i, op := range ops {
+		out[i] = found{op.Kind, op.Name, op.DocID}
+	}
+	return out
+}
+
+func equalFound(a, b []found) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []found
+	}{
+		{
+			name: "gql template literals with fragments",
+			src: "const F = gql`fragment UserParts on User { id name }`;\n" +
+				"const Q = gql`query Me { me { ...UserParts } } ${F}`;\n" +
+				"const M = gql`mutation Rename($n: String!) { rename(name: $n) { id } }`;",
+			want: []found{
+				{"query", "Me", ""},
+				{"mutation", "Rename", ""},
+				{KindFragment, "UserParts", ""},
+			},
+		},
+		{
+			name: "regex and comments are not strings",
+			src: "var r = /`query X { a }`/g; // 'query Y { b }'\n" +
+				"/* \"query Z { c }\" */ var q = 'query Real { d }';",
+			want: []found{{"query", "Real", ""}},
+		},
+		{
+			name: "Relay operation params",
+			src:  `var params = {"id":"1234567890","metadata":{},"name":"ProfileQuery","operationKind":"query","text":null};`,
+			want: []found{{"query", "ProfileQuery", "1234567890"}},
+		},
+		{
+			name: "Meta relay operation module",
+			src:  `__d("CometProfileQuery_facebookRelayOperation",[],(function(a,b,c,d,e,f){e.exports="987654321012"}),null);`,
+			want: []found{{"query", "CometProfileQuery", "987654321012"}},
+		},
+		{
+			name: "persisted query manifest",
+			src: `const manifest = {"5d41402abc4b2a76b9719d911017c592": "query Feed { feed { id } }",` +
+				` "7d793037a0760186574b0282f2f435e7": "mutation Like($id: ID!) { like(id: $id) }"};`,
+			want: []found{
+				{"query", "Feed", "5d41402abc4b2a76b9719d911017c592"},
+				{"mutation", "Like", "7d793037a0760186574b0282f2f435e7"},
+			},
+		},
+		{
+			name: "document joins its mapping",
+			src: `var p = {id: "55555", name: "SearchQuery", operationKind: "query", text: null};` +
+				"\nvar d = `query SearchQuery($q: String) { search(q: $q) { id } }`;",
+			want: []found{{"query", "SearchQuery", "55555"}},
+		},
+		{
+			name: "strings that aren't documents",
+			src:  `var a = "query"; var b = "query string too"; var c = "mutation {";`,
+			want: []found{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarize(Scan([]byte(tt.src)))
+			if !equalFound(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanCompletesDocuments(t *testing.T) {
+	src := "gql`fragment A on User { id ...B }`; gql`fragment B on User { name }`;" +
+		"gql`query Q { me { ...A } }`;"
+	ops := Scan([]byte(src))
+	if len(ops) == 0 || ops[0].Name != "Q" {
+		t.Fatalf("got %+v", ops)
+	}
+	for _, frag := range []string{"fragment A on User", "fragment B on User"} {
+		if !strings.Contains(ops[0].Text, frag) {
+			t.Errorf("text lacks %q:\n%s", frag, ops[0].Text)
+		}
+	}
+}
+
+func TestScanKeepsMappedText(t *testing.T) {
+	src := `var p = {id: "55555", name: "SearchQuery", operationKind: "query", text: null};` +
+		"\nvar d = `query SearchQuery { search { id } }`;"
+	ops := Scan([]byte(src))
+	if len(ops) != 1 || !strings.Contains(ops[0].Text, "search") {
+		t.Errorf("got %+v", ops)
+	}
+}
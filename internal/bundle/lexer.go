@@ -0,0 +1,229 @@
+package bundle
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Token kinds produced by the lexer.
+const (
+	tokString = iota // string or template literal, unescaped
+	tokWord          // identifier, keyword, or number
+	tokPunct         // any other single character
+)
+
+type token struct {
+	kind int
+	text string
+}
+
+// lex splits JavaScript (or JSON) source into string literals, words, and
+// punctuation. Comments and regex literals are skipped. It is tolerant
+// rather than correct: it only needs to find string literals and the
+// object keys around them in minified bundles.
+func lex(src string) []token {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return toks
+			}
+			i += end + 4
+		case c == '/' && regexAllowed(toks):
+			i = skipRegex(src, i)
+		case c == '"' || c == '\'':
+			s, next := lexQuoted(src, i)
+			toks = append(toks, token{tokString, s})
+			i = next
+		case c == '`':
+			s, next := lexTemplate(src, i)
+			toks = append(toks, token{tokString, s})
+			i = next
+		case isWordByte(c):
+			start := i
+			for i < len(src) && isWordByte(src[i]) {
+				i++
+			}
+			toks = append(toks, token{tokWord, src[start:i]})
+		default:
+			toks = append(toks, token{tokPunct, src[i : i+1]})
+			i++
+		}
+	}
+	return toks
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// regexAllowed reports whether a '/' after toks starts a regex literal
+// rather than a division.
+func regexAllowed(toks []token) bool {
+	if len(toks) == 0 {
+		return true
+	}
+	prev := toks[len(toks)-1]
+	switch prev.kind {
+	case tokPunct:
+		return prev.text != ")" && prev.text != "]" && prev.text != "}"
+	case tokWord:
+		switch prev.text {
+		case "return", "typeof", "case", "do", "else", "in", "of", "new", "delete", "void", "throw", "instanceof", "yield", "await":
+			return true
+		}
+	}
+	return false
+}
+
+// skipRegex returns the index just past the regex literal starting at i.
+func skipRegex(src string, i int) int {
+	inClass := false
+	for i++; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '\n':
+			return i
+		case '/':
+			if !inClass {
+				i++
+				for i < len(src) && isWordByte(src[i]) {
+					i++
+				}
+				return i
+			}
+		}
+	}
+	return i
+}
+
+// lexQuoted reads the '...' or "..." literal starting at i.
+func lexQuoted(src string, i int) (string, int) {
+	quote := src[i]
+	var b strings.Builder
+	for i++; i < len(src); i++ {
+		c := src[i]
+		switch c {
+		case quote:
+			return b.String(), i + 1
+		case '\\':
+			i = unescape(&b, src, i)
+		case '\n':
+			return b.String(), i
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), i
+}
+
+// lexTemplate reads the `...` literal starting at i. Each ${...}
+// substitution is replaced by a space: in gql-tagged documents they hold
+// fragment definitions, which are found on their own.
+func lexTemplate(src string, i int) (string, int) {
+	var b strings.Builder
+	for i++; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '`':
+			return b.String(), i + 1
+		case c == '\\':
+			i = unescape(&b, src, i)
+		case c == '$' && i+1 < len(src) && src[i+1] == '{':
+			i = skipSubstitution(src, i+2) - 1
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), i
+}
+
+// skipSubstitution returns the index just past the '}' closing a template
+// substitution whose body starts at i.
+func skipSubstitution(src string, i int) int {
+	depth := 1
+	for i < len(src) {
+		switch src[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case '"', '\'':
+			_, i = lexQuoted(src, i)
+			continue
+		case '`':
+			_, i = lexTemplate(src, i)
+			continue
+		}
+		i++
+	}
+	return i
+}
+
+// unescape writes the escape sequence at src[i] (a backslash) to b and
+// returns the index of its last byte.
+func unescape(b *strings.Builder, src string, i int) int {
+	if i+1 >= len(src) {
+		return i
+	}
+	i++
+	switch c := src[i]; c {
+	case 'n':
+		b.WriteByte('\n')
+	case 't':
+		b.WriteByte('\t')
+	case 'r':
+		b.WriteByte('\r')
+	case 'b', 'f', 'v', '0':
+		b.WriteByte(' ')
+	case '\n':
+		// line continuation
+	case 'x':
+		if i+2 < len(src) {
+			if n, err := strconv.ParseUint(src[i+1:i+3], 16, 8); err == nil {
+				b.WriteRune(rune(n))
+				return i + 2
+			}
+		}
+		b.WriteByte(c)
+	case 'u':
+		if i+1 < len(src) && src[i+1] == '{' {
+			if end := strings.IndexByte(src[i:], '}'); end > 0 {
+				if n, err := strconv.ParseUint(src[i+2:i+end], 16, 32); err == nil && utf8.ValidRune(rune(n)) {
+					b.WriteRune(rune(n))
+					return i + end
+				}
+			}
+		} else if i+4 < len(src) {
+			if n, err := strconv.ParseUint(src[i+1:i+5], 16, 16); err == nil {
+				b.WriteRune(rune(n))
+				return i + 4
+			}
+		}
+		b.WriteByte(c)
+	default:
+		b.WriteByte(c)
+	}
+	return i
+}
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/0xDTC/0xGQLForge/internal/analysis"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
 )
 
 // AnalysisView renders the security analysis page.
@@ -89,13 +92,14 @@ func (h *Handlers) FuzzFields(w http.ResponseWriter, r *http.Request) {
 		TargetURL string   `json:"targetUrl"`
 		TypeName  string   `json:"typeName"`
 		Words     []string `json:"words"`
+		ProjectID string   `json:"projectId"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		jsonErr(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	result, err := analysis.FuzzFields(req.TargetURL, req.TypeName, req.Words)
+	result, err := analysis.FuzzFields(req.TargetURL, req.TypeName, req.Words, h.upstreamConfig(req.ProjectID))
 	if err != nil {
 		jsonErr(w, http.StatusBadRequest, err.Error())
 		return
@@ -106,14 +110,23 @@ func (h *Handlers) FuzzFields(w http.ResponseWriter, r *http.Request) {
 // BypassIntrospection handles POST /api/bypass — tries introspection bypass techniques.
 func (h *Handlers) BypassIntrospection(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		TargetURL string `json:"targetUrl"`
+		TargetURL string            `json:"targetUrl"`
+		Headers   map[string]string `json:"headers"`
+		Cookies   map[string]string `json:"cookies"`
+		Auth      *analysis.Auth    `json:"auth"`
+		ProjectID string            `json:"projectId"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		jsonErr(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	results, err := analysis.TryBypass(req.TargetURL)
+	results, err := analysis.TryBypass(analysis.Target{
+		URL:     req.TargetURL,
+		Headers: req.Headers,
+		Cookies: req.Cookies,
+		Auth:    req.Auth,
+	}, h.upstreamConfig(req.ProjectID))
 	if err != nil {
 		jsonErr(w, http.StatusBadRequest, err.Error())
 		return
@@ -121,3 +134,199 @@ func (h *Handlers) BypassIntrospection(w http.ResponseWriter, r *http.Request) {
 	jsonResp(w, http.StatusOK, results)
 }
 
+// APQProbe handles POST /api/apq — checks whether the target runs
+// unregistered queries sent with a made-up or real persisted query hash.
+func (h *Handlers) APQProbe(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TargetURL string            `json:"targetUrl"`
+		Headers   map[string]string `json:"headers"`
+		Cookies   map[string]string `json:"cookies"`
+		Auth      *analysis.Auth    `json:"auth"`
+		ProjectID string            `json:"projectId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, err := analysis.ProbeAPQ(analysis.Target{
+		URL:     req.TargetURL,
+		Headers: req.Headers,
+		Cookies: req.Cookies,
+		Auth:    req.Auth,
+	}, h.upstreamConfig(req.ProjectID))
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, results)
+}
+
+// PersistedQueries handles GET /api/persisted-queries — the APQ hashes the
+// proxy has mapped to query text.
+func (h *Handlers) PersistedQueries(w http.ResponseWriter, r *http.Request) {
+	pqs, err := h.TrafficRepo.ListPersistedQueries()
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if pqs == nil {
+		pqs = []schema.PersistedQuery{}
+	}
+	jsonResp(w, http.StatusOK, pqs)
+}
+
+// DoSProbe handles POST /api/dos — runs the active DoS techniques against a
+// target. When a schema is given, its recursive paths drive the nesting probe
+// and the results are stored as its "dos" analysis.
+func (h *Handlers) DoSProbe(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		analysis.Target
+		SchemaID       string   `json:"schemaId"`
+		Techniques     []string `json:"techniques"`
+		MaxRequests    int      `json:"maxRequests"`
+		LatencyLimitMs int      `json:"latencyLimitMs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var s *schema.Schema
+	if req.SchemaID != "" {
+		var err error
+		s, err = h.SchemaRepo.Get(req.SchemaID)
+		if err != nil || s == nil {
+			jsonErr(w, http.StatusNotFound, "schema not found")
+			return
+		}
+	}
+
+	results, err := analysis.RunDoS(analysis.DoSOptions{
+		Target:       req.Target,
+		Schema:       s,
+		Techniques:   req.Techniques,
+		MaxRequests:  req.MaxRequests,
+		LatencyLimit: time.Duration(req.LatencyLimitMs) * time.Millisecond,
+		Upstream:     h.upstreamConfig(h.schemaProject(req.SchemaID)),
+	})
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if s != nil {
+		resultJSON, err := json.Marshal(results)
+		if err == nil {
+			if err := h.AnalysisRepo.Save(generateID(), s.ID, "dos", string(resultJSON)); err != nil {
+				log.Printf("save analysis result dos: %v", err)
+			}
+		}
+	}
+	jsonResp(w, http.StatusOK, results)
+}
+
+// UploadTest handles POST /api/uploads — re-sends the schema's operations
+// that take Upload arguments as multipart requests with malicious file
+// names, content types, polyglots, and an oversized file. The report is
+// stored as the schema's "uploads" analysis.
+func (h *Handlers) UploadTest(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		analysis.Target
+		SchemaID       string   `json:"schemaId"`
+		Operations     []string `json:"operations"`
+		Cases          []string `json:"cases"`
+		OversizedBytes int      `json:"oversizedBytes"`
+		MaxRequests    int      `json:"maxRequests"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s, err := h.SchemaRepo.Get(req.SchemaID)
+	if err != nil || s == nil {
+		jsonErr(w, http.StatusNotFound, "schema not found")
+		return
+	}
+
+	report, err := analysis.TestUploads(analysis.UploadOptions{
+		Target:         req.Target,
+		Schema:         s,
+		Operations:     req.Operations,
+		Cases:          req.Cases,
+		OversizedBytes: req.OversizedBytes,
+		MaxRequests:    req.MaxRequests,
+		Upstream:       h.upstreamConfig(h.schemaProject(s.ID)),
+	})
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resultJSON, err := json.Marshal(report)
+	if err == nil {
+		if err := h.AnalysisRepo.Save(generateID(), s.ID, "uploads", string(resultJSON)); err != nil {
+			log.Printf("save analysis result uploads: %v", err)
+		}
+	}
+	jsonResp(w, http.StatusOK, report)
+}
+
+// RecoverSchema handles POST /api/recovery — rebuilds a schema from
+// validation-error suggestions when introspection is disabled, and stores it.
+func (h *Handlers) RecoverSchema(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		analysis.Target
+		Name        string   `json:"name"`
+		FieldWords  []string `json:"fieldWords"`
+		ArgWords    []string `json:"argWords"`
+		EnumWords   []string `json:"enumWords"`
+		MaxRequests int      `json:"maxRequests"`
+		ProjectID   string   `json:"projectId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = "recovered_" + time.Now().Format("20060102_150405")
+		if u, err := url.Parse(req.URL); err == nil && u.Host != "" {
+			name = u.Host + "_" + name
+		}
+	}
+
+	result, err := analysis.RecoverSchema(analysis.RecoveryOptions{
+		Target:      req.Target,
+		FieldWords:  req.FieldWords,
+		ArgWords:    req.ArgWords,
+		EnumWords:   req.EnumWords,
+		MaxRequests: req.MaxRequests,
+		Upstream:    h.upstreamConfig(req.ProjectID),
+	}, generateID(), name)
+	if err != nil {
+		jsonErr(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	stats, _ := json.Marshal(result)
+	if err := h.SchemaRepo.Save(result.Schema, string(stats)); err != nil {
+		jsonErr(w, http.StatusInternalServerError, "save error: "+err.Error())
+		return
+	}
+
+	ops := schema.GetOperations(result.Schema)
+	jsonResp(w, http.StatusOK, map[string]any{
+		"id":            result.Schema.ID,
+		"name":          result.Schema.Name,
+		"requests":      result.Requests,
+		"truncated":     result.Truncated,
+		"inconclusive":  result.Inconclusive,
+		"typeCount":     len(schema.UserTypes(result.Schema)),
+		"queryCount":    countOps(ops, "query"),
+		"mutationCount": countOps(ops, "mutation"),
+		"redirectURL":   "/schema/" + result.Schema.ID + "/graph",
+	})
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/bundle"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// BundleUpload handles POST /api/projects/{id}/bundles — scans an uploaded
+// JavaScript bundle or persisted query manifest for GraphQL operations,
+// stores them with the project, and resolves its persisted query captures.
+// The file name is passed as ?name=.
+func (h *Handlers) BundleUpload(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	project, err := h.ProjectRepo.Get(id)
+	if err != nil || project == nil {
+		jsonErr(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 32<<20)) // 32MB limit
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, "failed to read body: "+err.Error())
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = "upload"
+	}
+
+	ops := bundle.Scan(body)
+	now := time.Now().UTC()
+	for i := range ops {
+		ops[i].ID = generateID()
+		ops[i].ProjectID = id
+		ops[i].Source = name
+		ops[i].FirstSeen = now
+	}
+	added, err := h.BundleRepo.Save(ops)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resolved, err := h.BundleRepo.ResolveTraffic(id)
+	if err != nil {
+		log.Printf("resolve persisted captures: %v", err)
+	}
+
+	jsonResp(w, http.StatusOK, map[string]any{
+		"found":    len(ops),
+		"added":    added,
+		"resolved": resolved,
+	})
+}
+
+// BundleList handles GET /api/projects/{id}/bundles — the operations found
+// in the project's bundles.
+func (h *Handlers) BundleList(w http.ResponseWriter, r *http.Request) {
+	ops, err := h.BundleRepo.ListByProject(r.PathValue("id"))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if ops == nil {
+		ops = []schema.BundleOperation{}
+	}
+	jsonResp(w, http.StatusOK, ops)
+}
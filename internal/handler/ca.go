@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// caDownloads maps each CA export format to its file extension and
+// content type.
+var caDownloads = map[string]struct{ ext, contentType string }{
+	"pem": {"pem", "application/x-pem-file"},
+	"der": {"crt", "application/x-x509-ca-cert"},
+	"p12": {"p12", "application/x-pkcs12"},
+}
+
+// ProxyCA handles GET /api/proxy/ca — describes the proxy's CA certificate.
+func (h *Handlers) ProxyCA(w http.ResponseWriter, r *http.Request) {
+	if h.proxyCtrl == nil {
+		jsonErr(w, http.StatusServiceUnavailable, "proxy not configured")
+		return
+	}
+	info, err := h.proxyCtrl.CAInfo()
+	if err != nil {
+		jsonErr(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, info)
+}
+
+// ProxyCADownload handles GET and POST /api/proxy/ca/download — downloads
+// the CA certificate as format "pem", "der", or "p12". A PKCS#12 bundle
+// holds only the certificate, never the CA key, but is encrypted with a
+// password; it is requested with a form POST to keep the password out of
+// the URL.
+func (h *Handlers) ProxyCADownload(w http.ResponseWriter, r *http.Request) {
+	if h.proxyCtrl == nil {
+		jsonErr(w, http.StatusServiceUnavailable, "proxy not configured")
+		return
+	}
+	format := r.FormValue("format")
+	if format == "" {
+		format = "pem"
+	}
+	d, ok := caDownloads[format]
+	if !ok {
+		jsonErr(w, http.StatusBadRequest, "format must be pem, der, or p12")
+		return
+	}
+	password := ""
+	if r.Method == http.MethodPost {
+		password = r.PostFormValue("password")
+	}
+	data, err := h.proxyCtrl.ExportCA(format, password)
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", d.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="gqlforge-ca.%s"`, d.ext))
+	w.Write(data)
+}
+
+// ProxyCARotate handles POST /api/proxy/ca/rotate — replaces the CA with a
+// newly generated one and discards the host certificates the old one
+// signed. Every client has to install the new CA.
+func (h *Handlers) ProxyCARotate(w http.ResponseWriter, r *http.Request) {
+	if h.proxyCtrl == nil {
+		jsonErr(w, http.StatusServiceUnavailable, "proxy not configured")
+		return
+	}
+	info, err := h.proxyCtrl.RotateCA()
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, info)
+}
@@ -8,7 +8,12 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/0xDTC/0xGQLForge/internal/har"
+	"github.com/0xDTC/0xGQLForge/internal/rewrite"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/scope"
 	"github.com/0xDTC/0xGQLForge/internal/storage"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
 )
 
 // Handlers holds all HTTP handler dependencies.
@@ -17,9 +22,18 @@ type Handlers struct {
 	TrafficRepo    *storage.TrafficRepo
 	AnalysisRepo   *storage.AnalysisRepo
 	ProjectRepo    *storage.ProjectRepo
+	ReplayRepo     *storage.ReplayRepo
+	RBACRepo       *storage.RBACRepo
+	WordlistRepo   *storage.WordlistRepo
+	InjectionRepo  *storage.InjectionRepo
+	RewriteRepo    *storage.RewriteRepo
+	BundleRepo     *storage.BundleRepo
 	tmpls          map[string]*template.Template
 	proxyCtrl      ProxyController
 	currentProject string // label for the active proxy session
+	// defaultProxyURL is the upstream proxy set at launch, restored when the
+	// proxy is stopped.
+	defaultProxyURL string
 }
 
 // ProxyController is the interface the handlers use to control the proxy.
@@ -32,15 +46,41 @@ type ProxyController interface {
 	Unsubscribe(<-chan []byte)
 	SetProjectID(string)
 	GetProjectID() string
+	UpstreamClient() *http.Client
+	UpstreamConfig() upstream.Config
+	SetUpstreamConfig(upstream.Config) error
+	SetProjectUpstream(func(projectID string, base upstream.Config) (upstream.Config, error))
+	RefreshProjectUpstream(projectID string)
+	ProjectUpstreamConfig(projectID string) upstream.Config
+	ProjectUpstreamClient(projectID string) *http.Client
+	SetScope(projectID string, s *scope.Scope)
+	SetRewriter(*rewrite.Engine)
+	InterceptSettings() schema.InterceptSettings
+	SetInterceptSettings(schema.InterceptSettings) error
+	Intercepted() []schema.InterceptedMessage
+	ResolveIntercept(id string, d schema.InterceptDecision) error
+	ImportEntry(projectID string, e *har.Entry) (int, error)
+	StartListener(schema.ProxyListener) (schema.ProxyListener, error)
+	StopListener(id string) error
+	Listeners() []schema.ProxyListener
+	CAInfo() (schema.CAInfo, error)
+	ExportCA(format, password string) ([]byte, error)
+	RotateCA() (schema.CAInfo, error)
 }
 
 // NewHandlers creates a new Handlers instance.
-func NewHandlers(sr *storage.SchemaRepo, tr *storage.TrafficRepo, ar *storage.AnalysisRepo, pr *storage.ProjectRepo) *Handlers {
+func NewHandlers(sr *storage.SchemaRepo, tr *storage.TrafficRepo, ar *storage.AnalysisRepo, pr *storage.ProjectRepo, rr *storage.ReplayRepo, rbr *storage.RBACRepo, wr *storage.WordlistRepo, ir *storage.InjectionRepo, rwr *storage.RewriteRepo, br *storage.BundleRepo) *Handlers {
 	return &Handlers{
-		SchemaRepo:   sr,
-		TrafficRepo:  tr,
-		AnalysisRepo: ar,
-		ProjectRepo:  pr,
+		SchemaRepo:    sr,
+		TrafficRepo:   tr,
+		AnalysisRepo:  ar,
+		ProjectRepo:   pr,
+		ReplayRepo:    rr,
+		RBACRepo:      rbr,
+		WordlistRepo:  wr,
+		InjectionRepo: ir,
+		RewriteRepo:   rwr,
+		BundleRepo:    br,
 	}
 }
 
@@ -49,9 +89,16 @@ func (h *Handlers) SetTemplates(tmpls map[string]*template.Template) {
 	h.tmpls = tmpls
 }
 
-// SetProxyController wires the proxy control interface.
+// SetProxyController wires the proxy control interface, loads the saved
+// match-and-replace rules into it, and has it take each project's upstream
+// proxy and TLS profile from the project. The proxy's upstream proxy at
+// this point, from the -upstream-proxy flag, is used for projects without
+// one of their own and restored whenever the proxy is stopped.
 func (h *Handlers) SetProxyController(ctrl ProxyController) {
 	h.proxyCtrl = ctrl
+	h.defaultProxyURL = ctrl.UpstreamConfig().ProxyURL
+	ctrl.SetProjectUpstream(h.projectUpstream)
+	h.applyRewriteRules()
 }
 
 // render executes a named template with the given data.
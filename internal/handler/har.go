@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/0xDTC/0xGQLForge/internal/har"
+)
+
+// HARImport handles POST /api/projects/{id}/har — captures the GraphQL
+// operations in an uploaded HAR file into the project. Entries are detected
+// and extracted the same way the proxy handles live traffic; the rest are
+// skipped.
+func (h *Handlers) HARImport(w http.ResponseWriter, r *http.Request) {
+	if h.proxyCtrl == nil {
+		jsonErr(w, http.StatusServiceUnavailable, "proxy not configured")
+		return
+	}
+	id := r.PathValue("id")
+	project, err := h.ProjectRepo.Get(id)
+	if err != nil || project == nil {
+		jsonErr(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 256<<20)) // 256MB limit
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, "failed to read body: "+err.Error())
+		return
+	}
+	file, err := har.Decode(bytes.NewReader(body))
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	imported, skipped := 0, 0
+	for i := range file.Log.Entries {
+		n, err := h.proxyCtrl.ImportEntry(id, &file.Log.Entries[i])
+		if err != nil {
+			log.Printf("import HAR entry %d: %v", i, err)
+		}
+		if n == 0 {
+			skipped++
+		}
+		imported += n
+	}
+
+	jsonResp(w, http.StatusOK, map[string]any{
+		"entries":  len(file.Log.Entries),
+		"imported": imported,
+		"skipped":  skipped,
+	})
+}
+
+// HARExport handles GET /api/projects/{id}/har — downloads the project's
+// captured traffic, with headers, bodies, and timings, as a HAR file.
+func (h *Handlers) HARExport(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	project, err := h.ProjectRepo.Get(id)
+	if err != nil || project == nil {
+		jsonErr(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	traffic, err := h.TrafficRepo.ListByProjectFull(id, 0)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	slices.Reverse(traffic) // oldest first
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.har"`, sdlFilename(project.Name)))
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(har.FromCaptures(traffic, har.Creator{Name: "0xGQLForge"}))
+}
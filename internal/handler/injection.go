@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/analysis"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// InjectionRun handles POST /api/injection — fuzzes the String, ID, and JSON
+// arguments of a schema's operations with injection payloads and stores the
+// anomalies found against the schema.
+func (h *Handlers) InjectionRun(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		analysis.Target
+		SchemaID         string   `json:"schemaId"`
+		Operations       []string `json:"operations"`
+		Classes          []string `json:"classes"`
+		IncludeMutations bool     `json:"includeMutations"`
+		MaxRequests      int      `json:"maxRequests"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s, err := h.SchemaRepo.Get(req.SchemaID)
+	if err != nil || s == nil {
+		jsonErr(w, http.StatusNotFound, "schema not found")
+		return
+	}
+	custom, err := h.WordlistRepo.Payloads()
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	report, err := analysis.FuzzInjection(analysis.InjectionOptions{
+		Target:           req.Target,
+		Schema:           s,
+		Operations:       req.Operations,
+		IncludeMutations: req.IncludeMutations,
+		Payloads:         analysis.MergePayloads(custom),
+		Classes:          req.Classes,
+		MaxRequests:      req.MaxRequests,
+		Upstream:         h.upstreamConfig(h.schemaProject(s.ID)),
+	})
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	runID := generateID()
+	now := time.Now().UTC()
+	for i := range report.Findings {
+		report.Findings[i].ID = generateID()
+		report.Findings[i].RunID = runID
+		report.Findings[i].SchemaID = s.ID
+		report.Findings[i].CreatedAt = now
+	}
+	if err := h.InjectionRepo.SaveFindings(report.Findings); err != nil {
+		jsonErr(w, http.StatusInternalServerError, "save error: "+err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, report)
+}
+
+// InjectionFindings handles GET /api/injection/{schemaID} — returns the
+// stored findings for a schema, newest first.
+func (h *Handlers) InjectionFindings(w http.ResponseWriter, r *http.Request) {
+	findings, err := h.InjectionRepo.ListBySchema(r.PathValue("schemaID"), 500)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if findings == nil {
+		findings = []schema.InjectionFinding{}
+	}
+	jsonResp(w, http.StatusOK, findings)
+}
+
+// InjectionPayloads handles GET /api/injection/payloads — returns the
+// payload classes the fuzzer will use, built-in and custom.
+func (h *Handlers) InjectionPayloads(w http.ResponseWriter, r *http.Request) {
+	custom, err := h.WordlistRepo.Payloads()
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, analysis.MergePayloads(custom))
+}
+
+// WordlistList handles GET /api/wordlists?prefix=...
+func (h *Handlers) WordlistList(w http.ResponseWriter, r *http.Request) {
+	lists, err := h.WordlistRepo.List(r.URL.Query().Get("prefix"))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if lists == nil {
+		lists = []schema.Wordlist{}
+	}
+	jsonResp(w, http.StatusOK, lists)
+}
+
+// WordlistSave handles POST /api/wordlists — creates a wordlist or replaces
+// the words of the one with the same name.
+func (h *Handlers) WordlistSave(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name  string   `json:"name"`
+		Words []string `json:"words"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		jsonErr(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	var words []string
+	for _, word := range req.Words {
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	list := &schema.Wordlist{ID: generateID(), Name: req.Name, Words: words, CreatedAt: time.Now().UTC()}
+	if err := h.WordlistRepo.Save(list); err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, list)
+}
+
+// WordlistDelete handles DELETE /api/wordlists/{id}.
+func (h *Handlers) WordlistDelete(w http.ResponseWriter, r *http.Request) {
+	if err := h.WordlistRepo.Delete(r.PathValue("id")); err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
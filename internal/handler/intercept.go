@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// InterceptState handles GET /api/proxy/intercept — the intercept settings
+// and the messages currently held.
+func (h *Handlers) InterceptState(w http.ResponseWriter, r *http.Request) {
+	if h.proxyCtrl == nil {
+		jsonErr(w, http.StatusServiceUnavailable, "proxy not configured")
+		return
+	}
+	jsonResp(w, http.StatusOK, map[string]any{
+		"settings": h.proxyCtrl.InterceptSettings(),
+		"pending":  h.proxyCtrl.Intercepted(),
+	})
+}
+
+// InterceptSettingsSave handles POST /api/proxy/intercept — switches
+// request and response interception on or off and sets the host and
+// operation filters and the timeout.
+func (h *Handlers) InterceptSettingsSave(w http.ResponseWriter, r *http.Request) {
+	if h.proxyCtrl == nil {
+		jsonErr(w, http.StatusServiceUnavailable, "proxy not configured")
+		return
+	}
+	var s schema.InterceptSettings
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if err := h.proxyCtrl.SetInterceptSettings(s); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, h.proxyCtrl.InterceptSettings())
+}
+
+// InterceptResolve handles POST /api/proxy/intercept/{id} — forwards, edits
+// and forwards, or drops a held request or response.
+func (h *Handlers) InterceptResolve(w http.ResponseWriter, r *http.Request) {
+	if h.proxyCtrl == nil {
+		jsonErr(w, http.StatusServiceUnavailable, "proxy not configured")
+		return
+	}
+	var d schema.InterceptDecision
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if err := h.proxyCtrl.ResolveIntercept(r.PathValue("id"), d); err != nil {
+		jsonErr(w, http.StatusConflict, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, map[string]string{"status": d.Action})
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
+	"github.com/0xDTC/0xGQLForge/internal/analysis"
 	"github.com/0xDTC/0xGQLForge/internal/parser"
 	"github.com/0xDTC/0xGQLForge/internal/schema"
 )
@@ -58,3 +61,88 @@ func countOps(ops []schema.Operation, kind string) int {
 	}
 	return n
 }
+
+// IntrospectionFetch handles POST /api/introspection/fetch — runs introspection
+// against a live endpoint and stores the result. When the target refuses
+// introspection the response reports disabled=true; resending with
+// "bypass": true runs the bypass techniques instead, and stores the schema
+// if one of them gets the full introspection query through.
+func (h *Handlers) IntrospectionFetch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		analysis.Target
+		Name      string `json:"name"`
+		Bypass    bool   `json:"bypass"`
+		ProjectID string `json:"projectId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := analysis.FetchIntrospection(req.Target, h.upstreamConfig(req.ProjectID))
+	if err != nil {
+		jsonErr(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	var bypass []schema.BypassResult
+	if result.Disabled && req.Bypass {
+		bypass, err = analysis.TryBypass(req.Target, h.upstreamConfig(req.ProjectID))
+		if err != nil {
+			jsonErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if recovered := analysis.FetchIntrospectionBypass(req.Target, h.upstreamConfig(req.ProjectID), bypass); recovered != nil {
+			recovered.Attempts = append(result.Attempts, recovered.Attempts...)
+			result = recovered
+		}
+	}
+
+	if result.Disabled {
+		resp := map[string]any{
+			"disabled": true,
+			"attempts": result.Attempts,
+		}
+		if bypass != nil {
+			resp["bypassResults"] = bypass
+		}
+		jsonResp(w, http.StatusOK, resp)
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = fmt.Sprintf("schema_%s", time.Now().Format("20060102_150405"))
+		if u, err := url.Parse(req.URL); err == nil && u.Host != "" {
+			name = u.Host + "_" + time.Now().Format("20060102_150405")
+		}
+	}
+
+	id := generateID()
+	parsed, err := parser.ParseIntrospection(result.Body, id, name)
+	if err != nil {
+		jsonErr(w, http.StatusBadGateway, "parse error: "+err.Error())
+		return
+	}
+
+	if err := h.SchemaRepo.Save(parsed, string(result.Body)); err != nil {
+		jsonErr(w, http.StatusInternalServerError, "save error: "+err.Error())
+		return
+	}
+
+	ops := schema.GetOperations(parsed)
+	userTypes := schema.UserTypes(parsed)
+
+	jsonResp(w, http.StatusOK, map[string]any{
+		"id":            parsed.ID,
+		"name":          parsed.Name,
+		"shape":         result.Shape,
+		"technique":     result.Technique,
+		"attempts":      result.Attempts,
+		"typeCount":     len(userTypes),
+		"queryCount":    countOps(ops, "query"),
+		"mutationCount": countOps(ops, "mutation"),
+		"subCount":      countOps(ops, "subscription"),
+		"redirectURL":   "/schema/" + parsed.ID,
+	})
+}
@@ -4,10 +4,14 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/0xDTC/0xGQLForge/internal/analysis"
+	"github.com/0xDTC/0xGQLForge/internal/bundle"
 	"github.com/0xDTC/0xGQLForge/internal/inference"
 	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
 )
 
 // ProjectListAPI returns all projects as JSON (used by the proxy page dropdown).
@@ -26,17 +30,24 @@ func (h *Handlers) ProjectListAPI(w http.ResponseWriter, r *http.Request) {
 // ProjectCreate creates a new project via JSON API.
 func (h *Handlers) ProjectCreate(w http.ResponseWriter, r *http.Request) {
 	var body struct {
-		Name string `json:"name"`
+		Name          string `json:"name"`
+		UpstreamProxy string `json:"upstreamProxy"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
 		jsonErr(w, http.StatusBadRequest, "name is required")
 		return
 	}
+	body.UpstreamProxy = strings.TrimSpace(body.UpstreamProxy)
+	if _, err := upstream.ParseProxyURL(body.UpstreamProxy); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	proj := &schema.Project{
-		ID:        generateProjectID(),
-		Name:      body.Name,
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
+		ID:            generateProjectID(),
+		Name:          body.Name,
+		UpstreamProxy: body.UpstreamProxy,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
 	}
 	if err := h.ProjectRepo.Create(proj); err != nil {
 		jsonErr(w, http.StatusInternalServerError, err.Error())
@@ -56,6 +67,7 @@ func (h *Handlers) ProjectDelete(w http.ResponseWriter, r *http.Request) {
 		jsonErr(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	h.refreshScope(id)
 	jsonResp(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
@@ -114,6 +126,18 @@ func (h *Handlers) ProjectInferSchema(w http.ResponseWriter, r *http.Request) {
 		jsonErr(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	// Operations found in the project's JS bundles add the fields they
+	// select, for operations never seen in traffic.
+	bundleOps, err := h.BundleRepo.ListByProject(id)
+	if err != nil {
+		log.Printf("list bundle operations: %v", err)
+	}
+	for _, op := range bundleOps {
+		if op.Text != "" && op.Kind != bundle.KindFragment {
+			traffic = append(traffic, schema.CapturedRequest{OperationName: op.Name, Query: op.Text})
+		}
+	}
+
 	if len(traffic) == 0 {
 		jsonErr(w, http.StatusBadRequest, "no traffic captured for this project")
 		return
@@ -136,3 +160,58 @@ func (h *Handlers) ProjectInferSchema(w http.ResponseWriter, r *http.Request) {
 		"redirectURL": "/schema/" + s.ID,
 	})
 }
+
+// ProjectIDORTest handles POST /api/projects/{id}/idor — replays the
+// project's captured operations with swapped IDs under a second user's
+// credentials. The report is also stored against the project's schema, when
+// it has one, as the "idor_active" analysis.
+func (h *Handlers) ProjectIDORTest(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	project, err := h.ProjectRepo.Get(id)
+	if err != nil || project == nil {
+		jsonErr(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	var req struct {
+		Attacker         analysis.Target `json:"attacker"`
+		IncludeMutations bool            `json:"includeMutations"`
+		MaxSwaps         int             `json:"maxSwaps"`
+		Neighbours       int             `json:"neighbours"`
+		MaxRequests      int             `json:"maxRequests"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+
+	traffic, err := h.TrafficRepo.ListByProjectFull(id, 500)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	report, err := analysis.VerifyIDOR(analysis.IDOROptions{
+		Traffic:          traffic,
+		Attacker:         req.Attacker,
+		Client:           h.upstreamClient(id),
+		IncludeMutations: req.IncludeMutations,
+		MaxSwaps:         req.MaxSwaps,
+		Neighbours:       req.Neighbours,
+		MaxRequests:      req.MaxRequests,
+	})
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if project.SchemaID != nil {
+		if resultJSON, err := json.Marshal(report); err == nil {
+			if err := h.AnalysisRepo.Save(generateID(), *project.SchemaID, "idor_active", string(resultJSON)); err != nil {
+				log.Printf("save idor report: %v", err)
+			}
+		}
+	}
+
+	jsonResp(w, http.StatusOK, report)
+}
@@ -5,11 +5,14 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
 )
 
 // ProxyView renders the proxy traffic viewer page.
@@ -21,7 +24,8 @@ func (h *Handlers) ProxyView(w http.ResponseWriter, r *http.Request) {
 }
 
 // ProxyTraffic returns captured traffic as JSON.
-// Accepts ?limit=N and ?project=ID query parameters.
+// Accepts ?limit=N and ?project=ID query parameters, or ?batch=ID for the
+// operations of one batched request.
 func (h *Handlers) ProxyTraffic(w http.ResponseWriter, r *http.Request) {
 	limit := 100
 	if l := r.URL.Query().Get("limit"); l != "" {
@@ -33,7 +37,9 @@ func (h *Handlers) ProxyTraffic(w http.ResponseWriter, r *http.Request) {
 	projectID := r.URL.Query().Get("project")
 	var traffic []schema.CapturedRequest
 	var err error
-	if projectID != "" {
+	if batchID := r.URL.Query().Get("batch"); batchID != "" {
+		traffic, err = h.TrafficRepo.ListByBatch(batchID)
+	} else if projectID != "" {
 		traffic, err = h.TrafficRepo.ListByProject(projectID, limit)
 	} else {
 		traffic, err = h.TrafficRepo.List(limit)
@@ -45,6 +51,21 @@ func (h *Handlers) ProxyTraffic(w http.ResponseWriter, r *http.Request) {
 	jsonResp(w, http.StatusOK, traffic)
 }
 
+// TrafficGet returns one captured request with its response body and, when
+// match-and-replace rules changed it, the original exchange.
+func (h *Handlers) TrafficGet(w http.ResponseWriter, r *http.Request) {
+	captured, err := h.TrafficRepo.Get(r.PathValue("id"))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if captured == nil {
+		jsonErr(w, http.StatusNotFound, "captured request not found")
+		return
+	}
+	jsonResp(w, http.StatusOK, captured)
+}
+
 // ProxyStart starts the MITM proxy.
 func (h *Handlers) ProxyStart(w http.ResponseWriter, r *http.Request) {
 	if h.proxyCtrl == nil {
@@ -72,6 +93,8 @@ func (h *Handlers) ProxyStop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	h.proxyCtrl.SetProjectID("")
+	h.applyScope("")
+	h.resetUpstreamProxy()
 	jsonResp(w, http.StatusOK, map[string]string{"status": "stopped"})
 }
 
@@ -80,18 +103,92 @@ func (h *Handlers) ProxyStatus(w http.ResponseWriter, r *http.Request) {
 	running := false
 	addr := ""
 	projectID := ""
+	listeners := []schema.ProxyListener{}
 	if h.proxyCtrl != nil {
 		running = h.proxyCtrl.Running()
 		addr = h.proxyCtrl.Addr()
 		projectID = h.proxyCtrl.GetProjectID()
+		listeners = h.proxyCtrl.Listeners()
 	}
 	jsonResp(w, http.StatusOK, map[string]any{
 		"running":   running,
 		"addr":      addr,
 		"projectId": projectID,
+		"listeners": listeners,
 	})
 }
 
+// ListenerList handles GET /api/proxy/listeners — the listeners running
+// alongside the main proxy.
+func (h *Handlers) ListenerList(w http.ResponseWriter, r *http.Request) {
+	if h.proxyCtrl == nil {
+		jsonResp(w, http.StatusOK, []schema.ProxyListener{})
+		return
+	}
+	jsonResp(w, http.StatusOK, h.proxyCtrl.Listeners())
+}
+
+// ListenerStart handles POST /api/proxy/listeners — opens another MITM or
+// reverse-proxy listener bound to a project and, optionally, an auth-role
+// label. A MITM listener without an addr uses the project's saved proxy
+// address.
+func (h *Handlers) ListenerStart(w http.ResponseWriter, r *http.Request) {
+	if h.proxyCtrl == nil {
+		jsonErr(w, http.StatusServiceUnavailable, "proxy not configured")
+		return
+	}
+	var cfg schema.ProxyListener
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	cfg.Addr = strings.TrimSpace(cfg.Addr)
+	cfg.Role = strings.TrimSpace(cfg.Role)
+	if cfg.Kind == "" {
+		cfg.Kind = schema.ListenerMITM
+	}
+	if cfg.ProjectID != "" {
+		project, err := h.ProjectRepo.Get(cfg.ProjectID)
+		if err != nil || project == nil {
+			jsonErr(w, http.StatusNotFound, "project not found")
+			return
+		}
+		if cfg.Addr == "" && cfg.Kind == schema.ListenerMITM && project.ProxyAddr != h.proxyCtrl.Addr() {
+			cfg.Addr = project.ProxyAddr
+		}
+	}
+	if cfg.Addr == "" {
+		jsonErr(w, http.StatusBadRequest, "addr is required")
+		return
+	}
+	if cfg.ProjectID != "" {
+		h.applyScope(cfg.ProjectID)
+	}
+
+	started, err := h.proxyCtrl.StartListener(cfg)
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, "start listener: "+err.Error())
+		return
+	}
+	if started.ProjectID != "" && started.Kind == schema.ListenerMITM {
+		h.ProjectRepo.UpdateProxyAddr(started.ProjectID, started.Addr) //nolint:errcheck
+	}
+	jsonResp(w, http.StatusOK, started)
+}
+
+// ListenerStop handles DELETE /api/proxy/listeners/{id}.
+func (h *Handlers) ListenerStop(w http.ResponseWriter, r *http.Request) {
+	if h.proxyCtrl == nil {
+		jsonErr(w, http.StatusServiceUnavailable, "proxy not configured")
+		return
+	}
+	if err := h.proxyCtrl.StopListener(r.PathValue("id")); err != nil {
+		jsonErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, map[string]string{"status": "stopped"})
+}
+
 // ProxyClearTraffic deletes all captured traffic.
 func (h *Handlers) ProxyClearTraffic(w http.ResponseWriter, r *http.Request) {
 	if err := h.TrafficRepo.Clear(); err != nil {
@@ -113,6 +210,7 @@ func (h *Handlers) ProxySetProject(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck
 	h.proxyCtrl.SetProjectID(body.ProjectID)
+	h.applyScope(body.ProjectID)
 	// Save the proxy address to the project record so it shows in the projects list.
 	if body.ProjectID != "" {
 		h.ProjectRepo.UpdateProxyAddr(body.ProjectID, h.proxyCtrl.Addr()) //nolint:errcheck
@@ -120,6 +218,70 @@ func (h *Handlers) ProxySetProject(w http.ResponseWriter, r *http.Request) {
 	jsonResp(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
+// resetUpstreamProxy restores the default upstream proxy to the one set at
+// launch.
+func (h *Handlers) resetUpstreamProxy() {
+	cfg := h.proxyCtrl.UpstreamConfig()
+	if cfg.ProxyURL == h.defaultProxyURL {
+		return
+	}
+	cfg.ProxyURL = h.defaultProxyURL
+	if err := h.proxyCtrl.SetUpstreamConfig(cfg); err != nil {
+		log.Printf("reset upstream proxy: %v", err)
+	}
+}
+
+// ProxyUpstream handles GET /api/proxy/upstream?projectId= — returns the
+// upstream proxy the project's traffic (or, without a projectId, the
+// default one) is chained through, or "" when connecting directly.
+func (h *Handlers) ProxyUpstream(w http.ResponseWriter, r *http.Request) {
+	jsonResp(w, http.StatusOK, map[string]string{
+		"proxyUrl": h.upstreamConfig(r.URL.Query().Get("projectId")).ProxyURL,
+	})
+}
+
+// ProxySetUpstream handles POST /api/proxy/upstream — chains the proxy and
+// the outbound tools through another HTTP or SOCKS5 proxy. An empty proxyUrl
+// connects directly again. With a projectId the setting is saved to that
+// project and used for its traffic, listeners, and tools; without one it
+// becomes the default for traffic outside a project and for projects
+// without an upstream proxy of their own, until the proxy is stopped.
+func (h *Handlers) ProxySetUpstream(w http.ResponseWriter, r *http.Request) {
+	if h.proxyCtrl == nil {
+		jsonErr(w, http.StatusServiceUnavailable, "proxy not configured")
+		return
+	}
+	var body struct {
+		ProxyURL  string `json:"proxyUrl"`
+		ProjectID string `json:"projectId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	body.ProxyURL = strings.TrimSpace(body.ProxyURL)
+	if _, err := upstream.ParseProxyURL(body.ProxyURL); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if body.ProjectID == "" {
+		cfg := h.proxyCtrl.UpstreamConfig()
+		cfg.ProxyURL = body.ProxyURL
+		if err := h.proxyCtrl.SetUpstreamConfig(cfg); err != nil {
+			jsonErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else {
+		if err := h.ProjectRepo.UpdateUpstreamProxy(body.ProjectID, body.ProxyURL); err != nil {
+			jsonErr(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		h.proxyCtrl.RefreshProjectUpstream(body.ProjectID)
+	}
+	jsonResp(w, http.StatusOK, map[string]string{"proxyUrl": body.ProxyURL})
+}
+
 // ProxySSE streams new traffic events via Server-Sent Events.
 func (h *Handlers) ProxySSE(w http.ResponseWriter, r *http.Request) {
 	flusher, ok := w.(http.Flusher)
@@ -137,7 +299,7 @@ func (h *Handlers) ProxySSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !h.proxyCtrl.Running() {
+	if !h.proxyCtrl.Running() && len(h.proxyCtrl.Listeners()) == 0 {
 		w.Header().Set("Content-Type", "text/event-stream")
 		w.Header().Set("Cache-Control", "no-cache")
 		// Send an explicit status event so the client knows the proxy isn't running,
@@ -165,7 +327,7 @@ func (h *Handlers) ProxySSE(w http.ResponseWriter, r *http.Request) {
 			if !ok {
 				return
 			}
-			fmt.Fprintf(w, "data: %s\n\n", data)
+			fmt.Fprintf(w, "%s\n\n", data)
 			flusher.Flush()
 		case <-ticker.C:
 			fmt.Fprintf(w, ": heartbeat\n\n")
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/analysis"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// AuthContextList handles GET /api/projects/{id}/auth-contexts.
+func (h *Handlers) AuthContextList(w http.ResponseWriter, r *http.Request) {
+	contexts, err := h.RBACRepo.ListContexts(r.PathValue("id"))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if contexts == nil {
+		contexts = []schema.AuthContext{}
+	}
+	jsonResp(w, http.StatusOK, contexts)
+}
+
+// AuthContextCreate handles POST /api/projects/{id}/auth-contexts — registers
+// a named set of headers and cookies, e.g. "admin" or "anonymous".
+func (h *Handlers) AuthContextCreate(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	project, err := h.ProjectRepo.Get(id)
+	if err != nil || project == nil {
+		jsonErr(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	var body struct {
+		Name      string            `json:"name"`
+		Privilege int               `json:"privilege"`
+		Headers   map[string]string `json:"headers"`
+		Cookies   map[string]string `json:"cookies"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		jsonErr(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	ctx := &schema.AuthContext{
+		ID:        generateID(),
+		ProjectID: id,
+		Name:      body.Name,
+		Privilege: body.Privilege,
+		Headers:   body.Headers,
+		Cookies:   body.Cookies,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := h.RBACRepo.CreateContext(ctx); err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusCreated, ctx)
+}
+
+// AuthContextDelete handles DELETE /api/projects/{id}/auth-contexts/{ctxID}.
+func (h *Handlers) AuthContextDelete(w http.ResponseWriter, r *http.Request) {
+	if err := h.RBACRepo.DeleteContext(r.PathValue("id"), r.PathValue("ctxID")); err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// RBACMatrix handles GET /api/projects/{id}/rbac — returns the entries of
+// the project's most recent authorization matrix run.
+func (h *Handlers) RBACMatrix(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.RBACRepo.LatestEntries(r.PathValue("id"))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if entries == nil {
+		entries = []schema.RBACEntry{}
+	}
+	jsonResp(w, http.StatusOK, map[string]any{"entries": entries})
+}
+
+// RBACRun handles POST /api/projects/{id}/rbac/run — executes the project's
+// captured operations, and optionally operations generated from its schema,
+// under every registered auth context and stores the matrix.
+func (h *Handlers) RBACRun(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	project, err := h.ProjectRepo.Get(id)
+	if err != nil || project == nil {
+		jsonErr(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	var req struct {
+		IncludeMutations bool   `json:"includeMutations"`
+		IncludeGenerated bool   `json:"includeGenerated"`
+		TargetURL        string `json:"targetUrl"`
+		MaxOperations    int    `json:"maxOperations"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+
+	contexts, err := h.RBACRepo.ListContexts(id)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	traffic, err := h.TrafficRepo.ListByProjectFull(id, 500)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var s *schema.Schema
+	if req.IncludeGenerated {
+		if project.SchemaID == nil {
+			jsonErr(w, http.StatusBadRequest, "project has no schema to generate operations from")
+			return
+		}
+		s, err = h.SchemaRepo.Get(*project.SchemaID)
+		if err != nil || s == nil {
+			jsonErr(w, http.StatusNotFound, "project schema not found")
+			return
+		}
+	}
+
+	matrix, err := analysis.RunRBAC(analysis.RBACOptions{
+		Contexts:         contexts,
+		Traffic:          traffic,
+		Schema:           s,
+		TargetURL:        req.TargetURL,
+		Client:           h.upstreamClient(id),
+		IncludeMutations: req.IncludeMutations,
+		MaxOperations:    req.MaxOperations,
+	})
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	runID := generateID()
+	now := time.Now().UTC()
+	for i := range matrix.Entries {
+		matrix.Entries[i].ID = generateID()
+		matrix.Entries[i].RunID = runID
+		matrix.Entries[i].ProjectID = id
+		matrix.Entries[i].CreatedAt = now
+	}
+	if err := h.RBACRepo.SaveEntries(matrix.Entries); err != nil {
+		jsonErr(w, http.StatusInternalServerError, "save error: "+err.Error())
+		return
+	}
+
+	jsonResp(w, http.StatusOK, matrix)
+}
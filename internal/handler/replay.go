@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/0xDTC/0xGQLForge/internal/replay"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+)
+
+// ReplayView renders the replay workbench for a captured request.
+func (h *Handlers) ReplayView(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	captured, err := h.TrafficRepo.Get(id)
+	if err != nil || captured == nil {
+		http.Error(w, "Captured request not found", http.StatusNotFound)
+		return
+	}
+
+	title := "Replay"
+	if captured.OperationName != "" {
+		title = "Replay: " + captured.OperationName
+	}
+	data := map[string]any{
+		"Title":   title,
+		"Traffic": captured,
+	}
+	h.render(w, "replay.html", data)
+}
+
+// ReplayDetail handles GET /api/replay/{id} — returns the captured request,
+// its original response, and every attempt replayed from it.
+func (h *Handlers) ReplayDetail(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	captured, err := h.TrafficRepo.Get(id)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if captured == nil {
+		jsonErr(w, http.StatusNotFound, "captured request not found")
+		return
+	}
+
+	history, err := h.ReplayRepo.ListByTraffic(id)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The captured body isn't guaranteed to be valid JSON, so it is sent as
+	// a string rather than embedded as a RawMessage.
+	originalBody := string(captured.ResponseBody)
+	captured.ResponseBody = nil
+
+	jsonResp(w, http.StatusOK, map[string]any{
+		"request":      captured,
+		"originalBody": originalBody,
+		"history":      history,
+	})
+}
+
+// ReplaySend handles POST /api/replay/{id}/send — sends an edited copy of a
+// captured request upstream, stores the attempt, and diffs its response
+// against the originally captured one.
+func (h *Handlers) ReplaySend(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	captured, err := h.TrafficRepo.Get(id)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if captured == nil {
+		jsonErr(w, http.StatusNotFound, "captured request not found")
+		return
+	}
+
+	var req struct {
+		replay.Request
+		ParentID string `json:"parentId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+
+	if req.ParentID != "" {
+		parent, err := h.ReplayRepo.Get(req.ParentID)
+		if err != nil {
+			jsonErr(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if parent == nil || parent.TrafficID != id {
+			jsonErr(w, http.StatusBadRequest, "parent attempt not found for this request")
+			return
+		}
+	}
+
+	// Edits are made to the body the request was captured with.
+	req.RawBody = replay.CapturedBody(captured)
+
+	projectID := ""
+	if captured.ProjectID != nil {
+		projectID = *captured.ProjectID
+	}
+	attempt, err := replay.Send(h.upstreamClient(projectID), req.Request)
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	attempt.ID = generateID()
+	attempt.TrafficID = id
+	if req.ParentID != "" {
+		attempt.ParentID = &req.ParentID
+	}
+
+	if err := h.ReplayRepo.Save(attempt); err != nil {
+		jsonErr(w, http.StatusInternalServerError, "save error: "+err.Error())
+		return
+	}
+
+	jsonResp(w, http.StatusOK, map[string]any{
+		"attempt": attempt,
+		"diff":    replay.DiffResponses(captured.ResponseBody, []byte(attempt.ResponseBody)),
+	})
+}
+
+// ReplayAttemptDiff handles GET /api/replay/{id}/attempts/{attemptID} —
+// returns a stored attempt with its diff against the original response.
+func (h *Handlers) ReplayAttemptDiff(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	attempt, err := h.ReplayRepo.Get(r.PathValue("attemptID"))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if attempt == nil || attempt.TrafficID != id {
+		jsonErr(w, http.StatusNotFound, "attempt not found")
+		return
+	}
+
+	captured, err := h.TrafficRepo.Get(id)
+	if err != nil || captured == nil {
+		jsonErr(w, http.StatusNotFound, "captured request not found")
+		return
+	}
+
+	jsonResp(w, http.StatusOK, map[string]any{
+		"attempt": attempt,
+		"diff":    replay.DiffResponses(captured.ResponseBody, []byte(attempt.ResponseBody)),
+	})
+}
+
+// upstreamClient returns the client a project's proxied traffic is sent
+// with, so replays honour the same settings, falling back to the defaults
+// when no proxy is wired.
+func (h *Handlers) upstreamClient(projectID string) *http.Client {
+	if h.proxyCtrl != nil {
+		return h.proxyCtrl.ProjectUpstreamClient(projectID)
+	}
+	return upstream.NewClient(upstream.DefaultConfig())
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/rewrite"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// RewriteView renders the match-and-replace rules page.
+func (h *Handlers) RewriteView(w http.ResponseWriter, r *http.Request) {
+	h.render(w, "rewrite.html", map[string]any{
+		"Title": "Match & Replace",
+	})
+}
+
+// RewriteList handles GET /api/rewrite.
+func (h *Handlers) RewriteList(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.RewriteRepo.List()
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if rules == nil {
+		rules = []schema.RewriteRule{}
+	}
+	jsonResp(w, http.StatusOK, rules)
+}
+
+// RewriteCreate handles POST /api/rewrite — adds a rule to the end of the
+// list. New rules are enabled.
+func (h *Handlers) RewriteCreate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name    string `json:"name"`
+		Phase   string `json:"phase"`
+		Kind    string `json:"kind"`
+		Host    string `json:"host"`
+		Match   string `json:"match"`
+		Replace string `json:"replace"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	rule := &schema.RewriteRule{
+		ID:        generateID(),
+		Name:      strings.TrimSpace(body.Name),
+		Enabled:   true,
+		Phase:     body.Phase,
+		Kind:      body.Kind,
+		Host:      strings.TrimSpace(body.Host),
+		Match:     body.Match,
+		Replace:   body.Replace,
+		CreatedAt: time.Now().UTC(),
+	}
+	if rule.Phase == "" {
+		rule.Phase = rewrite.PhaseRequest
+	}
+	if err := rewrite.Validate(*rule); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.RewriteRepo.Create(rule); err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.applyRewriteRules()
+	jsonResp(w, http.StatusCreated, rule)
+}
+
+// RewriteToggle handles POST /api/rewrite/{id}/toggle with {"enabled": bool}.
+func (h *Handlers) RewriteToggle(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if err := h.RewriteRepo.SetEnabled(r.PathValue("id"), body.Enabled); err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.applyRewriteRules()
+	jsonResp(w, http.StatusOK, map[string]bool{"enabled": body.Enabled})
+}
+
+// RewriteDelete handles DELETE /api/rewrite/{id}.
+func (h *Handlers) RewriteDelete(w http.ResponseWriter, r *http.Request) {
+	if err := h.RewriteRepo.Delete(r.PathValue("id")); err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.applyRewriteRules()
+	jsonResp(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// applyRewriteRules loads the saved rules into the proxy. Rules apply to
+// all proxied traffic, whichever project it is linked to.
+func (h *Handlers) applyRewriteRules() {
+	if h.proxyCtrl == nil || h.RewriteRepo == nil {
+		return
+	}
+	rules, err := h.RewriteRepo.List()
+	if err != nil {
+		log.Printf("load rewrite rules: %v", err)
+		return
+	}
+	e, err := rewrite.Compile(rules)
+	if err != nil {
+		log.Printf("compile rewrite rules: %v", err)
+		return
+	}
+	h.proxyCtrl.SetRewriter(e)
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/scope"
+)
+
+// ScopeRuleList handles GET /api/projects/{id}/scope.
+func (h *Handlers) ScopeRuleList(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.ProjectRepo.ListScopeRules(r.PathValue("id"))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if rules == nil {
+		rules = []schema.ScopeRule{}
+	}
+	jsonResp(w, http.StatusOK, rules)
+}
+
+// ScopeRuleCreate handles POST /api/projects/{id}/scope — adds an include or
+// exclude rule for hosts (glob), paths (regex), or operation names (glob).
+func (h *Handlers) ScopeRuleCreate(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	project, err := h.ProjectRepo.Get(id)
+	if err != nil || project == nil {
+		jsonErr(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	var body struct {
+		Kind    string `json:"kind"`
+		Pattern string `json:"pattern"`
+		Exclude bool   `json:"exclude"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	rule := &schema.ScopeRule{
+		ID:        generateID(),
+		ProjectID: id,
+		Kind:      body.Kind,
+		Pattern:   strings.TrimSpace(body.Pattern),
+		Exclude:   body.Exclude,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := scope.Validate(*rule); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.ProjectRepo.CreateScopeRule(rule); err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.refreshScope(id)
+	jsonResp(w, http.StatusCreated, rule)
+}
+
+// ScopeRuleDelete handles DELETE /api/projects/{id}/scope/{ruleID}.
+func (h *Handlers) ScopeRuleDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.ProjectRepo.DeleteScopeRule(id, r.PathValue("ruleID")); err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.refreshScope(id)
+	jsonResp(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// applyScope loads a project's scope rules into the proxy. An empty project
+// ID, or a project without rules, stores all traffic.
+func (h *Handlers) applyScope(projectID string) {
+	if h.proxyCtrl == nil {
+		return
+	}
+	if projectID == "" {
+		h.proxyCtrl.SetScope("", nil)
+		return
+	}
+	rules, err := h.ProjectRepo.ListScopeRules(projectID)
+	if err != nil {
+		log.Printf("load scope rules for project %s: %v", projectID, err)
+		return
+	}
+	var s *scope.Scope
+	if len(rules) > 0 {
+		if s, err = scope.Compile(rules); err != nil {
+			log.Printf("compile scope rules for project %s: %v", projectID, err)
+			return
+		}
+	}
+	h.proxyCtrl.SetScope(projectID, s)
+}
+
+// refreshScope reapplies a project's rules if the proxy or one of its
+// listeners is currently capturing into it.
+func (h *Handlers) refreshScope(projectID string) {
+	if h.proxyCtrl == nil {
+		return
+	}
+	if h.proxyCtrl.GetProjectID() == projectID {
+		h.applyScope(projectID)
+		return
+	}
+	for _, l := range h.proxyCtrl.Listeners() {
+		if l.ProjectID == projectID {
+			h.applyScope(projectID)
+			return
+		}
+	}
+}
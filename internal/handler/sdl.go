@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/parser"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// SDLImport handles POST /api/sdl — parses an uploaded or pasted SDL document.
+func (h *Handlers) SDLImport(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 10<<20)) // 10MB limit
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, "failed to read body: "+err.Error())
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = fmt.Sprintf("schema_%s", time.Now().Format("20060102_150405"))
+	}
+
+	id := generateID()
+	parsed, err := parser.ParseSDL(string(body), id, name)
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, "parse error: "+err.Error())
+		return
+	}
+
+	if err := h.SchemaRepo.Save(parsed, string(body)); err != nil {
+		jsonErr(w, http.StatusInternalServerError, "save error: "+err.Error())
+		return
+	}
+
+	ops := schema.GetOperations(parsed)
+	userTypes := schema.UserTypes(parsed)
+
+	jsonResp(w, http.StatusOK, map[string]any{
+		"id":            parsed.ID,
+		"name":          parsed.Name,
+		"typeCount":     len(userTypes),
+		"queryCount":    countOps(ops, "query"),
+		"mutationCount": countOps(ops, "mutation"),
+		"subCount":      countOps(ops, "subscription"),
+		"redirectURL":   "/schema/" + parsed.ID,
+	})
+}
+
+// SchemaSDL handles GET /api/schema/{id}/sdl — renders a stored schema as SDL.
+// Pass ?download=1 to receive it as a .graphql attachment.
+func (h *Handlers) SchemaSDL(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s, err := h.SchemaRepo.Get(id)
+	if err != nil || s == nil {
+		jsonErr(w, http.StatusNotFound, "schema not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if r.URL.Query().Get("download") != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.graphql"`, sdlFilename(s.Name)))
+	}
+	io.WriteString(w, parser.PrintSDL(s))
+}
+
+// sdlFilename reduces a schema name to characters safe for a download filename.
+func sdlFilename(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+	if safe == "" {
+		return "schema"
+	}
+	return safe
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/subscription"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+)
+
+// SubscriptionsView renders the subscription runner page. With ?traffic=ID
+// the form is prefilled from a captured WebSocket or SSE operation.
+func (h *Handlers) SubscriptionsView(w http.ResponseWriter, r *http.Request) {
+	data := map[string]any{
+		"Title": "Subscriptions",
+	}
+	if id := r.URL.Query().Get("traffic"); id != "" {
+		captured, err := h.TrafficRepo.Get(id)
+		if err != nil || captured == nil {
+			http.Error(w, "Captured request not found", http.StatusNotFound)
+			return
+		}
+		captured.ResponseBody = nil
+		data["Traffic"] = captured
+	}
+	h.render(w, "subscriptions.html", data)
+}
+
+// TrafficMessages handles GET /api/proxy/traffic/{id}/messages — returns the
+// WebSocket or SSE messages recorded for a streamed request.
+func (h *Handlers) TrafficMessages(w http.ResponseWriter, r *http.Request) {
+	msgs, err := h.TrafficRepo.Messages(r.PathValue("id"))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, msgs)
+}
+
+type subscriptionRequest struct {
+	URL              string            `json:"url"`
+	Protocol         string            `json:"protocol"`
+	Headers          map[string]string `json:"headers"`
+	ConnectionParams json.RawMessage   `json:"connectionParams"`
+	Query            string            `json:"query"`
+	OperationName    string            `json:"operationName"`
+	Variables        json.RawMessage   `json:"variables"`
+	ProjectID        string            `json:"projectId"`
+}
+
+// SubscriptionRun handles POST /api/subscription — connects to the target
+// and streams subscription events back as newline-delimited JSON until the
+// server ends the subscription or the browser disconnects.
+func (h *Handlers) SubscriptionRun(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		jsonErr(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	var req subscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if req.URL == "" || strings.TrimSpace(req.Query) == "" {
+		jsonErr(w, http.StatusBadRequest, "url and query are required")
+		return
+	}
+	switch req.Protocol {
+	case "", subscription.ProtocolTransportWS, subscription.ProtocolLegacyWS, subscription.ProtocolSSE:
+	default:
+		jsonErr(w, http.StatusBadRequest, "unknown protocol: "+req.Protocol)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	enc := json.NewEncoder(w)
+	emit := func(e subscription.Event) {
+		enc.Encode(e) //nolint:errcheck
+		flusher.Flush()
+	}
+	emit(subscription.Event{Time: time.Now().UTC(), Type: "connecting", Message: req.URL})
+
+	err := subscription.Run(r.Context(), subscription.Options{
+		URL:              req.URL,
+		Protocol:         req.Protocol,
+		Headers:          req.Headers,
+		ConnectionParams: req.ConnectionParams,
+		Query:            req.Query,
+		OperationName:    req.OperationName,
+		Variables:        req.Variables,
+		Upstream:         h.upstreamConfig(req.ProjectID),
+	}, emit)
+	if err != nil && r.Context().Err() == nil {
+		emit(subscription.Event{Time: time.Now().UTC(), Type: "error", Message: err.Error()})
+	}
+}
+
+// upstreamConfig returns the upstream settings of a project's traffic, or
+// of traffic outside any project when projectID is empty, falling back to
+// the defaults when no proxy is wired.
+func (h *Handlers) upstreamConfig(projectID string) upstream.Config {
+	if h.proxyCtrl != nil {
+		return h.proxyCtrl.ProjectUpstreamConfig(projectID)
+	}
+	return upstream.DefaultConfig()
+}
+
+// schemaProject returns the project a schema belongs to, whose upstream
+// settings the active modules run against it with, or "" if it belongs to
+// none.
+func (h *Handlers) schemaProject(schemaID string) string {
+	if schemaID == "" {
+		return ""
+	}
+	id, err := h.ProjectRepo.ProjectForSchema(schemaID)
+	if err != nil {
+		log.Printf("project for schema %s: %v", schemaID, err)
+	}
+	return id
+}
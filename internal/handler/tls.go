@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+)
+
+// ProjectTLS handles GET /api/projects/{id}/tls — returns the project's
+// upstream TLS profile. Key material isn't sent back; clientCert describes
+// the loaded certificate instead.
+func (h *Handlers) ProjectTLS(w http.ResponseWriter, r *http.Request) {
+	p, err := h.ProjectRepo.TLSProfile(r.PathValue("id"))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, tlsSummary(p))
+}
+
+// ProjectSetTLS handles POST /api/projects/{id}/tls — saves the project's
+// upstream TLS profile: a client certificate (PEM or PKCS#12), extra CA
+// certificates, and the verification policy. A profile without a new
+// certificate keeps the one already saved. The project's proxied traffic,
+// listeners, and tools run against its targets use it.
+func (h *Handlers) ProjectSetTLS(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	project, err := h.ProjectRepo.Get(id)
+	if err != nil || project == nil {
+		jsonErr(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	var p schema.TLSProfile
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<20)).Decode(&p); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if p.ClientCert == "" && p.ClientKey == "" && len(p.PKCS12) == 0 {
+		if old, err := h.ProjectRepo.TLSProfile(id); err == nil && old != nil {
+			p.ClientCert, p.ClientKey = old.ClientCert, old.ClientKey
+			p.PKCS12, p.PKCS12Password = old.PKCS12, old.PKCS12Password
+		}
+	}
+	var hosts []string
+	for _, host := range p.SkipVerifyHosts {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	p.SkipVerifyHosts = hosts
+
+	if _, err := withTLSProfile(upstream.DefaultConfig(), &p); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.ProjectRepo.UpdateTLSProfile(id, &p); err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.refreshProjectUpstream(id)
+	jsonResp(w, http.StatusOK, tlsSummary(&p))
+}
+
+// ProjectDeleteTLS handles DELETE /api/projects/{id}/tls — removes the
+// project's TLS profile, going back to the default of not verifying
+// certificates and presenting none.
+func (h *Handlers) ProjectDeleteTLS(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.ProjectRepo.UpdateTLSProfile(id, nil); err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.refreshProjectUpstream(id)
+	jsonResp(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// projectUpstream is the proxy's resolver for a project's upstream
+// settings: base with the project's upstream proxy, when it has one, and
+// its TLS profile, so one project's client certificate or tunnel is never
+// used for another project's targets.
+func (h *Handlers) projectUpstream(projectID string, base upstream.Config) (upstream.Config, error) {
+	project, err := h.ProjectRepo.Get(projectID)
+	if err != nil {
+		return base, err
+	}
+	if project == nil {
+		return base, fmt.Errorf("project %s not found", projectID)
+	}
+	if project.UpstreamProxy != "" {
+		base.ProxyURL = project.UpstreamProxy
+	}
+	p, err := h.ProjectRepo.TLSProfile(projectID)
+	if err != nil {
+		return base, err
+	}
+	return withTLSProfile(base, p)
+}
+
+// refreshProjectUpstream makes the proxy pick up a project's changed
+// upstream settings.
+func (h *Handlers) refreshProjectUpstream(projectID string) {
+	if h.proxyCtrl != nil {
+		h.proxyCtrl.RefreshProjectUpstream(projectID)
+	}
+}
+
+// withTLSProfile returns cfg with its TLS settings taken from p, or reset
+// to the defaults when p is nil.
+func withTLSProfile(cfg upstream.Config, p *schema.TLSProfile) (upstream.Config, error) {
+	cfg.InsecureSkipVerify = upstream.DefaultConfig().InsecureSkipVerify
+	cfg.ClientCert, cfg.RootCAs, cfg.SkipVerifyHosts = nil, nil, nil
+	if p == nil {
+		return cfg, nil
+	}
+
+	cfg.InsecureSkipVerify = !p.Verify
+	if err := upstream.ValidateHostGlobs(p.SkipVerifyHosts); err != nil {
+		return cfg, err
+	}
+	cfg.SkipVerifyHosts = p.SkipVerifyHosts
+	if p.ClientCert != "" || p.ClientKey != "" || len(p.PKCS12) > 0 {
+		cert, err := upstream.LoadClientCert(p.ClientCert, p.ClientKey, p.PKCS12, p.PKCS12Password)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.ClientCert = cert
+	}
+	if strings.TrimSpace(p.RootCAs) != "" {
+		roots, err := upstream.LoadRootCAs(p.RootCAs)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.RootCAs = roots
+	}
+	return cfg, nil
+}
+
+// tlsSummary describes a profile for the UI without its key material.
+func tlsSummary(p *schema.TLSProfile) map[string]any {
+	if p == nil {
+		return map[string]any{"configured": false, "verify": false, "skipVerifyHosts": []string{}}
+	}
+	clientCert := ""
+	if p.ClientCert != "" || len(p.PKCS12) > 0 {
+		if cert, err := upstream.LoadClientCert(p.ClientCert, p.ClientKey, p.PKCS12, p.PKCS12Password); err == nil && cert.Leaf != nil {
+			clientCert = cert.Leaf.Subject.String() + " (expires " + cert.Leaf.NotAfter.Format("2006-01-02") + ")"
+		}
+	}
+	hosts := p.SkipVerifyHosts
+	if hosts == nil {
+		hosts = []string{}
+	}
+	return map[string]any{
+		"configured":      true,
+		"verify":          p.Verify,
+		"skipVerifyHosts": hosts,
+		"rootCAs":         p.RootCAs,
+		"clientCert":      clientCert,
+	}
+}
@@ -0,0 +1,349 @@
+// Package har reads and writes HTTP Archive (HAR 1.2) files: importing a
+// browser's or another proxy's recorded traffic, and exporting captured
+// GraphQL traffic for other tools.
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// HAR is the top-level object of a HAR file.
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log holds the recorded entries.
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator names the application that wrote the file.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is one request/response exchange. Time is the total in
+// milliseconds.
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"`
+	Request         Request   `json:"request"`
+	Response        Response  `json:"response"`
+	Cache           struct{}  `json:"cache"`
+	Timings         Timings   `json:"timings"`
+}
+
+// Request is an entry's request.
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Response is an entry's response.
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// NameValue is a header, cookie, or query string parameter.
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is a request body. Encoding is "base64" when Text is encoded,
+// as for a binary file upload.
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Content is a response body. Encoding is "base64" when Text is encoded.
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Timings splits an entry's time into phases, in milliseconds; -1 means
+// the phase doesn't apply or wasn't measured.
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Decode reads a HAR file.
+func Decode(r io.Reader) (*HAR, error) {
+	var h HAR
+	if err := json.NewDecoder(r).Decode(&h); err != nil {
+		return nil, fmt.Errorf("decode HAR: %w", err)
+	}
+	return &h, nil
+}
+
+// HTTPRequest rebuilds the entry's request. HTTP/2 pseudo-headers and
+// Content-Length are dropped; the body sets the length.
+func (e *Entry) HTTPRequest() (*http.Request, error) {
+	var body []byte
+	if e.Request.PostData != nil {
+		body = decodeText(e.Request.PostData.Text, e.Request.PostData.Encoding)
+	}
+	req, err := http.NewRequest(e.Request.Method, e.Request.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("entry request: %w", err)
+	}
+	for _, h := range e.Request.Headers {
+		if strings.HasPrefix(h.Name, ":") || strings.EqualFold(h.Name, "Content-Length") {
+			continue
+		}
+		req.Header.Add(h.Name, h.Value)
+	}
+	if req.Header.Get("Content-Type") == "" && e.Request.PostData != nil && e.Request.PostData.MimeType != "" {
+		req.Header.Set("Content-Type", e.Request.PostData.MimeType)
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	if e.Request.HTTPVersion != "" {
+		req.Proto = strings.ToUpper(e.Request.HTTPVersion)
+	}
+	return req, nil
+}
+
+// HTTPResponse rebuilds the entry's response head. Body returns its body.
+func (e *Entry) HTTPResponse() *http.Response {
+	resp := &http.Response{
+		StatusCode: e.Response.Status,
+		Status:     fmt.Sprintf("%d %s", e.Response.Status, e.Response.StatusText),
+		Header:     make(http.Header),
+	}
+	for _, h := range e.Response.Headers {
+		if !strings.HasPrefix(h.Name, ":") {
+			resp.Header.Add(h.Name, h.Value)
+		}
+	}
+	return resp
+}
+
+// Body returns the response body, decoded if it was stored as base64.
+func (c *Content) Body() []byte {
+	return decodeText(c.Text, c.Encoding)
+}
+
+// decodeText returns a body stored as text, decoding it if encoding is
+// "base64".
+func decodeText(text, encoding string) []byte {
+	if encoding == "base64" {
+		if b, err := base64.StdEncoding.DecodeString(text); err == nil {
+			return b
+		}
+	}
+	return []byte(text)
+}
+
+// FromCaptures writes captures as HAR entries, in order. The operations of
+// a batched request make up one entry, whose response is the array of
+// their responses.
+func FromCaptures(captures []schema.CapturedRequest, creator Creator) *HAR {
+	h := &HAR{Log: Log{Version: "1.2", Creator: creator, Entries: []Entry{}}}
+	batches := map[string]int{} // batch ID -> index in Entries
+	members := map[string][]schema.CapturedRequest{}
+	for _, c := range captures {
+		if c.BatchID != "" {
+			members[c.BatchID] = append(members[c.BatchID], c)
+			if _, ok := batches[c.BatchID]; ok {
+				continue
+			}
+			batches[c.BatchID] = len(h.Log.Entries)
+		}
+		h.Log.Entries = append(h.Log.Entries, entry(c))
+	}
+	for id, i := range batches {
+		if body := batchResponse(members[id]); body != nil {
+			setResponseBody(&h.Log.Entries[i].Response, body)
+		}
+	}
+	return h
+}
+
+// entry converts one capture.
+func entry(c schema.CapturedRequest) Entry {
+	proto := c.Protocol
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	e := Entry{
+		StartedDateTime: c.Timestamp.Add(-time.Duration(c.DurationMs) * time.Millisecond),
+		Time:            float64(c.DurationMs),
+		Request: Request{
+			Method:      c.Method,
+			URL:         c.URL,
+			HTTPVersion: proto,
+			Cookies:     []NameValue{},
+			Headers:     nameValues(c.Headers),
+			QueryString: []NameValue{},
+			HeadersSize: -1,
+		},
+		Response: Response{
+			Status:      c.ResponseCode,
+			StatusText:  http.StatusText(c.ResponseCode),
+			HTTPVersion: proto,
+			Cookies:     []NameValue{},
+			Headers:     nameValues(c.ResponseHeaders),
+			HeadersSize: -1,
+		},
+		Timings: Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: float64(c.DurationMs)},
+	}
+	if u, err := url.Parse(c.URL); err == nil {
+		for name, values := range u.Query() {
+			for _, v := range values {
+				e.Request.QueryString = append(e.Request.QueryString, NameValue{Name: name, Value: v})
+			}
+		}
+		sort.Slice(e.Request.QueryString, func(i, j int) bool {
+			return e.Request.QueryString[i].Name < e.Request.QueryString[j].Name
+		})
+	}
+
+	body, mimeType := c.RequestBody, headerValue(c.Headers, "Content-Type")
+	if len(body) == 0 && c.Method != http.MethodGet && c.Query != "" {
+		body, mimeType = operationBody(c), "application/json"
+	}
+	if len(body) > 0 {
+		if mimeType == "" {
+			mimeType = "application/json"
+		}
+		e.Request.PostData = &PostData{MimeType: mimeType}
+		e.Request.PostData.Text, e.Request.PostData.Encoding = encodeText(body)
+		e.Request.BodySize = len(body)
+	}
+	setResponseBody(&e.Response, c.ResponseBody)
+	return e
+}
+
+// operationBody rebuilds a JSON request body for a capture stored before
+// raw bodies were kept, or a file upload whose body wasn't kept.
+func operationBody(c schema.CapturedRequest) []byte {
+	payload := map[string]any{"query": c.Query}
+	if c.OperationName != "" {
+		payload["operationName"] = c.OperationName
+	}
+	if len(c.Variables) > 0 {
+		payload["variables"] = c.Variables
+	}
+	b, _ := json.Marshal(payload)
+	return b
+}
+
+// batchResponse returns the response array of a batch's members, or nil if
+// every member kept the whole response (it wasn't an array to split).
+func batchResponse(members []schema.CapturedRequest) []byte {
+	if len(members) < 2 {
+		return nil
+	}
+	same := true
+	for _, m := range members[1:] {
+		if !bytes.Equal(m.ResponseBody, members[0].ResponseBody) {
+			same = false
+			break
+		}
+	}
+	if same {
+		return nil
+	}
+	sort.SliceStable(members, func(i, j int) bool { return members[i].BatchIndex < members[j].BatchIndex })
+	parts := make([]json.RawMessage, len(members))
+	for i, m := range members {
+		parts[i] = m.ResponseBody
+		if len(parts[i]) == 0 {
+			parts[i] = json.RawMessage("null")
+		}
+	}
+	b, err := json.Marshal(parts)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+func setResponseBody(r *Response, body []byte) {
+	mimeType := headerValue(headerMap(r.Headers), "Content-Type")
+	if mimeType == "" {
+		mimeType = "application/json"
+	}
+	r.Content = Content{Size: len(body), MimeType: mimeType}
+	r.Content.Text, r.Content.Encoding = encodeText(body)
+	r.BodySize = len(body)
+}
+
+// encodeText returns body as text for a HAR file, base64-encoded when it
+// isn't valid UTF-8 and so wouldn't survive as a JSON string.
+func encodeText(body []byte) (text, encoding string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// nameValues converts a header map, sorted by name.
+func nameValues(m map[string]string) []NameValue {
+	out := make([]NameValue, 0, len(m))
+	for name, value := range m {
+		out = append(out, NameValue{Name: name, Value: value})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func headerMap(nvs []NameValue) map[string]string {
+	m := make(map[string]string, len(nvs))
+	for _, nv := range nvs {
+		m[nv.Name] = nv.Value
+	}
+	return m
+}
+
+func headerValue(m map[string]string, name string) string {
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
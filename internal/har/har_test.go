@@ -0,0 +1,152 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// roundTrip encodes captures as a HAR file and decodes it again.
+func roundTrip(t *testing.T, captures []schema.CapturedRequest) *HAR {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(FromCaptures(captures, Creator{Name: "test", Version: "1"})); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	h, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return h
+}
+
+func TestRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	h := roundTrip(t, []schema.CapturedRequest{
+		{
+			Timestamp: ts, DurationMs: 250, Method: "POST", URL: "https://api.example.com/graphql?v=2",
+			Headers:       map[string]string{"Content-Type": "application/json", "Authorization": "Bearer x"},
+			OperationName: "Me", Query: "query Me { me { id } }", Variables: json.RawMessage(`{"a":1}`),
+			ResponseCode: 200, ResponseBody: []byte(`{"data":{"me":{"id":"1"}}}`),
+			ResponseHeaders: map[string]string{"Content-Type": "application/json"},
+			Protocol:        "HTTP/2.0",
+		},
+		{
+			Timestamp: ts, Method: "GET", URL: "https://api.example.com/graphql?query=%7B+a+%7D",
+			Query: "{ a }", ResponseCode: 200, ResponseBody: []byte{0x1f, 0x8b, 0xff, 0x00},
+		},
+	})
+
+	if h.Log.Version != "1.2" || len(h.Log.Entries) != 2 {
+		t.Fatalf("log = %+v", h.Log)
+	}
+
+	post := h.Log.Entries[0]
+	if !post.StartedDateTime.Equal(ts.Add(-250*time.Millisecond)) || post.Time != 250 {
+		t.Errorf("timing = %v, %v", post.StartedDateTime, post.Time)
+	}
+	req, err := post.HTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(req.Body)
+	var payload struct {
+		Query         string          `json:"query"`
+		OperationName string          `json:"operationName"`
+		Variables     json.RawMessage `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("request body %s: %v", body, err)
+	}
+	if payload.Query != "query Me { me { id } }" || payload.OperationName != "Me" || string(payload.Variables) != `{"a":1}` {
+		t.Errorf("request body = %s", body)
+	}
+	if req.Method != "POST" || req.URL.String() != "https://api.example.com/graphql?v=2" || req.Proto != "HTTP/2.0" {
+		t.Errorf("request = %s %s %s", req.Method, req.URL, req.Proto)
+	}
+	if req.Header.Get("Authorization") != "Bearer x" || req.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("headers = %v", req.Header)
+	}
+	if len(post.Request.QueryString) != 1 || post.Request.QueryString[0] != (NameValue{"v", "2"}) {
+		t.Errorf("queryString = %v", post.Request.QueryString)
+	}
+	resp := post.HTTPResponse()
+	if resp.StatusCode != 200 || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("response = %d %v", resp.StatusCode, resp.Header)
+	}
+	if got := post.Response.Content.Body(); string(got) != `{"data":{"me":{"id":"1"}}}` {
+		t.Errorf("response body = %s", got)
+	}
+
+	get := h.Log.Entries[1]
+	if get.Request.PostData != nil {
+		t.Errorf("GET has postData %+v", get.Request.PostData)
+	}
+	if get.Response.Content.Encoding != "base64" {
+		t.Errorf("binary response encoding = %q", get.Response.Content.Encoding)
+	}
+	if got := get.Response.Content.Body(); !bytes.Equal(got, []byte{0x1f, 0x8b, 0xff, 0x00}) {
+		t.Errorf("binary response body = %v", got)
+	}
+}
+
+func TestBatchResponse(t *testing.T) {
+	ts := time.Now().UTC()
+	h := roundTrip(t, []schema.CapturedRequest{
+		{Timestamp: ts, Method: "POST", URL: "https://x/graphql", Query: "{ b }", BatchID: "1", BatchIndex: 1, ResponseBody: []byte(`{"data":{"b":2}}`)},
+		{Timestamp: ts, Method: "POST", URL: "https://x/graphql", Query: "{ a }", BatchID: "1", BatchIndex: 0, ResponseBody: []byte(`{"data":{"a":1}}`)},
+		{Timestamp: ts, Method: "POST", URL: "https://x/graphql", Query: "{ c }", ResponseBody: []byte(`{"data":{"c":3}}`)},
+	})
+	if len(h.Log.Entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(h.Log.Entries))
+	}
+	if got := string(h.Log.Entries[0].Response.Content.Body()); got != `[{"data":{"a":1}},{"data":{"b":2}}]` {
+		t.Errorf("batch response = %s", got)
+	}
+}
+
+func TestHTTPRequestDropsPseudoHeaders(t *testing.T) {
+	e := Entry{Request: Request{
+		Method: "POST", URL: "https://x/graphql", HTTPVersion: "h2",
+		Headers: []NameValue{
+			{":authority", "x"}, {"Content-Length", "999"}, {"Host", "y"},
+		},
+		PostData: &PostData{MimeType: "application/json", Text: `{"query":"{ a }"}`},
+	}}
+	req, err := e.HTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Header.Get(":authority") != "" || req.Header.Get("Content-Length") != "" {
+		t.Errorf("headers = %v", req.Header)
+	}
+	if req.Host != "y" || req.Header.Get("Content-Type") != "application/json" || req.ContentLength != 17 {
+		t.Errorf("request = host %s, %v, length %d", req.Host, req.Header, req.ContentLength)
+	}
+}
+
+func TestUploadBodyRoundTrip(t *testing.T) {
+	body := []byte("--XyZ\r\nContent-Disposition: form-data; name=\"0\"; filename=\"a.png\"\r\n\r\n\x89PNG\x00\xff\r\n--XyZ--\r\n")
+	h := roundTrip(t, []schema.CapturedRequest{{
+		Timestamp: time.Now().UTC(), Method: "POST", URL: "https://x/graphql",
+		Headers:     map[string]string{"Content-Type": "multipart/form-data; boundary=XyZ"},
+		Query:       "mutation($f: Upload!) { up(file: $f) }",
+		RequestBody: body,
+	}})
+	post := h.Log.Entries[0].Request.PostData
+	if post == nil || post.Encoding != "base64" || post.MimeType != "multipart/form-data; boundary=XyZ" {
+		t.Fatalf("postData = %+v", post)
+	}
+	req, err := h.Log.Entries[0].HTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(req.Body)
+	if !bytes.Equal(got, body) {
+		t.Errorf("request body = %q, want %q", got, body)
+	}
+}
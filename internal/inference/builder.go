@@ -7,7 +7,6 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"regexp"
 	"strings"
 	"time"
 
@@ -15,8 +14,6 @@ import (
 	"github.com/0xDTC/0xGQLForge/internal/schema"
 )
 
-var opDeclRe = regexp.MustCompile(`(?i)^\s*(query|mutation|subscription)\b`)
-
 // BuildFromTraffic synthesises a Schema from captured proxy traffic.
 //
 // Strategy (best-data-first):
@@ -24,8 +21,9 @@ var opDeclRe = regexp.MustCompile(`(?i)^\s*(query|mutation|subscription)\b`)
 //     directly — this gives a complete, accurate schema.
 //  2. Otherwise walk every response body's "data" object to infer object
 //     types from the actual JSON shape, producing real graph edges.
-//  3. Fall back to operation-name-only entries for requests with no
-//     parseable response.
+//  3. For requests with no parseable response, infer fields from what the
+//     query document selects (this covers operations found in JS bundles).
+//  4. Fall back to operation-name-only entries for the rest.
 func BuildFromTraffic(reqs []schema.CapturedRequest, projectName string) *schema.Schema {
 	// Phase 1 — introspection auto-detection.
 	for _, req := range reqs {
@@ -39,6 +37,7 @@ func BuildFromTraffic(reqs []schema.CapturedRequest, projectName string) *schema
 	queryFields := map[string]schema.Field{}
 	mutFields := map[string]schema.Field{}
 	subFields := map[string]schema.Field{}
+	var docOnly []schema.CapturedRequest
 
 	for _, req := range reqs {
 		if req.Query == "" {
@@ -72,6 +71,13 @@ func BuildFromTraffic(reqs []schema.CapturedRequest, projectName string) *schema
 			}
 		}
 
+		// Nothing from the response: the document's own selections are
+		// merged in below.
+		if len(rootFields) == 0 && hasOperation(req) {
+			docOnly = append(docOnly, req)
+			continue
+		}
+
 		// Fallback: if we got nothing from the response, at least record
 		// the operation name so it appears in the schema.
 		// Use a descriptive return type name based on the operation.
@@ -97,6 +103,35 @@ func BuildFromTraffic(reqs []schema.CapturedRequest, projectName string) *schema
 		}
 	}
 
+	// Phase 3 — selection-set inference for operations without responses.
+	// Fields already inferred from responses win.
+	if len(docOnly) > 0 {
+		rs := parser.ReconstructSchema(docOnly, "", "")
+		for _, t := range rs.Types {
+			var bucket map[string]schema.Field
+			switch t.Name {
+			case "Query":
+				bucket = queryFields
+			case "Mutation":
+				bucket = mutFields
+			case "Subscription":
+				bucket = subFields
+			default:
+				if existing, ok := typeMap[t.Name]; ok {
+					typeMap[t.Name] = mergeType(existing, t)
+				} else {
+					typeMap[t.Name] = t
+				}
+				continue
+			}
+			for _, f := range t.Fields {
+				if _, ok := bucket[f.Name]; !ok {
+					bucket[f.Name] = f
+				}
+			}
+		}
+	}
+
 	s := &schema.Schema{
 		ID:        generateID(),
 		Name:      projectName + " (inferred)",
@@ -290,15 +325,16 @@ func mergeType(a, b schema.Type) schema.Type {
 	return merged
 }
 
+// hasOperation reports whether req's query parses and selects the operation
+// it names.
+func hasOperation(req schema.CapturedRequest) bool {
+	doc, err := parser.ParseDocument(req.Query)
+	return err == nil && parser.SelectOperation(doc, req.OperationName) != nil
+}
+
 // parseOpKind returns "query", "mutation", or "subscription".
 func parseOpKind(query string) string {
-	for _, line := range strings.Split(query, "\n") {
-		m := opDeclRe.FindStringSubmatch(strings.TrimSpace(line))
-		if m != nil {
-			return strings.ToLower(m[1])
-		}
-	}
-	return "query"
+	return parser.OperationType(query)
 }
 
 func isIDField(name string) bool {
@@ -0,0 +1,278 @@
+package parser
+
+// Position is a location in the source document. Line and Column are 1-based;
+// Column counts runes, matching what editors and GraphQL servers report.
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Document is the root of a parsed GraphQL document.
+type Document struct {
+	Definitions []Definition
+}
+
+// Definition is a top-level document definition.
+type Definition interface {
+	Pos() Position
+	definitionNode()
+}
+
+// OperationDefinition is a query, mutation, or subscription. Anonymous
+// shorthand queries ("{ ... }") have Operation "query" and an empty Name.
+type OperationDefinition struct {
+	Operation           string // "query", "mutation", "subscription"
+	Name                string
+	VariableDefinitions []*VariableDefinition
+	Directives          []*Directive
+	SelectionSet        []Selection
+	Position            Position
+}
+
+// FragmentDefinition is a named, reusable selection set.
+type FragmentDefinition struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Position      Position
+}
+
+func (d *OperationDefinition) Pos() Position { return d.Position }
+func (d *FragmentDefinition) Pos() Position  { return d.Position }
+
+func (*OperationDefinition) definitionNode() {}
+func (*FragmentDefinition) definitionNode()  {}
+
+// VariableDefinition declares an operation variable: $name: Type = default.
+type VariableDefinition struct {
+	Name         string
+	Type         Type
+	DefaultValue Value
+	Directives   []*Directive
+	Position     Position
+}
+
+// Directive is an applied directive: @name(args).
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+	Position  Position
+}
+
+// Argument is a name/value pair on a field or directive.
+type Argument struct {
+	Name     string
+	Value    Value
+	Position Position
+}
+
+// Selection is a Field, FragmentSpread, or InlineFragment.
+type Selection interface {
+	Pos() Position
+	selectionNode()
+}
+
+// Field is a field selection, optionally aliased.
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection
+	Position     Position
+}
+
+// ResponseKey returns the key this field occupies in the response: the alias
+// if present, otherwise the field name.
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// FragmentSpread references a named fragment: ...Name.
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Position   Position
+}
+
+// InlineFragment is an anonymous fragment: ... on Type { }. TypeCondition is
+// empty when omitted.
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Position      Position
+}
+
+func (s *Field) Pos() Position          { return s.Position }
+func (s *FragmentSpread) Pos() Position { return s.Position }
+func (s *InlineFragment) Pos() Position { return s.Position }
+
+func (*Field) selectionNode()          {}
+func (*FragmentSpread) selectionNode() {}
+func (*InlineFragment) selectionNode() {}
+
+// Type is a type reference in a variable definition: Named, [List], or NonNull!.
+type Type interface {
+	Pos() Position
+	typeNode()
+}
+
+// NamedType references a type by name.
+type NamedType struct {
+	Name     string
+	Position Position
+}
+
+// ListType wraps another type in a list.
+type ListType struct {
+	OfType   Type
+	Position Position
+}
+
+// NonNullType wraps a named or list type as non-null.
+type NonNullType struct {
+	OfType   Type
+	Position Position
+}
+
+func (t *NamedType) Pos() Position   { return t.Position }
+func (t *ListType) Pos() Position    { return t.Position }
+func (t *NonNullType) Pos() Position { return t.Position }
+
+func (*NamedType) typeNode()   {}
+func (*ListType) typeNode()    {}
+func (*NonNullType) typeNode() {}
+
+// Value is an input value literal or variable reference.
+type Value interface {
+	Pos() Position
+	valueNode()
+}
+
+// Variable references an operation variable: $name.
+type Variable struct {
+	Name     string
+	Position Position
+}
+
+// IntValue holds the raw text of an integer literal.
+type IntValue struct {
+	Raw      string
+	Position Position
+}
+
+// FloatValue holds the raw text of a float literal.
+type FloatValue struct {
+	Raw      string
+	Position Position
+}
+
+// StringValue holds the decoded contents of a string or block string literal.
+type StringValue struct {
+	Value    string
+	Block    bool
+	Position Position
+}
+
+// BooleanValue is true or false.
+type BooleanValue struct {
+	Value    bool
+	Position Position
+}
+
+// NullValue is the null literal.
+type NullValue struct {
+	Position Position
+}
+
+// EnumValue is a bare name used as a value.
+type EnumValue struct {
+	Name     string
+	Position Position
+}
+
+// ListValue is a list literal: [a, b].
+type ListValue struct {
+	Values   []Value
+	Position Position
+}
+
+// ObjectValue is an input object literal: {a: 1}.
+type ObjectValue struct {
+	Fields   []*ObjectField
+	Position Position
+}
+
+// ObjectField is one name/value pair inside an ObjectValue.
+type ObjectField struct {
+	Name     string
+	Value    Value
+	Position Position
+}
+
+func (v *Variable) Pos() Position     { return v.Position }
+func (v *IntValue) Pos() Position     { return v.Position }
+func (v *FloatValue) Pos() Position   { return v.Position }
+func (v *StringValue) Pos() Position  { return v.Position }
+func (v *BooleanValue) Pos() Position { return v.Position }
+func (v *NullValue) Pos() Position    { return v.Position }
+func (v *EnumValue) Pos() Position    { return v.Position }
+func (v *ListValue) Pos() Position    { return v.Position }
+func (v *ObjectValue) Pos() Position  { return v.Position }
+
+func (*Variable) valueNode()     {}
+func (*IntValue) valueNode()     {}
+func (*FloatValue) valueNode()   {}
+func (*StringValue) valueNode()  {}
+func (*BooleanValue) valueNode() {}
+func (*NullValue) valueNode()    {}
+func (*EnumValue) valueNode()    {}
+func (*ListValue) valueNode()    {}
+func (*ObjectValue) valueNode()  {}
+
+// Operations returns the document's operation definitions in source order.
+func (d *Document) Operations() []*OperationDefinition {
+	var ops []*OperationDefinition
+	for _, def := range d.Definitions {
+		if op, ok := def.(*OperationDefinition); ok {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// Fragments returns the document's fragment definitions keyed by name.
+func (d *Document) Fragments() map[string]*FragmentDefinition {
+	frags := make(map[string]*FragmentDefinition)
+	for _, def := range d.Definitions {
+		if f, ok := def.(*FragmentDefinition); ok {
+			frags[f.Name] = f
+		}
+	}
+	return frags
+}
+
+// Operation selects the operation to execute, following the spec's
+// GetOperation rules: with an empty name the document must contain exactly
+// one operation. Returns nil if no operation matches.
+func (d *Document) Operation(name string) *OperationDefinition {
+	ops := d.Operations()
+	if name == "" {
+		if len(ops) == 1 {
+			return ops[0]
+		}
+		return nil
+	}
+	for _, op := range ops {
+		if op.Name == name {
+			return op
+		}
+	}
+	return nil
+}
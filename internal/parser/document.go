@@ -0,0 +1,517 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// ParseDocument parses an executable GraphQL document (operations and
+// fragments) per the October 2021 specification. Errors are *SyntaxError
+// values carrying the line and column of the offending token.
+func ParseDocument(src string) (*Document, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{}
+	for p.tok.kind != tokEOF {
+		def, err := p.parseExecutableDefinition()
+		if err != nil {
+			return nil, err
+		}
+		doc.Definitions = append(doc.Definitions, def)
+	}
+	if len(doc.Definitions) == 0 {
+		return nil, p.unexpected()
+	}
+	return doc, nil
+}
+
+// ParseValue parses a single input value literal such as `{a: [1, 2]}`.
+func ParseValue(src string) (Value, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+	v, err := p.parseValue(false)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokEOF); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ParseType parses a type reference such as `[ID!]!`.
+func ParseType(src string) (Type, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+	t, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokEOF); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parser is a recursive-descent parser with one token of lookahead.
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+func newParser(src string) (*parser, error) {
+	p := &parser{lex: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) errorAt(pos Position, format string, args ...any) *SyntaxError {
+	return &SyntaxError{Message: fmt.Sprintf(format, args...), Line: pos.Line, Column: pos.Column}
+}
+
+func (p *parser) unexpected() *SyntaxError {
+	return p.errorAt(p.tok.pos, "unexpected %s", p.tok.describe())
+}
+
+// peek reports whether the current token is of kind k.
+func (p *parser) peek(k tokenKind) bool {
+	return p.tok.kind == k
+}
+
+// peekKeyword reports whether the current token is the name kw.
+func (p *parser) peekKeyword(kw string) bool {
+	return p.tok.kind == tokName && p.tok.value == kw
+}
+
+// skip consumes the current token if it is of kind k.
+func (p *parser) skip(k tokenKind) (bool, error) {
+	if p.tok.kind != k {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+// expect consumes a token of kind k or fails.
+func (p *parser) expect(k tokenKind) error {
+	if p.tok.kind != k {
+		return p.errorAt(p.tok.pos, "expected %s, found %s", k, p.tok.describe())
+	}
+	return p.advance()
+}
+
+// expectKeyword consumes the name kw or fails.
+func (p *parser) expectKeyword(kw string) error {
+	if !p.peekKeyword(kw) {
+		return p.errorAt(p.tok.pos, "expected %q, found %s", kw, p.tok.describe())
+	}
+	return p.advance()
+}
+
+// parseName consumes a Name token and returns its value.
+func (p *parser) parseName() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.errorAt(p.tok.pos, "expected Name, found %s", p.tok.describe())
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) parseExecutableDefinition() (Definition, error) {
+	if p.peek(tokBraceL) {
+		return p.parseOperationDefinition()
+	}
+	if p.tok.kind == tokName {
+		switch p.tok.value {
+		case "query", "mutation", "subscription":
+			return p.parseOperationDefinition()
+		case "fragment":
+			return p.parseFragmentDefinition()
+		case "schema", "scalar", "type", "interface", "union", "enum", "input", "directive", "extend":
+			return nil, p.errorAt(p.tok.pos, "unexpected %s: type system definitions are not allowed in executable documents", p.tok.describe())
+		}
+	}
+	return nil, p.unexpected()
+}
+
+func (p *parser) parseOperationDefinition() (*OperationDefinition, error) {
+	op := &OperationDefinition{Operation: "query", Position: p.tok.pos}
+	if p.peek(tokBraceL) {
+		sels, err := p.parseSelectionSet()
+		if err != nil {
+			return nil, err
+		}
+		op.SelectionSet = sels
+		return op, nil
+	}
+
+	op.Operation = p.tok.value
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokName) {
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		op.Name = name
+	}
+
+	var err error
+	if op.VariableDefinitions, err = p.parseVariableDefinitions(); err != nil {
+		return nil, err
+	}
+	if op.Directives, err = p.parseDirectives(false); err != nil {
+		return nil, err
+	}
+	if op.SelectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinitions() ([]*VariableDefinition, error) {
+	if !p.peek(tokParenL) {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var defs []*VariableDefinition
+	for {
+		def := &VariableDefinition{Position: p.tok.pos}
+		if err := p.expect(tokDollar); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		def.Name = name
+		if err := p.expect(tokColon); err != nil {
+			return nil, err
+		}
+		if def.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip(tokEquals); err != nil {
+			return nil, err
+		} else if ok {
+			if def.DefaultValue, err = p.parseValue(true); err != nil {
+				return nil, err
+			}
+		}
+		if def.Directives, err = p.parseDirectives(true); err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+
+		if done, err := p.skip(tokParenR); err != nil {
+			return nil, err
+		} else if done {
+			return defs, nil
+		}
+	}
+}
+
+func (p *parser) parseFragmentDefinition() (*FragmentDefinition, error) {
+	frag := &FragmentDefinition{Position: p.tok.pos}
+	if err := p.expectKeyword("fragment"); err != nil {
+		return nil, err
+	}
+	if p.peekKeyword("on") {
+		return nil, p.unexpected()
+	}
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	frag.Name = name
+	if err := p.expectKeyword("on"); err != nil {
+		return nil, err
+	}
+	if frag.TypeCondition, err = p.parseName(); err != nil {
+		return nil, err
+	}
+	if frag.Directives, err = p.parseDirectives(false); err != nil {
+		return nil, err
+	}
+	if frag.SelectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+// parseSelectionSet parses `{ Selection+ }`.
+func (p *parser) parseSelectionSet() ([]Selection, error) {
+	if err := p.expect(tokBraceL); err != nil {
+		return nil, err
+	}
+	var sels []Selection
+	for {
+		sel, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+
+		if done, err := p.skip(tokBraceR); err != nil {
+			return nil, err
+		} else if done {
+			return sels, nil
+		}
+	}
+}
+
+func (p *parser) parseSelection() (Selection, error) {
+	if p.peek(tokSpread) {
+		return p.parseFragment()
+	}
+	return p.parseField()
+}
+
+func (p *parser) parseField() (*Field, error) {
+	f := &Field{Position: p.tok.pos}
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(tokColon); err != nil {
+		return nil, err
+	} else if ok {
+		f.Alias = name
+		if name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+	f.Name = name
+
+	if f.Arguments, err = p.parseArguments(false); err != nil {
+		return nil, err
+	}
+	if f.Directives, err = p.parseDirectives(false); err != nil {
+		return nil, err
+	}
+	if p.peek(tokBraceL) {
+		if f.SelectionSet, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// parseFragment parses a FragmentSpread or InlineFragment after "...".
+func (p *parser) parseFragment() (Selection, error) {
+	pos := p.tok.pos
+	if err := p.expect(tokSpread); err != nil {
+		return nil, err
+	}
+
+	if p.peek(tokName) && !p.peekKeyword("on") {
+		spread := &FragmentSpread{Position: pos}
+		spread.Name = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var err error
+		if spread.Directives, err = p.parseDirectives(false); err != nil {
+			return nil, err
+		}
+		return spread, nil
+	}
+
+	frag := &InlineFragment{Position: pos}
+	var err error
+	if p.peekKeyword("on") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if frag.TypeCondition, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+	if frag.Directives, err = p.parseDirectives(false); err != nil {
+		return nil, err
+	}
+	if frag.SelectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+// parseArguments parses an optional `( Argument+ )` list.
+func (p *parser) parseArguments(isConst bool) ([]*Argument, error) {
+	if !p.peek(tokParenL) {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var args []*Argument
+	for {
+		arg := &Argument{Position: p.tok.pos}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		arg.Name = name
+		if err := p.expect(tokColon); err != nil {
+			return nil, err
+		}
+		if arg.Value, err = p.parseValue(isConst); err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		if done, err := p.skip(tokParenR); err != nil {
+			return nil, err
+		} else if done {
+			return args, nil
+		}
+	}
+}
+
+// parseDirectives parses zero or more `@name(args)` directives.
+func (p *parser) parseDirectives(isConst bool) ([]*Directive, error) {
+	var dirs []*Directive
+	for p.peek(tokAt) {
+		d := &Directive{Position: p.tok.pos}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		d.Name = name
+		if d.Arguments, err = p.parseArguments(isConst); err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs, nil
+}
+
+// parseType parses NamedType, [ListType], and the trailing ! for NonNullType.
+func (p *parser) parseType() (Type, error) {
+	pos := p.tok.pos
+	var t Type
+	if ok, err := p.skip(tokBracketL); err != nil {
+		return nil, err
+	} else if ok {
+		inner, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokBracketR); err != nil {
+			return nil, err
+		}
+		t = &ListType{OfType: inner, Position: pos}
+	} else {
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		t = &NamedType{Name: name, Position: pos}
+	}
+
+	if ok, err := p.skip(tokBang); err != nil {
+		return nil, err
+	} else if ok {
+		return &NonNullType{OfType: t, Position: pos}, nil
+	}
+	return t, nil
+}
+
+// parseValue parses a Value; variables are rejected when isConst is set.
+func (p *parser) parseValue(isConst bool) (Value, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokDollar:
+		if isConst {
+			return nil, p.errorAt(tok.pos, "unexpected variable in constant value")
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		return &Variable{Name: name, Position: tok.pos}, nil
+
+	case tokInt:
+		return &IntValue{Raw: tok.value, Position: tok.pos}, p.advance()
+
+	case tokFloat:
+		return &FloatValue{Raw: tok.value, Position: tok.pos}, p.advance()
+
+	case tokString, tokBlockString:
+		return &StringValue{Value: tok.value, Block: tok.kind == tokBlockString, Position: tok.pos}, p.advance()
+
+	case tokName:
+		var v Value
+		switch tok.value {
+		case "true", "false":
+			v = &BooleanValue{Value: tok.value == "true", Position: tok.pos}
+		case "null":
+			v = &NullValue{Position: tok.pos}
+		default:
+			v = &EnumValue{Name: tok.value, Position: tok.pos}
+		}
+		return v, p.advance()
+
+	case tokBracketL:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := &ListValue{Position: tok.pos}
+		for !p.peek(tokBracketR) {
+			item, err := p.parseValue(isConst)
+			if err != nil {
+				return nil, err
+			}
+			list.Values = append(list.Values, item)
+		}
+		return list, p.advance()
+
+	case tokBraceL:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		obj := &ObjectValue{Position: tok.pos}
+		for !p.peek(tokBraceR) {
+			field := &ObjectField{Position: p.tok.pos}
+			name, err := p.parseName()
+			if err != nil {
+				return nil, err
+			}
+			field.Name = name
+			if err := p.expect(tokColon); err != nil {
+				return nil, err
+			}
+			if field.Value, err = p.parseValue(isConst); err != nil {
+				return nil, err
+			}
+			obj.Fields = append(obj.Fields, field)
+		}
+		return obj, p.advance()
+	}
+
+	return nil, p.unexpected()
+}
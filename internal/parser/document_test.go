@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDocumentPrint(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "shorthand query",
+			src:  "{ a b }",
+			want: "{\n  a\n  b\n}",
+		},
+		{
+			name: "named operation with alias and arguments",
+			src:  `query Q { me: user(id: 4, name: "x") { id } }`,
+			want: "query Q {\n  me: user(id: 4, name: \"x\") {\n    id\n  }\n}",
+		},
+		{
+			name: "variables with defaults and directives",
+			src:  `query Q($id: ID!, $n: [Int!] = [1, 2] @deprecated, $f: Filter = {a: null, b: RED}) { user(id: $id) { id } }`,
+			want: "query Q($id: ID!, $n: [Int!] = [1, 2] @deprecated, $f: Filter = {a: null, b: RED}) {\n  user(id: $id) {\n    id\n  }\n}",
+		},
+		{
+			name: "field and operation directives",
+			src:  `mutation M @live { a @include(if: $x) @skip(if: false) }`,
+			want: "mutation M @live {\n  a @include(if: $x) @skip(if: false)\n}",
+		},
+		{
+			name: "fragments and inline fragments",
+			src: `query { node { ...F ... on User @defer(label: "u") { name } ... @include(if: true) { id } } }
+fragment F on Node @x { id }`,
+			want: "{\n  node {\n    ...F\n    ... on User @defer(label: \"u\") {\n      name\n    }\n    ... @include(if: true) {\n      id\n    }\n  }\n}\n\nfragment F on Node @x {\n  id\n}",
+		},
+		{
+			name: "subscription",
+			src:  "subscription S { onEvent { id } }",
+			want: "subscription S {\n  onEvent {\n    id\n  }\n}",
+		},
+		{
+			name: "values",
+			src:  `{ f(a: -1.5e3, b: true, c: null, d: [], e: {}, g: "q\"\n", h: ENUM) }`,
+			want: "{\n  f(a: -1.5e3, b: true, c: null, d: [], e: {}, g: \"q\\\"\\n\", h: ENUM)\n}",
+		},
+		{
+			name: "block string argument",
+			src:  "{ f(s: \"\"\"\n    one\n      two\n  \"\"\") }",
+			want: "{\n  f(s: \"\"\"\none\n  two\n\"\"\")\n}",
+		},
+		{
+			name: "keywords as names",
+			src:  "{ query fragment on: type(on: true, null: 1) }",
+			want: "{\n  query\n  fragment\n  on: type(on: true, null: 1)\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseDocument(tt.src)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got := Print(doc)
+			if got != tt.want {
+				t.Fatalf("Print:\n%s\nwant:\n%s", got, tt.want)
+			}
+			// The printed document parses back to the same document.
+			again, err := ParseDocument(got)
+			if err != nil {
+				t.Fatalf("reparse: %v", err)
+			}
+			if Print(again) != got {
+				t.Errorf("reprint:\n%s\nwant:\n%s", Print(again), got)
+			}
+		})
+	}
+}
+
+func TestParseDocumentAST(t *testing.T) {
+	src := `query Q($id: ID! = "1", $tags: [String]) @a {
+  alias: node(id: $id, tags: $tags) { ...F }
+}
+fragment F on User { name }`
+	doc, err := ParseDocument(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Definitions) != 2 {
+		t.Fatalf("got %d definitions, want 2", len(doc.Definitions))
+	}
+
+	op := doc.Operation("Q")
+	if op == nil || op.Operation != "query" {
+		t.Fatalf("Operation(Q) = %+v", op)
+	}
+	if len(op.VariableDefinitions) != 2 {
+		t.Fatalf("got %d variables, want 2", len(op.VariableDefinitions))
+	}
+	id := op.VariableDefinitions[0]
+	if id.Name != "id" || PrintType(id.Type) != "ID!" {
+		t.Errorf("variable 0 = $%s: %s", id.Name, PrintType(id.Type))
+	}
+	if s, ok := id.DefaultValue.(*StringValue); !ok || s.Value != "1" {
+		t.Errorf("default = %#v, want \"1\"", id.DefaultValue)
+	}
+	if _, ok := id.Type.(*NonNullType); !ok {
+		t.Errorf("type %T, want *NonNullType", id.Type)
+	}
+	if got := PrintType(op.VariableDefinitions[1].Type); got != "[String]" {
+		t.Errorf("variable 1 type = %s, want [String]", got)
+	}
+	if len(op.Directives) != 1 || op.Directives[0].Name != "a" {
+		t.Errorf("directives = %+v", op.Directives)
+	}
+
+	field, ok := op.SelectionSet[0].(*Field)
+	if !ok {
+		t.Fatalf("selection %T, want *Field", op.SelectionSet[0])
+	}
+	if field.Alias != "alias" || field.Name != "node" || field.ResponseKey() != "alias" {
+		t.Errorf("field = %s: %s", field.Alias, field.Name)
+	}
+	if field.Position != (Position{Offset: 48, Line: 2, Column: 3}) {
+		t.Errorf("field position = %+v", field.Position)
+	}
+	if v, ok := field.Arguments[0].Value.(*Variable); !ok || v.Name != "id" {
+		t.Errorf("argument 0 = %#v, want $id", field.Arguments[0].Value)
+	}
+
+	frags := doc.Fragments()
+	f := frags["F"]
+	if f == nil || f.TypeCondition != "User" {
+		t.Fatalf("fragment F = %+v", f)
+	}
+	fields := CollectFields(field.SelectionSet, frags)
+	if len(fields) != 1 || fields[0].Name != "name" {
+		t.Errorf("CollectFields = %v", fields)
+	}
+}
+
+func TestCollectFieldsCycle(t *testing.T) {
+	doc, err := ParseDocument(`{ ...A } fragment A on Q { a ...B } fragment B on Q { b ...A }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range CollectFields(doc.Operations()[0].SelectionSet, doc.Fragments()) {
+		names = append(names, f.Name)
+	}
+	if strings.Join(names, ",") != "a,b" {
+		t.Errorf("fields = %v, want [a b]", names)
+	}
+}
+
+func TestOperationNameAndType(t *testing.T) {
+	tests := []struct {
+		query    string
+		name, op string
+	}{
+		{"query GetUser { me { id } }", "GetUser", "query"},
+		{"mutation { a }", "", "mutation"},
+		{"fragment F on T { a } subscription S { a }", "S", "subscription"},
+		{"{ a }", "", "query"},
+		{"not graphql", "", "query"},
+	}
+	for _, tt := range tests {
+		if got := OperationName(tt.query); got != tt.name {
+			t.Errorf("OperationName(%q) = %q, want %q", tt.query, got, tt.name)
+		}
+		if got := OperationType(tt.query); got != tt.op {
+			t.Errorf("OperationType(%q) = %q, want %q", tt.query, got, tt.op)
+		}
+	}
+}
+
+func TestParseDocumentErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		line     int
+		column   int
+		contains string
+	}{
+		{src: "", line: 1, column: 1, contains: "unexpected <EOF>"},
+		{src: "{", line: 1, column: 2, contains: "<EOF>"},
+		{src: "{ }", line: 1, column: 3, contains: `"}"`},
+		{src: "query {\n  a(b: )\n}", line: 2, column: 8, contains: `unexpected ")"`},
+		{src: "query Q($a) { a }", line: 1, column: 11, contains: `expected ":"`},
+		{src: "query Q($a: Int = $b) { a }", line: 1, column: 19, contains: "variable in constant value"},
+		{src: "fragment on on T { a }", line: 1, column: 10, contains: `"on"`},
+		{src: "fragment F T { a }", line: 1, column: 12, contains: `expected "on"`},
+		{src: "type Query { a: Int }", line: 1, column: 1, contains: "type system definitions"},
+		{src: "{ a }\n  b", line: 2, column: 3, contains: `Name "b"`},
+		{src: "{ a(x: [1, 2) }", line: 1, column: 13, contains: `")"`},
+		{src: "{ a @ }", line: 1, column: 7, contains: "expected Name"},
+		{src: "query Q($a: [Int) { a }", line: 1, column: 17, contains: `expected "]"`},
+		{src: "{ a(x: 01) }", line: 1, column: 9, contains: "unexpected digit after 0"},
+	}
+	for _, tt := range tests {
+		_, err := ParseDocument(tt.src)
+		se, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("parse %q: err = %v, want *SyntaxError", tt.src, err)
+			continue
+		}
+		if se.Line != tt.line || se.Column != tt.column || !strings.Contains(se.Message, tt.contains) {
+			t.Errorf("parse %q: got %d:%d %q, want %d:%d containing %q", tt.src, se.Line, se.Column, se.Message, tt.line, tt.column, tt.contains)
+		}
+	}
+}
+
+func TestParseValueAndType(t *testing.T) {
+	values := []string{`{a: [1, 2.5, "s"], b: {c: null}}`, `[[true], []]`, `RED`, `"""block"""`}
+	for _, src := range values {
+		v, err := ParseValue(src)
+		if err != nil {
+			t.Errorf("ParseValue(%q): %v", src, err)
+			continue
+		}
+		if got := PrintValue(v); got != src {
+			t.Errorf("PrintValue(ParseValue(%q)) = %q", src, got)
+		}
+	}
+	if _, err := ParseValue("1 2"); err == nil {
+		t.Error("ParseValue(\"1 2\") succeeded, want trailing-token error")
+	}
+
+	for _, src := range []string{"ID", "[ID!]!", "[[Int]!]"} {
+		typ, err := ParseType(src)
+		if err != nil {
+			t.Errorf("ParseType(%q): %v", src, err)
+			continue
+		}
+		if got := PrintType(typ); got != src {
+			t.Errorf("PrintType(ParseType(%q)) = %q", src, got)
+		}
+	}
+	if _, err := ParseType("ID!!"); err == nil {
+		t.Error("ParseType(\"ID!!\") succeeded, want error")
+	}
+}
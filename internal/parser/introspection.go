@@ -21,6 +21,7 @@ type altIntrospectionResponse struct {
 }
 
 type rawSchema struct {
+	Description      *string        `json:"description"`
 	QueryType        *rawNameRef    `json:"queryType"`
 	MutationType     *rawNameRef    `json:"mutationType"`
 	SubscriptionType *rawNameRef    `json:"subscriptionType"`
@@ -33,14 +34,15 @@ type rawNameRef struct {
 }
 
 type rawType struct {
-	Kind          string         `json:"kind"`
-	Name          string         `json:"name"`
-	Description   *string        `json:"description"`
-	Fields        []rawField     `json:"fields"`
-	InputFields   []rawField     `json:"inputFields"`
-	Interfaces    []rawTypeRef   `json:"interfaces"`
-	EnumValues    []rawEnumValue `json:"enumValues"`
-	PossibleTypes []rawTypeRef   `json:"possibleTypes"`
+	Kind           string         `json:"kind"`
+	Name           string         `json:"name"`
+	Description    *string        `json:"description"`
+	Fields         []rawField     `json:"fields"`
+	InputFields    []rawField     `json:"inputFields"`
+	Interfaces     []rawTypeRef   `json:"interfaces"`
+	EnumValues     []rawEnumValue `json:"enumValues"`
+	PossibleTypes  []rawTypeRef   `json:"possibleTypes"`
+	SpecifiedByURL *string        `json:"specifiedByURL"`
 }
 
 type rawField struct {
@@ -48,15 +50,18 @@ type rawField struct {
 	Description       *string    `json:"description"`
 	Args              []rawArg   `json:"args"`
 	Type              rawTypeRef `json:"type"`
+	DefaultValue      *string    `json:"defaultValue"` // inputFields only
 	IsDeprecated      bool       `json:"isDeprecated"`
 	DeprecationReason *string    `json:"deprecationReason"`
 }
 
 type rawArg struct {
-	Name         string     `json:"name"`
-	Description  *string    `json:"description"`
-	Type         rawTypeRef `json:"type"`
-	DefaultValue *string    `json:"defaultValue"`
+	Name              string     `json:"name"`
+	Description       *string    `json:"description"`
+	Type              rawTypeRef `json:"type"`
+	DefaultValue      *string    `json:"defaultValue"`
+	IsDeprecated      bool       `json:"isDeprecated"`
+	DeprecationReason *string    `json:"deprecationReason"`
 }
 
 type rawTypeRef struct {
@@ -73,10 +78,11 @@ type rawEnumValue struct {
 }
 
 type rawDirective struct {
-	Name        string   `json:"name"`
-	Description *string  `json:"description"`
-	Locations   []string `json:"locations"`
-	Args        []rawArg `json:"args"`
+	Name         string   `json:"name"`
+	Description  *string  `json:"description"`
+	Locations    []string `json:"locations"`
+	Args         []rawArg `json:"args"`
+	IsRepeatable bool     `json:"isRepeatable"`
 }
 
 // ParseIntrospection parses a raw introspection JSON response into our Schema model.
@@ -93,6 +99,9 @@ func ParseIntrospection(data []byte, id, name string) (*schema.Schema, error) {
 		CreatedAt: time.Now().UTC(),
 	}
 
+	if raw.Description != nil {
+		s.Description = *raw.Description
+	}
 	if raw.QueryType != nil {
 		s.QueryType = raw.QueryType.Name
 	}
@@ -145,6 +154,9 @@ func convertType(rt rawType) schema.Type {
 	if rt.Description != nil {
 		t.Description = *rt.Description
 	}
+	if rt.SpecifiedByURL != nil {
+		t.SpecifiedByURL = *rt.SpecifiedByURL
+	}
 
 	for _, rf := range rt.Fields {
 		t.Fields = append(t.Fields, convertField(rf))
@@ -172,6 +184,7 @@ func convertField(rf rawField) schema.Field {
 	f := schema.Field{
 		Name:         rf.Name,
 		Type:         convertTypeRef(rf.Type),
+		DefaultValue: rf.DefaultValue,
 		IsDeprecated: rf.IsDeprecated,
 	}
 	if rf.Description != nil {
@@ -191,10 +204,14 @@ func convertArg(ra rawArg) schema.Argument {
 		Name:         ra.Name,
 		Type:         convertTypeRef(ra.Type),
 		DefaultValue: ra.DefaultValue,
+		IsDeprecated: ra.IsDeprecated,
 	}
 	if ra.Description != nil {
 		a.Description = *ra.Description
 	}
+	if ra.DeprecationReason != nil {
+		a.DeprecationReason = *ra.DeprecationReason
+	}
 	return a
 }
 
@@ -230,8 +247,9 @@ func convertEnumValue(rev rawEnumValue) schema.EnumValue {
 
 func convertDirective(rd rawDirective) schema.Directive {
 	d := schema.Directive{
-		Name:      rd.Name,
-		Locations: rd.Locations,
+		Name:         rd.Name,
+		Locations:    rd.Locations,
+		IsRepeatable: rd.IsRepeatable,
 	}
 	if rd.Description != nil {
 		d.Description = *rd.Description
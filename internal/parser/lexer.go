@@ -0,0 +1,463 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind identifies a lexical token.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokBang
+	tokDollar
+	tokAmp
+	tokParenL
+	tokParenR
+	tokSpread
+	tokColon
+	tokEquals
+	tokAt
+	tokBracketL
+	tokBracketR
+	tokBraceL
+	tokPipe
+	tokBraceR
+	tokName
+	tokInt
+	tokFloat
+	tokString
+	tokBlockString
+)
+
+var tokenNames = map[tokenKind]string{
+	tokEOF:         "<EOF>",
+	tokBang:        "\"!\"",
+	tokDollar:      "\"$\"",
+	tokAmp:         "\"&\"",
+	tokParenL:      "\"(\"",
+	tokParenR:      "\")\"",
+	tokSpread:      "\"...\"",
+	tokColon:       "\":\"",
+	tokEquals:      "\"=\"",
+	tokAt:          "\"@\"",
+	tokBracketL:    "\"[\"",
+	tokBracketR:    "\"]\"",
+	tokBraceL:      "\"{\"",
+	tokPipe:        "\"|\"",
+	tokBraceR:      "\"}\"",
+	tokName:        "Name",
+	tokInt:         "Int",
+	tokFloat:       "Float",
+	tokString:      "String",
+	tokBlockString: "BlockString",
+}
+
+func (k tokenKind) String() string {
+	return tokenNames[k]
+}
+
+// token is a single lexical token. Value holds the name, the raw numeric
+// text, or the decoded string contents.
+type token struct {
+	kind  tokenKind
+	value string
+	pos   Position
+}
+
+// describe renders the token for error messages, e.g. `Name "foo"`.
+func (t token) describe() string {
+	switch t.kind {
+	case tokName, tokInt, tokFloat:
+		return fmt.Sprintf("%s %q", t.kind, t.value)
+	}
+	return t.kind.String()
+}
+
+// SyntaxError reports a lexing or parsing failure at a source position.
+type SyntaxError struct {
+	Message string
+	Line    int
+	Column  int
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// bom is the Unicode byte order mark, which the spec treats as ignored.
+const bom = "\uFEFF"
+
+// lexer converts GraphQL source text into tokens, skipping ignored tokens
+// (whitespace, line terminators, commas, comments, and a leading BOM).
+type lexer struct {
+	src       string
+	pos       int
+	line      int
+	lineStart int
+}
+
+func newLexer(src string) *lexer {
+	l := &lexer{src: src, line: 1}
+	if strings.HasPrefix(src, bom) {
+		l.pos = len(bom)
+		l.lineStart = l.pos
+	}
+	return l
+}
+
+// position returns the Position for a byte offset on the current line.
+func (l *lexer) position(offset int) Position {
+	return Position{
+		Offset: offset,
+		Line:   l.line,
+		Column: utf8.RuneCountInString(l.src[l.lineStart:offset]) + 1,
+	}
+}
+
+func (l *lexer) errorf(offset int, format string, args ...any) *SyntaxError {
+	p := l.position(offset)
+	return &SyntaxError{Message: fmt.Sprintf(format, args...), Line: p.Line, Column: p.Column}
+}
+
+// newline records a line terminator ending at offset end.
+func (l *lexer) newline(end int) {
+	l.line++
+	l.lineStart = end
+}
+
+// next returns the next significant token.
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: l.position(l.pos)}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	punct := func(k tokenKind, n int) (token, error) {
+		l.pos += n
+		return token{kind: k, pos: l.position(start)}, nil
+	}
+
+	switch c {
+	case '!':
+		return punct(tokBang, 1)
+	case '$':
+		return punct(tokDollar, 1)
+	case '&':
+		return punct(tokAmp, 1)
+	case '(':
+		return punct(tokParenL, 1)
+	case ')':
+		return punct(tokParenR, 1)
+	case ':':
+		return punct(tokColon, 1)
+	case '=':
+		return punct(tokEquals, 1)
+	case '@':
+		return punct(tokAt, 1)
+	case '[':
+		return punct(tokBracketL, 1)
+	case ']':
+		return punct(tokBracketR, 1)
+	case '{':
+		return punct(tokBraceL, 1)
+	case '|':
+		return punct(tokPipe, 1)
+	case '}':
+		return punct(tokBraceR, 1)
+	case '.':
+		if strings.HasPrefix(l.src[l.pos:], "...") {
+			return punct(tokSpread, 3)
+		}
+		return token{}, l.errorf(start, "unexpected \".\", did you mean \"...\"?")
+	case '"':
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			return l.readBlockString()
+		}
+		return l.readString()
+	}
+
+	if isNameStart(c) {
+		for l.pos < len(l.src) && isNameContinue(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokName, value: l.src[start:l.pos], pos: l.position(start)}, nil
+	}
+	if c == '-' || isDigit(c) {
+		return l.readNumber()
+	}
+
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, l.errorf(start, "unexpected character %q", r)
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case ' ', '\t', ',':
+			l.pos++
+		case '\n':
+			l.pos++
+			l.newline(l.pos)
+		case '\r':
+			l.pos++
+			if l.pos < len(l.src) && l.src[l.pos] == '\n' {
+				l.pos++
+			}
+			l.newline(l.pos)
+		case '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			if strings.HasPrefix(l.src[l.pos:], bom) {
+				l.pos += len(bom)
+				continue
+			}
+			return
+		}
+	}
+}
+
+// readNumber lexes IntValue and FloatValue, rejecting leading zeros and
+// numbers immediately followed by a name start or dot.
+func (l *lexer) readNumber() (token, error) {
+	start := l.pos
+	isFloat := false
+
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '0' {
+		l.pos++
+		if l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			return token{}, l.errorf(l.pos, "invalid number, unexpected digit after 0")
+		}
+	} else if err := l.readDigits(); err != nil {
+		return token{}, err
+	}
+
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		isFloat = true
+		l.pos++
+		if err := l.readDigits(); err != nil {
+			return token{}, err
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		isFloat = true
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if err := l.readDigits(); err != nil {
+			return token{}, err
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == '.' || isNameStart(l.src[l.pos])) {
+		return token{}, l.errorf(l.pos, "invalid number, unexpected %q", l.src[l.pos])
+	}
+
+	kind := tokInt
+	if isFloat {
+		kind = tokFloat
+	}
+	return token{kind: kind, value: l.src[start:l.pos], pos: l.position(start)}, nil
+}
+
+func (l *lexer) readDigits() error {
+	if l.pos >= len(l.src) || !isDigit(l.src[l.pos]) {
+		if l.pos >= len(l.src) {
+			return l.errorf(l.pos, "invalid number, expected digit but found <EOF>")
+		}
+		return l.errorf(l.pos, "invalid number, expected digit but found %q", l.src[l.pos])
+	}
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+	return nil
+}
+
+// readString lexes a single-line "..." string, decoding escape sequences.
+func (l *lexer) readString() (token, error) {
+	start := l.pos
+	l.pos++ // opening quote
+	var b strings.Builder
+
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return token{kind: tokString, value: b.String(), pos: l.position(start)}, nil
+		case c == '\n' || c == '\r':
+			return token{}, l.errorf(l.pos, "unterminated string")
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, l.errorf(l.pos, "unterminated string")
+			}
+			esc := l.src[l.pos+1]
+			switch esc {
+			case '"', '\\', '/':
+				b.WriteByte(esc)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				r, n, err := l.readUnicodeEscape(l.pos)
+				if err != nil {
+					return token{}, err
+				}
+				b.WriteRune(r)
+				l.pos += n
+				continue
+			default:
+				return token{}, l.errorf(l.pos, "invalid escape sequence \\%c", esc)
+			}
+			l.pos += 2
+		default:
+			if c < 0x20 && c != '\t' {
+				return token{}, l.errorf(l.pos, "invalid character within string: %q", c)
+			}
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+	return token{}, l.errorf(l.pos, "unterminated string")
+}
+
+// readUnicodeEscape decodes \uXXXX at offset, combining UTF-16 surrogate
+// pairs. It returns the rune and the number of bytes consumed.
+func (l *lexer) readUnicodeEscape(offset int) (rune, int, error) {
+	hex4 := func(at int) (rune, bool) {
+		if at+6 > len(l.src) || l.src[at] != '\\' || l.src[at+1] != 'u' {
+			return 0, false
+		}
+		v, err := strconv.ParseUint(l.src[at+2:at+6], 16, 32)
+		if err != nil {
+			return 0, false
+		}
+		return rune(v), true
+	}
+
+	r, ok := hex4(offset)
+	if !ok {
+		return 0, 0, l.errorf(offset, "invalid unicode escape sequence")
+	}
+	if r >= 0xD800 && r <= 0xDBFF {
+		lo, ok := hex4(offset + 6)
+		if !ok || lo < 0xDC00 || lo > 0xDFFF {
+			return 0, 0, l.errorf(offset, "invalid unicode surrogate pair")
+		}
+		return (r-0xD800)<<10 + (lo - 0xDC00) + 0x10000, 12, nil
+	}
+	if r >= 0xDC00 && r <= 0xDFFF {
+		return 0, 0, l.errorf(offset, "invalid unicode surrogate pair")
+	}
+	return r, 6, nil
+}
+
+// readBlockString lexes a """...""" block string and applies the spec's
+// BlockStringValue indentation rules.
+func (l *lexer) readBlockString() (token, error) {
+	start := l.pos
+	startPos := l.position(start)
+	l.pos += 3
+	var raw strings.Builder
+
+	for l.pos < len(l.src) {
+		rest := l.src[l.pos:]
+		switch {
+		case strings.HasPrefix(rest, `"""`):
+			l.pos += 3
+			return token{kind: tokBlockString, value: blockStringValue(raw.String()), pos: startPos}, nil
+		case strings.HasPrefix(rest, `\"""`):
+			raw.WriteString(`"""`)
+			l.pos += 4
+		case rest[0] == '\n':
+			raw.WriteByte('\n')
+			l.pos++
+			l.newline(l.pos)
+		case rest[0] == '\r':
+			raw.WriteByte('\n')
+			l.pos++
+			if l.pos < len(l.src) && l.src[l.pos] == '\n' {
+				l.pos++
+			}
+			l.newline(l.pos)
+		default:
+			raw.WriteByte(rest[0])
+			l.pos++
+		}
+	}
+	return token{}, l.errorf(l.pos, "unterminated block string")
+}
+
+// blockStringValue removes common indentation and leading/trailing blank
+// lines from a raw block string.
+func blockStringValue(raw string) string {
+	lines := strings.Split(raw, "\n")
+
+	common := -1
+	for i, line := range lines {
+		if i == 0 {
+			continue
+		}
+		indent := leadingWhitespace(line)
+		if indent == len(line) {
+			continue
+		}
+		if common < 0 || indent < common {
+			common = indent
+		}
+	}
+	if common > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= common {
+				lines[i] = lines[i][common:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+
+	for len(lines) > 0 && leadingWhitespace(lines[0]) == len(lines[0]) {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && leadingWhitespace(lines[len(lines)-1]) == len(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func leadingWhitespace(s string) int {
+	n := 0
+	for n < len(s) && (s[n] == ' ' || s[n] == '\t') {
+		n++
+	}
+	return n
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameContinue(c byte) bool {
+	return isNameStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+// lexAll returns every token of src up to, not including, EOF.
+func lexAll(src string) ([]token, error) {
+	l := newLexer(src)
+	var toks []token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return toks, err
+		}
+		if tok.kind == tokEOF {
+			return toks, nil
+		}
+		toks = append(toks, tok)
+	}
+}
+
+func TestLexer(t *testing.T) {
+	type tok struct {
+		kind  tokenKind
+		value string
+	}
+	tests := []struct {
+		name string
+		src  string
+		want []tok
+	}{
+		{
+			name: "punctuation",
+			src:  "! $ & ( ) ... : = @ [ ] { | }",
+			want: []tok{
+				{tokBang, ""}, {tokDollar, ""}, {tokAmp, ""}, {tokParenL, ""}, {tokParenR, ""},
+				{tokSpread, ""}, {tokColon, ""}, {tokEquals, ""}, {tokAt, ""}, {tokBracketL, ""},
+				{tokBracketR, ""}, {tokBraceL, ""}, {tokPipe, ""}, {tokBraceR, ""},
+			},
+		},
+		{
+			name: "ignored tokens",
+			src:  "\uFEFFa, b\t# comment { }\r\nc\rd\n",
+			want: []tok{{tokName, "a"}, {tokName, "b"}, {tokName, "c"}, {tokName, "d"}},
+		},
+		{
+			name: "names",
+			src:  "_ __typename node2 Node_X",
+			want: []tok{{tokName, "_"}, {tokName, "__typename"}, {tokName, "node2"}, {tokName, "Node_X"}},
+		},
+		{
+			name: "numbers",
+			src:  "0 -0 42 -7 1.5 -0.25 1e10 2E-3 6.02e+23",
+			want: []tok{
+				{tokInt, "0"}, {tokInt, "-0"}, {tokInt, "42"}, {tokInt, "-7"}, {tokFloat, "1.5"},
+				{tokFloat, "-0.25"}, {tokFloat, "1e10"}, {tokFloat, "2E-3"}, {tokFloat, "6.02e+23"},
+			},
+		},
+		{
+			name: "string escapes",
+			src:  `"a\"b\\c\/d\b\f\n\r\t" "éA" "😀" "tab	inside"`,
+			want: []tok{{tokString, "a\"b\\c/d\b\f\n\r\t"}, {tokString, "éA"}, {tokString, "😀"}, {tokString, "tab\tinside"}},
+		},
+		{
+			name: "empty strings",
+			src:  `"" """"""`,
+			want: []tok{{tokString, ""}, {tokBlockString, ""}},
+		},
+		{
+			name: "block string indentation",
+			src:  "\"\"\"\n    Hello,\n      World!\n\n    Yours,\n      GraphQL.\n  \"\"\"",
+			want: []tok{{tokBlockString, "Hello,\n  World!\n\nYours,\n  GraphQL."}},
+		},
+		{
+			name: "block string first line kept",
+			src:  "\"\"\"  first\n    second\"\"\"",
+			want: []tok{{tokBlockString, "  first\nsecond"}},
+		},
+		{
+			name: "block string escaped quotes and raw escapes",
+			src:  `"""a \""" b \n c"""`,
+			want: []tok{{tokBlockString, `a """ b \n c`}},
+		},
+		{
+			name: "block string CRLF",
+			src:  "\"\"\"\r\n  one\r\n  two\r\n\"\"\"",
+			want: []tok{{tokBlockString, "one\ntwo"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toks, err := lexAll(tt.src)
+			if err != nil {
+				t.Fatalf("lex %q: %v", tt.src, err)
+			}
+			if len(toks) != len(tt.want) {
+				t.Fatalf("lex %q: got %d tokens, want %d: %v", tt.src, len(toks), len(tt.want), toks)
+			}
+			for i, got := range toks {
+				if got.kind != tt.want[i].kind || got.value != tt.want[i].value {
+					t.Errorf("token %d = %s %q, want %s %q", i, got.kind, got.value, tt.want[i].kind, tt.want[i].value)
+				}
+			}
+		})
+	}
+}
+
+func TestLexerPositions(t *testing.T) {
+	src := "query {\n  \"é\" a\r\n\t\"\"\"\n  x\n\"\"\" b\n}"
+	want := []Position{
+		{Offset: 0, Line: 1, Column: 1},  // query
+		{Offset: 6, Line: 1, Column: 7},  // {
+		{Offset: 10, Line: 2, Column: 3}, // "é"
+		{Offset: 15, Line: 2, Column: 7}, // a, columns count runes
+		{Offset: 19, Line: 3, Column: 2}, // """
+		{Offset: 31, Line: 5, Column: 5}, // b
+		{Offset: 33, Line: 6, Column: 1}, // }
+	}
+	toks, err := lexAll(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(toks) != len(want) {
+		t.Fatalf("got %d tokens, want %d", len(toks), len(want))
+	}
+	for i, tok := range toks {
+		if tok.pos != want[i] {
+			t.Errorf("token %d (%s) at %+v, want %+v", i, tok.describe(), tok.pos, want[i])
+		}
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		line     int
+		column   int
+		contains string
+	}{
+		{src: "..", line: 1, column: 1, contains: `did you mean "..."`},
+		{src: "a ?", line: 1, column: 3, contains: "unexpected character"},
+		{src: "\n  01", line: 2, column: 4, contains: "unexpected digit after 0"},
+		{src: "1.", line: 1, column: 3, contains: "expected digit but found <EOF>"},
+		{src: "1.a", line: 1, column: 3, contains: "expected digit"},
+		{src: "1e", line: 1, column: 3, contains: "expected digit"},
+		{src: "12abc", line: 1, column: 3, contains: "invalid number"},
+		{src: "1.5.2", line: 1, column: 4, contains: "invalid number"},
+		{src: `"abc`, line: 1, column: 5, contains: "unterminated string"},
+		{src: "\"ab\ncd\"", line: 1, column: 4, contains: "unterminated string"},
+		{src: `"\x"`, line: 1, column: 2, contains: `invalid escape sequence \x`},
+		{src: `"\u12"`, line: 1, column: 2, contains: "invalid unicode escape"},
+		{src: `"\ud83d"`, line: 1, column: 2, contains: "surrogate pair"},
+		{src: `"\ude00"`, line: 1, column: 2, contains: "surrogate pair"},
+		{src: "\"a\x01\"", line: 1, column: 3, contains: "invalid character within string"},
+		{src: "\"\"\"\nabc", line: 2, column: 4, contains: "unterminated block string"},
+	}
+	for _, tt := range tests {
+		_, err := lexAll(tt.src)
+		se, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("lex %q: err = %v, want *SyntaxError", tt.src, err)
+			continue
+		}
+		if se.Line != tt.line || se.Column != tt.column || !strings.Contains(se.Message, tt.contains) {
+			t.Errorf("lex %q: got %d:%d %q, want %d:%d containing %q", tt.src, se.Line, se.Column, se.Message, tt.line, tt.column, tt.contains)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Print renders a document back to GraphQL source with two-space indentation.
+// The output re-parses to an equivalent document.
+func Print(doc *Document) string {
+	var b strings.Builder
+	for i, def := range doc.Definitions {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		switch d := def.(type) {
+		case *OperationDefinition:
+			printOperation(&b, d)
+		case *FragmentDefinition:
+			fmt.Fprintf(&b, "fragment %s on %s", d.Name, d.TypeCondition)
+			printDirectives(&b, d.Directives)
+			b.WriteByte(' ')
+			printSelectionSet(&b, d.SelectionSet, 0)
+		}
+	}
+	return b.String()
+}
+
+func printOperation(b *strings.Builder, op *OperationDefinition) {
+	// Anonymous queries without variables or directives use the shorthand form.
+	if op.Operation == "query" && op.Name == "" && len(op.VariableDefinitions) == 0 && len(op.Directives) == 0 {
+		printSelectionSet(b, op.SelectionSet, 0)
+		return
+	}
+	b.WriteString(op.Operation)
+	if op.Name != "" {
+		b.WriteByte(' ')
+		b.WriteString(op.Name)
+	}
+	if len(op.VariableDefinitions) > 0 {
+		b.WriteByte('(')
+		for i, v := range op.VariableDefinitions {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(b, "$%s: %s", v.Name, PrintType(v.Type))
+			if v.DefaultValue != nil {
+				b.WriteString(" = ")
+				b.WriteString(PrintValue(v.DefaultValue))
+			}
+			printDirectives(b, v.Directives)
+		}
+		b.WriteByte(')')
+	}
+	printDirectives(b, op.Directives)
+	b.WriteByte(' ')
+	printSelectionSet(b, op.SelectionSet, 0)
+}
+
+func printSelectionSet(b *strings.Builder, sels []Selection, depth int) {
+	b.WriteString("{\n")
+	indent := strings.Repeat("  ", depth+1)
+	for _, sel := range sels {
+		b.WriteString(indent)
+		switch s := sel.(type) {
+		case *Field:
+			if s.Alias != "" {
+				b.WriteString(s.Alias)
+				b.WriteString(": ")
+			}
+			b.WriteString(s.Name)
+			printArguments(b, s.Arguments)
+			printDirectives(b, s.Directives)
+			if len(s.SelectionSet) > 0 {
+				b.WriteByte(' ')
+				printSelectionSet(b, s.SelectionSet, depth+1)
+			}
+		case *FragmentSpread:
+			b.WriteString("...")
+			b.WriteString(s.Name)
+			printDirectives(b, s.Directives)
+		case *InlineFragment:
+			b.WriteString("...")
+			if s.TypeCondition != "" {
+				b.WriteString(" on ")
+				b.WriteString(s.TypeCondition)
+			}
+			printDirectives(b, s.Directives)
+			b.WriteByte(' ')
+			printSelectionSet(b, s.SelectionSet, depth+1)
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(strings.Repeat("  ", depth))
+	b.WriteByte('}')
+}
+
+func printArguments(b *strings.Builder, args []*Argument) {
+	if len(args) == 0 {
+		return
+	}
+	b.WriteByte('(')
+	for i, a := range args {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(a.Name)
+		b.WriteString(": ")
+		b.WriteString(PrintValue(a.Value))
+	}
+	b.WriteByte(')')
+}
+
+func printDirectives(b *strings.Builder, dirs []*Directive) {
+	for _, d := range dirs {
+		b.WriteString(" @")
+		b.WriteString(d.Name)
+		printArguments(b, d.Arguments)
+	}
+}
+
+// PrintType renders a type reference such as `[ID!]!`.
+func PrintType(t Type) string {
+	switch t := t.(type) {
+	case *NamedType:
+		return t.Name
+	case *ListType:
+		return "[" + PrintType(t.OfType) + "]"
+	case *NonNullType:
+		return PrintType(t.OfType) + "!"
+	}
+	return ""
+}
+
+// PrintValue renders a value literal in GraphQL syntax.
+func PrintValue(v Value) string {
+	switch v := v.(type) {
+	case *Variable:
+		return "$" + v.Name
+	case *IntValue:
+		return v.Raw
+	case *FloatValue:
+		return v.Raw
+	case *StringValue:
+		if v.Block {
+			return printBlockString(v.Value)
+		}
+		return QuoteString(v.Value)
+	case *BooleanValue:
+		if v.Value {
+			return "true"
+		}
+		return "false"
+	case *NullValue:
+		return "null"
+	case *EnumValue:
+		return v.Name
+	case *ListValue:
+		parts := make([]string, len(v.Values))
+		for i, item := range v.Values {
+			parts[i] = PrintValue(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *ObjectValue:
+		parts := make([]string, len(v.Fields))
+		for i, f := range v.Fields {
+			parts[i] = f.Name + ": " + PrintValue(f.Value)
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	return ""
+}
+
+// QuoteString renders s as a GraphQL string literal.
+func QuoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// printBlockString renders s as a block string, falling back to a quoted
+// string when block-string indentation rules would not round-trip the value.
+func printBlockString(s string) string {
+	escaped := strings.ReplaceAll(s, `"""`, `\"""`)
+	if !strings.Contains(s, "\n") && !strings.HasSuffix(s, `"`) && blockStringValue(s) == s {
+		return `"""` + escaped + `"""`
+	}
+	if blockStringValue("\n"+s+"\n") == s {
+		return "\"\"\"\n" + escaped + "\n\"\"\""
+	}
+	return QuoteString(s)
+}
@@ -1,236 +1,97 @@
 package parser
 
 import (
-	"strings"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
 )
 
-// ParsedQuery represents a minimally parsed GraphQL query.
-type ParsedQuery struct {
-	OperationType string            // "query", "mutation", "subscription"
-	OperationName string            // name of the operation, if named
-	Fields        []ParsedSelection // top-level selections
-	Variables     []ParsedVariable  // declared variables
-}
-
-// ParsedSelection represents a field selection in a query.
-type ParsedSelection struct {
-	Name      string
-	Alias     string
-	Arguments []ParsedArgument
-	Children  []ParsedSelection
-}
-
-// ParsedVariable represents a declared variable.
-type ParsedVariable struct {
-	Name string
-	Type string
+// OperationName returns the name of the operation in query, or "" if the
+// operation is anonymous or the query does not parse. For multi-operation
+// documents the first operation's name is returned.
+func OperationName(query string) string {
+	if op := firstOperation(query); op != nil {
+		return op.Name
+	}
+	return ""
 }
 
-// ParsedArgument represents a field argument in a query.
-type ParsedArgument struct {
-	Name  string
-	Value string
+// OperationType returns "query", "mutation", or "subscription" for the first
+// operation in query. Unparseable queries are reported as "query".
+func OperationType(query string) string {
+	if op := firstOperation(query); op != nil {
+		return op.Operation
+	}
+	return "query"
 }
 
-// ParseQuery does a minimal parse of a GraphQL query string to extract structure.
-// This is NOT a full spec-compliant parser — it handles the common cases needed
-// for traffic analysis and schema reconstruction.
-func ParseQuery(query string) *ParsedQuery {
-	query = strings.TrimSpace(query)
-	if query == "" {
+func firstOperation(query string) *OperationDefinition {
+	doc, err := ParseDocument(query)
+	if err != nil {
 		return nil
 	}
-
-	p := &ParsedQuery{}
-	tokens := tokenize(query)
-	pos := 0
-
-	// Parse operation type and name
-	if pos < len(tokens) {
-		switch tokens[pos] {
-		case "query", "mutation", "subscription":
-			p.OperationType = tokens[pos]
-			pos++
-			// Check for operation name
-			if pos < len(tokens) && tokens[pos] != "(" && tokens[pos] != "{" {
-				p.OperationName = tokens[pos]
-				pos++
-			}
-			// Skip variable declarations
-			if pos < len(tokens) && tokens[pos] == "(" {
-				pos = skipParens(tokens, pos)
-			}
-		case "{":
-			p.OperationType = "query" // anonymous query
-		}
-	}
-
-	// Parse selection set
-	if pos < len(tokens) && tokens[pos] == "{" {
-		pos++
-		p.Fields, pos = parseSelectionSet(tokens, pos)
+	ops := doc.Operations()
+	if len(ops) == 0 {
+		return nil
 	}
-
-	return p
+	return ops[0]
 }
 
-func parseSelectionSet(tokens []string, pos int) ([]ParsedSelection, int) {
-	var selections []ParsedSelection
-
-	for pos < len(tokens) && tokens[pos] != "}" {
-		if tokens[pos] == "..." {
-			// Inline fragment or fragment spread
-			pos++
-			if pos < len(tokens) && tokens[pos] == "on" {
-				pos++ // skip "on"
-				if pos < len(tokens) {
-					pos++ // skip type name
-				}
-			} else if pos < len(tokens) && tokens[pos] != "{" {
-				// Named fragment spread (e.g. ...UserFields) — consume the name
-				pos++
-			}
-			if pos < len(tokens) && tokens[pos] == "{" {
-				pos++
-				_, pos = parseSelectionSet(tokens, pos)
-			}
-			continue
-		}
-
-		sel := ParsedSelection{}
-
-		// Check for alias: "alias: fieldName"
-		if pos+2 < len(tokens) && tokens[pos+1] == ":" {
-			sel.Alias = tokens[pos]
-			pos += 2
-		}
-
-		if pos >= len(tokens) || tokens[pos] == "}" {
-			break
-		}
-
-		sel.Name = tokens[pos]
-		pos++
-
-		// Parse arguments
-		if pos < len(tokens) && tokens[pos] == "(" {
-			pos++
-			for pos < len(tokens) && tokens[pos] != ")" {
-				arg := ParsedArgument{}
-				arg.Name = tokens[pos]
-				pos++
-				if pos < len(tokens) && tokens[pos] == ":" {
-					pos++
-					if pos < len(tokens) {
-						arg.Value = tokens[pos]
-						pos++
-					}
-				}
-				if pos < len(tokens) && tokens[pos] == "," {
-					pos++
-				}
-				sel.Arguments = append(sel.Arguments, arg)
-			}
-			if pos < len(tokens) {
-				pos++ // skip ")"
-			}
-		}
-
-		// Parse nested selection set
-		if pos < len(tokens) && tokens[pos] == "{" {
-			pos++
-			sel.Children, pos = parseSelectionSet(tokens, pos)
-		}
-
-		selections = append(selections, sel)
+// SelectOperation returns the operation named name, or the first operation
+// when name is empty or unknown. Captured traffic frequently omits
+// operationName for multi-operation documents, so this is more lenient than
+// Document.Operation.
+func SelectOperation(doc *Document, name string) *OperationDefinition {
+	if op := doc.Operation(name); op != nil {
+		return op
 	}
-
-	if pos < len(tokens) && tokens[pos] == "}" {
-		pos++ // consume "}"
+	ops := doc.Operations()
+	if len(ops) == 0 {
+		return nil
 	}
-
-	return selections, pos
+	return ops[0]
 }
 
-func skipParens(tokens []string, pos int) int {
-	if pos >= len(tokens) || tokens[pos] != "(" {
-		return pos
-	}
-	depth := 0
-	for pos < len(tokens) {
-		if tokens[pos] == "(" {
-			depth++
-		} else if tokens[pos] == ")" {
-			depth--
-			if depth == 0 {
-				pos++
-				break
-			}
-		}
-		pos++
-	}
-	return pos
+// CollectFields flattens a selection set into its fields, expanding fragment
+// spreads and inline fragments in place. Each named fragment is expanded at
+// most once per path, so cyclic spreads terminate.
+func CollectFields(sels []Selection, frags map[string]*FragmentDefinition) []*Field {
+	var fields []*Field
+	collectFields(sels, frags, map[string]bool{}, &fields)
+	return fields
 }
 
-func tokenize(s string) []string {
-	var tokens []string
-	var current strings.Builder
-
-	for i := 0; i < len(s); i++ {
-		ch := s[i]
-		switch {
-		case ch == '{' || ch == '}' || ch == '(' || ch == ')' || ch == ':' || ch == ',' || ch == '!' || ch == '$' || ch == '@':
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
-			}
-			tokens = append(tokens, string(ch))
-		case ch == '.' && i+2 < len(s) && s[i+1] == '.' && s[i+2] == '.':
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
-			}
-			tokens = append(tokens, "...")
-			i += 2
-		case ch == '"':
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
+func collectFields(sels []Selection, frags map[string]*FragmentDefinition, visiting map[string]bool, out *[]*Field) {
+	for _, sel := range sels {
+		switch s := sel.(type) {
+		case *Field:
+			*out = append(*out, s)
+		case *InlineFragment:
+			collectFields(s.SelectionSet, frags, visiting, out)
+		case *FragmentSpread:
+			frag, ok := frags[s.Name]
+			if !ok || visiting[s.Name] {
+				continue
 			}
-			// Read string literal
-			i++
-			var str strings.Builder
-			str.WriteByte('"')
-			for i < len(s) && s[i] != '"' {
-				if s[i] == '\\' && i+1 < len(s) {
-					str.WriteByte(s[i])
-					i++
-					str.WriteByte(s[i])
-				} else {
-					str.WriteByte(s[i])
-				}
-				i++
-			}
-			str.WriteByte('"')
-			tokens = append(tokens, str.String())
-		case ch == '#':
-			// Skip comment to end of line
-			for i < len(s) && s[i] != '\n' {
-				i++
-			}
-		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
-			}
-		default:
-			current.WriteByte(ch)
+			visiting[s.Name] = true
+			collectFields(frag.SelectionSet, frags, visiting, out)
+			delete(visiting, s.Name)
 		}
 	}
+}
 
-	if current.Len() > 0 {
-		tokens = append(tokens, current.String())
+// TypeRefFromAST converts a parsed type reference into the schema model.
+// Named types are reported as scalars because a document alone cannot tell
+// scalars, enums, and input objects apart.
+func TypeRefFromAST(t Type) schema.TypeRef {
+	switch t := t.(type) {
+	case *NonNullType:
+		inner := TypeRefFromAST(t.OfType)
+		return schema.TypeRef{Kind: schema.KindNonNull, OfType: &inner}
+	case *ListType:
+		inner := TypeRefFromAST(t.OfType)
+		return schema.TypeRef{Kind: schema.KindList, OfType: &inner}
+	case *NamedType:
+		name := t.Name
+		return schema.TypeRef{Kind: schema.KindScalar, Name: &name}
 	}
-
-	return tokens
+	return schema.TypeRef{}
 }
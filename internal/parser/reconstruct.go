@@ -27,13 +27,17 @@ func ReconstructSchema(requests []schema.CapturedRequest, id, name string) *sche
 	hasSubscription := false
 
 	for _, req := range requests {
-		parsed := ParseQuery(req.Query)
-		if parsed == nil {
+		doc, err := ParseDocument(req.Query)
+		if err != nil {
+			continue
+		}
+		op := SelectOperation(doc, req.OperationName)
+		if op == nil {
 			continue
 		}
 
 		rootType := "Query"
-		switch parsed.OperationType {
+		switch op.Operation {
 		case "mutation":
 			rootType = "Mutation"
 			hasMutation = true
@@ -48,12 +52,21 @@ func ReconstructSchema(requests []schema.CapturedRequest, id, name string) *sche
 			}
 		}
 
+		r := &reconstructor{
+			typeFields: typeFields,
+			fragments:  doc.Fragments(),
+			varTypes:   make(map[string]schema.TypeRef),
+		}
+		for _, v := range op.VariableDefinitions {
+			r.varTypes[v.Name] = TypeRefFromAST(v.Type)
+		}
+
 		// Infer fields from query structure
-		inferFromSelections(typeFields, rootType, parsed.Fields)
+		r.inferFromSelections(rootType, op.SelectionSet)
 
 		// Infer return types from response body
 		if len(req.ResponseBody) > 0 {
-			inferFromResponse(typeFields, rootType, parsed.Fields, req.ResponseBody)
+			inferFromResponse(typeFields, rootType, CollectFields(op.SelectionSet, r.fragments), req.ResponseBody)
 		}
 	}
 
@@ -89,42 +102,69 @@ type inferredField struct {
 	args    []schema.Argument
 }
 
-func inferFromSelections(typeFields map[string]map[string]inferredField, parentType string, selections []ParsedSelection) {
+// reconstructor carries per-document state while walking a selection set.
+type reconstructor struct {
+	typeFields map[string]map[string]inferredField // typeName -> fieldName -> field info
+	fragments  map[string]*FragmentDefinition
+	varTypes   map[string]schema.TypeRef // declared operation variable types
+	visiting   map[string]bool           // fragment spreads on the current path
+}
+
+func (r *reconstructor) inferFromSelections(parentType string, selections []Selection) {
+	if _, ok := r.typeFields[parentType]; !ok {
+		r.typeFields[parentType] = make(map[string]inferredField)
+	}
+
 	for _, sel := range selections {
-		if sel.Name == "" || strings.HasPrefix(sel.Name, "__") {
+		switch s := sel.(type) {
+		case *InlineFragment:
+			target := parentType
+			if s.TypeCondition != "" {
+				target = s.TypeCondition
+			}
+			r.inferFromSelections(target, s.SelectionSet)
+			continue
+		case *FragmentSpread:
+			frag, ok := r.fragments[s.Name]
+			if !ok || r.visiting[s.Name] {
+				continue
+			}
+			if r.visiting == nil {
+				r.visiting = make(map[string]bool)
+			}
+			r.visiting[s.Name] = true
+			r.inferFromSelections(frag.TypeCondition, frag.SelectionSet)
+			delete(r.visiting, s.Name)
 			continue
 		}
 
-		if _, ok := typeFields[parentType]; !ok {
-			typeFields[parentType] = make(map[string]inferredField)
+		f := sel.(*Field)
+		if strings.HasPrefix(f.Name, "__") {
+			continue
 		}
 
-		field := typeFields[parentType][sel.Name]
+		field := r.typeFields[parentType][f.Name]
 
 		// Infer arguments
-		for _, arg := range sel.Arguments {
-			if arg.Name == "" || arg.Name == "$" {
-				continue
-			}
-			argName := strings.TrimPrefix(arg.Name, "$")
+		for _, arg := range f.Arguments {
 			found := false
 			for _, existing := range field.args {
-				if existing.Name == argName {
+				if existing.Name == arg.Name {
 					found = true
 					break
 				}
 			}
 			if !found {
 				field.args = append(field.args, schema.Argument{
-					Name: argName,
-					Type: inferArgType(arg.Value),
+					Name: arg.Name,
+					Type: r.inferArgType(arg.Value),
 				})
 			}
 		}
 
 		// If field has children, it returns an object type
-		if len(sel.Children) > 0 {
-			childTypeName := capitalize(sel.Name)
+		if len(f.SelectionSet) > 0 {
+			childTypeName := r.childTypeName(f)
 			nameStr := childTypeName
 			field.typeRef = schema.TypeRef{
 				Kind: schema.KindObject,
@@ -132,7 +172,7 @@ func inferFromSelections(typeFields map[string]map[string]inferredField, parentT
 			}
 
 			// Recurse into children
-			inferFromSelections(typeFields, childTypeName, sel.Children)
+			r.inferFromSelections(childTypeName, f.SelectionSet)
 		} else if field.typeRef.Name == nil {
 			// Leaf field — assume scalar
 			scalarName := "String"
@@ -142,11 +182,71 @@ func inferFromSelections(typeFields map[string]map[string]inferredField, parentT
 			}
 		}
 
-		typeFields[parentType][sel.Name] = field
+		r.typeFields[parentType][f.Name] = field
+	}
+}
+
+// childTypeName names the object type returned by f. When every type
+// condition in its selection set agrees (e.g. `viewer { ...UserFields }`),
+// that type is used; otherwise the name is derived from the field.
+func (r *reconstructor) childTypeName(f *Field) string {
+	cond := ""
+	for _, sel := range f.SelectionSet {
+		var tc string
+		switch s := sel.(type) {
+		case *InlineFragment:
+			tc = s.TypeCondition
+		case *FragmentSpread:
+			if frag, ok := r.fragments[s.Name]; ok {
+				tc = frag.TypeCondition
+			}
+		}
+		if tc == "" {
+			continue
+		}
+		if cond != "" && cond != tc {
+			return capitalize(f.Name)
+		}
+		cond = tc
+	}
+	if cond != "" {
+		return cond
+	}
+	return capitalize(f.Name)
+}
+
+// inferArgType derives an argument type from its value: declared variable
+// types are used as-is, literals map to the matching built-in scalar.
+func (r *reconstructor) inferArgType(v Value) schema.TypeRef {
+	switch v := v.(type) {
+	case *Variable:
+		if t, ok := r.varTypes[v.Name]; ok {
+			return t
+		}
+	case *IntValue:
+		nameStr := "Int"
+		return schema.TypeRef{Kind: schema.KindScalar, Name: &nameStr}
+	case *FloatValue:
+		nameStr := "Float"
+		return schema.TypeRef{Kind: schema.KindScalar, Name: &nameStr}
+	case *BooleanValue:
+		nameStr := "Boolean"
+		return schema.TypeRef{Kind: schema.KindScalar, Name: &nameStr}
+	case *ListValue:
+		inner := schema.TypeRef{}
+		if len(v.Values) > 0 {
+			inner = r.inferArgType(v.Values[0])
+		} else {
+			nameStr := "String"
+			inner = schema.TypeRef{Kind: schema.KindScalar, Name: &nameStr}
+		}
+		return schema.TypeRef{Kind: schema.KindList, OfType: &inner}
 	}
+	nameStr := "String"
+	return schema.TypeRef{Kind: schema.KindScalar, Name: &nameStr}
 }
 
-func inferFromResponse(typeFields map[string]map[string]inferredField, rootType string, selections []ParsedSelection, responseBody json.RawMessage) {
+func inferFromResponse(typeFields map[string]map[string]inferredField, rootType string, fields []*Field, responseBody json.RawMessage) {
 	var resp struct {
 		Data map[string]json.RawMessage `json:"data"`
 	}
@@ -154,13 +254,13 @@ func inferFromResponse(typeFields map[string]map[string]inferredField, rootType
 		return
 	}
 
-	for _, sel := range selections {
-		data, ok := resp.Data[sel.Name]
+	for _, f := range fields {
+		data, ok := resp.Data[f.ResponseKey()]
 		if !ok {
 			continue
 		}
 
-		inferTypeFromJSON(typeFields, rootType, sel.Name, data)
+		inferTypeFromJSON(typeFields, rootType, f.Name, data)
 	}
 }
 
@@ -235,28 +335,6 @@ func inferTypeFromJSON(typeFields map[string]map[string]inferredField, parentTyp
 	typeFields[parentType][fieldName] = field
 }
 
-func inferArgType(value string) schema.TypeRef {
-	value = strings.TrimSpace(value)
-	switch {
-	case value == "" || strings.HasPrefix(value, "$"):
-		nameStr := "String"
-		return schema.TypeRef{Kind: schema.KindScalar, Name: &nameStr}
-	case value == "true" || value == "false":
-		nameStr := "Boolean"
-		return schema.TypeRef{Kind: schema.KindScalar, Name: &nameStr}
-	case len(value) > 0 && value[0] >= '0' && value[0] <= '9':
-		if strings.Contains(value, ".") {
-			nameStr := "Float"
-			return schema.TypeRef{Kind: schema.KindScalar, Name: &nameStr}
-		}
-		nameStr := "Int"
-		return schema.TypeRef{Kind: schema.KindScalar, Name: &nameStr}
-	default:
-		nameStr := "String"
-		return schema.TypeRef{Kind: schema.KindScalar, Name: &nameStr}
-	}
-}
-
 func capitalize(s string) string {
 	if len(s) == 0 {
 		return s
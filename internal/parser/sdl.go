@@ -0,0 +1,712 @@
+package parser
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// builtinScalars are the specified scalars every schema contains, whether or
+// not the SDL declares them.
+var builtinScalars = []string{"String", "Int", "Float", "Boolean", "ID"}
+
+// builtinDirectives are the directives every schema supports implicitly.
+var builtinDirectives = []schema.Directive{
+	{
+		Name:        "skip",
+		Description: "Directs the executor to skip this field or fragment when the `if` argument is true.",
+		Locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		Args:        []schema.Argument{{Name: "if", Description: "Skipped when true.", Type: nonNullNamed(schema.KindScalar, "Boolean")}},
+	},
+	{
+		Name:        "include",
+		Description: "Directs the executor to include this field or fragment only when the `if` argument is true.",
+		Locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		Args:        []schema.Argument{{Name: "if", Description: "Included when true.", Type: nonNullNamed(schema.KindScalar, "Boolean")}},
+	},
+	{
+		Name:        "deprecated",
+		Description: "Marks an element of a GraphQL schema as no longer supported.",
+		Locations:   []string{"FIELD_DEFINITION", "ARGUMENT_DEFINITION", "INPUT_FIELD_DEFINITION", "ENUM_VALUE"},
+		Args:        []schema.Argument{{Name: "reason", Type: named(schema.KindScalar, "String"), DefaultValue: strPtr(QuoteString(defaultDeprecationReason))}},
+	},
+	{
+		Name:        "specifiedBy",
+		Description: "Exposes a URL that specifies the behavior of this scalar.",
+		Locations:   []string{"SCALAR"},
+		Args:        []schema.Argument{{Name: "url", Type: nonNullNamed(schema.KindScalar, "String")}},
+	},
+}
+
+const defaultDeprecationReason = "No longer supported"
+
+// directiveLocations is the set of valid locations in a directive definition.
+var directiveLocations = map[string]bool{
+	"QUERY": true, "MUTATION": true, "SUBSCRIPTION": true, "FIELD": true,
+	"FRAGMENT_DEFINITION": true, "FRAGMENT_SPREAD": true, "INLINE_FRAGMENT": true,
+	"VARIABLE_DEFINITION": true, "SCHEMA": true, "SCALAR": true, "OBJECT": true,
+	"FIELD_DEFINITION": true, "ARGUMENT_DEFINITION": true, "INTERFACE": true,
+	"UNION": true, "ENUM": true, "ENUM_VALUE": true, "INPUT_OBJECT": true,
+	"INPUT_FIELD_DEFINITION": true,
+}
+
+// ParseSDL parses a GraphQL schema definition language document into our
+// Schema model. Type extensions are merged into their base definitions and
+// @deprecated / @specifiedBy are mapped onto the corresponding model fields;
+// other applied directives are not retained. Named types that the document
+// references but never defines are added as custom scalars.
+func ParseSDL(src, id, name string) (*schema.Schema, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+	b := &sdlBuilder{p: p, index: make(map[string]int), roots: make(map[string]string)}
+	for !p.peek(tokEOF) {
+		if err := b.parseDefinition(); err != nil {
+			return nil, err
+		}
+	}
+	if err := b.applyExtensions(); err != nil {
+		return nil, err
+	}
+	if len(b.types) == 0 && len(b.directives) == 0 && len(b.roots) == 0 {
+		return nil, fmt.Errorf("SDL document contains no definitions")
+	}
+
+	s := &schema.Schema{
+		ID:          id,
+		Name:        name,
+		Source:      schema.SourceImport,
+		Description: b.description,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	for _, n := range builtinScalars {
+		if _, ok := b.index[n]; !ok {
+			b.addType(schema.Type{Name: n, Kind: schema.KindScalar})
+		}
+	}
+	b.declareMissingTypes()
+	b.resolveKinds()
+	s.Types = b.types
+
+	s.QueryType = b.rootType("query", "Query")
+	s.MutationType = b.rootType("mutation", "Mutation")
+	s.SubscriptionType = b.rootType("subscription", "Subscription")
+	if s.QueryType == "" {
+		return nil, fmt.Errorf("schema has no query root type")
+	}
+
+	defined := make(map[string]bool, len(b.directives))
+	for _, d := range b.directives {
+		defined[d.Name] = true
+		b.resolveArgKinds(d.Args)
+	}
+	for _, d := range builtinDirectives {
+		if !defined[d.Name] {
+			s.Directives = append(s.Directives, d)
+		}
+	}
+	s.Directives = append(s.Directives, b.directives...)
+
+	return s, nil
+}
+
+// sdlBuilder accumulates definitions while parsing an SDL document.
+type sdlBuilder struct {
+	p           *parser
+	types       []schema.Type
+	index       map[string]int // type name -> position in types
+	extensions  []sdlExtension
+	directives  []schema.Directive
+	roots       map[string]string // operation -> root type name
+	description string
+	hasSchema   bool
+}
+
+// sdlExtension is a parsed `extend ...` definition awaiting its base type.
+type sdlExtension struct {
+	typ schema.Type
+	pos Position
+}
+
+func (b *sdlBuilder) addType(t schema.Type) {
+	b.index[t.Name] = len(b.types)
+	b.types = append(b.types, t)
+}
+
+func (b *sdlBuilder) rootType(op, fallback string) string {
+	if name, ok := b.roots[op]; ok {
+		return name
+	}
+	if b.hasSchema {
+		return ""
+	}
+	if _, ok := b.index[fallback]; ok {
+		return fallback
+	}
+	return ""
+}
+
+func (b *sdlBuilder) parseDefinition() error {
+	p := b.p
+	desc, err := b.parseDescription()
+	if err != nil {
+		return err
+	}
+	if !p.peek(tokName) {
+		return p.unexpected()
+	}
+
+	pos := p.tok.pos
+	switch p.tok.value {
+	case "extend":
+		if desc != "" {
+			return p.errorAt(pos, "unexpected description on type extension")
+		}
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if p.peekKeyword("schema") {
+			return b.parseSchemaDefinition(true)
+		}
+		pos := p.tok.pos
+		t, err := b.parseTypeDefinition()
+		if err != nil {
+			return err
+		}
+		b.extensions = append(b.extensions, sdlExtension{typ: t, pos: pos})
+		return nil
+	case "schema":
+		b.description = desc
+		return b.parseSchemaDefinition(false)
+	case "directive":
+		d, err := b.parseDirectiveDefinition()
+		if err != nil {
+			return err
+		}
+		d.Description = desc
+		for _, existing := range b.directives {
+			if existing.Name == d.Name {
+				return p.errorAt(pos, "directive @%s is defined more than once", d.Name)
+			}
+		}
+		b.directives = append(b.directives, d)
+		return nil
+	case "query", "mutation", "subscription", "fragment":
+		return p.errorAt(pos, "unexpected %s: executable definitions are not allowed in a schema document", p.tok.describe())
+	}
+
+	t, err := b.parseTypeDefinition()
+	if err != nil {
+		return err
+	}
+	t.Description = desc
+	if _, dup := b.index[t.Name]; dup {
+		return p.errorAt(pos, "type %q is defined more than once", t.Name)
+	}
+	b.addType(t)
+	return nil
+}
+
+// parseDescription consumes an optional description string.
+func (b *sdlBuilder) parseDescription() (string, error) {
+	p := b.p
+	if p.peek(tokString) || p.peek(tokBlockString) {
+		desc := p.tok.value
+		return desc, p.advance()
+	}
+	return "", nil
+}
+
+// parseSchemaDefinition parses `schema @dirs { query: Q ... }`; the leading
+// `extend` has already been consumed for extensions.
+func (b *sdlBuilder) parseSchemaDefinition(extension bool) error {
+	p := b.p
+	pos := p.tok.pos
+	if err := p.expectKeyword("schema"); err != nil {
+		return err
+	}
+	if !extension {
+		if b.hasSchema {
+			return p.errorAt(pos, "schema is defined more than once")
+		}
+		b.hasSchema = true
+	}
+	if _, err := p.parseDirectives(true); err != nil {
+		return err
+	}
+	if !p.peek(tokBraceL) {
+		if extension {
+			return nil
+		}
+		return p.errorAt(p.tok.pos, "expected %s, found %s", tokBraceL, p.tok.describe())
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+	for !p.peek(tokBraceR) {
+		opPos := p.tok.pos
+		op, err := p.parseName()
+		if err != nil {
+			return err
+		}
+		if op != "query" && op != "mutation" && op != "subscription" {
+			return p.errorAt(opPos, "unknown operation type %q", op)
+		}
+		if _, dup := b.roots[op]; dup {
+			return p.errorAt(opPos, "root %s type is defined more than once", op)
+		}
+		if err := p.expect(tokColon); err != nil {
+			return err
+		}
+		typeName, err := p.parseName()
+		if err != nil {
+			return err
+		}
+		b.roots[op] = typeName
+	}
+	return p.advance()
+}
+
+// parseTypeDefinition parses a scalar, type, interface, union, enum, or input
+// definition (or the body of an extension of one).
+func (b *sdlBuilder) parseTypeDefinition() (schema.Type, error) {
+	p := b.p
+	var t schema.Type
+	if !p.peek(tokName) {
+		return t, p.unexpected()
+	}
+	keyword := p.tok.value
+	switch keyword {
+	case "scalar":
+		t.Kind = schema.KindScalar
+	case "type":
+		t.Kind = schema.KindObject
+	case "interface":
+		t.Kind = schema.KindInterface
+	case "union":
+		t.Kind = schema.KindUnion
+	case "enum":
+		t.Kind = schema.KindEnum
+	case "input":
+		t.Kind = schema.KindInputObject
+	default:
+		return t, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return t, err
+	}
+	name, err := p.parseName()
+	if err != nil {
+		return t, err
+	}
+	t.Name = name
+
+	if t.Kind == schema.KindObject || t.Kind == schema.KindInterface {
+		if t.Interfaces, err = b.parseImplements(); err != nil {
+			return t, err
+		}
+	}
+
+	dirs, err := p.parseDirectives(true)
+	if err != nil {
+		return t, err
+	}
+	if t.Kind == schema.KindScalar {
+		t.SpecifiedByURL = directiveString(dirs, "specifiedBy", "url", "")
+	}
+
+	switch t.Kind {
+	case schema.KindObject, schema.KindInterface:
+		t.Fields, err = b.parseFieldsDefinition()
+	case schema.KindInputObject:
+		t.InputFields, err = b.parseInputFieldsDefinition()
+	case schema.KindEnum:
+		t.EnumValues, err = b.parseEnumValuesDefinition()
+	case schema.KindUnion:
+		t.PossibleTypes, err = b.parseUnionMembers()
+	}
+	return t, err
+}
+
+// parseImplements parses `implements & A & B`.
+func (b *sdlBuilder) parseImplements() ([]string, error) {
+	p := b.p
+	if !p.peekKeyword("implements") {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if _, err := p.skip(tokAmp); err != nil {
+		return nil, err
+	}
+	var names []string
+	for {
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if ok, err := p.skip(tokAmp); err != nil {
+			return nil, err
+		} else if !ok {
+			return names, nil
+		}
+	}
+}
+
+// parseUnionMembers parses `= | A | B`.
+func (b *sdlBuilder) parseUnionMembers() ([]string, error) {
+	p := b.p
+	if ok, err := p.skip(tokEquals); err != nil || !ok {
+		return nil, err
+	}
+	if _, err := p.skip(tokPipe); err != nil {
+		return nil, err
+	}
+	var names []string
+	for {
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if ok, err := p.skip(tokPipe); err != nil {
+			return nil, err
+		} else if !ok {
+			return names, nil
+		}
+	}
+}
+
+// parseFieldsDefinition parses an optional `{ field(args): Type @dirs ... }`.
+func (b *sdlBuilder) parseFieldsDefinition() ([]schema.Field, error) {
+	p := b.p
+	if ok, err := p.skip(tokBraceL); err != nil || !ok {
+		return nil, err
+	}
+	var fields []schema.Field
+	for !p.peek(tokBraceR) {
+		desc, err := b.parseDescription()
+		if err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		f := schema.Field{Name: name, Description: desc}
+		if f.Args, err = b.parseArgumentsDefinition(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokColon); err != nil {
+			return nil, err
+		}
+		if f.Type, err = b.parseTypeRef(); err != nil {
+			return nil, err
+		}
+		dirs, err := p.parseDirectives(true)
+		if err != nil {
+			return nil, err
+		}
+		f.IsDeprecated, f.DeprecationReason = deprecation(dirs)
+		fields = append(fields, f)
+	}
+	return fields, p.advance()
+}
+
+// parseArgumentsDefinition parses an optional `(name: Type = default ...)`.
+func (b *sdlBuilder) parseArgumentsDefinition() ([]schema.Argument, error) {
+	p := b.p
+	if ok, err := p.skip(tokParenL); err != nil || !ok {
+		return nil, err
+	}
+	var args []schema.Argument
+	for !p.peek(tokParenR) {
+		a, err := b.parseInputValueDefinition()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+	}
+	return args, p.advance()
+}
+
+// parseInputFieldsDefinition parses an optional `{ name: Type = default ... }`.
+func (b *sdlBuilder) parseInputFieldsDefinition() ([]schema.Field, error) {
+	p := b.p
+	if ok, err := p.skip(tokBraceL); err != nil || !ok {
+		return nil, err
+	}
+	var fields []schema.Field
+	for !p.peek(tokBraceR) {
+		a, err := b.parseInputValueDefinition()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, schema.Field{
+			Name:              a.Name,
+			Description:       a.Description,
+			Type:              a.Type,
+			DefaultValue:      a.DefaultValue,
+			IsDeprecated:      a.IsDeprecated,
+			DeprecationReason: a.DeprecationReason,
+		})
+	}
+	return fields, p.advance()
+}
+
+func (b *sdlBuilder) parseInputValueDefinition() (schema.Argument, error) {
+	p := b.p
+	var a schema.Argument
+	desc, err := b.parseDescription()
+	if err != nil {
+		return a, err
+	}
+	name, err := p.parseName()
+	if err != nil {
+		return a, err
+	}
+	a.Name, a.Description = name, desc
+	if err := p.expect(tokColon); err != nil {
+		return a, err
+	}
+	if a.Type, err = b.parseTypeRef(); err != nil {
+		return a, err
+	}
+	if ok, err := p.skip(tokEquals); err != nil {
+		return a, err
+	} else if ok {
+		v, err := p.parseValue(true)
+		if err != nil {
+			return a, err
+		}
+		a.DefaultValue = strPtr(PrintValue(v))
+	}
+	dirs, err := p.parseDirectives(true)
+	if err != nil {
+		return a, err
+	}
+	a.IsDeprecated, a.DeprecationReason = deprecation(dirs)
+	return a, nil
+}
+
+// parseEnumValuesDefinition parses an optional `{ A B @deprecated ... }`.
+func (b *sdlBuilder) parseEnumValuesDefinition() ([]schema.EnumValue, error) {
+	p := b.p
+	if ok, err := p.skip(tokBraceL); err != nil || !ok {
+		return nil, err
+	}
+	var values []schema.EnumValue
+	for !p.peek(tokBraceR) {
+		desc, err := b.parseDescription()
+		if err != nil {
+			return nil, err
+		}
+		pos := p.tok.pos
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		if name == "true" || name == "false" || name == "null" {
+			return nil, p.errorAt(pos, "enum value cannot be named %q", name)
+		}
+		dirs, err := p.parseDirectives(true)
+		if err != nil {
+			return nil, err
+		}
+		ev := schema.EnumValue{Name: name, Description: desc}
+		ev.IsDeprecated, ev.DeprecationReason = deprecation(dirs)
+		values = append(values, ev)
+	}
+	return values, p.advance()
+}
+
+// parseDirectiveDefinition parses `directive @name(args) repeatable on A | B`.
+func (b *sdlBuilder) parseDirectiveDefinition() (schema.Directive, error) {
+	p := b.p
+	var d schema.Directive
+	if err := p.expectKeyword("directive"); err != nil {
+		return d, err
+	}
+	if err := p.expect(tokAt); err != nil {
+		return d, err
+	}
+	name, err := p.parseName()
+	if err != nil {
+		return d, err
+	}
+	d.Name = name
+	if d.Args, err = b.parseArgumentsDefinition(); err != nil {
+		return d, err
+	}
+	if p.peekKeyword("repeatable") {
+		d.IsRepeatable = true
+		if err := p.advance(); err != nil {
+			return d, err
+		}
+	}
+	if err := p.expectKeyword("on"); err != nil {
+		return d, err
+	}
+	if _, err := p.skip(tokPipe); err != nil {
+		return d, err
+	}
+	for {
+		pos := p.tok.pos
+		loc, err := p.parseName()
+		if err != nil {
+			return d, err
+		}
+		if !directiveLocations[loc] {
+			return d, p.errorAt(pos, "unknown directive location %q", loc)
+		}
+		d.Locations = append(d.Locations, loc)
+		if ok, err := p.skip(tokPipe); err != nil {
+			return d, err
+		} else if !ok {
+			return d, nil
+		}
+	}
+}
+
+// parseTypeRef parses a type reference. Named types are provisionally
+// scalars; resolveKinds fixes them up once every definition is known.
+func (b *sdlBuilder) parseTypeRef() (schema.TypeRef, error) {
+	t, err := b.p.parseType()
+	if err != nil {
+		return schema.TypeRef{}, err
+	}
+	return TypeRefFromAST(t), nil
+}
+
+// applyExtensions merges every `extend` definition into its base type.
+func (b *sdlBuilder) applyExtensions() error {
+	for _, ext := range b.extensions {
+		i, ok := b.index[ext.typ.Name]
+		if !ok {
+			return b.p.errorAt(ext.pos, "cannot extend undefined type %q", ext.typ.Name)
+		}
+		base := &b.types[i]
+		if base.Kind != ext.typ.Kind {
+			return b.p.errorAt(ext.pos, "cannot extend %s %q as %s", base.Kind, base.Name, ext.typ.Kind)
+		}
+		base.Fields = append(base.Fields, ext.typ.Fields...)
+		base.InputFields = append(base.InputFields, ext.typ.InputFields...)
+		base.EnumValues = append(base.EnumValues, ext.typ.EnumValues...)
+		base.Interfaces = append(base.Interfaces, ext.typ.Interfaces...)
+		base.PossibleTypes = append(base.PossibleTypes, ext.typ.PossibleTypes...)
+		if ext.typ.SpecifiedByURL != "" {
+			base.SpecifiedByURL = ext.typ.SpecifiedByURL
+		}
+	}
+	return nil
+}
+
+// declareMissingTypes adds a scalar definition for every referenced type
+// name that the document never defines.
+func (b *sdlBuilder) declareMissingTypes() {
+	var missing []string
+	seen := make(map[string]bool)
+	note := func(ref schema.TypeRef) {
+		n := ref.BaseName()
+		if _, ok := b.index[n]; !ok && n != "" && !seen[n] {
+			seen[n] = true
+			missing = append(missing, n)
+		}
+	}
+	for _, t := range b.types {
+		for _, f := range t.Fields {
+			note(f.Type)
+			for _, a := range f.Args {
+				note(a.Type)
+			}
+		}
+		for _, f := range t.InputFields {
+			note(f.Type)
+		}
+	}
+	for _, d := range b.directives {
+		for _, a := range d.Args {
+			note(a.Type)
+		}
+	}
+	for _, n := range missing {
+		b.addType(schema.Type{Name: n, Kind: schema.KindScalar})
+	}
+}
+
+// resolveKinds sets the Kind of every named TypeRef to its definition's kind.
+func (b *sdlBuilder) resolveKinds() {
+	for i := range b.types {
+		t := &b.types[i]
+		for j := range t.Fields {
+			b.resolveKind(&t.Fields[j].Type)
+			b.resolveArgKinds(t.Fields[j].Args)
+		}
+		for j := range t.InputFields {
+			b.resolveKind(&t.InputFields[j].Type)
+		}
+	}
+}
+
+func (b *sdlBuilder) resolveArgKinds(args []schema.Argument) {
+	for i := range args {
+		b.resolveKind(&args[i].Type)
+	}
+}
+
+func (b *sdlBuilder) resolveKind(ref *schema.TypeRef) {
+	for ref.OfType != nil {
+		ref = ref.OfType
+	}
+	if ref.Name == nil {
+		return
+	}
+	if i, ok := b.index[*ref.Name]; ok {
+		ref.Kind = b.types[i].Kind
+	}
+}
+
+// deprecation reads @deprecated(reason:) from a directive list.
+func deprecation(dirs []*Directive) (bool, string) {
+	for _, d := range dirs {
+		if d.Name == "deprecated" {
+			return true, directiveString(dirs, "deprecated", "reason", defaultDeprecationReason)
+		}
+	}
+	return false, ""
+}
+
+// directiveString returns the string argument arg of the first directive
+// named name, or fallback when the directive or argument is absent.
+func directiveString(dirs []*Directive, name, arg, fallback string) string {
+	for _, d := range dirs {
+		if d.Name != name {
+			continue
+		}
+		for _, a := range d.Arguments {
+			if s, ok := a.Value.(*StringValue); ok && a.Name == arg {
+				return s.Value
+			}
+		}
+		return fallback
+	}
+	return fallback
+}
+
+func named(kind schema.TypeKind, name string) schema.TypeRef {
+	return schema.TypeRef{Kind: kind, Name: &name}
+}
+
+func nonNullNamed(kind schema.TypeKind, name string) schema.TypeRef {
+	inner := named(kind, name)
+	return schema.TypeRef{Kind: schema.KindNonNull, OfType: &inner}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
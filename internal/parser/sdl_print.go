@@ -0,0 +1,229 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// PrintSDL renders a schema as GraphQL SDL. Introspection types, built-in
+// scalars, and built-in directives are omitted, and the schema block is only
+// emitted when the root types differ from the Query/Mutation/Subscription
+// defaults or the schema carries a description.
+func PrintSDL(s *schema.Schema) string {
+	var blocks []string
+
+	if needsSchemaBlock(s) {
+		var b strings.Builder
+		printDescription(&b, s.Description, "")
+		b.WriteString("schema {\n")
+		if s.QueryType != "" {
+			b.WriteString("  query: " + s.QueryType + "\n")
+		}
+		if s.MutationType != "" {
+			b.WriteString("  mutation: " + s.MutationType + "\n")
+		}
+		if s.SubscriptionType != "" {
+			b.WriteString("  subscription: " + s.SubscriptionType + "\n")
+		}
+		b.WriteByte('}')
+		blocks = append(blocks, b.String())
+	}
+
+	for _, d := range s.Directives {
+		if isBuiltinDirective(d.Name) {
+			continue
+		}
+		blocks = append(blocks, printDirectiveDefinition(d))
+	}
+
+	for _, t := range s.Types {
+		if strings.HasPrefix(t.Name, "__") || (t.Kind == schema.KindScalar && isBuiltinScalar(t.Name)) {
+			continue
+		}
+		blocks = append(blocks, printTypeDefinition(t))
+	}
+
+	if len(blocks) == 0 {
+		return ""
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+func needsSchemaBlock(s *schema.Schema) bool {
+	if s.Description != "" {
+		return true
+	}
+	return (s.QueryType != "" && s.QueryType != "Query") ||
+		(s.MutationType != "" && s.MutationType != "Mutation") ||
+		(s.SubscriptionType != "" && s.SubscriptionType != "Subscription")
+}
+
+func isBuiltinDirective(name string) bool {
+	for _, d := range builtinDirectives {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isBuiltinScalar(name string) bool {
+	for _, n := range builtinScalars {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func printTypeDefinition(t schema.Type) string {
+	var b strings.Builder
+	printDescription(&b, t.Description, "")
+
+	switch t.Kind {
+	case schema.KindScalar:
+		b.WriteString("scalar " + t.Name)
+		if t.SpecifiedByURL != "" {
+			b.WriteString(" @specifiedBy(url: " + QuoteString(t.SpecifiedByURL) + ")")
+		}
+
+	case schema.KindObject, schema.KindInterface:
+		if t.Kind == schema.KindObject {
+			b.WriteString("type " + t.Name)
+		} else {
+			b.WriteString("interface " + t.Name)
+		}
+		if len(t.Interfaces) > 0 {
+			b.WriteString(" implements " + strings.Join(t.Interfaces, " & "))
+		}
+		if len(t.Fields) > 0 {
+			b.WriteString(" {\n")
+			for _, f := range t.Fields {
+				printDescription(&b, f.Description, "  ")
+				b.WriteString("  " + f.Name)
+				printArgumentsDefinition(&b, f.Args, "  ")
+				b.WriteString(": " + f.Type.Signature())
+				printDeprecated(&b, f.IsDeprecated, f.DeprecationReason)
+				b.WriteByte('\n')
+			}
+			b.WriteByte('}')
+		}
+
+	case schema.KindUnion:
+		b.WriteString("union " + t.Name)
+		if len(t.PossibleTypes) > 0 {
+			b.WriteString(" = " + strings.Join(t.PossibleTypes, " | "))
+		}
+
+	case schema.KindEnum:
+		b.WriteString("enum " + t.Name)
+		if len(t.EnumValues) > 0 {
+			b.WriteString(" {\n")
+			for _, ev := range t.EnumValues {
+				printDescription(&b, ev.Description, "  ")
+				b.WriteString("  " + ev.Name)
+				printDeprecated(&b, ev.IsDeprecated, ev.DeprecationReason)
+				b.WriteByte('\n')
+			}
+			b.WriteByte('}')
+		}
+
+	case schema.KindInputObject:
+		b.WriteString("input " + t.Name)
+		if len(t.InputFields) > 0 {
+			b.WriteString(" {\n")
+			for _, f := range t.InputFields {
+				printDescription(&b, f.Description, "  ")
+				b.WriteString("  " + f.Name + ": " + f.Type.Signature())
+				if f.DefaultValue != nil {
+					b.WriteString(" = " + *f.DefaultValue)
+				}
+				printDeprecated(&b, f.IsDeprecated, f.DeprecationReason)
+				b.WriteByte('\n')
+			}
+			b.WriteByte('}')
+		}
+	}
+
+	return b.String()
+}
+
+func printDirectiveDefinition(d schema.Directive) string {
+	var b strings.Builder
+	printDescription(&b, d.Description, "")
+	b.WriteString("directive @" + d.Name)
+	printArgumentsDefinition(&b, d.Args, "")
+	if d.IsRepeatable {
+		b.WriteString(" repeatable")
+	}
+	b.WriteString(" on " + strings.Join(d.Locations, " | "))
+	return b.String()
+}
+
+// printArgumentsDefinition writes `(a: Int, b: String = "x")`, switching to
+// one argument per line when any argument has a description.
+func printArgumentsDefinition(b *strings.Builder, args []schema.Argument, indent string) {
+	if len(args) == 0 {
+		return
+	}
+	multiline := false
+	for _, a := range args {
+		if a.Description != "" {
+			multiline = true
+			break
+		}
+	}
+
+	b.WriteByte('(')
+	for i, a := range args {
+		if multiline {
+			b.WriteByte('\n')
+			printDescription(b, a.Description, indent+"  ")
+			b.WriteString(indent + "  ")
+		} else if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(a.Name + ": " + a.Type.Signature())
+		if a.DefaultValue != nil {
+			b.WriteString(" = " + *a.DefaultValue)
+		}
+		printDeprecated(b, a.IsDeprecated, a.DeprecationReason)
+	}
+	if multiline {
+		b.WriteString("\n" + indent)
+	}
+	b.WriteByte(')')
+}
+
+func printDeprecated(b *strings.Builder, deprecated bool, reason string) {
+	if !deprecated {
+		return
+	}
+	b.WriteString(" @deprecated")
+	if reason != "" && reason != defaultDeprecationReason {
+		b.WriteString("(reason: " + QuoteString(reason) + ")")
+	}
+}
+
+// printDescription writes desc on its own line at the given indentation,
+// using a block string for multi-line text when it round-trips exactly.
+func printDescription(b *strings.Builder, desc, indent string) {
+	if desc == "" {
+		return
+	}
+	if strings.Contains(desc, "\n") {
+		lines := strings.Split(desc, "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = indent + line
+			}
+		}
+		body := "\n" + strings.Join(lines, "\n") + "\n" + indent
+		if blockStringValue(body) == desc {
+			b.WriteString(indent + `"""` + strings.ReplaceAll(body, `"""`, `\"""`) + `"""` + "\n")
+			return
+		}
+	}
+	b.WriteString(indent + QuoteString(desc) + "\n")
+}
@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+const testSDL = `"""
+The API.
+"""
+schema {
+  query: Root
+  mutation: Mutations
+}
+
+"Marks a field as cached."
+directive @cached(ttl: Int = 60) repeatable on FIELD_DEFINITION | OBJECT
+
+scalar DateTime @specifiedBy(url: "https://example.com/datetime")
+
+interface Node {
+  id: ID!
+}
+
+"""
+A person.
+  Indented line.
+"""
+type User implements Node & Named @cached {
+  id: ID!
+  name(format: NameFormat = FULL, "Max length." max: Int): String @deprecated(reason: "Use fullName.")
+  friends(first: Int = 10, after: String): [User!]!
+  createdAt: DateTime
+}
+
+interface Named {
+  name: String
+}
+
+union SearchResult = User | Post
+
+type Post implements Node {
+  id: ID!
+  title: String!
+}
+
+enum NameFormat {
+  FULL
+  "Only the first name."
+  SHORT
+  LEGACY @deprecated
+}
+
+input UserFilter {
+  ids: [ID!]
+  name: String = "x"
+  nested: UserFilter
+  format: NameFormat! = SHORT
+}
+
+type Root {
+  node(id: ID!): Node
+  search(filter: UserFilter = {name: "a", ids: ["1"]}): [SearchResult]
+}
+
+type Mutations {
+  rename(id: ID!, name: String!): User
+}
+
+extend type Root {
+  me: User
+}
+`
+
+func TestSDLRoundTrip(t *testing.T) {
+	s, err := ParseSDL(testSDL, "id", "test")
+	if err != nil {
+		t.Fatalf("ParseSDL: %v", err)
+	}
+	printed := PrintSDL(s)
+
+	again, err := ParseSDL(printed, "id", "test")
+	if err != nil {
+		t.Fatalf("ParseSDL(PrintSDL): %v\n%s", err, printed)
+	}
+	if reprinted := PrintSDL(again); reprinted != printed {
+		t.Fatalf("print is not stable:\n%s\nthen:\n%s", printed, reprinted)
+	}
+
+	for _, want := range []string{
+		"schema {\n  query: Root\n  mutation: Mutations\n}",
+		"directive @cached(ttl: Int = 60) repeatable on FIELD_DEFINITION | OBJECT",
+		`scalar DateTime @specifiedBy(url: "https://example.com/datetime")`,
+		"type User implements Node & Named",
+		`@deprecated(reason: "Use fullName.")`,
+		"friends(first: Int = 10, after: String): [User!]!",
+		"union SearchResult = User | Post",
+		"LEGACY @deprecated",
+		"format: NameFormat! = SHORT",
+		"me: User",
+	} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("PrintSDL output lacks %q:\n%s", want, printed)
+		}
+	}
+}
+
+func TestParseSDLModel(t *testing.T) {
+	s, err := ParseSDL(testSDL, "id", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.QueryType != "Root" || s.MutationType != "Mutations" || s.SubscriptionType != "" {
+		t.Errorf("roots = %q %q %q", s.QueryType, s.MutationType, s.SubscriptionType)
+	}
+	if s.Description != "The API." {
+		t.Errorf("description = %q", s.Description)
+	}
+
+	types := map[string]schema.Type{}
+	for _, typ := range s.Types {
+		types[typ.Name] = typ
+	}
+	kinds := map[string]schema.TypeKind{
+		"User": schema.KindObject, "Node": schema.KindInterface, "SearchResult": schema.KindUnion,
+		"NameFormat": schema.KindEnum, "UserFilter": schema.KindInputObject, "DateTime": schema.KindScalar,
+	}
+	for name, kind := range kinds {
+		if types[name].Kind != kind {
+			t.Errorf("%s kind = %s, want %s", name, types[name].Kind, kind)
+		}
+	}
+
+	user := types["User"]
+	if user.Description != "A person.\n  Indented line." {
+		t.Errorf("User description = %q", user.Description)
+	}
+	if strings.Join(user.Interfaces, ",") != "Node,Named" {
+		t.Errorf("User interfaces = %v", user.Interfaces)
+	}
+	var name, friends schema.Field
+	for _, f := range user.Fields {
+		switch f.Name {
+		case "name":
+			name = f
+		case "friends":
+			friends = f
+		}
+	}
+	if !name.IsDeprecated || name.DeprecationReason != "Use fullName." {
+		t.Errorf("name deprecation = %v %q", name.IsDeprecated, name.DeprecationReason)
+	}
+	if len(name.Args) != 2 || name.Args[0].Type.Kind != schema.KindEnum || name.Args[1].Description != "Max length." {
+		t.Errorf("name args = %+v", name.Args)
+	}
+	if got := friends.Type.Signature(); got != "[User!]!" {
+		t.Errorf("friends type = %s", got)
+	}
+
+	root := types["Root"]
+	var hasMe bool
+	for _, f := range root.Fields {
+		hasMe = hasMe || f.Name == "me"
+	}
+	if !hasMe {
+		t.Error("extend type Root did not add me")
+	}
+}
+
+func TestParseSDLErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		line     int
+		column   int
+		contains string
+	}{
+		{src: "type Query {\n  a: \n}", line: 3, column: 1, contains: `"}"`},
+		{src: "type Query { a(b Int): Int }", line: 1, column: 18, contains: `expected ":"`},
+		{src: "union U = | | A", line: 1, column: 13, contains: "expected Name"},
+		{src: "directive @d on NOWHERE", line: 1, column: 17, contains: "NOWHERE"},
+		{src: "query { a }", line: 1, column: 1, contains: "query"},
+	}
+	for _, tt := range tests {
+		_, err := ParseSDL(tt.src, "id", "test")
+		se, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("ParseSDL(%q): err = %v, want *SyntaxError", tt.src, err)
+			continue
+		}
+		if se.Line != tt.line || se.Column != tt.column || !strings.Contains(se.Message, tt.contains) {
+			t.Errorf("ParseSDL(%q): got %d:%d %q, want %d:%d containing %q", tt.src, se.Line, se.Column, se.Message, tt.line, tt.column, tt.contains)
+		}
+	}
+}
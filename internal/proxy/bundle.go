@@ -0,0 +1,137 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/bundle"
+	"github.com/0xDTC/0xGQLForge/internal/storage"
+)
+
+// maxBundleSize skips JavaScript responses too large to scan.
+const maxBundleSize = 32 << 20
+
+// bundleScanner scans JavaScript responses once per project and content.
+type bundleScanner struct {
+	repo *storage.BundleRepo
+	mu   sync.Mutex
+	seen map[[sha256.Size]byte]struct{}
+}
+
+// SetBundleRepo turns on scanning of JavaScript responses for GraphQL
+// documents and persisted query mappings, stored in repo. Persisted queries
+// the bundles map are captured with their operation name and text.
+func (p *Proxy) SetBundleRepo(repo *storage.BundleRepo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bundles = &bundleScanner{repo: repo, seen: make(map[[sha256.Size]byte]struct{})}
+}
+
+func (p *Proxy) bundleScanner() *bundleScanner {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.bundles
+}
+
+// isBundleResponse reports whether resp carries JavaScript, or a JSON
+// persisted query manifest.
+func isBundleResponse(req *http.Request, resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/javascript", "text/javascript", "application/x-javascript", "application/ecmascript", "text/ecmascript":
+		return true
+	case "application/json":
+		name := strings.ToLower(path.Base(req.URL.Path))
+		return strings.Contains(name, "persisted") || strings.Contains(name, "manifest")
+	case "", "application/octet-stream", "text/plain":
+		ext := path.Ext(req.URL.Path)
+		return ext == ".js" || ext == ".mjs"
+	}
+	return false
+}
+
+// scanBundle extracts operations from a JavaScript response and stores them
+// for projID, then resolves the project's persisted query captures that
+// they map.
+func (p *Proxy) scanBundle(projID, source string, body []byte) {
+	s := p.bundleScanner()
+	if s == nil || len(body) == 0 || len(body) > maxBundleSize {
+		return
+	}
+	key := sha256.Sum256(append([]byte(projID+"\x00"), body...))
+	s.mu.Lock()
+	if _, ok := s.seen[key]; ok {
+		s.mu.Unlock()
+		return
+	}
+	if len(s.seen) >= 4096 {
+		clear(s.seen)
+	}
+	s.seen[key] = struct{}{}
+	s.mu.Unlock()
+
+	ops := bundle.Scan(body)
+	if len(ops) == 0 {
+		return
+	}
+	now := time.Now().UTC()
+	for i := range ops {
+		ops[i].ID = generateBundleOpID()
+		ops[i].ProjectID = projID
+		ops[i].Source = source
+		ops[i].FirstSeen = now
+	}
+	added, err := s.repo.Save(ops)
+	if err != nil {
+		log.Printf("save bundle operations from %s: %v", source, err)
+		return
+	}
+	if added == 0 {
+		return
+	}
+	resolved, err := s.repo.ResolveTraffic(projID)
+	if err != nil {
+		log.Printf("resolve persisted captures: %v", err)
+	}
+	log.Printf("bundle %s: %d new GraphQL operation(s), %d capture(s) resolved", source, added, resolved)
+}
+
+// bundleOperation returns the operation name and text the project's
+// bundles map a persisted query's ID to.
+func (p *Proxy) bundleOperation(projID string, payload *graphqlPayload) (name, text string) {
+	s := p.bundleScanner()
+	if s == nil {
+		return "", ""
+	}
+	for _, id := range []string{payload.DocID, payload.QueryHash, payload.persistedHash()} {
+		if id == "" {
+			continue
+		}
+		var err error
+		if name, text, err = s.repo.Resolve(projID, id); err != nil {
+			log.Printf("resolve persisted query %s: %v", id, err)
+			return "", ""
+		}
+		if name != "" || text != "" {
+			return name, text
+		}
+	}
+	return "", ""
+}
+
+func generateBundleOpID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("bop_%d", time.Now().UnixNano())
+	}
+	return "bop_" + hex.EncodeToString(b)
+}
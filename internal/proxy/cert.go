@@ -1,20 +1,29 @@
 package proxy
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/hex"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"log"
 	"math/big"
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 // certMintEntry deduplicates concurrent cert minting for the same host.
@@ -24,13 +33,31 @@ type certMintEntry struct {
 	err  error
 }
 
+// authority is a CA certificate and its key.
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// CA export formats.
+const (
+	CAFormatPEM    = "pem"
+	CAFormatDER    = "der"
+	CAFormatPKCS12 = "p12"
+)
+
+// leafRenewBefore is how long before expiry a stored leaf certificate is
+// replaced by a fresh one.
+const leafRenewBefore = 7 * 24 * time.Hour
+
 // CertManager handles CA certificate generation and per-host certificate minting.
+// Minted certificates are kept under the config directory, so clients that
+// pinned or cached one see the same certificate after a restart.
 type CertManager struct {
-	caCert    *x509.Certificate
-	caKey     *ecdsa.PrivateKey
-	caTLS     tls.Certificate
+	mu        sync.RWMutex // guards ca, replaced by Rotate
+	ca        *authority
 	certCache sync.Map // map[string]*tls.Certificate
-	mintOnce  sync.Map // map[string]*certResult — dedup concurrent mints
+	mintOnce  sync.Map // map[string]*certMintEntry — dedup concurrent mints
 	certDir   string
 }
 
@@ -41,12 +68,13 @@ func NewCertManager(configDir string) (*CertManager, error) {
 	caPath := filepath.Join(configDir, "ca.pem")
 	keyPath := filepath.Join(configDir, "ca-key.pem")
 
+	var err error
 	if fileExists(caPath) && fileExists(keyPath) {
-		if err := cm.loadCA(caPath, keyPath); err != nil {
+		if cm.ca, err = loadCA(caPath, keyPath); err != nil {
 			return nil, fmt.Errorf("load CA: %w", err)
 		}
 	} else {
-		if err := cm.generateCA(caPath, keyPath); err != nil {
+		if cm.ca, err = generateCA(caPath, keyPath); err != nil {
 			return nil, fmt.Errorf("generate CA: %w", err)
 		}
 	}
@@ -59,6 +87,98 @@ func (cm *CertManager) CACertPath() string {
 	return filepath.Join(cm.certDir, "ca.pem")
 }
 
+// leafDir is where minted host certificates are stored.
+func (cm *CertManager) leafDir() string {
+	return filepath.Join(cm.certDir, "certs")
+}
+
+// authority returns the current CA.
+func (cm *CertManager) authority() *authority {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.ca
+}
+
+// Info describes the current CA certificate.
+func (cm *CertManager) Info() schema.CAInfo {
+	ca := cm.authority()
+	sum := sha256.Sum256(ca.cert.Raw)
+	info := schema.CAInfo{
+		Subject:   ca.cert.Subject.String(),
+		SHA256:    hex.EncodeToString(sum[:]),
+		NotBefore: ca.cert.NotBefore,
+		NotAfter:  ca.cert.NotAfter,
+		Path:      cm.CACertPath(),
+	}
+	if entries, err := os.ReadDir(cm.leafDir()); err == nil {
+		for _, e := range entries {
+			if strings.HasSuffix(e.Name(), ".pem") {
+				info.Leaves++
+			}
+		}
+	}
+	return info
+}
+
+// Export encodes the CA certificate as PEM, DER, or a PKCS#12 trust store
+// for devices that only import certificates in that form. The CA key is
+// never exported. The bundle is encrypted with password, which must not be
+// empty.
+func (cm *CertManager) Export(format, password string) ([]byte, error) {
+	ca := cm.authority()
+	switch format {
+	case CAFormatPEM:
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), nil
+	case CAFormatDER:
+		return ca.cert.Raw, nil
+	case CAFormatPKCS12:
+		if password == "" {
+			return nil, errors.New("PKCS#12 export needs a password")
+		}
+		return pkcs12.Modern.EncodeTrustStore([]*x509.Certificate{ca.cert}, password)
+	}
+	return nil, fmt.Errorf("unknown CA format %q", format)
+}
+
+// Rotate replaces the CA with a newly generated one and discards every
+// certificate minted by the old CA. Clients must trust the new CA before
+// their HTTPS traffic can be intercepted again.
+func (cm *CertManager) Rotate() error {
+	caPath := cm.CACertPath()
+	keyPath := filepath.Join(cm.certDir, "ca-key.pem")
+	// Generate beside the live files so a failure leaves them intact.
+	ca, err := generateCA(caPath+".new", keyPath+".new")
+	if err != nil {
+		os.Remove(caPath + ".new")
+		os.Remove(keyPath + ".new")
+		return fmt.Errorf("generate CA: %w", err)
+	}
+	// The key is replaced first; if the certificate then can't be, the old
+	// key is put back so the files on disk still form a pair.
+	oldKey, err := os.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("read CA key: %w", err)
+	}
+	if err := os.Rename(keyPath+".new", keyPath); err != nil {
+		return fmt.Errorf("replace CA key: %w", err)
+	}
+	if err := os.Rename(caPath+".new", caPath); err != nil {
+		if rerr := os.WriteFile(keyPath, oldKey, 0o600); rerr != nil {
+			return fmt.Errorf("replace CA cert: %w (restoring the old key also failed: %v)", err, rerr)
+		}
+		return fmt.Errorf("replace CA cert: %w", err)
+	}
+
+	cm.mu.Lock()
+	cm.ca = ca
+	cm.mu.Unlock()
+	cm.certCache.Clear()
+	if err := os.RemoveAll(cm.leafDir()); err != nil {
+		log.Printf("remove old host certificates: %v", err)
+	}
+	return nil
+}
+
 // GetCertificate returns a TLS certificate for the given hostname, generating one if needed.
 // Uses a per-host mutex via sync.Map to prevent duplicate cert minting under concurrent requests.
 func (cm *CertManager) GetCertificate(host string) (*tls.Certificate, error) {
@@ -67,8 +187,13 @@ func (cm *CertManager) GetCertificate(host string) (*tls.Certificate, error) {
 		host = h
 	}
 
+	ca := cm.authority()
 	if cached, ok := cm.certCache.Load(host); ok {
-		return cached.(*tls.Certificate), nil
+		cert := cached.(*tls.Certificate)
+		// A mint racing Rotate may have cached a certificate from the old CA.
+		if bytes.Equal(cert.Certificate[1], ca.cert.Raw) {
+			return cert, nil
+		}
 	}
 
 	// Use LoadOrStore with a sync.Once to ensure only one goroutine mints per host.
@@ -76,7 +201,7 @@ func (cm *CertManager) GetCertificate(host string) (*tls.Certificate, error) {
 	actual, loaded := cm.mintOnce.LoadOrStore(host, entry)
 	result := actual.(*certMintEntry)
 	result.once.Do(func() {
-		result.cert, result.err = cm.mintCert(host)
+		result.cert, result.err = cm.leafCert(ca, host)
 		if result.err == nil {
 			cm.certCache.Store(host, result.cert)
 		}
@@ -93,15 +218,111 @@ func (cm *CertManager) GetCertificate(host string) (*tls.Certificate, error) {
 	return result.cert, nil
 }
 
-func (cm *CertManager) generateCA(certPath, keyPath string) error {
+// leafCert returns the stored certificate for host if ca issued it and it
+// isn't about to expire, and otherwise mints and stores a new one. Storage
+// errors only cost the certificate its persistence.
+func (cm *CertManager) leafCert(ca *authority, host string) (*tls.Certificate, error) {
+	path := filepath.Join(cm.leafDir(), leafFileName(host))
+	if cert, err := loadLeaf(path, ca, host); err == nil {
+		return cert, nil
+	}
+	cert, err := mintCert(ca, host)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveLeaf(path, cert); err != nil {
+		log.Printf("store certificate for %s: %v", host, err)
+	}
+	return cert, nil
+}
+
+// leafFileName maps a host to a file name, replacing characters that
+// aren't safe in one (such as the colons of IPv6 addresses).
+func leafFileName(host string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(host))
+	return strings.Trim(name, ".") + ".pem"
+}
+
+// loadLeaf reads a stored host certificate, rejecting one that ca didn't
+// sign, that doesn't cover host, or that expires within leafRenewBefore.
+func loadLeaf(path string, ca *authority, host string) (*tls.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var certDER, keyDER []byte
+	for rest := data; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		switch block.Type {
+		case "CERTIFICATE":
+			certDER = block.Bytes
+		case "EC PRIVATE KEY":
+			keyDER = block.Bytes
+		}
+	}
+	if certDER == nil || keyDER == nil {
+		return nil, errors.New("incomplete certificate file")
+	}
+	leaf, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, err
+	}
+	if err := leaf.CheckSignatureFrom(ca.cert); err != nil {
+		return nil, err
+	}
+	if err := leaf.VerifyHostname(host); err != nil {
+		return nil, err
+	}
+	if time.Until(leaf.NotAfter) < leafRenewBefore {
+		return nil, errors.New("certificate about to expire")
+	}
+	key, err := x509.ParseECPrivateKey(keyDER)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{
+		Certificate: [][]byte{certDER, ca.cert.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
+
+// saveLeaf writes a host certificate and its key to path.
+func saveLeaf(path string, cert *tls.Certificate) error {
+	key, ok := cert.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return errors.New("unsupported key type")
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}) //nolint:errcheck
+	pem.Encode(&buf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})         //nolint:errcheck
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
+
+func generateCA(certPath, keyPath string) (*authority, error) {
 	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
-		return fmt.Errorf("generate CA key: %w", err)
+		return nil, fmt.Errorf("generate CA key: %w", err)
 	}
 
 	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
 	if err != nil {
-		return fmt.Errorf("generate serial: %w", err)
+		return nil, fmt.Errorf("generate serial: %w", err)
 	}
 
 	template := &x509.Certificate{
@@ -120,96 +341,82 @@ func (cm *CertManager) generateCA(certPath, keyPath string) error {
 
 	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
 	if err != nil {
-		return fmt.Errorf("create CA cert: %w", err)
+		return nil, fmt.Errorf("create CA cert: %w", err)
 	}
 
 	cert, err := x509.ParseCertificate(certDER)
 	if err != nil {
-		return fmt.Errorf("parse CA cert: %w", err)
+		return nil, fmt.Errorf("parse CA cert: %w", err)
 	}
 
 	// Save cert
 	certFile, err := os.Create(certPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if err := pem.Encode(certFile, &pem.Block{Type: "CERTIFICATE", Bytes: certDER}); err != nil {
 		certFile.Close()
-		return fmt.Errorf("write CA cert: %w", err)
+		return nil, fmt.Errorf("write CA cert: %w", err)
 	}
 	if err := certFile.Close(); err != nil {
-		return fmt.Errorf("close CA cert file: %w", err)
+		return nil, fmt.Errorf("close CA cert file: %w", err)
 	}
 
 	// Save key
 	keyBytes, err := x509.MarshalECPrivateKey(key)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	keyFile, err := os.OpenFile(keyPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if err := pem.Encode(keyFile, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}); err != nil {
 		keyFile.Close()
-		return fmt.Errorf("write CA key: %w", err)
+		return nil, fmt.Errorf("write CA key: %w", err)
 	}
 	if err := keyFile.Close(); err != nil {
-		return fmt.Errorf("close CA key file: %w", err)
-	}
-
-	cm.caCert = cert
-	cm.caKey = key
-	cm.caTLS = tls.Certificate{
-		Certificate: [][]byte{certDER},
-		PrivateKey:  key,
+		return nil, fmt.Errorf("close CA key file: %w", err)
 	}
 
-	return nil
+	return &authority{cert: cert, key: key}, nil
 }
 
-func (cm *CertManager) loadCA(certPath, keyPath string) error {
+func loadCA(certPath, keyPath string) (*authority, error) {
 	certPEM, err := os.ReadFile(certPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	keyPEM, err := os.ReadFile(keyPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Parse certificate
 	block, _ := pem.Decode(certPEM)
 	if block == nil {
-		return fmt.Errorf("no PEM block in CA cert")
+		return nil, fmt.Errorf("no PEM block in CA cert")
 	}
 	cert, err := x509.ParseCertificate(block.Bytes)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Parse key
 	keyBlock, _ := pem.Decode(keyPEM)
 	if keyBlock == nil {
-		return fmt.Errorf("no PEM block in CA key")
+		return nil, fmt.Errorf("no PEM block in CA key")
 	}
 	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
 	if err != nil {
-		return err
-	}
-
-	cm.caCert = cert
-	cm.caKey = key
-	cm.caTLS = tls.Certificate{
-		Certificate: [][]byte{block.Bytes},
-		PrivateKey:  key,
+		return nil, err
 	}
 
-	return nil
+	return &authority{cert: cert, key: key}, nil
 }
 
-// mintCert generates a TLS certificate for a specific host, signed by our CA.
-func (cm *CertManager) mintCert(host string) (*tls.Certificate, error) {
+// mintCert generates a TLS certificate for a specific host, signed by ca.
+func mintCert(ca *authority, host string) (*tls.Certificate, error) {
 	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
 		return nil, err
@@ -240,13 +447,13 @@ func (cm *CertManager) mintCert(host string) (*tls.Certificate, error) {
 		template.DNSNames = []string{host}
 	}
 
-	certDER, err := x509.CreateCertificate(rand.Reader, template, cm.caCert, &key.PublicKey, cm.caKey)
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
 	if err != nil {
 		return nil, err
 	}
 
 	tlsCert := &tls.Certificate{
-		Certificate: [][]byte{certDER, cm.caTLS.Certificate[0]},
+		Certificate: [][]byte{certDER, ca.cert.Raw},
 		PrivateKey:  key,
 	}
 
@@ -0,0 +1,357 @@
+package proxy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// Intercept decisions.
+const (
+	InterceptForward = "forward"
+	InterceptDrop    = "drop"
+)
+
+const (
+	defaultInterceptTimeout = 60
+	maxInterceptTimeout     = 3600
+)
+
+// interceptQueue holds GraphQL requests and responses until the user
+// forwards, edits, or drops them, or their timeout runs out.
+type interceptQueue struct {
+	mu       sync.Mutex
+	settings schema.InterceptSettings
+	pending  map[string]*heldMessage
+}
+
+type heldMessage struct {
+	msg      schema.InterceptedMessage
+	decision chan schema.InterceptDecision // buffered; written once under mu
+}
+
+// InterceptSettings returns the current intercept settings.
+func (p *Proxy) InterceptSettings() schema.InterceptSettings {
+	q := &p.intercept
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.settings
+}
+
+// SetInterceptSettings validates and applies intercept settings. Messages
+// held for a phase that is switched off are forwarded unchanged.
+func (p *Proxy) SetInterceptSettings(s schema.InterceptSettings) error {
+	s, err := normalizeInterceptSettings(s)
+	if err != nil {
+		return err
+	}
+	q := &p.intercept
+	q.mu.Lock()
+	q.settings = s
+	for id, h := range q.pending {
+		if (h.msg.Phase == "request" && !s.Requests) || (h.msg.Phase == "response" && !s.Responses) {
+			delete(q.pending, id)
+			h.decision <- schema.InterceptDecision{Action: InterceptForward}
+		}
+	}
+	q.mu.Unlock()
+	return nil
+}
+
+func normalizeInterceptSettings(s schema.InterceptSettings) (schema.InterceptSettings, error) {
+	clean := func(globs []string, lower bool) ([]string, error) {
+		out := []string{}
+		for _, g := range globs {
+			g = strings.TrimSpace(g)
+			if lower {
+				g = strings.ToLower(g)
+			}
+			if g == "" {
+				continue
+			}
+			if _, err := path.Match(g, ""); err != nil {
+				return nil, fmt.Errorf("invalid glob %q: %w", g, err)
+			}
+			out = append(out, g)
+		}
+		return out, nil
+	}
+	var err error
+	if s.Hosts, err = clean(s.Hosts, true); err != nil {
+		return s, err
+	}
+	if s.Operations, err = clean(s.Operations, false); err != nil {
+		return s, err
+	}
+	if s.TimeoutSeconds == 0 {
+		s.TimeoutSeconds = defaultInterceptTimeout
+	}
+	if s.TimeoutSeconds < 5 || s.TimeoutSeconds > maxInterceptTimeout {
+		return s, fmt.Errorf("timeout must be between 5 and %d seconds", maxInterceptTimeout)
+	}
+	switch s.OnTimeout {
+	case "":
+		s.OnTimeout = InterceptForward
+	case InterceptForward, InterceptDrop:
+	default:
+		return s, fmt.Errorf("unknown timeout action %q: must be forward or drop", s.OnTimeout)
+	}
+	return s, nil
+}
+
+// Intercepted returns the messages waiting for a decision, oldest first.
+func (p *Proxy) Intercepted() []schema.InterceptedMessage {
+	q := &p.intercept
+	q.mu.Lock()
+	msgs := make([]schema.InterceptedMessage, 0, len(q.pending))
+	for _, h := range q.pending {
+		msgs = append(msgs, h.msg)
+	}
+	q.mu.Unlock()
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].Timestamp.Before(msgs[j].Timestamp) })
+	return msgs
+}
+
+// ResolveIntercept hands a decision to a held message.
+func (p *Proxy) ResolveIntercept(id string, d schema.InterceptDecision) error {
+	if d.Action != InterceptForward && d.Action != InterceptDrop {
+		return fmt.Errorf("unknown action %q: must be forward or drop", d.Action)
+	}
+	q := &p.intercept
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	h, ok := q.pending[id]
+	if !ok {
+		return fmt.Errorf("message %s is no longer held", id)
+	}
+	delete(q.pending, id)
+	h.decision <- d
+	return nil
+}
+
+// releaseIntercepted forwards every held message unchanged.
+func (p *Proxy) releaseIntercepted() {
+	q := &p.intercept
+	q.mu.Lock()
+	for id, h := range q.pending {
+		delete(q.pending, id)
+		h.decision <- schema.InterceptDecision{Action: InterceptForward}
+	}
+	q.mu.Unlock()
+}
+
+// hold queues msg, announces it to SSE subscribers, and waits for a
+// decision. When the timeout runs out the configured timeout action is
+// taken; when the client goes away the message is dropped.
+func (p *Proxy) hold(ctx context.Context, msg schema.InterceptedMessage) schema.InterceptDecision {
+	q := &p.intercept
+	q.mu.Lock()
+	timeout := time.Duration(q.settings.TimeoutSeconds) * time.Second
+	onTimeout := q.settings.OnTimeout
+	msg.ID = generateInterceptID()
+	msg.Timestamp = time.Now().UTC()
+	msg.Deadline = msg.Timestamp.Add(timeout)
+	h := &heldMessage{msg: msg, decision: make(chan schema.InterceptDecision, 1)}
+	q.pending[msg.ID] = h
+	q.mu.Unlock()
+
+	p.publish("intercept", msg)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var d schema.InterceptDecision
+	select {
+	case d = <-h.decision:
+	case <-timer.C:
+		d = p.expire(h, onTimeout)
+	case <-ctx.Done():
+		d = p.expire(h, InterceptDrop)
+	}
+	p.publish("intercept-done", map[string]string{"id": msg.ID, "action": d.Action})
+	return d
+}
+
+// expire takes a held message off the queue with the given action, unless a
+// decision arrived in the meantime.
+func (p *Proxy) expire(h *heldMessage, action string) schema.InterceptDecision {
+	q := &p.intercept
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.pending[h.msg.ID]; !ok {
+		return <-h.decision
+	}
+	delete(q.pending, h.msg.ID)
+	return schema.InterceptDecision{Action: action}
+}
+
+// interceptMatch reports whether a GraphQL request to host carrying the
+// named operation is held for the phases switched on in s.
+func interceptMatch(s schema.InterceptSettings, host, operation string) bool {
+	if !s.Requests && !s.Responses {
+		return false
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return globsMatch(s.Hosts, strings.ToLower(host)) && globsMatch(s.Operations, operation)
+}
+
+// globsMatch reports whether value matches one of globs; an empty list
+// matches everything.
+func globsMatch(globs []string, value string) bool {
+	if len(globs) == 0 {
+		return true
+	}
+	for _, g := range globs {
+		if ok, _ := path.Match(g, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// interceptRequest holds a matching GraphQL request for a decision and
+// applies any edit. It reports false when the request was dropped. Held
+// requests are marked so their response can be held too.
+func (p *Proxy) interceptRequest(req *http.Request) (*http.Request, bool) {
+	s := p.InterceptSettings()
+	if (!s.Requests && !s.Responses) || !IsGraphQLRequest(req) {
+		return req, true
+	}
+	var opName string
+	if payloads, _, _ := ExtractGraphQLPayloads(req); len(payloads) > 0 && payloads[0] != nil {
+		opName = payloads[0].OperationName
+		if opName == "" && payloads[0].Query != "" {
+			opName = ExtractOperationName(payloads[0].Query)
+		}
+	}
+	if !interceptMatch(s, req.Host, opName) {
+		return req, true
+	}
+	req, rec := withExchangeRecord(req)
+	rec.operation = opName
+	rec.holdResponse = s.Responses
+	if !s.Requests {
+		return req, true
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+	setRequestBody(req, body)
+	msg := schema.InterceptedMessage{
+		Phase:         "request",
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		Host:          req.Host,
+		OperationName: opName,
+		Headers:       flattenHeaders(req.Header),
+		Body:          string(body),
+	}
+	d := p.hold(req.Context(), msg)
+	if d.Action == InterceptDrop {
+		return req, false
+	}
+	if !d.Edit || (d.Method == msg.Method && d.URL == msg.URL && d.Body == msg.Body && reflect.DeepEqual(d.Headers, msg.Headers)) {
+		return req, true
+	}
+	if err := applyRequestEdit(req, d); err != nil {
+		log.Printf("intercept edit of %s ignored: %v", msg.URL, err)
+		return req, true
+	}
+	rec.rules = append(rec.rules, "intercept request")
+	if rec.original.Request == nil {
+		rec.original.Request = &schema.OriginalMessage{URL: msg.URL, Headers: msg.Headers, Body: msg.Body}
+	}
+	return req, true
+}
+
+// applyRequestEdit replaces the method, URL, headers, and body of req.
+func applyRequestEdit(req *http.Request, d schema.InterceptDecision) error {
+	u, err := url.Parse(strings.TrimSpace(d.URL))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid URL %q", d.URL)
+	}
+	if method := strings.ToUpper(strings.TrimSpace(d.Method)); method != "" {
+		req.Method = method
+	}
+	req.URL = u
+	req.Host = u.Host
+	req.Header = make(http.Header, len(d.Headers))
+	for k, v := range d.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	setRequestBody(req, []byte(d.Body))
+	return nil
+}
+
+// interceptResponse holds the buffered response to a held request for a
+// decision and returns the body to send. It reports false when the response
+// was dropped.
+func (p *Proxy) interceptResponse(req *http.Request, resp *http.Response, body []byte) ([]byte, bool) {
+	rec, _ := req.Context().Value(rewriteKey{}).(*exchangeRewrite)
+	if rec == nil || !rec.holdResponse || !p.InterceptSettings().Responses {
+		return body, true
+	}
+	msg := schema.InterceptedMessage{
+		Phase:         "response",
+		URL:           req.URL.String(),
+		Host:          req.Host,
+		OperationName: rec.operation,
+		StatusCode:    resp.StatusCode,
+		Headers:       flattenHeaders(resp.Header),
+		Body:          string(body),
+	}
+	d := p.hold(req.Context(), msg)
+	if d.Action == InterceptDrop {
+		return body, false
+	}
+	if !d.Edit || (d.StatusCode == msg.StatusCode && d.Body == msg.Body && reflect.DeepEqual(d.Headers, msg.Headers)) {
+		return body, true
+	}
+	if d.StatusCode != 0 {
+		if d.StatusCode < 100 || d.StatusCode > 999 {
+			log.Printf("intercept edit of %s response ignored: invalid status %d", msg.URL, d.StatusCode)
+			return body, true
+		}
+		resp.StatusCode = d.StatusCode
+		resp.Status = strings.TrimSpace(fmt.Sprintf("%d %s", d.StatusCode, http.StatusText(d.StatusCode)))
+	}
+	resp.Header = make(http.Header, len(d.Headers))
+	for k, v := range d.Headers {
+		resp.Header.Set(k, v)
+	}
+	rec.rules = append(rec.rules, "intercept response")
+	if rec.original.Response == nil {
+		rec.original.Response = &schema.OriginalMessage{Headers: msg.Headers, Body: msg.Body}
+	}
+	return []byte(d.Body), true
+}
+
+func generateInterceptID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("int_%d", time.Now().UnixNano())
+	}
+	return "int_" + hex.EncodeToString(b)
+}
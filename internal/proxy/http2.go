@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// serveHTTP2 serves a client connection that negotiated h2. The standard
+// library's HTTP/2 server demultiplexes the streams; each one goes through
+// the same forwarding and capture pipeline as HTTP/1.1 requests.
+func (p *Proxy) serveHTTP2(tlsConn *tls.Conn, host string, b *binding) {
+	ln := newSingleConnListener(tlsConn)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.URL.Scheme = "https"
+			r.URL.Host = host // keep original host:port for non-standard ports
+			r.RequestURI = ""
+			p.forwardAndCapture(&writerSink{w: w}, withBinding(r, b))
+		}),
+		// Stream resets and client disconnects are routine in a proxy.
+		ErrorLog: log.New(io.Discard, "", 0),
+		ConnState: func(_ net.Conn, state http.ConnState) {
+			if state == http.StateClosed || state == http.StateHijacked {
+				ln.Close()
+			}
+		},
+	}
+	srv.Serve(ln) //nolint:errcheck
+}
+
+// singleConnListener hands out one already-accepted connection, then blocks
+// until closed. It lets http.Server serve a connection the proxy accepted
+// and TLS-terminated itself.
+type singleConnListener struct {
+	conn   net.Conn
+	once   sync.Once
+	closed chan struct{}
+	accept chan net.Conn
+}
+
+func newSingleConnListener(conn net.Conn) *singleConnListener {
+	l := &singleConnListener{
+		conn:   conn,
+		closed: make(chan struct{}),
+		accept: make(chan net.Conn, 1),
+	}
+	l.accept <- conn
+	return l
+}
+
+func (l *singleConnListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.accept:
+		return c, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *singleConnListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *singleConnListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
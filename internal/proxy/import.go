@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/har"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// ImportEntry captures the GraphQL operations of a recorded HAR entry into
+// projectID, detected and extracted as if the exchange had been proxied.
+// Scope rules don't apply and subscribers aren't notified. It returns the
+// number of operations stored; entries that aren't GraphQL store none.
+func (p *Proxy) ImportEntry(projectID string, e *har.Entry) (int, error) {
+	req, err := e.HTTPRequest()
+	if err != nil {
+		return 0, err
+	}
+	resp := e.HTTPResponse()
+	respBody := e.Response.Content.Body()
+	if !IsGraphQLRequest(req) && !DetectGraphQLResponse(respBody) {
+		return 0, nil
+	}
+	payloads, batch, err := ExtractGraphQLPayloads(req)
+	if err != nil {
+		return 0, err
+	}
+
+	var captures []*schema.CapturedRequest
+	if batch {
+		captures = p.newBatchCaptured(projectID, req, payloads, resp, respBody)
+	} else if len(payloads) > 0 && payloads[0] != nil && payloads[0].isOperation() {
+		captures = []*schema.CapturedRequest{p.buildCaptured(projectID, req, payloads[0], resp, respBody)}
+	}
+
+	duration := time.Duration(e.Time * float64(time.Millisecond))
+	stored := 0
+	for _, captured := range captures {
+		captured.Timestamp = e.StartedDateTime.Add(duration).UTC()
+		captured.DurationMs = duration.Milliseconds()
+		p.learnPersistedQuery(captured)
+		if err := p.trafficRepo.Save(captured); err != nil {
+			return stored, err
+		}
+		stored++
+	}
+	return stored, nil
+}
@@ -0,0 +1,297 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// maxIncrementalPart bounds a single part of an incremental response that is
+// kept for capture. Larger parts are still relayed, just not recorded.
+const maxIncrementalPart = 16 << 20
+
+// maxListGap bounds how far past the end of a list a patch path may point.
+// Servers only patch existing items or append, so anything further out is a
+// malformed response and is ignored rather than padded with nulls.
+const maxListGap = 64
+
+// isIncrementalResponse reports whether resp is an @defer/@stream
+// incremental delivery response.
+func isIncrementalResponse(resp *http.Response) bool {
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/mixed" && params["boundary"] != ""
+}
+
+// errWriter remembers the first write error so relaying can stop once the
+// client has gone away.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.w.Write(p)
+	e.err = err
+	return n, err
+}
+
+// relayIncremental streams a multipart/mixed incremental delivery response to
+// the client as it arrives. Each part is recorded as a stream message and the
+// patches are merged into the final result, which becomes the response body.
+func (p *Proxy) relayIncremental(sink responseSink, req *http.Request, isGQL bool, payload *graphqlPayload, resp *http.Response) {
+	defer sink.End()
+
+	if err := sink.WriteHead(resp, -1); err != nil {
+		return
+	}
+
+	var captured *schema.CapturedRequest
+	if isGQL && payload != nil && payload.isOperation() {
+		captured = p.newCaptured(req, payload, resp, nil)
+		captured.Transport = "multipart"
+		if !p.saveCaptured(captured) {
+			captured = nil
+		}
+	}
+
+	// Everything read from upstream goes straight to the client; the
+	// multipart reader only observes the stream.
+	client := &errWriter{w: sink}
+	body := io.TeeReader(resp.Body, client)
+	_, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	mr := multipart.NewReader(body, params["boundary"])
+
+	var parts []json.RawMessage
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		data, err := io.ReadAll(io.LimitReader(part, maxIncrementalPart+1))
+		if err != nil {
+			break
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) > maxIncrementalPart || !json.Valid(data) {
+			continue
+		}
+		parts = append(parts, data)
+		if captured != nil {
+			msgType := "subsequent"
+			if len(parts) == 1 {
+				msgType = "initial"
+			}
+			p.trafficRepo.AppendMessage(captured.ID, schema.StreamMessage{ //nolint:errcheck
+				Timestamp: time.Now().UTC(),
+				Direction: "server",
+				Type:      msgType,
+				Payload:   data,
+			})
+		}
+	}
+	// Relay whatever is left: the epilogue, or the rest of a stream the
+	// reader gave up on.
+	if client.err == nil {
+		io.Copy(io.Discard, body) //nolint:errcheck
+	}
+	if len(parts) == 0 {
+		return
+	}
+
+	merged, err := mergeIncremental(parts)
+	if err != nil {
+		log.Printf("merge incremental response: %v", err)
+		return
+	}
+	switch {
+	case captured != nil:
+		p.trafficRepo.SetResponseBody(captured.ID, merged) //nolint:errcheck
+	case !isGQL && resp.StatusCode == 200 && DetectGraphQLResponse(merged):
+		// Response-based fallback, as for buffered responses.
+		if payload = tryExtractPayloadRetroactive(req); payload != nil {
+			p.captureTraffic(req, payload, resp, merged)
+		}
+	}
+}
+
+// mergeIncremental assembles the parts of an incremental delivery response
+// into the result a non-incremental server would have returned. It accepts
+// both the path-based format (deferSpec=20220824, and the older top-level
+// path/data patches) and the id-based pending/incremental/completed format.
+func mergeIncremental(parts []json.RawMessage) (json.RawMessage, error) {
+	var (
+		data       any
+		errs       []any
+		extensions any
+		pending    = map[string][]any{}
+	)
+
+	for i, raw := range parts {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var m map[string]any
+		if err := dec.Decode(&m); err != nil {
+			return nil, err
+		}
+
+		if list, ok := m["errors"].([]any); ok {
+			errs = append(errs, list...)
+		}
+		if ext, ok := m["extensions"]; ok {
+			extensions = deepMerge(extensions, ext)
+		}
+		for _, entry := range asObjects(m["pending"]) {
+			if id, ok := entry["id"].(string); ok {
+				pending[id], _ = entry["path"].([]any)
+			}
+		}
+		for _, entry := range asObjects(m["completed"]) {
+			if list, ok := entry["errors"].([]any); ok {
+				errs = append(errs, list...)
+			}
+		}
+
+		if i == 0 {
+			data = m["data"]
+			continue
+		}
+
+		incremental := asObjects(m["incremental"])
+		for _, entry := range incremental {
+			if list, ok := entry["errors"].([]any); ok {
+				errs = append(errs, list...)
+			}
+			data = applyIncremental(data, entry, pending)
+		}
+		if _, ok := m["path"]; ok && len(incremental) == 0 {
+			// Pre-2022 format: the part itself is the patch. Its errors
+			// were already collected above.
+			data = applyIncremental(data, m, pending)
+		}
+	}
+
+	result := map[string]any{"data": data}
+	if len(errs) > 0 {
+		result["errors"] = errs
+	}
+	if extensions != nil {
+		result["extensions"] = extensions
+	}
+	return json.Marshal(result)
+}
+
+// applyIncremental merges one deferred fragment or streamed item list into
+// data and returns the updated tree.
+func applyIncremental(data any, entry map[string]any, pending map[string][]any) any {
+	path, _ := entry["path"].([]any)
+	id, byID := entry["id"].(string)
+	if byID {
+		path = append(append([]any(nil), pending[id]...), asSlice(entry["subPath"])...)
+	}
+
+	if patch, ok := entry["data"]; ok && patch != nil {
+		return updateAt(data, path, func(node any) any { return deepMerge(node, patch) })
+	}
+
+	items, ok := entry["items"].([]any)
+	if !ok {
+		return data
+	}
+	if byID || len(path) == 0 {
+		// id-based streams append to the list at the pending path.
+		return updateAt(data, path, func(node any) any {
+			list, _ := node.([]any)
+			return append(list, items...)
+		})
+	}
+	// Path-based streams end in the index of the first item.
+	start, isIndex := path[len(path)-1].(json.Number)
+	if !isIndex {
+		return data
+	}
+	first, err := start.Int64()
+	if err != nil || first < 0 {
+		return data
+	}
+	return updateAt(data, path[:len(path)-1], func(node any) any {
+		list, _ := node.([]any)
+		if first > int64(len(list))+maxListGap {
+			return node
+		}
+		for int64(len(list)) < first+int64(len(items)) {
+			list = append(list, nil)
+		}
+		copy(list[first:], items)
+		return list
+	})
+}
+
+// updateAt replaces the node at path with fn(node), creating objects and
+// list slots along the way, and returns the updated tree. A list index more
+// than maxListGap past the end leaves the tree unchanged.
+func updateAt(node any, path []any, fn func(any) any) any {
+	if len(path) == 0 {
+		return fn(node)
+	}
+	switch key := path[0].(type) {
+	case string:
+		obj, ok := node.(map[string]any)
+		if !ok {
+			obj = map[string]any{}
+		}
+		obj[key] = updateAt(obj[key], path[1:], fn)
+		return obj
+	case json.Number:
+		i, err := key.Int64()
+		list, _ := node.([]any)
+		if err != nil || i < 0 || i > int64(len(list))+maxListGap {
+			return node
+		}
+		for int64(len(list)) <= i {
+			list = append(list, nil)
+		}
+		list[i] = updateAt(list[i], path[1:], fn)
+		return list
+	}
+	return node
+}
+
+// deepMerge merges src into dst. Objects are merged key by key; anything
+// else in src replaces dst.
+func deepMerge(dst, src any) any {
+	d, ok1 := dst.(map[string]any)
+	s, ok2 := src.(map[string]any)
+	if !ok1 || !ok2 {
+		return src
+	}
+	for k, v := range s {
+		d[k] = deepMerge(d[k], v)
+	}
+	return d
+}
+
+func asObjects(v any) []map[string]any {
+	list, _ := v.([]any)
+	out := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		if obj, ok := item.(map[string]any); ok {
+			out = append(out, obj)
+		}
+	}
+	return out
+}
+
+func asSlice(v any) []any {
+	list, _ := v.([]any)
+	return list
+}
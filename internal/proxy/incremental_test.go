@@ -0,0 +1,135 @@
+package proxy
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergeIncremental(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts []string
+		want  string
+	}{
+		{
+			name:  "single part",
+			parts: []string{`{"data":{"a":1},"hasNext":false}`},
+			want:  `{"data":{"a":1}}`,
+		},
+		{
+			name: "path-based defer",
+			parts: []string{
+				`{"data":{"user":{"id":"1"}},"hasNext":true}`,
+				`{"incremental":[{"path":["user"],"data":{"name":"Ada","friends":[{"id":"2"}]}}],"hasNext":true}`,
+				`{"incremental":[{"path":["user","friends",0],"data":{"name":"Bob"}}],"hasNext":false}`,
+			},
+			want: `{"data":{"user":{"id":"1","name":"Ada","friends":[{"id":"2","name":"Bob"}]}}}`,
+		},
+		{
+			name: "path-based stream",
+			parts: []string{
+				`{"data":{"list":[1]},"hasNext":true}`,
+				`{"incremental":[{"path":["list",1],"items":[2,3]}],"hasNext":true}`,
+				`{"incremental":[{"path":["list",3],"items":[4]}],"hasNext":false}`,
+			},
+			want: `{"data":{"list":[1,2,3,4]}}`,
+		},
+		{
+			name: "pre-2022 top-level patches",
+			parts: []string{
+				`{"data":{"a":{"b":1}},"hasNext":true}`,
+				`{"path":["a"],"data":{"c":2},"errors":[{"message":"partial"}],"hasNext":false}`,
+			},
+			want: `{"data":{"a":{"b":1,"c":2}},"errors":[{"message":"partial"}]}`,
+		},
+		{
+			name: "id-based defer with subPath",
+			parts: []string{
+				`{"data":{"user":{"id":"1"}},"pending":[{"id":"0","path":["user"]}],"hasNext":true}`,
+				`{"incremental":[{"id":"0","data":{"name":"Ada"}},{"id":"0","subPath":["profile"],"data":{"bio":"hi"}}],"completed":[{"id":"0"}],"hasNext":false}`,
+			},
+			want: `{"data":{"user":{"id":"1","name":"Ada","profile":{"bio":"hi"}}}}`,
+		},
+		{
+			name: "id-based stream with errors",
+			parts: []string{
+				`{"data":{"list":[]},"pending":[{"id":"s","path":["list"]}],"hasNext":true}`,
+				`{"incremental":[{"id":"s","items":["a","b"]}],"hasNext":true}`,
+				`{"incremental":[{"id":"s","items":["c"],"errors":[{"message":"item"}]}],"completed":[{"id":"s","errors":[{"message":"done"}]}],"hasNext":false}`,
+			},
+			want: `{"data":{"list":["a","b","c"]},"errors":[{"message":"done"},{"message":"item"}]}`,
+		},
+		{
+			name: "pending announced in a later part",
+			parts: []string{
+				`{"data":{"a":{}},"pending":[{"id":"0","path":["a"]}],"hasNext":true}`,
+				`{"incremental":[{"id":"0","data":{"b":{"c":1}}}],"pending":[{"id":"1","path":["a","b"]}],"completed":[{"id":"0"}],"hasNext":true}`,
+				`{"incremental":[{"id":"1","data":{"d":2}}],"completed":[{"id":"1"}],"hasNext":false}`,
+			},
+			want: `{"data":{"a":{"b":{"c":1,"d":2}}}}`,
+		},
+		{
+			name: "extensions are merged",
+			parts: []string{
+				`{"data":{},"extensions":{"a":1,"o":{"x":1}},"hasNext":true}`,
+				`{"incremental":[],"extensions":{"b":2,"o":{"y":2}},"hasNext":false}`,
+			},
+			want: `{"data":{},"extensions":{"a":1,"b":2,"o":{"x":1,"y":2}}}`,
+		},
+		{
+			name: "out-of-range stream index is ignored",
+			parts: []string{
+				`{"data":{"list":[1]},"hasNext":true}`,
+				`{"incremental":[{"path":["list",99999999999],"items":[2]}],"hasNext":false}`,
+			},
+			want: `{"data":{"list":[1]}}`,
+		},
+		{
+			name: "stream index far past the end is ignored",
+			parts: []string{
+				`{"data":{"list":[1]},"hasNext":true}`,
+				`{"incremental":[{"path":["list",1000000],"items":[2]}],"hasNext":false}`,
+			},
+			want: `{"data":{"list":[1]}}`,
+		},
+		{
+			name: "defer path index far past the end is ignored",
+			parts: []string{
+				`{"data":{"list":[{"id":"1"}]},"hasNext":true}`,
+				`{"incremental":[{"path":["list",1000000],"data":{"name":"x"}}],"hasNext":false}`,
+			},
+			want: `{"data":{"list":[{"id":"1"}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := make([]json.RawMessage, len(tt.parts))
+			for i, p := range tt.parts {
+				parts[i] = json.RawMessage(p)
+			}
+			got, err := mergeIncremental(parts)
+			if err != nil {
+				t.Fatalf("mergeIncremental: %v", err)
+			}
+			var gotV, wantV any
+			if err := json.Unmarshal(got, &gotV); err != nil {
+				t.Fatalf("result is not JSON: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantV); err != nil {
+				t.Fatalf("bad want: %v", err)
+			}
+			if !reflect.DeepEqual(gotV, wantV) {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeIncrementalInvalidPart(t *testing.T) {
+	_, err := mergeIncremental([]json.RawMessage{json.RawMessage(`{"data":{}}`), json.RawMessage(`not json`)})
+	if err == nil {
+		t.Error("mergeIncremental accepted a part that isn't JSON")
+	}
+}
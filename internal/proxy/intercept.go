@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/parser"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
 )
 
 // graphqlPayload represents a decoded GraphQL request body.
@@ -17,9 +21,43 @@ type graphqlPayload struct {
 	DocID         string          `json:"doc_id,omitempty"`         // persisted query ID (e.g. Instagram/Relay)
 	QueryHash     string          `json:"query_hash,omitempty"`     // legacy persisted query hash
 	FriendlyName  string          `json:"fb_api_req_friendly_name"` // Meta-style operation name
+	Extensions    json.RawMessage `json:"extensions,omitempty"`     // carries the Apollo APQ hash
+	// uploads are the files of a multipart upload request that fill this
+	// operation's variables.
+	uploads []schema.UploadedFile
+}
+
+// persistedHash returns the Apollo APQ hash from
+// extensions.persistedQuery.sha256Hash, or "" if there is none.
+func (p *graphqlPayload) persistedHash() string {
+	return persistedHashOf(p.Extensions)
+}
+
+func persistedHashOf(extensions []byte) string {
+	if len(extensions) == 0 {
+		return ""
+	}
+	var ext struct {
+		PersistedQuery struct {
+			SHA256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	}
+	if json.Unmarshal(extensions, &ext) != nil {
+		return ""
+	}
+	return ext.PersistedQuery.SHA256Hash
+}
+
+// isOperation reports whether the payload carries an operation: query text,
+// or the ID or hash of a persisted query.
+func (p *graphqlPayload) isOperation() bool {
+	return p.Query != "" || p.DocID != "" || p.QueryHash != "" || p.persistedHash() != ""
 }
 
 // IsGraphQLRequest determines if an HTTP request is a GraphQL operation.
+// Multipart bodies elsewhere than a GraphQL path count only when their first
+// part is the "operations" part of a GraphQL file upload; the start of the
+// body is read to tell, and put back.
 func IsGraphQLRequest(r *http.Request) bool {
 	// Check URL path
 	path := strings.ToLower(r.URL.Path)
@@ -27,24 +65,34 @@ func IsGraphQLRequest(r *http.Request) bool {
 		return true
 	}
 
-	// Check for GET with query parameter
-	if r.Method == "GET" && r.URL.Query().Get("query") != "" {
-		return true
+	// Check for GET with query parameter, or an APQ hash in place of one
+	if r.Method == "GET" {
+		q := r.URL.Query()
+		if q.Get("query") != "" || persistedHashOf([]byte(q.Get("extensions"))) != "" {
+			return true
+		}
 	}
 
-	// Check Content-Type for JSON or form-encoded POST requests
+	// Check Content-Type for JSON or form-encoded POST requests, and
+	// multipart ones that are file uploads
 	ct := strings.ToLower(r.Header.Get("Content-Type"))
 	if r.Method == "POST" && (strings.Contains(ct, "application/json") || strings.Contains(ct, "application/x-www-form-urlencoded")) {
 		return true
 	}
+	if r.Method == "POST" && strings.Contains(ct, "multipart/form-data") {
+		return isUploadRequest(r)
+	}
 
 	return false
 }
 
-// ExtractGraphQLPayload reads the GraphQL query, operation name, and variables from a request.
+// ExtractGraphQLPayloads reads the GraphQL query, operation name, and variables from a request.
 // It replaces the request body so it can still be forwarded.
-// Handles JSON, form-encoded, and batch payloads.
-func ExtractGraphQLPayload(r *http.Request) (*graphqlPayload, error) {
+// Handles JSON, form-encoded, multipart file upload, and batch payloads. batch
+// reports whether the operations were a JSON array, in which case payloads holds
+// one entry per element (nil for elements that aren't operations) so they line
+// up with the response array.
+func ExtractGraphQLPayloads(r *http.Request) (payloads []*graphqlPayload, batch bool, err error) {
 	if r.Method == "GET" {
 		q := r.URL.Query()
 		p := &graphqlPayload{
@@ -54,43 +102,76 @@ func ExtractGraphQLPayload(r *http.Request) (*graphqlPayload, error) {
 		if vars := q.Get("variables"); vars != "" {
 			p.Variables = json.RawMessage(vars)
 		}
-		return p, nil
+		if ext := q.Get("extensions"); ext != "" {
+			p.Extensions = json.RawMessage(ext)
+		}
+		return []*graphqlPayload{p}, false, nil
+	}
+
+	// File uploads (graphql-multipart-request-spec). The boundary is
+	// case-sensitive, so the header is parsed as sent.
+	if mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && mediaType == "multipart/form-data" {
+		body, truncated, err := peekBody(r, maxUploadScan)
+		if err != nil {
+			return nil, false, err
+		}
+		return parseMultipartPayloads(body, params["boundary"], truncated)
 	}
 
 	// POST — read and restore the body
 	body, err := io.ReadAll(r.Body)
 	r.Body.Close()
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
 
 	ct := strings.ToLower(r.Header.Get("Content-Type"))
 
 	// Try form-encoded body (used by Instagram/Meta GraphQL endpoints)
 	if strings.Contains(ct, "application/x-www-form-urlencoded") {
-		return parseFormPayload(body)
+		p, err := parseFormPayload(body)
+		if p == nil {
+			return nil, false, err
+		}
+		return []*graphqlPayload{p}, false, err
 	}
 
+	payloads, batch = decodeJSONPayloads(body)
+	return payloads, batch, nil
+}
+
+// decodeJSONPayloads decodes a JSON body holding one operation or a batch
+// array of them.
+func decodeJSONPayloads(body []byte) (payloads []*graphqlPayload, batch bool) {
 	// Try to parse as single JSON query
 	var p graphqlPayload
-	if err := json.Unmarshal(body, &p); err == nil && (p.Query != "" || p.DocID != "") {
+	if err := json.Unmarshal(body, &p); err == nil && p.isOperation() {
 		if p.OperationName == "" && p.FriendlyName != "" {
 			p.OperationName = p.FriendlyName
 		}
-		return &p, nil
+		return []*graphqlPayload{&p}, false
 	}
 
-	// Try batch query (array of queries) — take the first one
-	var batch []graphqlPayload
-	if err := json.Unmarshal(body, &batch); err == nil && len(batch) > 0 {
-		if batch[0].OperationName == "" && batch[0].FriendlyName != "" {
-			batch[0].OperationName = batch[0].FriendlyName
+	// Try batch query (array of queries, as sent by Apollo and Relay batching links)
+	var batchItems []json.RawMessage
+	if err := json.Unmarshal(body, &batchItems); err == nil && len(batchItems) > 0 {
+		payloads = make([]*graphqlPayload, len(batchItems))
+		for i, item := range batchItems {
+			var bp graphqlPayload
+			if json.Unmarshal(item, &bp) != nil || !bp.isOperation() {
+				continue
+			}
+			if bp.OperationName == "" && bp.FriendlyName != "" {
+				bp.OperationName = bp.FriendlyName
+			}
+			payloads[i] = &bp
 		}
-		return &batch[0], nil
+		return payloads, true
 	}
 
-	return nil, nil
+	return nil, false
 }
 
 // parseFormPayload handles application/x-www-form-urlencoded GraphQL bodies.
@@ -112,6 +193,9 @@ func parseFormPayload(body []byte) (*graphqlPayload, error) {
 	if vars := values.Get("variables"); vars != "" {
 		p.Variables = json.RawMessage(vars)
 	}
+	if ext := values.Get("extensions"); ext != "" {
+		p.Extensions = json.RawMessage(ext)
+	}
 
 	// Use friendly name as operation name if none provided
 	if p.OperationName == "" && p.FriendlyName != "" {
@@ -119,7 +203,7 @@ func parseFormPayload(body []byte) (*graphqlPayload, error) {
 	}
 
 	// Nothing useful extracted
-	if p.Query == "" && p.DocID == "" && p.QueryHash == "" {
+	if !p.isOperation() {
 		return nil, nil
 	}
 
@@ -140,39 +224,10 @@ func tryExtractPayloadRetroactive(r *http.Request) *graphqlPayload {
 	}
 }
 
-// ExtractOperationName attempts to parse the operation name from a GraphQL query string.
+// ExtractOperationName parses the operation name from a GraphQL query string.
+// It returns "" for anonymous operations and for queries that do not parse.
 func ExtractOperationName(query string) string {
-	query = strings.TrimSpace(query)
-
-	// Look for named operations: query Foo, mutation Bar, subscription Baz
-	// Only match keywords at position 0 or preceded by whitespace to avoid
-	// false matches inside string literal values like { search(q: "mutation foo") }.
-	for _, prefix := range []string{"query ", "mutation ", "subscription "} {
-		idx := strings.Index(query, prefix)
-		if idx < 0 {
-			continue
-		}
-		if idx > 0 {
-			prev := query[idx-1]
-			if prev != ' ' && prev != '\n' && prev != '\r' && prev != '\t' && prev != ',' {
-				continue
-			}
-		}
-		rest := query[idx+len(prefix):]
-		// Read until ( or { or whitespace
-		var name strings.Builder
-		for _, c := range rest {
-			if c == '(' || c == '{' || c == ' ' || c == '\n' || c == '\r' || c == '\t' {
-				break
-			}
-			name.WriteRune(c)
-		}
-		if name.Len() > 0 {
-			return name.String()
-		}
-	}
-
-	return ""
+	return parser.OperationName(query)
 }
 
 // DetectGraphQLResponse checks if a response body looks like a GraphQL response.
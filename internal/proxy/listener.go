@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// listener is a listener run alongside the main proxy, bound to a project
+// and role so that several testers or browser profiles can capture at once
+// without their traffic colliding.
+type listener struct {
+	cfg  schema.ProxyListener
+	bind *binding
+	ln   net.Listener
+	// target and srv are set for reverse listeners.
+	target *url.URL
+	srv    *http.Server
+}
+
+// StartListener opens a listener described by cfg: a MITM proxy like the
+// main one, or a reverse proxy pinned to cfg.Target. It returns cfg with
+// its ID and start time filled in.
+func (p *Proxy) StartListener(cfg schema.ProxyListener) (schema.ProxyListener, error) {
+	l := &listener{bind: &binding{projectID: cfg.ProjectID, role: cfg.Role}}
+	switch cfg.Kind {
+	case schema.ListenerMITM:
+		if p.certMgr == nil {
+			return cfg, fmt.Errorf("no CA configured for MITM listeners")
+		}
+		cfg.Target = ""
+	case schema.ListenerReverse:
+		target, err := parseReverseTarget(cfg.Target)
+		if err != nil {
+			return cfg, err
+		}
+		l.target = target
+		cfg.Target = target.String()
+	default:
+		return cfg, fmt.Errorf("unknown listener kind %q", cfg.Kind)
+	}
+
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return cfg, fmt.Errorf("listen %s: %w", cfg.Addr, err)
+	}
+	cfg.ID = generateListenerID()
+	cfg.StartedAt = time.Now().UTC()
+	l.cfg, l.ln = cfg, ln
+
+	p.mu.Lock()
+	p.listeners[cfg.ID] = l
+	p.mu.Unlock()
+
+	if l.target != nil {
+		l.srv = &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				p.serveReverse(l, w, r)
+			}),
+			// Client disconnects are routine in a proxy.
+			ErrorLog: log.New(io.Discard, "", 0),
+		}
+		log.Printf("reverse proxy listening on %s -> %s", cfg.Addr, cfg.Target)
+		go l.srv.Serve(ln) //nolint:errcheck
+	} else {
+		log.Printf("MITM proxy listening on %s", cfg.Addr)
+		go p.serve(ln, l.bind)
+	}
+	return cfg, nil
+}
+
+// StopListener closes a listener. Tunnels already open through it run until
+// either side closes them.
+func (p *Proxy) StopListener(id string) error {
+	p.mu.Lock()
+	l, ok := p.listeners[id]
+	delete(p.listeners, id)
+	p.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("listener %s not found", id)
+	}
+	if l.srv != nil {
+		return l.srv.Close()
+	}
+	return l.ln.Close()
+}
+
+// StopListeners closes every listener started with StartListener.
+func (p *Proxy) StopListeners() {
+	for _, l := range p.Listeners() {
+		p.StopListener(l.ID) //nolint:errcheck
+	}
+}
+
+// Listeners returns the open listeners, oldest first.
+func (p *Proxy) Listeners() []schema.ProxyListener {
+	p.mu.RLock()
+	out := make([]schema.ProxyListener, 0, len(p.listeners))
+	for _, l := range p.listeners {
+		out = append(out, l.cfg)
+	}
+	p.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out
+}
+
+func generateListenerID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("lst_%d", time.Now().UnixNano())
+	}
+	return "lst_" + hex.EncodeToString(b)
+}
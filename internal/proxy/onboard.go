@@ -0,0 +1,158 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// OnboardingHost is the host name the proxy answers itself instead of
+// forwarding. A device pointed at the proxy browses to http://gqlforge.local
+// to download the CA certificate and a PAC file, without access to the
+// config directory. Requests sent straight to the proxy's own address are
+// answered the same way.
+const OnboardingHost = "gqlforge.local"
+
+var errNoCA = errors.New("no CA configured")
+
+// CAInfo describes the CA the proxy signs host certificates with.
+func (p *Proxy) CAInfo() (schema.CAInfo, error) {
+	if p.certMgr == nil {
+		return schema.CAInfo{}, errNoCA
+	}
+	return p.certMgr.Info(), nil
+}
+
+// ExportCA encodes the CA in one of the CAFormat formats.
+func (p *Proxy) ExportCA(format, password string) ([]byte, error) {
+	if p.certMgr == nil {
+		return nil, errNoCA
+	}
+	return p.certMgr.Export(format, password)
+}
+
+// RotateCA replaces the CA with a new one. Connections already
+// intercepted keep the certificates they were given.
+func (p *Proxy) RotateCA() (schema.CAInfo, error) {
+	if p.certMgr == nil {
+		return schema.CAInfo{}, errNoCA
+	}
+	if err := p.certMgr.Rotate(); err != nil {
+		return schema.CAInfo{}, err
+	}
+	return p.certMgr.Info(), nil
+}
+
+// isOnboarding reports whether a plain HTTP request is for the proxy
+// itself: one for OnboardingHost, or one sent without a proxy-form URL to
+// the address the client reached the proxy on (local), which would
+// otherwise be forwarded back to the proxy.
+func isOnboarding(req *http.Request, local net.Addr) bool {
+	if req.URL.Host != "" {
+		host := strings.ToLower(req.URL.Hostname())
+		return host == OnboardingHost || host == "gqlforge"
+	}
+	host, port, err := net.SplitHostPort(req.Host)
+	if err != nil {
+		host, port = req.Host, "80"
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == OnboardingHost || host == "gqlforge" {
+		return true
+	}
+	localHost, localPort, err := net.SplitHostPort(local.String())
+	if err != nil || port != localPort {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return host == "localhost" || ip != nil && (ip.IsLoopback() || ip.Equal(net.ParseIP(localHost)))
+}
+
+// serveOnboarding answers a request for the onboarding host. local is the
+// address the client reached the proxy on, which the PAC file points at.
+func (p *Proxy) serveOnboarding(sink responseSink, req *http.Request, local net.Addr) {
+	status, contentType, name := http.StatusOK, "", ""
+	var body []byte
+	var err error
+	switch req.URL.Path {
+	case "/", "/index.html":
+		contentType = "text/html; charset=utf-8"
+		body = []byte(p.onboardingPage(local))
+	case "/ca.pem":
+		contentType, name = "application/x-pem-file", "gqlforge-ca.pem"
+		body, err = p.ExportCA(CAFormatPEM, "")
+	case "/ca.crt", "/ca.der", "/ca.cer":
+		// DER with this type opens the certificate installer on Android and
+		// the profile installer on iOS.
+		contentType, name = "application/x-x509-ca-cert", "gqlforge-ca"+req.URL.Path[3:]
+		body, err = p.ExportCA(CAFormatDER, "")
+	case "/proxy.pac":
+		contentType = "application/x-ns-proxy-autoconfig"
+		body = []byte(pacFile(local))
+	default:
+		status, contentType = http.StatusNotFound, "text/plain; charset=utf-8"
+		body = []byte("not found\n")
+	}
+	if err != nil {
+		status, contentType = http.StatusServiceUnavailable, "text/plain; charset=utf-8"
+		body = []byte(err.Error() + "\n")
+	}
+
+	header := http.Header{"Content-Type": {contentType}, "Cache-Control": {"no-store"}}
+	if name != "" {
+		header.Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	}
+	resp := &http.Response{
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode: status,
+		Header:     header,
+	}
+	if req.Method == http.MethodHead {
+		sink.WriteHead(resp, 0) //nolint:errcheck
+		return
+	}
+	if sink.WriteHead(resp, len(body)) == nil {
+		sink.Write(body) //nolint:errcheck
+	}
+}
+
+// pacFile returns a proxy auto-config script sending everything but plain
+// host names and localhost through the proxy at local.
+func pacFile(local net.Addr) string {
+	return fmt.Sprintf(`function FindProxyForURL(url, host) {
+  if (isPlainHostName(host) || host === "localhost" || host === "127.0.0.1") {
+    return "DIRECT";
+  }
+  return "PROXY %s";
+}
+`, local.String())
+}
+
+// onboardingPage renders the page linking the CA downloads and PAC file.
+func (p *Proxy) onboardingPage(local net.Addr) string {
+	fingerprint := "unavailable"
+	if info, err := p.CAInfo(); err == nil {
+		fingerprint = info.SHA256
+	}
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>0xGQLForge CA</title>
+<style>body{font-family:system-ui,sans-serif;max-width:40rem;margin:2rem auto;padding:0 1rem;line-height:1.5}code{word-break:break-all}</style>
+</head><body>
+<h1>0xGQLForge</h1>
+<p>Install the CA certificate so this device trusts the proxy's HTTPS interception.</p>
+<ul>
+<li><a href="/ca.crt">ca.crt</a> &mdash; Android (Settings &rarr; Security &rarr; Install a certificate &rarr; CA certificate) and iOS (install the profile, then enable full trust under General &rarr; About &rarr; Certificate Trust Settings)</li>
+<li><a href="/ca.pem">ca.pem</a> &mdash; browsers, desktop trust stores, and command-line tools</li>
+<li><a href="/proxy.pac">proxy.pac</a> &mdash; automatic proxy configuration URL</li>
+</ul>
+<p>Proxy: <code>%s</code></p>
+<p>SHA-256 fingerprint: <code>%s</code></p>
+</body></html>
+`, html.EscapeString(local.String()), html.EscapeString(fingerprint))
+}
@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// persistedQueryText returns the query text learned for an APQ hash sent to
+// host within a project, or "" when the proxy hasn't seen the full query
+// there yet. Mappings are kept apart per project and host, so a client
+// sending the wrong text with a hash can't mislabel other targets' traffic.
+func (p *Proxy) persistedQueryText(projID, host, hash string) string {
+	query, err := p.trafficRepo.PersistedQuery(projID, host, hash)
+	if err != nil {
+		log.Printf("look up persisted query %s: %v", hash, err)
+	}
+	return query
+}
+
+// learnPersistedQuery records the hash-to-text mapping when an APQ client
+// sends the full query along with its hash (the retry after a
+// PersistedQueryNotFound), and fills in earlier hash-only captures.
+func (p *Proxy) learnPersistedQuery(captured *schema.CapturedRequest) {
+	if captured.PersistedHash == "" || captured.Query == "" || strings.HasPrefix(captured.Query, "# persisted query") {
+		return
+	}
+	sum := sha256.Sum256([]byte(captured.Query))
+	projID := ""
+	if captured.ProjectID != nil {
+		projID = *captured.ProjectID
+	}
+	n, err := p.trafficRepo.SavePersistedQuery(&schema.PersistedQuery{
+		Hash:          captured.PersistedHash,
+		Query:         captured.Query,
+		OperationName: captured.OperationName,
+		Host:          captured.Host,
+		ProjectID:     projID,
+		Verified:      strings.EqualFold(hex.EncodeToString(sum[:]), captured.PersistedHash),
+		FirstSeen:     captured.Timestamp,
+	})
+	if err != nil {
+		log.Printf("save persisted query: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("persisted query %s: filled in %d earlier capture(s)", captured.PersistedHash, n)
+	}
+}
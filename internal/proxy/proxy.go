@@ -2,7 +2,7 @@ package proxy
 
 import (
 	"bufio"
-	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/tls"
 	"encoding/hex"
@@ -13,13 +13,18 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/0xDTC/0xGQLForge/internal/rewrite"
 	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/scope"
 	"github.com/0xDTC/0xGQLForge/internal/storage"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+	"github.com/0xDTC/0xGQLForge/internal/ws"
 )
 
 // Proxy is the MITM proxy engine that intercepts and analyzes GraphQL traffic.
@@ -30,29 +35,72 @@ type Proxy struct {
 	listener    net.Listener
 	running     bool
 	projectID   string
+	// scopes limit which requests are stored, by project; a project
+	// without one stores all.
+	scopes      map[string]*scope.Scope
 	mu          sync.RWMutex
 	subs        map[chan []byte]struct{}
 	subsMu      sync.RWMutex
+	upstreamCfg upstream.Config
 	client      *http.Client
+	// streamClient forwards requests that expect a server-sent event
+	// stream, which the client's overall timeout would cut off.
+	streamClient *http.Client
+	// rewriter holds the match-and-replace rules; nil rewrites nothing.
+	rewriter  *rewrite.Engine
+	intercept interceptQueue
+	// bundles stores operations found in JavaScript responses; nil skips
+	// scanning them.
+	bundles *bundleScanner
+	// listeners are the listeners run alongside the main one, by ID.
+	listeners map[string]*listener
+	// retainUploads is the largest file upload request body kept on its
+	// capture; larger ones keep only file metadata.
+	retainUploads int64
+	// resolveProject layers a project's upstream settings over upstreamCfg;
+	// nil gives every project upstreamCfg. projectClients caches the result
+	// by project ID; projectGen counts invalidations, so clients derived
+	// from settings that changed meanwhile aren't cached.
+	resolveProject func(projectID string, base upstream.Config) (upstream.Config, error)
+	projectClients map[string]*upstreamClients
+	projectGen     uint64
+}
+
+// upstreamClients are the clients built from one upstream configuration.
+type upstreamClients struct {
+	cfg    upstream.Config
+	client *http.Client
+	// stream forwards requests that expect a server-sent event stream,
+	// which the client's overall timeout would cut off.
+	stream *http.Client
+}
+
+func newUpstreamClients(cfg upstream.Config) *upstreamClients {
+	return &upstreamClients{cfg: cfg, client: upstream.NewClient(cfg), stream: upstream.NewStreamingClient(cfg)}
+}
+
+func (c *upstreamClients) closeIdle() {
+	c.client.CloseIdleConnections()
+	c.stream.CloseIdleConnections()
 }
 
 // NewProxy creates a new MITM proxy.
 func NewProxy(addr string, certMgr *CertManager, trafficRepo *storage.TrafficRepo) *Proxy {
+	cfg := upstream.DefaultConfig()
 	return &Proxy{
-		addr:        addr,
-		certMgr:     certMgr,
-		trafficRepo: trafficRepo,
-		subs:        make(map[chan []byte]struct{}),
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-				MaxIdleConns:    100,
-				IdleConnTimeout: 90 * time.Second,
-			},
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return http.ErrUseLastResponse
-			},
+		addr:           addr,
+		certMgr:        certMgr,
+		trafficRepo:    trafficRepo,
+		scopes:         make(map[string]*scope.Scope),
+		listeners:      make(map[string]*listener),
+		projectClients: make(map[string]*upstreamClients),
+		subs:           make(map[chan []byte]struct{}),
+		upstreamCfg:    cfg,
+		client:         upstream.NewClient(cfg),
+		streamClient:   upstream.NewStreamingClient(cfg),
+		intercept: interceptQueue{
+			settings: schema.InterceptSettings{TimeoutSeconds: defaultInterceptTimeout, OnTimeout: InterceptForward},
+			pending:  make(map[string]*heldMessage),
 		},
 	}
 }
@@ -76,7 +124,7 @@ func (p *Proxy) Start() error {
 
 	log.Printf("MITM proxy listening on %s (CA: %s)", p.addr, p.certMgr.CACertPath())
 
-	go p.serve(ln)
+	go p.serve(ln, nil)
 	return nil
 }
 
@@ -90,6 +138,8 @@ func (p *Proxy) Stop() error {
 	}
 
 	p.running = false
+	// Nobody is left to decide on held messages.
+	p.releaseIntercepted()
 	if p.listener != nil {
 		return p.listener.Close()
 	}
@@ -117,6 +167,156 @@ func (p *Proxy) SetProjectID(id string) {
 	p.mu.Unlock()
 }
 
+// SetScope limits which of a project's captured requests are stored.
+// Requests outside the scope are still forwarded. A nil scope stores
+// everything.
+func (p *Proxy) SetScope(projectID string, s *scope.Scope) {
+	p.mu.Lock()
+	if s == nil {
+		delete(p.scopes, projectID)
+	} else {
+		p.scopes[projectID] = s
+	}
+	p.mu.Unlock()
+}
+
+// inScope reports whether captured falls within its project's scope.
+func (p *Proxy) inScope(captured *schema.CapturedRequest) bool {
+	var projectID string
+	if captured.ProjectID != nil {
+		projectID = *captured.ProjectID
+	}
+	p.mu.RLock()
+	s := p.scopes[projectID]
+	p.mu.RUnlock()
+	if s == nil {
+		return true
+	}
+	var urlPath string
+	if u, err := url.Parse(captured.URL); err == nil {
+		urlPath = u.Path
+	}
+	return s.Allows(captured.Host, urlPath, captured.OperationName)
+}
+
+// UpstreamClient returns the HTTP client the proxy forwards requests with.
+// Other features that send traffic to targets use it so they honour the same
+// upstream settings.
+func (p *Proxy) UpstreamClient() *http.Client {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.client
+}
+
+// UpstreamConfig returns the settings behind UpstreamClient, for features
+// that need their own transport, such as the subscription runner.
+func (p *Proxy) UpstreamConfig() upstream.Config {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.upstreamCfg
+}
+
+// SetUpstreamConfig replaces the upstream settings of traffic outside any
+// project, and the base the projects' settings are layered over, for
+// example to chain through another proxy. Requests already in flight finish
+// on the old clients.
+func (p *Proxy) SetUpstreamConfig(cfg upstream.Config) error {
+	if _, err := upstream.ParseProxyURL(cfg.ProxyURL); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	old, oldStream := p.client, p.streamClient
+	p.upstreamCfg = cfg
+	p.client = upstream.NewClient(cfg)
+	p.streamClient = upstream.NewStreamingClient(cfg)
+	stale := p.projectClients
+	p.projectClients = make(map[string]*upstreamClients)
+	p.projectGen++
+	p.mu.Unlock()
+
+	old.CloseIdleConnections()
+	oldStream.CloseIdleConnections()
+	for _, c := range stale {
+		c.closeIdle()
+	}
+	return nil
+}
+
+// SetProjectUpstream sets how a project's upstream settings are derived
+// from the base ones, such as its upstream proxy and TLS profile. Traffic
+// is sent with the settings of the project it is captured into, so one
+// project's client certificate or tunnel never carries another's traffic.
+func (p *Proxy) SetProjectUpstream(resolve func(projectID string, base upstream.Config) (upstream.Config, error)) {
+	p.mu.Lock()
+	p.resolveProject = resolve
+	stale := p.projectClients
+	p.projectClients = make(map[string]*upstreamClients)
+	p.projectGen++
+	p.mu.Unlock()
+	for _, c := range stale {
+		c.closeIdle()
+	}
+}
+
+// RefreshProjectUpstream drops a project's clients after its settings
+// change, so they are derived again on its next request.
+func (p *Proxy) RefreshProjectUpstream(projectID string) {
+	p.mu.Lock()
+	c := p.projectClients[projectID]
+	delete(p.projectClients, projectID)
+	p.projectGen++
+	p.mu.Unlock()
+	if c != nil {
+		c.closeIdle()
+	}
+}
+
+// ProjectUpstreamConfig returns the upstream settings of a project's
+// traffic. An empty ID gives those of traffic outside any project.
+func (p *Proxy) ProjectUpstreamConfig(projectID string) upstream.Config {
+	return p.clientsFor(projectID).cfg
+}
+
+// ProjectUpstreamClient returns the client a project's traffic is sent
+// with, for features that send requests on a project's behalf.
+func (p *Proxy) ProjectUpstreamClient(projectID string) *http.Client {
+	return p.clientsFor(projectID).client
+}
+
+// clientsFor returns the clients for a project's traffic, deriving and
+// caching them on first use. A project whose settings can't be derived
+// falls back to the base settings.
+func (p *Proxy) clientsFor(projectID string) *upstreamClients {
+	p.mu.RLock()
+	base := &upstreamClients{cfg: p.upstreamCfg, client: p.client, stream: p.streamClient}
+	resolve := p.resolveProject
+	c := p.projectClients[projectID]
+	gen := p.projectGen
+	p.mu.RUnlock()
+	if projectID == "" || resolve == nil {
+		return base
+	}
+	if c != nil {
+		return c
+	}
+
+	cfg, err := resolve(projectID, base.cfg)
+	if err != nil {
+		log.Printf("upstream settings for project %s: %v", projectID, err)
+		cfg = base.cfg
+	}
+	c = newUpstreamClients(cfg)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cached := p.projectClients[projectID]; cached != nil {
+		return cached
+	}
+	if gen == p.projectGen {
+		p.projectClients[projectID] = c
+	}
+	return c
+}
+
 // GetProjectID returns the currently linked project ID.
 func (p *Proxy) GetProjectID() string {
 	p.mu.RLock()
@@ -124,8 +324,48 @@ func (p *Proxy) GetProjectID() string {
 	return p.projectID
 }
 
-// Subscribe returns a channel that receives SSE events for new traffic.
-// The returned channel must be passed back to Unsubscribe when done.
+// binding is what a listener ties its traffic to: a project, or the linked
+// project when empty, and an auth-role label.
+type binding struct {
+	projectID string
+	role      string
+}
+
+// bindingKey is the request context key for the binding of the listener a
+// request arrived on.
+type bindingKey struct{}
+
+// withBinding returns req carrying b. A nil b leaves req to the linked
+// project.
+func withBinding(req *http.Request, b *binding) *http.Request {
+	if b == nil {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), bindingKey{}, b))
+}
+
+// projectFor returns the project req's captures are filed under: the one
+// its listener is bound to, or else the linked project.
+func (p *Proxy) projectFor(req *http.Request) string {
+	if b, _ := req.Context().Value(bindingKey{}).(*binding); b != nil && b.projectID != "" {
+		return b.projectID
+	}
+	return p.GetProjectID()
+}
+
+// roleFor returns the auth-role label of the listener req arrived on.
+func roleFor(req *http.Request) string {
+	if b, _ := req.Context().Value(bindingKey{}).(*binding); b != nil {
+		return b.role
+	}
+	return ""
+}
+
+// Subscribe returns a channel that receives SSE events: new traffic as
+// unnamed events, held messages as "intercept" events and their resolution
+// as "intercept-done" events. Each element is a complete event without the
+// terminating blank line. The returned channel must be passed back to
+// Unsubscribe when done.
 func (p *Proxy) Subscribe() <-chan []byte {
 	ch := make(chan []byte, 64)
 	p.subsMu.Lock()
@@ -150,21 +390,23 @@ func (p *Proxy) Unsubscribe(ch <-chan []byte) {
 	}
 }
 
-func (p *Proxy) serve(ln net.Listener) {
+// serve accepts proxy connections on ln until it is closed. Their traffic
+// is captured under b, or the linked project when b is nil.
+func (p *Proxy) serve(ln net.Listener, b *binding) {
 	for {
 		conn, err := ln.Accept()
 		if err != nil {
-			if !p.Running() {
+			if errors.Is(err, net.ErrClosed) {
 				return
 			}
 			log.Printf("proxy accept error: %v", err)
 			continue
 		}
-		go p.handleConnection(conn)
+		go p.handleConnection(conn, b)
 	}
 }
 
-func (p *Proxy) handleConnection(conn net.Conn) {
+func (p *Proxy) handleConnection(conn net.Conn, b *binding) {
 	defer conn.Close()
 
 	// Wrap connection in a bufio.Reader so http.ReadRequest can handle
@@ -176,14 +418,14 @@ func (p *Proxy) handleConnection(conn net.Conn) {
 	}
 
 	if req.Method == "CONNECT" {
-		p.handleConnect(conn, req)
+		p.handleConnect(conn, req, b)
 	} else {
-		p.handleHTTP(conn, req, br)
+		p.handleHTTP(conn, withBinding(req, b), br)
 	}
 }
 
 // handleConnect handles HTTPS CONNECT tunneling with MITM.
-func (p *Proxy) handleConnect(clientConn net.Conn, req *http.Request) {
+func (p *Proxy) handleConnect(clientConn net.Conn, req *http.Request, b *binding) {
 	// Send 200 Connection Established
 	if _, err := clientConn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
 		return
@@ -199,6 +441,7 @@ func (p *Proxy) handleConnect(clientConn net.Conn, req *http.Request) {
 	// TLS handshake with client using our minted cert
 	tlsConfig := &tls.Config{
 		Certificates: []tls.Certificate{*cert},
+		NextProtos:   []string{"h2", "http/1.1"},
 	}
 	tlsConn := tls.Server(clientConn, tlsConfig)
 	if err := tlsConn.Handshake(); err != nil {
@@ -212,6 +455,11 @@ func (p *Proxy) handleConnect(clientConn net.Conn, req *http.Request) {
 	}
 	defer tlsConn.Close()
 
+	if tlsConn.ConnectionState().NegotiatedProtocol == "h2" {
+		p.serveHTTP2(tlsConn, req.Host, b)
+		return
+	}
+
 	// Read decrypted HTTP requests from the TLS connection.
 	// CRITICAL: Reuse a single bufio.Reader for the entire tunnel.
 	// Creating a new one per request loses buffered bytes, corrupting
@@ -231,13 +479,24 @@ func (p *Proxy) handleConnect(clientConn net.Conn, req *http.Request) {
 		innerReq.URL.Scheme = "https"
 		innerReq.URL.Host = req.Host // keep original host:port for non-standard ports
 		innerReq.RequestURI = ""
+		innerReq = withBinding(innerReq, b)
 
-		p.forwardAndCapture(tlsConn, innerReq)
+		// A WebSocket upgrade takes over the connection for good.
+		if ws.IsUpgrade(innerReq) {
+			p.tunnelWebSocket(tlsConn, tlsBuf, innerReq)
+			return
+		}
+
+		p.forwardAndCapture(&connSink{conn: tlsConn}, innerReq)
 	}
 }
 
 // handleHTTP handles plain HTTP requests (non-CONNECT).
-func (p *Proxy) handleHTTP(clientConn net.Conn, req *http.Request, _ *bufio.Reader) {
+func (p *Proxy) handleHTTP(clientConn net.Conn, req *http.Request, br *bufio.Reader) {
+	if isOnboarding(req, clientConn.LocalAddr()) {
+		p.serveOnboarding(&connSink{conn: clientConn}, req, clientConn.LocalAddr())
+		return
+	}
 	if req.URL.Scheme == "" {
 		req.URL.Scheme = "http"
 	}
@@ -246,28 +505,62 @@ func (p *Proxy) handleHTTP(clientConn net.Conn, req *http.Request, _ *bufio.Read
 	}
 	req.RequestURI = ""
 
-	p.forwardAndCapture(clientConn, req)
+	if ws.IsUpgrade(req) {
+		p.tunnelWebSocket(clientConn, br, req)
+		return
+	}
+
+	p.forwardAndCapture(&connSink{conn: clientConn}, req)
 }
 
 // forwardAndCapture forwards the request to the target, captures the response, and writes it back.
-func (p *Proxy) forwardAndCapture(clientConn net.Conn, req *http.Request) {
+func (p *Proxy) forwardAndCapture(sink responseSink, req *http.Request) {
+	// Rules run first so the capture records what was actually sent, and
+	// an intercepted request shows up as it would be forwarded.
+	req, err := p.rewriteRequest(req)
+	if err != nil {
+		log.Printf("%v", err)
+		writeBadGateway(sink) //nolint:errcheck
+		return
+	}
+	req, ok := p.interceptRequest(req)
+	if !ok {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		writeBadGateway(sink) //nolint:errcheck
+		return
+	}
 	isGQL := IsGraphQLRequest(req)
 
-	var payload *graphqlPayload
+	var payloads []*graphqlPayload
+	var batch bool
 	if isGQL {
 		var err error
-		payload, err = ExtractGraphQLPayload(req)
+		payloads, batch, err = ExtractGraphQLPayloads(req)
 		if err != nil {
 			log.Printf("extract graphql payload: %v", err)
 		}
 	}
+	var payload *graphqlPayload
+	if len(payloads) > 0 {
+		payload = payloads[0]
+	}
 
 	// Remove Accept-Encoding so Go's http.Transport decompresses gzip/br
 	// responses transparently. This ensures respBody is always plain text,
 	// which is critical for GraphQL detection, schema inference, and storage.
 	req.Header.Del("Accept-Encoding")
 
-	resp, err := p.client.Do(req)
+	req, rec := withExchangeRecord(req)
+	rec.sent = time.Now()
+
+	clients := p.clientsFor(p.projectFor(req))
+	client := clients.client
+	if accept := req.Header.Get("Accept"); strings.Contains(accept, "text/event-stream") || strings.Contains(accept, "multipart/mixed") {
+		client = clients.stream
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		// Drain any unconsumed request body to keep the stream in sync
 		// for subsequent requests on the same keep-alive connection.
@@ -275,8 +568,7 @@ func (p *Proxy) forwardAndCapture(clientConn net.Conn, req *http.Request) {
 			io.Copy(io.Discard, req.Body)
 			req.Body.Close()
 		}
-		errResp := "HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\n\r\n"
-		if _, werr := clientConn.Write([]byte(errResp)); werr != nil {
+		if werr := writeBadGateway(sink); werr != nil {
 			if !isConnClosed(werr) {
 				log.Printf("write 502 to client: %v", werr)
 			}
@@ -285,34 +577,42 @@ func (p *Proxy) forwardAndCapture(clientConn net.Conn, req *http.Request) {
 	}
 	defer resp.Body.Close()
 
+	// Event streams and @defer/@stream responses are relayed as they
+	// arrive instead of buffered.
+	if strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream") {
+		p.rewriteResponse(req, resp, nil)
+		p.relayEventStream(sink, req, payload, resp)
+		return
+	}
+	if isIncrementalResponse(resp) {
+		p.rewriteResponse(req, resp, nil)
+		p.relayIncremental(sink, req, isGQL, payload, resp)
+		return
+	}
+
 	// Read response body
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("read response body: %v", err)
 		// Send 502 on read failure rather than forwarding truncated body
-		errResp := "HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\n\r\n"
-		clientConn.Write([]byte(errResp))
+		writeBadGateway(sink)
 		return
 	}
+	respBody = p.rewriteResponse(req, resp, respBody)
+	respBody, ok = p.interceptResponse(req, resp, respBody)
 
-	// Write response back to client.
-	// Use resp.Status (e.g. "200 OK") which already includes the code.
-	// Strip Transfer-Encoding and Content-Length since we re-buffer the body.
-	var respBuf bytes.Buffer
-	respBuf.WriteString(fmt.Sprintf("HTTP/1.1 %s\r\n", resp.Status))
-	for k, vals := range resp.Header {
-		kl := strings.ToLower(k)
-		if kl == "transfer-encoding" || kl == "content-length" || kl == "content-encoding" {
-			continue
-		}
-		for _, v := range vals {
-			respBuf.WriteString(fmt.Sprintf("%s: %s\r\n", k, v))
+	// Write response back to client with the re-buffered body's length. A
+	// dropped response reaches the client as an empty 502 but is still
+	// captured.
+	if ok {
+		err = sink.WriteHead(resp, len(respBody))
+		if err == nil {
+			_, err = sink.Write(respBody)
 		}
+	} else {
+		err = writeBadGateway(sink)
 	}
-	respBuf.WriteString(fmt.Sprintf("Content-Length: %d\r\n", len(respBody)))
-	respBuf.WriteString("\r\n")
-	respBuf.Write(respBody)
-	if _, err := clientConn.Write(respBuf.Bytes()); err != nil {
+	if err != nil {
 		// Broken pipe / connection reset are normal — the client closed
 		// before we finished writing (navigation, cancellation, HTTP/2).
 		// Only log unexpected write errors.
@@ -325,78 +625,207 @@ func (p *Proxy) forwardAndCapture(clientConn net.Conn, req *http.Request) {
 	// Primary path: request detected as GQL and payload extracted.
 	// Fallback: response looks like GraphQL (has "data"/"errors" fields)
 	// even if the request wasn't detected — catches non-standard endpoints.
-	if isGQL && payload != nil && (payload.Query != "" || payload.DocID != "") {
-		p.captureTraffic(req, payload, resp.StatusCode, respBody)
+	if isGQL && batch {
+		p.captureBatch(req, payloads, resp, respBody)
+	} else if isGQL && payload != nil && payload.isOperation() {
+		p.captureTraffic(req, payload, resp, respBody)
 	} else if !isGQL && resp.StatusCode == 200 && DetectGraphQLResponse(respBody) {
 		// Response-based fallback: capture unknown endpoints that return GQL responses
 		payload = tryExtractPayloadRetroactive(req)
 		if payload != nil {
-			p.captureTraffic(req, payload, resp.StatusCode, respBody)
+			p.captureTraffic(req, payload, resp, respBody)
 		}
+	} else if !isGQL && resp.StatusCode == 200 && isBundleResponse(req, resp) {
+		go p.scanBundle(p.projectFor(req), req.URL.String(), respBody)
 	}
 }
 
-func (p *Proxy) captureTraffic(req *http.Request, payload *graphqlPayload, statusCode int, respBody []byte) {
-	opName := payload.OperationName
-	if opName == "" && payload.Query != "" {
-		opName = ExtractOperationName(payload.Query)
+func (p *Proxy) captureTraffic(req *http.Request, payload *graphqlPayload, resp *http.Response, respBody []byte) {
+	p.saveCaptured(p.newCaptured(req, payload, resp, respBody))
+}
+
+// captureBatch captures each operation of a batched request as its own
+// entry, paired with the matching element of the response array. When the
+// response isn't an array of the same length (e.g. a top-level error), every
+// entry keeps the whole body.
+func (p *Proxy) captureBatch(req *http.Request, payloads []*graphqlPayload, resp *http.Response, respBody []byte) {
+	for _, captured := range p.newBatchCaptured(p.projectFor(req), req, payloads, resp, respBody) {
+		p.saveCaptured(captured)
 	}
+}
 
-	headers := make(map[string]string)
-	for k := range req.Header {
-		headers[k] = req.Header.Get(k)
+// newBatchCaptured builds one entry per operation of a batched request.
+func (p *Proxy) newBatchCaptured(projID string, req *http.Request, payloads []*graphqlPayload, resp *http.Response, respBody []byte) []*schema.CapturedRequest {
+	var responses []json.RawMessage
+	if json.Unmarshal(respBody, &responses) != nil || len(responses) != len(payloads) {
+		responses = nil
 	}
 
-	p.mu.Lock()
-	projID := p.projectID
-	p.mu.Unlock()
+	var out []*schema.CapturedRequest
+	batchID := generateBatchID()
+	for i, payload := range payloads {
+		if payload == nil {
+			continue
+		}
+		body := respBody
+		if responses != nil {
+			body = responses[i]
+		}
+		captured := p.buildCaptured(projID, req, payload, resp, body)
+		captured.BatchID = batchID
+		captured.BatchIndex = i
+		out = append(out, captured)
+	}
+	return out
+}
+
+func (p *Proxy) newCaptured(req *http.Request, payload *graphqlPayload, resp *http.Response, respBody []byte) *schema.CapturedRequest {
+	return p.buildCaptured(p.projectFor(req), req, payload, resp, respBody)
+}
 
-	// For persisted queries (doc_id/query_hash), store the ID as the query
-	// so the traffic entry isn't blank.
+// buildCaptured builds the entry for one operation of an exchange, filed
+// under projID.
+func (p *Proxy) buildCaptured(projID string, req *http.Request, payload *graphqlPayload, resp *http.Response, respBody []byte) *schema.CapturedRequest {
+	// A persisted query without query text gets the text learned for its
+	// APQ hash, or the operation the project's JS bundles map its ID to.
+	hash := payload.persistedHash()
 	query := payload.Query
+	opName := payload.OperationName
+	if query == "" && hash != "" {
+		query = p.persistedQueryText(projID, req.Host, hash)
+	}
+	if query == "" {
+		var name string
+		name, query = p.bundleOperation(projID, payload)
+		if opName == "" {
+			opName = name
+		}
+	}
+
+	if opName == "" && query != "" {
+		opName = ExtractOperationName(query)
+	}
+
+	// For persisted queries (doc_id/query_hash/APQ hash), store the ID as
+	// the query so the traffic entry isn't blank.
 	if query == "" && payload.DocID != "" {
 		query = "# persisted query doc_id=" + payload.DocID
 	} else if query == "" && payload.QueryHash != "" {
 		query = "# persisted query query_hash=" + payload.QueryHash
+	} else if query == "" && hash != "" {
+		query = "# persisted query sha256Hash=" + hash
 	}
 
 	captured := &schema.CapturedRequest{
-		ID:            generateTrafficID(),
-		Timestamp:     time.Now().UTC(),
-		Method:        req.Method,
-		URL:           req.URL.String(),
-		Host:          req.Host,
-		Headers:       headers,
-		OperationName: opName,
-		Query:         query,
-		Variables:     payload.Variables,
-		ResponseCode:  statusCode,
-		ResponseBody:  respBody,
+		ID:              generateTrafficID(),
+		Timestamp:       time.Now().UTC(),
+		Method:          req.Method,
+		URL:             req.URL.String(),
+		Host:            req.Host,
+		Headers:         flattenHeaders(req.Header),
+		OperationName:   opName,
+		Query:           query,
+		Variables:       payload.Variables,
+		ResponseCode:    resp.StatusCode,
+		ResponseBody:    respBody,
+		Protocol:        resp.Proto,
+		PersistedHash:   hash,
+		RequestBody:     requestBody(req),
+		ResponseHeaders: flattenHeaders(resp.Header),
+		Role:            roleFor(req),
+	}
+	if captured.Protocol == "" {
+		captured.Protocol = req.Proto
+	}
+	recordTLS(captured, resp.TLS)
+	if len(payload.uploads) > 0 {
+		captured.Uploads = payload.uploads
+		if !p.retainUpload(len(captured.RequestBody)) {
+			captured.RequestBody = nil
+		}
 	}
 	if projID != "" {
 		captured.ProjectID = &projID
 	}
+	recordExchange(req, captured)
+	return captured
+}
 
+// recordTLS notes the TLS version and cipher suite negotiated with the
+// target; cs is nil for plain HTTP.
+func recordTLS(captured *schema.CapturedRequest, cs *tls.ConnectionState) {
+	if cs == nil {
+		return
+	}
+	captured.TLSVersion = tls.VersionName(cs.Version)
+	captured.TLSCipher = tls.CipherSuiteName(cs.CipherSuite)
+}
+
+// saveCaptured stores captured if it is in scope and notifies SSE
+// subscribers. It reports whether the request was stored.
+func (p *Proxy) saveCaptured(captured *schema.CapturedRequest) bool {
+	// The hash-to-text map is learned from all traffic, in scope or not;
+	// it is kept per project and host.
+	p.learnPersistedQuery(captured)
+	if !p.inScope(captured) {
+		return false
+	}
 	if err := p.trafficRepo.Save(captured); err != nil {
 		log.Printf("save traffic error: %v", err)
+		return false
 	}
 
 	// Notify SSE subscribers
 	p.broadcast(captured)
+	return true
+}
+
+// requestBody returns the body req was sent with, if it can be replayed.
+func requestBody(req *http.Request) []byte {
+	if req.GetBody == nil {
+		return nil
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+	body, _ := io.ReadAll(rc)
+	return body
+}
+
+// flattenHeaders keeps the first value of each header, which is how
+// captured requests store them.
+func flattenHeaders(h http.Header) map[string]string {
+	headers := make(map[string]string, len(h))
+	for k := range h {
+		headers[k] = h.Get(k)
+	}
+	return headers
 }
 
 func (p *Proxy) broadcast(req *schema.CapturedRequest) {
-	data, err := json.Marshal(req)
+	p.publish("", req)
+}
+
+// publish sends v as an SSE event to every subscriber. An empty event name
+// sends an unnamed event, which browsers deliver as "message".
+func (p *Proxy) publish(event string, v any) {
+	data, err := json.Marshal(v)
 	if err != nil {
 		return
 	}
+	frame := "data: " + string(data)
+	if event != "" {
+		frame = "event: " + event + "\n" + frame
+	}
 
 	p.subsMu.RLock()
 	defer p.subsMu.RUnlock()
 
 	for ch := range p.subs {
 		select {
-		case ch <- data:
+		case ch <- []byte(frame):
 		default:
 			// Drop if subscriber is slow
 		}
@@ -427,3 +856,11 @@ func generateTrafficID() string {
 	}
 	return "trf_" + hex.EncodeToString(b)
 }
+
+func generateBatchID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("bat_%d", time.Now().UnixNano())
+	}
+	return "bat_" + hex.EncodeToString(b)
+}
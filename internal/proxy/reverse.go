@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/ws"
+)
+
+// serveReverse forwards one request received by a reverse listener to its
+// target, for clients that can't be pointed at an HTTP proxy. They talk to
+// the listener as if it were the target, so no CA is needed. Exchanges go
+// through the same rewrite, intercept, and capture pipeline as proxied
+// ones.
+func (p *Proxy) serveReverse(l *listener, w http.ResponseWriter, r *http.Request) {
+	pinToTarget(r, l.target)
+	r = withBinding(r, l.bind)
+
+	if ws.IsUpgrade(r) {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "websocket upgrade not supported", http.StatusInternalServerError)
+			return
+		}
+		conn, brw, err := hj.Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		p.tunnelWebSocket(conn, brw.Reader, r)
+		return
+	}
+
+	p.forwardAndCapture(&writerSink{w: w}, r)
+}
+
+// parseReverseTarget checks that target is an absolute http or https URL.
+func parseReverseTarget(target string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(target))
+	if err != nil {
+		return nil, fmt.Errorf("invalid target URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("target must be an http:// or https:// URL, got %q", target)
+	}
+	return u, nil
+}
+
+// pinToTarget points r at target. A request for / goes to the target URL
+// itself; other paths are resolved under the target's path unless they
+// already start with it. Host and Origin name the target, so it sees the
+// request as its own clients would send it.
+func pinToTarget(r *http.Request, target *url.URL) {
+	r.URL.Scheme = target.Scheme
+	r.URL.Host = target.Host
+	r.URL.Path = targetPath(target.Path, r.URL.Path)
+	r.URL.RawPath = ""
+	if r.URL.RawQuery == "" {
+		r.URL.RawQuery = target.RawQuery
+	}
+	r.Host = target.Host
+	r.RequestURI = ""
+	if r.Header.Get("Origin") != "" {
+		r.Header.Set("Origin", target.Scheme+"://"+target.Host)
+	}
+}
+
+// targetPath joins a request path onto the target's base path.
+func targetPath(base, reqPath string) string {
+	base = strings.TrimSuffix(base, "/")
+	switch {
+	case reqPath == "" || reqPath == "/":
+		if base == "" {
+			return "/"
+		}
+		return base
+	case base == "" || reqPath == base || strings.HasPrefix(reqPath, base+"/"):
+		return reqPath
+	}
+	return base + reqPath
+}
@@ -0,0 +1,195 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/rewrite"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// rewriteKey is the request context key for the exchange's rewrite record.
+type rewriteKey struct{}
+
+// exchangeRewrite records which rules, or intercept edits, changed an
+// exchange and what it looked like before they did, and when the request
+// was sent upstream.
+type exchangeRewrite struct {
+	rules    []string
+	original schema.OriginalExchange
+	sent     time.Time
+
+	// Set for intercepted requests whose response is held as well.
+	operation    string
+	holdResponse bool
+}
+
+// withExchangeRecord returns req carrying a rewrite record, adding one to
+// its context if it has none yet.
+func withExchangeRecord(req *http.Request) (*http.Request, *exchangeRewrite) {
+	if rec, ok := req.Context().Value(rewriteKey{}).(*exchangeRewrite); ok {
+		return req, rec
+	}
+	rec := &exchangeRewrite{}
+	return req.WithContext(context.WithValue(req.Context(), rewriteKey{}, rec)), rec
+}
+
+// SetRewriter sets the match-and-replace rules applied to proxied HTTP
+// traffic. A nil engine disables rewriting. WebSocket frames are relayed
+// unchanged.
+func (p *Proxy) SetRewriter(e *rewrite.Engine) {
+	p.mu.Lock()
+	p.rewriter = e
+	p.mu.Unlock()
+}
+
+func (p *Proxy) currentRewriter() *rewrite.Engine {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.rewriter
+}
+
+// maxRewriteBody is the largest request body the request rules are applied
+// to. Larger bodies, such as file uploads, are forwarded unchanged without
+// being buffered.
+const maxRewriteBody = 8 << 20
+
+// rewriteRequest applies the request rules to req before it is forwarded.
+// The returned request carries the exchange's rewrite record in its context,
+// so the response rules and the capture can add to it. An error means the
+// body couldn't be read, so the request can't be forwarded whole.
+func (p *Proxy) rewriteRequest(req *http.Request) (*http.Request, error) {
+	e := p.currentRewriter()
+	if !e.HasRequestRules() && !e.HasResponseRules() {
+		return req, nil
+	}
+	req, rec := withExchangeRecord(req)
+	if !e.HasRequestRules() {
+		return req, nil
+	}
+
+	body, truncated, err := peekBody(req, maxRewriteBody)
+	if err != nil {
+		return req, fmt.Errorf("read request body for rewrite: %w", err)
+	}
+	if truncated {
+		return req, nil
+	}
+	before := &schema.OriginalMessage{
+		URL:     req.URL.String(),
+		Headers: flattenHeaders(req.Header),
+		Body:    string(body),
+	}
+
+	if body == nil {
+		body = []byte{}
+	}
+	urlHost := req.URL.Host
+	m := &rewrite.Message{URL: req.URL, Header: req.Header, Body: body}
+	rules := e.Request(req.Host, m)
+	// Go sends req.Host rather than a Host header, so honour both a header
+	// rule and a URL rule that moved the request to another host.
+	if h := req.Header.Get("Host"); h != "" {
+		req.Host = h
+		req.Header.Del("Host")
+	} else if req.URL.Host != urlHost {
+		req.Host = req.URL.Host
+	}
+	setRequestBody(req, m.Body)
+	if len(rules) > 0 {
+		rec.rules = append(rec.rules, rules...)
+		rec.original.Request = before
+	}
+	return req, nil
+}
+
+// peekBody reads up to n bytes of r's body and puts them back in front of
+// the rest. truncated reports whether the body goes on past them; only a
+// body read whole can be replayed through GetBody.
+func peekBody(r *http.Request, n int64) (head []byte, truncated bool, err error) {
+	if r.Body == nil {
+		return nil, false, nil
+	}
+	head, err = io.ReadAll(io.LimitReader(r.Body, n+1))
+	if err != nil {
+		r.Body.Close()
+		return nil, false, err
+	}
+	if int64(len(head)) <= n {
+		r.Body.Close()
+		body := head
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		return head, false, nil
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	r.GetBody = nil
+	return head[:n], true, nil
+}
+
+// setRequestBody replaces the body of req and keeps its length in step.
+func setRequestBody(req *http.Request, body []byte) {
+	req.ContentLength = int64(len(body))
+	req.TransferEncoding = nil
+	if len(body) == 0 {
+		req.Body = http.NoBody
+		req.Header.Del("Content-Length")
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	if req.Header.Get("Content-Length") != "" {
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+}
+
+// rewriteResponse applies the response rules to resp and its buffered body
+// and returns the body to send on. A nil body means the response is streamed
+// and only header rules apply.
+func (p *Proxy) rewriteResponse(req *http.Request, resp *http.Response, body []byte) []byte {
+	rec, _ := req.Context().Value(rewriteKey{}).(*exchangeRewrite)
+	e := p.currentRewriter()
+	if rec == nil || !e.HasResponseRules() {
+		return body
+	}
+	before := &schema.OriginalMessage{
+		Headers: flattenHeaders(resp.Header),
+		Body:    string(body),
+	}
+	m := &rewrite.Message{Header: resp.Header, Body: body}
+	rules := e.Response(req.Host, m)
+	if len(rules) == 0 {
+		return body
+	}
+	rec.rules = append(rec.rules, rules...)
+	rec.original.Response = before
+	return m.Body
+}
+
+// recordExchange copies the exchange's record onto a capture: its duration
+// so far and any rewrites.
+func recordExchange(req *http.Request, captured *schema.CapturedRequest) {
+	rec, _ := req.Context().Value(rewriteKey{}).(*exchangeRewrite)
+	if rec == nil {
+		return
+	}
+	if !rec.sent.IsZero() {
+		captured.DurationMs = time.Since(rec.sent).Milliseconds()
+	}
+	if len(rec.rules) == 0 {
+		return
+	}
+	captured.RewrittenBy = rec.rules
+	original := rec.original
+	captured.Original = &original
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// responseSink is where a forwarded response goes: the raw client connection
+// for HTTP/1.1, or the server's ResponseWriter for HTTP/2. Both protocols
+// share one forwarding and capture pipeline through it.
+type responseSink interface {
+	// WriteHead sends the status line and headers. A negative length means
+	// the body is streamed as it arrives and ended by End.
+	WriteHead(resp *http.Response, length int) error
+	// Write sends body bytes to the client without buffering.
+	Write(p []byte) (int, error)
+	// End finishes a streamed body.
+	End()
+}
+
+// framingHeader reports whether a response header is recomputed by the sink
+// rather than copied from upstream. Content-Encoding is among them because
+// the transport has already decompressed the body.
+func framingHeader(name string) bool {
+	switch strings.ToLower(name) {
+	case "transfer-encoding", "content-length", "content-encoding", "connection", "keep-alive":
+		return true
+	}
+	return false
+}
+
+// writeBadGateway answers with an empty 502.
+func writeBadGateway(sink responseSink) error {
+	return sink.WriteHead(&http.Response{
+		Status:     "502 Bad Gateway",
+		StatusCode: http.StatusBadGateway,
+		Header:     http.Header{},
+	}, 0)
+}
+
+// connSink writes HTTP/1.1 responses straight to the client connection.
+// Streamed bodies are delimited by closing the connection.
+type connSink struct {
+	conn     net.Conn
+	streamed bool
+}
+
+func (s *connSink) WriteHead(resp *http.Response, length int) error {
+	var head strings.Builder
+	// resp.Status (e.g. "200 OK") already includes the code.
+	fmt.Fprintf(&head, "HTTP/1.1 %s\r\n", resp.Status)
+	for k, vals := range resp.Header {
+		if framingHeader(k) {
+			continue
+		}
+		for _, v := range vals {
+			fmt.Fprintf(&head, "%s: %s\r\n", k, v)
+		}
+	}
+	if length >= 0 {
+		fmt.Fprintf(&head, "Content-Length: %d\r\n\r\n", length)
+	} else {
+		s.streamed = true
+		head.WriteString("Connection: close\r\n\r\n")
+	}
+	_, err := s.conn.Write([]byte(head.String()))
+	return err
+}
+
+func (s *connSink) Write(p []byte) (int, error) {
+	return s.conn.Write(p)
+}
+
+func (s *connSink) End() {
+	if s.streamed {
+		s.conn.Close()
+	}
+}
+
+// writerSink writes responses through an http.ResponseWriter, as used for
+// HTTP/2 streams, flushing after every write so streamed bodies aren't held
+// back.
+type writerSink struct {
+	w http.ResponseWriter
+}
+
+func (s *writerSink) WriteHead(resp *http.Response, length int) error {
+	h := s.w.Header()
+	for k, vals := range resp.Header {
+		if framingHeader(k) {
+			continue
+		}
+		for _, v := range vals {
+			h.Add(k, v)
+		}
+	}
+	if length >= 0 {
+		h.Set("Content-Length", fmt.Sprint(length))
+	}
+	s.w.WriteHeader(resp.StatusCode)
+	s.flush()
+	return nil
+}
+
+func (s *writerSink) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	s.flush()
+	return n, err
+}
+
+func (s *writerSink) End() {}
+
+func (s *writerSink) flush() {
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
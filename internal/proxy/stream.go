@@ -0,0 +1,311 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+	"github.com/0xDTC/0xGQLForge/internal/subscription"
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+	"github.com/0xDTC/0xGQLForge/internal/ws"
+)
+
+// tunnelWebSocket forwards a WebSocket upgrade to the target and relays
+// frames in both directions until either side closes. Frames are forwarded
+// byte for byte; GraphQL-over-WebSocket messages are decoded on the side
+// and each subscribe/start becomes a captured request with its stream.
+func (p *Proxy) tunnelWebSocket(clientConn net.Conn, clientBuf *bufio.Reader, req *http.Request) {
+	upConn, err := p.dialUpstream(req)
+	if err != nil {
+		log.Printf("websocket dial %s: %v", req.Host, err)
+		clientConn.Write([]byte("HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\n\r\n"))
+		return
+	}
+	defer upConn.Close()
+
+	// Compressed frames can't be decoded, so don't let the peers negotiate it.
+	req.Header.Del("Sec-WebSocket-Extensions")
+	if err := req.Write(upConn); err != nil {
+		log.Printf("websocket handshake to %s: %v", req.Host, err)
+		return
+	}
+	upBuf := bufio.NewReader(upConn)
+	resp, err := http.ReadResponse(upBuf, req)
+	if err != nil {
+		log.Printf("websocket handshake from %s: %v", req.Host, err)
+		clientConn.Write([]byte("HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\n\r\n"))
+		return
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		// The target refused the upgrade; pass its answer on and hang up.
+		resp.Header.Set("Connection", "close")
+		resp.Write(clientConn) //nolint:errcheck
+		resp.Body.Close()
+		return
+	}
+
+	var head strings.Builder
+	fmt.Fprintf(&head, "HTTP/1.1 %s\r\n", resp.Status)
+	for k, vals := range resp.Header {
+		for _, v := range vals {
+			fmt.Fprintf(&head, "%s: %s\r\n", k, v)
+		}
+	}
+	head.WriteString("\r\n")
+	if _, err := clientConn.Write([]byte(head.String())); err != nil {
+		return
+	}
+
+	capture := &wsCapture{
+		proxy:     p,
+		req:       req,
+		projectID: p.projectFor(req),
+		role:      roleFor(req),
+		proto:     resp.Proto,
+		ops:       make(map[string]string),
+		answered:  make(map[string]bool),
+	}
+	if tc, ok := upConn.(*tls.Conn); ok {
+		cs := tc.ConnectionState()
+		capture.tls = &cs
+	}
+
+	// When one direction ends, close both connections so the other pump's
+	// read fails and the tunnel is torn down.
+	var once sync.Once
+	shutdown := func() {
+		once.Do(func() {
+			clientConn.Close()
+			upConn.Close()
+		})
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer shutdown()
+		pumpFrames(clientBuf, upConn, "client", capture)
+	}()
+	go func() {
+		defer wg.Done()
+		defer shutdown()
+		pumpFrames(upBuf, clientConn, "server", capture)
+	}()
+	wg.Wait()
+}
+
+// dialUpstream opens a connection to the target of req with the upstream
+// settings of the project it is captured into.
+func (p *Proxy) dialUpstream(req *http.Request) (net.Conn, error) {
+	cfg := p.ProjectUpstreamConfig(p.projectFor(req))
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	return upstream.Dial(ctx, cfg, req.URL)
+}
+
+// pumpFrames copies frames from src to dst, handing complete text messages
+// to the capture.
+func pumpFrames(src *bufio.Reader, dst io.Writer, direction string, capture *wsCapture) {
+	asm := ws.NewAssembler(ws.MaxFrameSize)
+	for {
+		f, raw, err := ws.ReadFrame(src)
+		if err != nil {
+			if err != io.EOF && !isConnClosed(err) {
+				log.Printf("websocket read (%s): %v", direction, err)
+			}
+			return
+		}
+		if _, err := dst.Write(raw); err != nil {
+			return
+		}
+		if f.IsControl() {
+			continue
+		}
+		if op, msg, ok := asm.Add(f); ok && op == ws.OpText {
+			capture.observe(direction, msg)
+		}
+	}
+}
+
+// wsCapture turns the messages of one WebSocket connection into captured
+// requests, one per GraphQL operation.
+type wsCapture struct {
+	proxy     *Proxy
+	req       *http.Request
+	projectID string
+	role      string
+	proto     string               // HTTP version of the target's handshake response
+	tls       *tls.ConnectionState // nil for ws://
+
+	mu       sync.Mutex
+	init     *schema.StreamMessage // connection_init, recorded on every operation
+	ops      map[string]string     // operation id -> traffic id
+	answered map[string]bool       // traffic ids whose first result is stored
+}
+
+func (c *wsCapture) observe(direction string, data []byte) {
+	m, ok := subscription.ParseMessage(data)
+	if !ok {
+		return
+	}
+	msg := schema.StreamMessage{
+		Timestamp: time.Now().UTC(),
+		Direction: direction,
+		Type:      m.Type,
+		Payload:   json.RawMessage(data),
+	}
+	repo := c.proxy.trafficRepo
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case direction == "client" && m.Type == "connection_init":
+		c.init = &msg
+	case direction == "client" && subscription.IsStart(m.Type):
+		captured := c.start(m)
+		if !c.proxy.saveCaptured(captured) {
+			return
+		}
+		if c.init != nil {
+			repo.AppendMessage(captured.ID, *c.init) //nolint:errcheck
+		}
+		repo.AppendMessage(captured.ID, msg) //nolint:errcheck
+		c.ops[m.ID] = captured.ID
+	default:
+		trafficID, ok := c.ops[m.ID]
+		if !ok {
+			return // keep-alives, pings, acks
+		}
+		if err := repo.AppendMessage(trafficID, msg); err != nil {
+			log.Printf("save stream message error: %v", err)
+		}
+		if direction == "server" && subscription.IsResult(m.Type) && !c.answered[trafficID] {
+			// The first result stands for the operation's response.
+			repo.SetResponseBody(trafficID, m.Payload) //nolint:errcheck
+			c.answered[trafficID] = true
+		}
+		if subscription.IsEnd(m.Type) {
+			delete(c.ops, m.ID)
+			delete(c.answered, trafficID)
+		}
+	}
+}
+
+// start builds the captured request for a subscribe or start message.
+func (c *wsCapture) start(m subscription.Message) *schema.CapturedRequest {
+	var op subscription.OperationPayload
+	json.Unmarshal(m.Payload, &op) //nolint:errcheck
+
+	opName := op.OperationName
+	if opName == "" && op.Query != "" {
+		opName = ExtractOperationName(op.Query)
+	}
+
+	u := *c.req.URL
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+
+	captured := &schema.CapturedRequest{
+		ID:            generateTrafficID(),
+		Timestamp:     time.Now().UTC(),
+		Method:        c.req.Method,
+		URL:           u.String(),
+		Host:          c.req.Host,
+		Headers:       flattenHeaders(c.req.Header),
+		OperationName: opName,
+		Query:         op.Query,
+		Variables:     op.Variables,
+		ResponseCode:  http.StatusSwitchingProtocols,
+		Transport:     "websocket",
+		Protocol:      c.proto,
+		Role:          c.role,
+	}
+	if c.projectID != "" {
+		captured.ProjectID = &c.projectID
+	}
+	recordTLS(captured, c.tls)
+	return captured
+}
+
+// relayEventStream copies a server-sent event response to the client as it
+// arrives and records each event on the captured request.
+func (p *Proxy) relayEventStream(sink responseSink, req *http.Request, payload *graphqlPayload, resp *http.Response) {
+	defer sink.End()
+
+	if err := sink.WriteHead(resp, -1); err != nil {
+		return
+	}
+
+	var trafficID string
+	if payload != nil && payload.Query != "" {
+		captured := p.newCaptured(req, payload, resp, nil)
+		captured.Transport = "sse"
+		if p.saveCaptured(captured) {
+			trafficID = captured.ID
+		}
+	}
+
+	var (
+		dec      subscription.SSEDecoder
+		answered bool
+	)
+	chunk := make([]byte, 32<<10)
+	for {
+		n, err := resp.Body.Read(chunk)
+		if n > 0 {
+			if _, werr := sink.Write(chunk[:n]); werr != nil {
+				return
+			}
+			if trafficID != "" {
+				p.recordEvents(trafficID, dec.Feed(chunk[:n]), &answered)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// recordEvents stores server-sent events as stream messages. The first
+// result also becomes the request's response body; answered records that it
+// has been stored.
+func (p *Proxy) recordEvents(trafficID string, events []subscription.SSEEvent, answered *bool) {
+	for _, e := range events {
+		msg := schema.StreamMessage{
+			Timestamp: time.Now().UTC(),
+			Direction: "server",
+			Type:      e.Event,
+		}
+		if msg.Type == "" {
+			msg.Type = "message"
+		}
+		switch {
+		case e.Data == "":
+		case json.Valid([]byte(e.Data)):
+			msg.Payload = json.RawMessage(e.Data)
+		default:
+			msg.Payload, _ = json.Marshal(e.Data)
+		}
+		if err := p.trafficRepo.AppendMessage(trafficID, msg); err != nil {
+			log.Printf("save stream message error: %v", err)
+		}
+		if !*answered && len(msg.Payload) > 0 && (msg.Type == "next" || msg.Type == "message") {
+			p.trafficRepo.SetResponseBody(trafficID, msg.Payload) //nolint:errcheck
+			*answered = true
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// maxUploadScan is how much of a multipart upload body is read to capture
+// it. The rest is forwarded unbuffered: files past the limit go unrecorded
+// and the body is never retained.
+const maxUploadScan = 32 << 20
+
+// SetRetainUploads keeps the raw body of GraphQL file upload requests of up
+// to limit bytes on their captures, so they can be replayed or exported
+// with the files. Zero, the default, keeps only each file's metadata and
+// hash. Bodies over maxUploadScan are never kept.
+func (p *Proxy) SetRetainUploads(limit int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.retainUploads = limit
+}
+
+// retainUpload reports whether an upload request body of n bytes is kept.
+func (p *Proxy) retainUpload(n int) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return int64(n) <= p.retainUploads
+}
+
+// isUploadRequest reports whether a multipart request's first part is named
+// "operations", as the GraphQL multipart request spec requires.
+func isUploadRequest(r *http.Request) bool {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || r.Body == nil {
+		return false
+	}
+	head, _, err := peekBody(r, 8<<10)
+	if err != nil {
+		return false
+	}
+	part, err := multipart.NewReader(bytes.NewReader(head), params["boundary"]).NextPart()
+	if err != nil {
+		return false
+	}
+	defer part.Close()
+	return part.FormName() == "operations"
+}
+
+// parseMultipartPayloads decodes a multipart/form-data request following
+// the GraphQL multipart request spec: an "operations" part holding the
+// operation (or a batch array) with null in place of each file, a "map"
+// part assigning file parts to variable paths, then the files. Each file
+// is hashed and attached to the operation whose variables it fills. A body
+// without an operations part isn't a GraphQL upload and yields nothing. A
+// truncated body ends at maxUploadScan; the files read whole before that
+// point are kept.
+func parseMultipartPayloads(body []byte, boundary string, truncated bool) ([]*graphqlPayload, bool, error) {
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	var operations []byte
+	fileMap := map[string][]string{}
+	var files []schema.UploadedFile
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if truncated && operations != nil {
+				break
+			}
+			return nil, false, fmt.Errorf("read multipart body: %w", err)
+		}
+		name := part.FormName()
+		switch _, mapped := fileMap[name]; {
+		case name == "operations":
+			operations, err = io.ReadAll(part)
+		case name == "map":
+			var raw []byte
+			if raw, err = io.ReadAll(part); err == nil && json.Unmarshal(raw, &fileMap) != nil {
+				err = errors.New("invalid upload map")
+			}
+		case mapped || part.FileName() != "":
+			h := sha256.New()
+			var n int64
+			if n, err = io.Copy(h, part); err == nil {
+				files = append(files, schema.UploadedFile{
+					Field:       name,
+					Paths:       fileMap[name],
+					FileName:    part.FileName(),
+					ContentType: part.Header.Get("Content-Type"),
+					Size:        n,
+					SHA256:      hex.EncodeToString(h.Sum(nil)),
+				})
+			}
+		}
+		part.Close()
+		if err != nil {
+			if truncated && operations != nil {
+				break
+			}
+			return nil, false, err
+		}
+	}
+	if operations == nil {
+		return nil, false, nil
+	}
+
+	payloads, batch := decodeJSONPayloads(operations)
+	for _, f := range files {
+		if !batch {
+			if len(payloads) > 0 {
+				payloads[0].uploads = append(payloads[0].uploads, f)
+			}
+			continue
+		}
+		// In a batch, paths start with the operation's index: "0.variables.file".
+		seen := map[int]bool{}
+		for _, path := range f.Paths {
+			i, err := strconv.Atoi(strings.SplitN(path, ".", 2)[0])
+			if err != nil || i < 0 || i >= len(payloads) || payloads[i] == nil || seen[i] {
+				continue
+			}
+			seen[i] = true
+			payloads[i].uploads = append(payloads[i].uploads, f)
+		}
+	}
+	return payloads, batch, nil
+}
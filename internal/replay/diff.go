@@ -0,0 +1,208 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Diff row operations.
+const (
+	OpEqual  = "equal"
+	OpChange = "change"
+	OpDelete = "delete"
+	OpInsert = "insert"
+)
+
+// maxEditDistance bounds the line diff. Responses that differ by more lines
+// than this are shown as a wholesale replacement instead.
+const maxEditDistance = 2000
+
+// DiffRow is one row of a side-by-side diff. Line numbers are 1-based; a zero
+// line number means the row has no line on that side.
+type DiffRow struct {
+	Op        string `json:"op"`
+	Left      string `json:"left,omitempty"`
+	Right     string `json:"right,omitempty"`
+	LeftLine  int    `json:"leftLine,omitempty"`
+	RightLine int    `json:"rightLine,omitempty"`
+}
+
+// DiffResponses compares two response bodies line by line. JSON bodies are
+// pretty-printed first so that formatting differences don't hide the real
+// ones. Adjacent deletions and insertions are paired into change rows.
+func DiffResponses(original, replayed []byte) []DiffRow {
+	a := bodyLines(original)
+	b := bodyLines(replayed)
+	return pairRows(a, b, diffLines(a, b))
+}
+
+func bodyLines(body []byte) []string {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	if json.Valid(body) && json.Indent(&buf, body, "", "  ") == nil {
+		body = buf.Bytes()
+	}
+	return strings.Split(string(body), "\n")
+}
+
+type editOp int
+
+const (
+	editEqual editOp = iota
+	editDelete
+	editInsert
+)
+
+// diffLines returns the edit script turning a into b, using Myers' algorithm
+// on whatever remains after trimming the common prefix and suffix.
+func diffLines(a, b []string) []editOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]editOp, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, editEqual)
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, editEqual)
+	}
+	return ops
+}
+
+func myers(a, b []string) []editOp {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(n, m)
+	}
+
+	limit := n + m
+	if limit > maxEditDistance {
+		limit = maxEditDistance
+	}
+	// v[offset+k] is the furthest x reached on diagonal k. trace[d] keeps the
+	// slice of v covering diagonals -d-1..d+1 as it was before step d.
+	offset := limit + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		return replaceAll(n, m)
+	}
+
+	// Walk the trace backwards, collecting ops in reverse.
+	var rev []editOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		snap := trace[d]
+		at := func(k int) int { return snap[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			rev = append(rev, editEqual)
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				rev = append(rev, editInsert)
+			} else {
+				rev = append(rev, editDelete)
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	ops := make([]editOp, len(rev))
+	for i, op := range rev {
+		ops[len(rev)-1-i] = op
+	}
+	return ops
+}
+
+func replaceAll(n, m int) []editOp {
+	ops := make([]editOp, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, editDelete)
+	}
+	for i := 0; i < m; i++ {
+		ops = append(ops, editInsert)
+	}
+	return ops
+}
+
+// pairRows lays an edit script out side by side.
+func pairRows(a, b []string, ops []editOp) []DiffRow {
+	var rows []DiffRow
+	i, j := 0, 0
+	for p := 0; p < len(ops); {
+		if ops[p] == editEqual {
+			rows = append(rows, DiffRow{Op: OpEqual, Left: a[i], Right: b[j], LeftLine: i + 1, RightLine: j + 1})
+			i++
+			j++
+			p++
+			continue
+		}
+		// Gather one run of deletions and insertions, in either order.
+		var dels, ins []int
+		for ; p < len(ops) && ops[p] != editEqual; p++ {
+			if ops[p] == editDelete {
+				dels = append(dels, i)
+				i++
+			} else {
+				ins = append(ins, j)
+				j++
+			}
+		}
+		for q := 0; q < len(dels) || q < len(ins); q++ {
+			switch {
+			case q < len(dels) && q < len(ins):
+				rows = append(rows, DiffRow{Op: OpChange, Left: a[dels[q]], Right: b[ins[q]], LeftLine: dels[q] + 1, RightLine: ins[q] + 1})
+			case q < len(dels):
+				rows = append(rows, DiffRow{Op: OpDelete, Left: a[dels[q]], LeftLine: dels[q] + 1})
+			default:
+				rows = append(rows, DiffRow{Op: OpInsert, Right: b[ins[q]], RightLine: ins[q] + 1})
+			}
+		}
+	}
+	return rows
+}
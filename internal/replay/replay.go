@@ -0,0 +1,267 @@
+// Package replay re-sends captured GraphQL requests, optionally edited, and
+// compares the responses with what was originally captured.
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// maxResponseSize caps how much of a replayed response is kept.
+const maxResponseSize = 50 << 20 // 50MB
+
+// Request is an editable GraphQL request. POST requests are sent as a JSON
+// body; GET requests carry query, variables, and operationName as URL
+// parameters, as in the GraphQL-over-HTTP spec.
+type Request struct {
+	Method        string            `json:"method"`
+	URL           string            `json:"url"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	OperationName string            `json:"operationName,omitempty"`
+	Query         string            `json:"query"`
+	Variables     json.RawMessage   `json:"variables,omitempty"`
+	// RawBody is the body the request was captured with. A POST edits the
+	// query, variables, and operation name into it instead of encoding a
+	// new body, so APQ extensions and doc_id forms survive; a file upload
+	// is resent unchanged.
+	RawBody []byte `json:"-"`
+}
+
+// FromCaptured returns the request that reproduces a captured one.
+func FromCaptured(c *schema.CapturedRequest) Request {
+	headers := make(map[string]string, len(c.Headers))
+	for k, v := range c.Headers {
+		headers[k] = v
+	}
+	return Request{
+		Method:        c.Method,
+		URL:           c.URL,
+		Headers:       headers,
+		OperationName: c.OperationName,
+		Query:         c.Query,
+		Variables:     c.Variables,
+		RawBody:       CapturedBody(c),
+	}
+}
+
+// CapturedBody returns the raw body of c's own operation: the whole body,
+// or its element of a batched request's array.
+func CapturedBody(c *schema.CapturedRequest) []byte {
+	if c.BatchID == "" {
+		return c.RequestBody
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(c.RequestBody, &batch); err != nil || c.BatchIndex >= len(batch) {
+		return nil
+	}
+	return batch[c.BatchIndex]
+}
+
+// persistedPlaceholder starts the query stored for a persisted query whose
+// text isn't known. It was never sent, so it isn't replayed.
+const persistedPlaceholder = "# persisted query"
+
+// skipHeaders are recomputed by the HTTP client and must not be copied from
+// the captured request. Accept-Encoding is dropped so the transport
+// decompresses the response, matching what the proxy stores.
+var skipHeaders = map[string]bool{
+	"content-length":    true,
+	"transfer-encoding": true,
+	"connection":        true,
+	"proxy-connection":  true,
+	"keep-alive":        true,
+	"accept-encoding":   true,
+	"upgrade":           true,
+}
+
+// Build converts r into an *http.Request. It rejects non-http(s) URLs and
+// variables that are not a JSON object.
+func (r Request) Build() (*http.Request, error) {
+	method := strings.ToUpper(strings.TrimSpace(r.Method))
+	if method == "" {
+		method = http.MethodPost
+	}
+	u, err := url.ParseRequestURI(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid URL: must be http or https")
+	}
+
+	vars := bytes.TrimSpace(r.Variables)
+	if len(vars) > 0 && !bytes.Equal(vars, []byte("null")) {
+		var obj map[string]any
+		if err := json.Unmarshal(vars, &obj); err != nil {
+			return nil, fmt.Errorf("variables must be a JSON object: %w", err)
+		}
+	} else {
+		vars = nil
+	}
+
+	query := r.Query
+	if strings.HasPrefix(query, persistedPlaceholder) {
+		query = ""
+	}
+
+	var body io.Reader
+	var contentType string
+	if method == http.MethodGet {
+		params := u.Query()
+		setParam(params, "query", query)
+		setParam(params, "variables", string(vars))
+		setParam(params, "operationName", r.OperationName)
+		u.RawQuery = params.Encode()
+	} else {
+		b, ct, err := r.encodeBody(query, vars)
+		if err != nil {
+			return nil, fmt.Errorf("encode body: %w", err)
+		}
+		body, contentType = bytes.NewReader(b), ct
+	}
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	for name, value := range r.Headers {
+		if skipHeaders[strings.ToLower(name)] {
+			continue
+		}
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+// encodeBody returns the POST body and its content type. A captured JSON
+// object or form body is edited in place, keeping every other field; a
+// captured multipart body is sent unchanged. Otherwise a JSON body is
+// encoded from the editable fields.
+func (r Request) encodeBody(query string, vars []byte) ([]byte, string, error) {
+	contentType := r.header("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	raw := bytes.TrimSpace(r.RawBody)
+	if len(raw) > 0 {
+		switch {
+		case mediaType == "multipart/form-data":
+			return r.RawBody, contentType, nil
+		case mediaType == "application/x-www-form-urlencoded":
+			if form, err := url.ParseQuery(string(raw)); err == nil {
+				setParam(form, "query", query)
+				setParam(form, "variables", string(vars))
+				setParam(form, "operationName", r.OperationName)
+				return []byte(form.Encode()), contentType, nil
+			}
+		case raw[0] == '{':
+			var payload map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &payload); err == nil {
+				setField(payload, "query", query)
+				setField(payload, "operationName", r.OperationName)
+				delete(payload, "variables")
+				if vars != nil {
+					payload["variables"] = json.RawMessage(vars)
+				}
+				b, err := json.Marshal(payload)
+				return b, "application/json", err
+			}
+		}
+	}
+
+	payload := map[string]any{}
+	if query != "" {
+		payload["query"] = query
+	}
+	if vars != nil {
+		payload["variables"] = json.RawMessage(vars)
+	}
+	if r.OperationName != "" {
+		payload["operationName"] = r.OperationName
+	}
+	b, err := json.Marshal(payload)
+	return b, "application/json", err
+}
+
+// header returns the value of r's header name, matched case-insensitively.
+func (r Request) header(name string) string {
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// setField sets a string field of a JSON object, or removes it when value
+// is empty.
+func setField(payload map[string]json.RawMessage, key, value string) {
+	if value == "" {
+		delete(payload, key)
+		return
+	}
+	b, _ := json.Marshal(value)
+	payload[key] = b
+}
+
+func setParam(params url.Values, key, value string) {
+	if value == "" {
+		params.Del(key)
+		return
+	}
+	params.Set(key, value)
+}
+
+// Send issues r through client and records the outcome. Transport failures
+// are reported in the attempt's Error field rather than returned, so they
+// still show up in the history; only an unbuildable request is an error.
+func Send(client *http.Client, r Request) (*schema.ReplayAttempt, error) {
+	req, err := r.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	attempt := &schema.ReplayAttempt{
+		Timestamp:     time.Now().UTC(),
+		Method:        req.Method,
+		URL:           r.URL,
+		Headers:       r.Headers,
+		OperationName: r.OperationName,
+		Query:         r.Query,
+		Variables:     r.Variables,
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		attempt.DurationMs = time.Since(start).Milliseconds()
+		attempt.Error = err.Error()
+		return attempt, nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = "read response: " + err.Error()
+	}
+
+	attempt.ResponseCode = resp.StatusCode
+	attempt.ResponseBody = string(body)
+	attempt.ResponseHeaders = make(map[string]string, len(resp.Header))
+	for k := range resp.Header {
+		attempt.ResponseHeaders[k] = resp.Header.Get(k)
+	}
+	return attempt, nil
+}
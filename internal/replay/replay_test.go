@@ -0,0 +1,135 @@
+package replay
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+func TestBuildFromCaptured(t *testing.T) {
+	tests := []struct {
+		name     string
+		captured schema.CapturedRequest
+		edit     func(*Request)
+		url      string
+		ct       string
+		body     string
+	}{
+		{
+			name: "plain JSON",
+			captured: schema.CapturedRequest{
+				Method: "POST", URL: "https://t/graphql", OperationName: "Q", Query: "query Q { a }",
+				Variables: json.RawMessage(`{"id":1}`),
+			},
+			url:  "https://t/graphql",
+			ct:   "application/json",
+			body: `{"operationName":"Q","query":"query Q { a }","variables":{"id":1}}`,
+		},
+		{
+			name: "APQ keeps extensions and drops the placeholder",
+			captured: schema.CapturedRequest{
+				Method: "POST", URL: "https://t/graphql", Headers: map[string]string{"content-type": "application/json"},
+				Query: "# persisted query sha256Hash=abc", Variables: json.RawMessage(`{"id":1}`),
+				RequestBody: []byte(`{"variables":{"id":1},"extensions":{"persistedQuery":{"version":1,"sha256Hash":"abc"}}}`),
+			},
+			edit: func(r *Request) { r.Variables = json.RawMessage(`{"id":2}`) },
+			url:  "https://t/graphql",
+			ct:   "application/json",
+			body: `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"abc"}},"variables":{"id":2}}`,
+		},
+		{
+			name: "form-encoded doc_id",
+			captured: schema.CapturedRequest{
+				Method: "POST", URL: "https://t/api/graphql", Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+				Query: "# persisted query doc_id=123", Variables: json.RawMessage(`{"id":1}`),
+				RequestBody: []byte(`doc_id=123&variables=%7B%22id%22%3A1%7D&fb_dtsg=tok`),
+			},
+			edit: func(r *Request) { r.Variables = json.RawMessage(`{"id":2}`) },
+			url:  "https://t/api/graphql",
+			ct:   "application/x-www-form-urlencoded",
+			body: url.Values{"doc_id": {"123"}, "fb_dtsg": {"tok"}, "variables": {`{"id":2}`}}.Encode(),
+		},
+		{
+			name: "batch member",
+			captured: schema.CapturedRequest{
+				Method: "POST", URL: "https://t/graphql", Query: "{ b }", BatchID: "batch", BatchIndex: 1,
+				RequestBody: []byte(`[{"query":"{ a }"},{"query":"{ b }","extensions":{"k":1}}]`),
+			},
+			url:  "https://t/graphql",
+			ct:   "application/json",
+			body: `{"extensions":{"k":1},"query":"{ b }"}`,
+		},
+		{
+			name: "upload sent unchanged",
+			captured: schema.CapturedRequest{
+				Method: "POST", URL: "https://t/graphql", Headers: map[string]string{"Content-Type": "multipart/form-data; boundary=XyZ"},
+				Query:       "mutation($f: Upload!) { up(file: $f) }",
+				RequestBody: []byte("--XyZ\r\nContent-Disposition: form-data; name=\"operations\"\r\n\r\n{}\r\n--XyZ--\r\n\xff\x00"),
+			},
+			url:  "https://t/graphql",
+			ct:   "multipart/form-data; boundary=XyZ",
+			body: "--XyZ\r\nContent-Disposition: form-data; name=\"operations\"\r\n\r\n{}\r\n--XyZ--\r\n\xff\x00",
+		},
+		{
+			name: "persisted GET",
+			captured: schema.CapturedRequest{
+				Method: "GET", URL: `https://t/graphql?extensions=%7B%7D&variables=%7B%22id%22%3A1%7D`,
+				Query: "# persisted query sha256Hash=abc", Variables: json.RawMessage(`{"id":1}`),
+			},
+			url: `https://t/graphql?extensions=%7B%7D&variables=%7B%22id%22%3A1%7D`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := FromCaptured(&tt.captured)
+			if tt.edit != nil {
+				tt.edit(&r)
+			}
+			req, err := r.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.URL.String() != tt.url {
+				t.Errorf("URL = %s, want %s", req.URL, tt.url)
+			}
+			if got := req.Header.Get("Content-Type"); got != tt.ct {
+				t.Errorf("Content-Type = %q, want %q", got, tt.ct)
+			}
+			var body []byte
+			if req.Body != nil {
+				body, _ = io.ReadAll(req.Body)
+			}
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestBuildRejects(t *testing.T) {
+	for _, r := range []Request{
+		{Method: "POST", URL: "ftp://t/graphql", Query: "{ a }"},
+		{Method: "POST", URL: "/graphql", Query: "{ a }"},
+		{Method: "POST", URL: "https://t/graphql", Query: "{ a }", Variables: json.RawMessage(`[1]`)},
+	} {
+		if _, err := r.Build(); err == nil {
+			t.Errorf("Build(%+v) succeeded, want error", r)
+		}
+	}
+}
+
+func TestCapturedBody(t *testing.T) {
+	c := &schema.CapturedRequest{BatchID: "b", BatchIndex: 2, RequestBody: []byte(`[{"a":1},{"b":2}]`)}
+	if got := CapturedBody(c); got != nil {
+		t.Errorf("out-of-range batch index = %s, want nil", got)
+	}
+	c.BatchIndex = 0
+	if got := CapturedBody(c); !reflect.DeepEqual(json.RawMessage(got), json.RawMessage(`{"a":1}`)) {
+		t.Errorf("batch element = %s", got)
+	}
+}
@@ -0,0 +1,190 @@
+package rewrite
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/0xDTC/0xGQLForge/internal/parser"
+)
+
+// editOperations runs fn on every GraphQL operation payload in m: the JSON
+// body (a single object or a batch array), or the query string of a GET
+// request. The body or URL is rewritten only when fn changed something.
+func editOperations(m *Message, fn func(op map[string]any) bool) bool {
+	if m.URL != nil && len(bytes.TrimSpace(m.Body)) == 0 {
+		q := m.URL.Query()
+		if !q.Has("query") {
+			return false
+		}
+		op := map[string]any{"query": q.Get("query")}
+		if name := q.Get("operationName"); name != "" {
+			op["operationName"] = name
+		}
+		if vars, ok := decodeJSON([]byte(q.Get("variables"))); ok {
+			op["variables"] = vars
+		}
+		if !fn(op) {
+			return false
+		}
+		query, _ := op["query"].(string)
+		q.Set("query", query)
+		if name, ok := op["operationName"].(string); ok {
+			q.Set("operationName", name)
+		}
+		if vars, ok := op["variables"]; ok {
+			q.Set("variables", string(encodeJSON(vars)))
+		}
+		m.URL.RawQuery = q.Encode()
+		return true
+	}
+
+	doc, ok := decodeJSON(m.Body)
+	if !ok {
+		return false
+	}
+	changed := false
+	switch d := doc.(type) {
+	case map[string]any:
+		changed = fn(d)
+	case []any:
+		for _, item := range d {
+			if op, ok := item.(map[string]any); ok && fn(op) {
+				changed = true
+			}
+		}
+	}
+	if changed {
+		m.Body = encodeJSON(doc)
+	}
+	return changed
+}
+
+// selectOperation returns the operation a request executes: the one named
+// name, or the document's only operation when name is empty.
+func selectOperation(doc *parser.Document, name string) *parser.OperationDefinition {
+	var found *parser.OperationDefinition
+	count := 0
+	for _, def := range doc.Definitions {
+		op, ok := def.(*parser.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name != "" && op.Name == name {
+			return op
+		}
+		found = op
+		count++
+	}
+	if name == "" && count == 1 {
+		return found
+	}
+	return nil
+}
+
+// parseSelections parses a selection such as "isAdmin" or
+// "roles { name }".
+func parseSelections(src string) ([]parser.Selection, error) {
+	doc, err := parser.ParseDocument("{ " + src + " }")
+	if err != nil {
+		return nil, fmt.Errorf("invalid selection: %w", err)
+	}
+	if len(doc.Definitions) != 1 {
+		return nil, fmt.Errorf("invalid selection %q", src)
+	}
+	op, ok := doc.Definitions[0].(*parser.OperationDefinition)
+	if !ok || len(op.SelectionSet) == 0 {
+		return nil, fmt.Errorf("invalid selection %q", src)
+	}
+	return op.SelectionSet, nil
+}
+
+// addSelections appends sels to the selection set at fieldPath (response
+// keys from the operation root). Fields already selected under the same
+// response key are skipped, as are paths ending in a leaf field.
+func addSelections(op *parser.OperationDefinition, fieldPath []string, sels []parser.Selection) bool {
+	target := &op.SelectionSet
+	for _, key := range fieldPath {
+		f := findField(*target, key)
+		if f == nil {
+			return false
+		}
+		target = &f.SelectionSet
+	}
+	if len(*target) == 0 {
+		return false
+	}
+	changed := false
+	for _, sel := range sels {
+		if f, ok := sel.(*parser.Field); ok && findField(*target, f.ResponseKey()) != nil {
+			continue
+		}
+		*target = append(*target, sel)
+		changed = true
+	}
+	return changed
+}
+
+// findField finds the field selected under key, looking through inline
+// fragments.
+func findField(sels []parser.Selection, key string) *parser.Field {
+	for _, sel := range sels {
+		switch s := sel.(type) {
+		case *parser.Field:
+			if s.ResponseKey() == key {
+				return s
+			}
+		case *parser.InlineFragment:
+			if f := findField(s.SelectionSet, key); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// removeDirective strips every use of the named directive from doc.
+func removeDirective(doc *parser.Document, name string) bool {
+	changed := false
+	strip := func(dirs []*parser.Directive) []*parser.Directive {
+		kept := dirs[:0]
+		for _, d := range dirs {
+			if d.Name == name {
+				changed = true
+				continue
+			}
+			kept = append(kept, d)
+		}
+		return kept
+	}
+
+	var walk func(sels []parser.Selection)
+	walk = func(sels []parser.Selection) {
+		for _, sel := range sels {
+			switch s := sel.(type) {
+			case *parser.Field:
+				s.Directives = strip(s.Directives)
+				walk(s.SelectionSet)
+			case *parser.FragmentSpread:
+				s.Directives = strip(s.Directives)
+			case *parser.InlineFragment:
+				s.Directives = strip(s.Directives)
+				walk(s.SelectionSet)
+			}
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		switch d := def.(type) {
+		case *parser.OperationDefinition:
+			d.Directives = strip(d.Directives)
+			for _, v := range d.VariableDefinitions {
+				v.Directives = strip(v.Directives)
+			}
+			walk(d.SelectionSet)
+		case *parser.FragmentDefinition:
+			d.Directives = strip(d.Directives)
+			walk(d.SelectionSet)
+		}
+	}
+	return changed
+}
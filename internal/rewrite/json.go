@@ -0,0 +1,118 @@
+package rewrite
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// parsePath parses a JSON path such as "data.me.isAdmin" or "input.ids[0]".
+// Object keys are strings and list indexes are ints.
+func parsePath(s string) ([]any, error) {
+	if s == "" {
+		return nil, fmt.Errorf("JSON path is required")
+	}
+	var path []any
+	for rest := s; rest != ""; {
+		switch rest[0] {
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: unclosed [", s)
+			}
+			n, err := strconv.Atoi(rest[1:end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: bad index %q", s, rest[1:end])
+			}
+			path = append(path, n)
+			rest = rest[end+1:]
+		case '.':
+			rest = rest[1:]
+			if len(path) == 0 || rest == "" || rest[0] == '.' || rest[0] == '[' {
+				return nil, fmt.Errorf("invalid JSON path %q: empty key", s)
+			}
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			path = append(path, rest[:end])
+			rest = rest[end:]
+		}
+	}
+	return path, nil
+}
+
+// parseValue reads a rule's replacement as JSON, falling back to a plain
+// string so `true`, `42`, and `{"a":1}` keep their types while `alice`
+// needs no quoting.
+func parseValue(s string) any {
+	v, ok := decodeJSON([]byte(s))
+	if !ok {
+		return s
+	}
+	return v
+}
+
+// setPath sets the value at path inside node, creating objects for missing
+// keys, and returns the updated node and whether anything changed. List
+// indexes past the end are left alone.
+func setPath(node any, path []any, value any) (any, bool) {
+	if len(path) == 0 {
+		if reflect.DeepEqual(node, value) {
+			return node, false
+		}
+		return value, true
+	}
+	switch key := path[0].(type) {
+	case string:
+		obj, ok := node.(map[string]any)
+		if !ok {
+			if node != nil {
+				return node, false
+			}
+			obj = map[string]any{}
+		}
+		child, changed := setPath(obj[key], path[1:], value)
+		if !changed {
+			return node, false
+		}
+		obj[key] = child
+		return obj, true
+	case int:
+		list, ok := node.([]any)
+		if !ok || key >= len(list) {
+			return node, false
+		}
+		child, changed := setPath(list[key], path[1:], value)
+		if changed {
+			list[key] = child
+		}
+		return list, changed
+	}
+	return node, false
+}
+
+// decodeJSON parses a complete JSON document, keeping numbers exact.
+func decodeJSON(data []byte) (any, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return nil, false
+	}
+	return v, true
+}
+
+// encodeJSON marshals v without escaping HTML characters, so bodies only
+// differ where a rule changed them.
+func encodeJSON(v any) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v) //nolint:errcheck
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
@@ -0,0 +1,334 @@
+// Package rewrite implements match-and-replace rules for proxied traffic:
+// header, URL, and raw body regex edits, JSON path edits, and GraphQL-aware
+// edits to the operations a request carries.
+package rewrite
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/parser"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// Phases a rule runs in.
+const (
+	PhaseRequest  = "request"
+	PhaseResponse = "response"
+)
+
+// Rule kinds. Match and Replace are interpreted per kind.
+const (
+	KindHeader          = "header"           // Match: header name; Replace: value, or "" to remove it
+	KindURL             = "url"              // Match: regex over the URL; Replace: replacement ($1 expands)
+	KindBody            = "body"             // Match: regex over the raw body; Replace: replacement
+	KindJSON            = "json"             // Match: JSON path in the body; Replace: JSON value
+	KindVariable        = "variable"         // Match: path under variables; Replace: JSON value
+	KindOperationName   = "operation-name"   // Match: current name, "" for any; Replace: new name
+	KindAddField        = "add-field"        // Match: field path from the root, "" for the root; Replace: selection
+	KindRemoveDirective = "remove-directive" // Match: directive name
+)
+
+// Message is the part of a request or response the rules can change. URL is
+// nil for responses. A nil Body means the body is streamed, so only header
+// rules apply.
+type Message struct {
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+}
+
+// Engine is a compiled, ordered set of enabled rules. A nil Engine has no
+// rules.
+type Engine struct {
+	request  []*rule
+	response []*rule
+}
+
+type rule struct {
+	schema.RewriteRule
+	host      string
+	re        *regexp.Regexp
+	path      []any
+	fieldPath []string
+	value     any
+}
+
+var namePattern = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// Compile builds an Engine from rules, skipping disabled ones and failing on
+// the first invalid rule. Rules run in the order given.
+func Compile(rules []schema.RewriteRule) (*Engine, error) {
+	e := &Engine{}
+	for _, r := range rules {
+		if !r.Enabled {
+			continue
+		}
+		c, err := compile(r)
+		if err != nil {
+			return nil, err
+		}
+		if r.Phase == PhaseRequest {
+			e.request = append(e.request, c)
+		} else {
+			e.response = append(e.response, c)
+		}
+	}
+	return e, nil
+}
+
+// Validate checks a single rule.
+func Validate(r schema.RewriteRule) error {
+	_, err := compile(r)
+	return err
+}
+
+func compile(r schema.RewriteRule) (*rule, error) {
+	c := &rule{RewriteRule: r}
+	switch r.Phase {
+	case PhaseRequest:
+	case PhaseResponse:
+		if r.Kind != KindHeader && r.Kind != KindBody && r.Kind != KindJSON {
+			return nil, fmt.Errorf("%s rules only apply to requests", r.Kind)
+		}
+	default:
+		return nil, fmt.Errorf("unknown phase %q: must be request or response", r.Phase)
+	}
+	if r.Host != "" {
+		c.host = strings.ToLower(r.Host)
+		if _, err := path.Match(c.host, ""); err != nil {
+			return nil, fmt.Errorf("invalid host glob %q: %w", r.Host, err)
+		}
+	}
+
+	var err error
+	switch r.Kind {
+	case KindHeader:
+		if r.Match == "" || strings.ContainsAny(r.Match, " \t\r\n:") {
+			return nil, fmt.Errorf("invalid header name %q", r.Match)
+		}
+		if strings.ContainsAny(r.Replace, "\r\n") {
+			return nil, fmt.Errorf("header value must not contain line breaks")
+		}
+	case KindURL, KindBody:
+		if r.Match == "" {
+			return nil, fmt.Errorf("match pattern is required")
+		}
+		if c.re, err = regexp.Compile(r.Match); err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", r.Match, err)
+		}
+	case KindJSON, KindVariable:
+		if c.path, err = parsePath(r.Match); err != nil {
+			return nil, err
+		}
+		c.value = parseValue(r.Replace)
+	case KindOperationName:
+		if r.Match != "" && !namePattern.MatchString(r.Match) {
+			return nil, fmt.Errorf("invalid operation name %q", r.Match)
+		}
+		if !namePattern.MatchString(r.Replace) {
+			return nil, fmt.Errorf("invalid operation name %q", r.Replace)
+		}
+	case KindAddField:
+		if r.Match != "" {
+			c.fieldPath = strings.Split(r.Match, ".")
+			for _, key := range c.fieldPath {
+				if !namePattern.MatchString(key) {
+					return nil, fmt.Errorf("invalid field path %q", r.Match)
+				}
+			}
+		}
+		if _, err := parseSelections(r.Replace); err != nil {
+			return nil, err
+		}
+	case KindRemoveDirective:
+		c.Match = strings.TrimPrefix(r.Match, "@")
+		if !namePattern.MatchString(c.Match) {
+			return nil, fmt.Errorf("invalid directive name %q", r.Match)
+		}
+	default:
+		return nil, fmt.Errorf("unknown rule kind %q", r.Kind)
+	}
+	return c, nil
+}
+
+// HasRequestRules reports whether any rule runs on requests.
+func (e *Engine) HasRequestRules() bool {
+	return e != nil && len(e.request) > 0
+}
+
+// HasResponseRules reports whether any rule runs on responses.
+func (e *Engine) HasResponseRules() bool {
+	return e != nil && len(e.response) > 0
+}
+
+// Request applies the request rules for host to m in place and returns the
+// labels of the rules that changed it.
+func (e *Engine) Request(host string, m *Message) []string {
+	if e == nil {
+		return nil
+	}
+	return apply(e.request, host, m)
+}
+
+// Response applies the response rules for host to m in place and returns
+// the labels of the rules that changed it.
+func (e *Engine) Response(host string, m *Message) []string {
+	if e == nil {
+		return nil
+	}
+	return apply(e.response, host, m)
+}
+
+func apply(rules []*rule, host string, m *Message) []string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+
+	var applied []string
+	for _, r := range rules {
+		if r.host != "" {
+			if ok, _ := path.Match(r.host, host); !ok {
+				continue
+			}
+		}
+		if r.apply(m) {
+			applied = append(applied, r.label())
+		}
+	}
+	return applied
+}
+
+// label names the rule in capture records.
+func (r *rule) label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	if r.Match == "" {
+		return r.Kind
+	}
+	return r.Kind + " " + r.Match
+}
+
+// apply runs the rule on m and reports whether it changed anything.
+func (r *rule) apply(m *Message) bool {
+	switch r.Kind {
+	case KindHeader:
+		name := http.CanonicalHeaderKey(r.Match)
+		if r.Replace == "" {
+			if _, ok := m.Header[name]; !ok {
+				return false
+			}
+			m.Header.Del(name)
+			return true
+		}
+		if vals := m.Header.Values(name); len(vals) == 1 && vals[0] == r.Replace {
+			return false
+		}
+		m.Header.Set(name, r.Replace)
+		return true
+
+	case KindURL:
+		if m.URL == nil {
+			return false
+		}
+		before := m.URL.String()
+		after := r.re.ReplaceAllString(before, r.Replace)
+		if after == before {
+			return false
+		}
+		u, err := url.Parse(after)
+		if err != nil || u.Host == "" {
+			return false
+		}
+		*m.URL = *u
+		return true
+
+	case KindBody:
+		if m.Body == nil {
+			return false
+		}
+		out := r.re.ReplaceAll(m.Body, []byte(r.Replace))
+		if bytes.Equal(out, m.Body) {
+			return false
+		}
+		m.Body = out
+		return true
+
+	case KindJSON:
+		if m.Body == nil {
+			return false
+		}
+		doc, ok := decodeJSON(m.Body)
+		if !ok {
+			return false
+		}
+		doc, changed := setPath(doc, r.path, r.value)
+		if !changed {
+			return false
+		}
+		m.Body = encodeJSON(doc)
+		return true
+	}
+	return editOperations(m, r.editOperation)
+}
+
+// editOperation applies a GraphQL-aware rule to one operation payload
+// ({"query", "operationName", "variables"}) and reports whether it changed.
+func (r *rule) editOperation(op map[string]any) bool {
+	if r.Kind == KindVariable {
+		vars, changed := setPath(op["variables"], r.path, r.value)
+		if changed {
+			op["variables"] = vars
+		}
+		return changed
+	}
+
+	query, _ := op["query"].(string)
+	if query == "" {
+		return false // persisted queries carry no document to edit
+	}
+	doc, err := parser.ParseDocument(query)
+	if err != nil {
+		return false
+	}
+	opName, _ := op["operationName"].(string)
+	def := selectOperation(doc, opName)
+	if def == nil {
+		return false
+	}
+
+	changed := false
+	switch r.Kind {
+	case KindOperationName:
+		if (r.Match != "" && def.Name != r.Match) || def.Name == r.Replace {
+			return false
+		}
+		def.Name = r.Replace
+		if opName != "" {
+			op["operationName"] = r.Replace
+		}
+		changed = true
+	case KindAddField:
+		// Parsed per request: the added nodes become part of this document
+		// and later rules may edit them.
+		sels, err := parseSelections(r.Replace)
+		if err != nil {
+			return false
+		}
+		changed = addSelections(def, r.fieldPath, sels)
+	case KindRemoveDirective:
+		changed = removeDirective(doc, r.Match)
+	}
+	if changed {
+		op["query"] = parser.Print(doc)
+	}
+	return changed
+}
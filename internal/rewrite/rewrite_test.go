@@ -0,0 +1,254 @@
+package rewrite
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/0xDTC/0xGQLForge/internal/parser"
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// normalize prints query the way editOperation does, so tests don't depend
+// on the printer's layout.
+func normalize(t *testing.T, query string) string {
+	t.Helper()
+	doc, err := parser.ParseDocument(query)
+	if err != nil {
+		t.Fatalf("parse %q: %v", query, err)
+	}
+	return parser.Print(doc)
+}
+
+func operation(t *testing.T, body []byte) map[string]any {
+	t.Helper()
+	var op map[string]any
+	if err := json.Unmarshal(body, &op); err != nil {
+		t.Fatalf("body is not a JSON object: %v\n%s", err, body)
+	}
+	return op
+}
+
+func request(body string) *Message {
+	u, _ := url.Parse("https://api.example.com/graphql")
+	return &Message{URL: u, Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(body)}
+}
+
+func TestRequestRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    schema.RewriteRule
+		body    string
+		applied bool
+		check   func(t *testing.T, m *Message)
+	}{
+		{
+			name:    "header set",
+			rule:    schema.RewriteRule{Kind: KindHeader, Match: "authorization", Replace: "Bearer other"},
+			body:    `{"query":"{ me { id } }"}`,
+			applied: true,
+			check: func(t *testing.T, m *Message) {
+				if got := m.Header.Get("Authorization"); got != "Bearer other" {
+					t.Errorf("Authorization = %q", got)
+				}
+			},
+		},
+		{
+			name:    "header remove of a missing header",
+			rule:    schema.RewriteRule{Kind: KindHeader, Match: "X-Debug"},
+			body:    `{"query":"{ me { id } }"}`,
+			applied: false,
+		},
+		{
+			name:    "url regex",
+			rule:    schema.RewriteRule{Kind: KindURL, Match: `/graphql$`, Replace: "/v2/graphql"},
+			body:    `{"query":"{ me { id } }"}`,
+			applied: true,
+			check: func(t *testing.T, m *Message) {
+				if m.URL.Path != "/v2/graphql" {
+					t.Errorf("path = %s", m.URL.Path)
+				}
+			},
+		},
+		{
+			name:    "json path",
+			rule:    schema.RewriteRule{Kind: KindJSON, Match: "extensions.debug", Replace: "true"},
+			body:    `{"query":"{ me { id } }","extensions":{"n":12345678901234567890}}`,
+			applied: true,
+			check: func(t *testing.T, m *Message) {
+				want := `{"extensions":{"debug":true,"n":12345678901234567890},"query":"{ me { id } }"}`
+				if string(m.Body) != want {
+					t.Errorf("body = %s, want %s", m.Body, want)
+				}
+			},
+		},
+		{
+			name:    "variable in every batch member",
+			rule:    schema.RewriteRule{Kind: KindVariable, Match: "input.ids[0]", Replace: "2"},
+			body:    `[{"query":"{ a }","variables":{"input":{"ids":[1]}}},{"query":"{ b }","variables":{"input":{"ids":[1,5]}}}]`,
+			applied: true,
+			check: func(t *testing.T, m *Message) {
+				var batch []struct {
+					Variables map[string]map[string][]int `json:"variables"`
+				}
+				json.Unmarshal(m.Body, &batch)
+				if len(batch) != 2 || batch[0].Variables["input"]["ids"][0] != 2 || !reflect.DeepEqual(batch[1].Variables["input"]["ids"], []int{2, 5}) {
+					t.Errorf("body = %s", m.Body)
+				}
+			},
+		},
+		{
+			name:    "variable index past the end",
+			rule:    schema.RewriteRule{Kind: KindVariable, Match: "ids[3]", Replace: "2"},
+			body:    `{"query":"{ a }","variables":{"ids":[1]}}`,
+			applied: false,
+		},
+		{
+			name:    "operation name",
+			rule:    schema.RewriteRule{Kind: KindOperationName, Match: "Me", Replace: "Admin"},
+			body:    `{"query":"query Me { me { id } }","operationName":"Me"}`,
+			applied: true,
+			check: func(t *testing.T, m *Message) {
+				op := operation(t, m.Body)
+				if op["operationName"] != "Admin" || op["query"] != normalize(t, "query Admin { me { id } }") {
+					t.Errorf("body = %s", m.Body)
+				}
+			},
+		},
+		{
+			name:    "add field",
+			rule:    schema.RewriteRule{Kind: KindAddField, Match: "me", Replace: "isAdmin roles { name }"},
+			body:    `{"query":"query { me { id ... on User { isAdmin } } }"}`,
+			applied: true,
+			check: func(t *testing.T, m *Message) {
+				want := normalize(t, "query { me { id ... on User { isAdmin } roles { name } } }")
+				if got := operation(t, m.Body)["query"]; got != want {
+					t.Errorf("query = %q, want %q", got, want)
+				}
+			},
+		},
+		{
+			name:    "add field under a leaf",
+			rule:    schema.RewriteRule{Kind: KindAddField, Match: "me.id", Replace: "x"},
+			body:    `{"query":"{ me { id } }"}`,
+			applied: false,
+		},
+		{
+			name:    "remove directive",
+			rule:    schema.RewriteRule{Kind: KindRemoveDirective, Match: "@include"},
+			body:    `{"query":"query($a: Boolean!) { me { id secret @include(if: $a) } }","variables":{"a":false}}`,
+			applied: true,
+			check: func(t *testing.T, m *Message) {
+				want := normalize(t, "query($a: Boolean!) { me { id secret } }")
+				if got := operation(t, m.Body)["query"]; got != want {
+					t.Errorf("query = %q, want %q", got, want)
+				}
+			},
+		},
+		{
+			name:    "persisted query has no document",
+			rule:    schema.RewriteRule{Kind: KindRemoveDirective, Match: "skip"},
+			body:    `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"abc"}}}`,
+			applied: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Enabled = true
+			tt.rule.Phase = PhaseRequest
+			e, err := Compile([]schema.RewriteRule{tt.rule})
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			m := request(tt.body)
+			applied := e.Request("api.example.com:443", m)
+			if (len(applied) > 0) != tt.applied {
+				t.Fatalf("applied = %v, want %v\nbody: %s", applied, tt.applied, m.Body)
+			}
+			if !tt.applied && string(m.Body) != tt.body {
+				t.Errorf("unapplied rule changed the body to %s", m.Body)
+			}
+			if tt.check != nil {
+				tt.check(t, m)
+			}
+		})
+	}
+}
+
+func TestGETQueryString(t *testing.T) {
+	e, err := Compile([]schema.RewriteRule{{
+		Enabled: true, Phase: PhaseRequest, Kind: KindVariable, Match: "id", Replace: "7",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(`https://api.example.com/graphql?query=%7B+a+%7D&variables=%7B%22id%22%3A1%7D`)
+	m := &Message{URL: u, Header: http.Header{}}
+	if applied := e.Request("api.example.com", m); len(applied) != 1 {
+		t.Fatalf("applied = %v", applied)
+	}
+	if got := m.URL.Query().Get("variables"); got != `{"id":7}` {
+		t.Errorf("variables = %s", got)
+	}
+}
+
+func TestHostAndOrder(t *testing.T) {
+	e, err := Compile([]schema.RewriteRule{
+		{Name: "first", Enabled: true, Phase: PhaseResponse, Kind: KindBody, Match: `"isAdmin":false`, Replace: `"isAdmin":true`},
+		{Name: "second", Enabled: true, Phase: PhaseResponse, Kind: KindJSON, Match: "data.me.isAdmin", Replace: "false"},
+		{Name: "other host", Enabled: true, Phase: PhaseResponse, Kind: KindHeader, Host: "*.other.com", Match: "X-A", Replace: "1"},
+		{Name: "disabled", Phase: PhaseResponse, Kind: KindHeader, Match: "X-B", Replace: "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.HasRequestRules() || !e.HasResponseRules() {
+		t.Error("rules filed under the wrong phase")
+	}
+	m := &Message{Header: http.Header{}, Body: []byte(`{"data":{"me":{"isAdmin":false}}}`)}
+	applied := e.Response("API.example.com", m)
+	if !reflect.DeepEqual(applied, []string{"first", "second"}) {
+		t.Errorf("applied = %v", applied)
+	}
+	if string(m.Body) != `{"data":{"me":{"isAdmin":false}}}` {
+		t.Errorf("body = %s", m.Body)
+	}
+	if len(m.Header) != 0 {
+		t.Errorf("headers = %v", m.Header)
+	}
+}
+
+func TestStreamedBody(t *testing.T) {
+	e, _ := Compile([]schema.RewriteRule{
+		{Enabled: true, Phase: PhaseResponse, Kind: KindBody, Match: "a", Replace: "b"},
+		{Enabled: true, Phase: PhaseResponse, Kind: KindHeader, Match: "X-A", Replace: "1"},
+	})
+	m := &Message{Header: http.Header{}}
+	if applied := e.Response("example.com", m); len(applied) != 1 || m.Body != nil {
+		t.Errorf("applied = %v, body = %q", applied, m.Body)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, r := range []schema.RewriteRule{
+		{Phase: "both", Kind: KindHeader, Match: "X-A"},
+		{Phase: PhaseResponse, Kind: KindVariable, Match: "id"},
+		{Phase: PhaseRequest, Kind: KindHeader, Match: "Bad Name"},
+		{Phase: PhaseRequest, Kind: KindHeader, Match: "X-A", Replace: "a\r\nX-B: b"},
+		{Phase: PhaseRequest, Kind: KindBody, Match: "("},
+		{Phase: PhaseRequest, Kind: KindJSON, Match: "a..b"},
+		{Phase: PhaseRequest, Kind: KindJSON, Match: "ids[x]"},
+		{Phase: PhaseRequest, Kind: KindOperationName, Replace: "not-a-name"},
+		{Phase: PhaseRequest, Kind: KindAddField, Match: "me", Replace: "{"},
+		{Phase: PhaseRequest, Kind: KindRemoveDirective, Match: "@"},
+		{Phase: PhaseRequest, Kind: "cookie", Match: "a"},
+		{Phase: PhaseRequest, Kind: KindHeader, Host: "[", Match: "X-A"},
+	} {
+		if err := Validate(r); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want error", r)
+		}
+	}
+}
@@ -33,6 +33,7 @@ type Schema struct {
 	ID               string       `json:"id"`
 	Name             string       `json:"name"`
 	Source           SchemaSource `json:"source"`
+	Description      string       `json:"description,omitempty"`
 	QueryType        string       `json:"queryType"`
 	MutationType     string       `json:"mutationType,omitempty"`
 	SubscriptionType string       `json:"subscriptionType,omitempty"`
@@ -43,14 +44,15 @@ type Schema struct {
 
 // Type represents a GraphQL type (object, enum, scalar, input, interface, union).
 type Type struct {
-	Name          string      `json:"name"`
-	Kind          TypeKind    `json:"kind"`
-	Description   string      `json:"description,omitempty"`
-	Fields        []Field     `json:"fields,omitempty"`
-	InputFields   []Field     `json:"inputFields,omitempty"`
-	EnumValues    []EnumValue `json:"enumValues,omitempty"`
-	Interfaces    []string    `json:"interfaces,omitempty"`
-	PossibleTypes []string    `json:"possibleTypes,omitempty"`
+	Name           string      `json:"name"`
+	Kind           TypeKind    `json:"kind"`
+	Description    string      `json:"description,omitempty"`
+	Fields         []Field     `json:"fields,omitempty"`
+	InputFields    []Field     `json:"inputFields,omitempty"`
+	EnumValues     []EnumValue `json:"enumValues,omitempty"`
+	Interfaces     []string    `json:"interfaces,omitempty"`
+	PossibleTypes  []string    `json:"possibleTypes,omitempty"`
+	SpecifiedByURL string      `json:"specifiedByURL,omitempty"` // custom scalars only
 }
 
 // Field represents a field on a GraphQL type.
@@ -59,6 +61,7 @@ type Field struct {
 	Description       string     `json:"description,omitempty"`
 	Type              TypeRef    `json:"type"`
 	Args              []Argument `json:"args,omitempty"`
+	DefaultValue      *string    `json:"defaultValue,omitempty"` // input fields only
 	IsDeprecated      bool       `json:"isDeprecated,omitempty"`
 	DeprecationReason string     `json:"deprecationReason,omitempty"`
 }
@@ -131,10 +134,12 @@ func (t TypeRef) Signature() string {
 
 // Argument represents a field or directive argument.
 type Argument struct {
-	Name         string  `json:"name"`
-	Description  string  `json:"description,omitempty"`
-	Type         TypeRef `json:"type"`
-	DefaultValue *string `json:"defaultValue,omitempty"`
+	Name              string  `json:"name"`
+	Description       string  `json:"description,omitempty"`
+	Type              TypeRef `json:"type"`
+	DefaultValue      *string `json:"defaultValue,omitempty"`
+	IsDeprecated      bool    `json:"isDeprecated,omitempty"`
+	DeprecationReason string  `json:"deprecationReason,omitempty"`
 }
 
 // IsRequired returns true if the argument is non-null and has no default value.
@@ -152,10 +157,11 @@ type EnumValue struct {
 
 // Directive represents a GraphQL directive.
 type Directive struct {
-	Name        string     `json:"name"`
-	Description string     `json:"description,omitempty"`
-	Locations   []string   `json:"locations,omitempty"`
-	Args        []Argument `json:"args,omitempty"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description,omitempty"`
+	Locations    []string   `json:"locations,omitempty"`
+	Args         []Argument `json:"args,omitempty"`
+	IsRepeatable bool       `json:"isRepeatable,omitempty"`
 }
 
 // Operation represents a single query, mutation, or subscription operation.
@@ -211,13 +217,42 @@ type GraphLink struct {
 
 // Project represents a proxy capture session with associated traffic and optional inferred schema.
 type Project struct {
-	ID           string    `json:"id"`
-	Name         string    `json:"name"`
-	ProxyAddr    string    `json:"proxyAddr,omitempty"`
-	SchemaID     *string   `json:"schemaId,omitempty"`
-	TrafficCount int       `json:"trafficCount"`
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	ProxyAddr string `json:"proxyAddr,omitempty"`
+	// UpstreamProxy is the proxy outbound traffic is chained through while
+	// this project is linked; empty connects directly.
+	UpstreamProxy string    `json:"upstreamProxy,omitempty"`
+	SchemaID      *string   `json:"schemaId,omitempty"`
+	TrafficCount  int       `json:"trafficCount"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// TLSProfile is a project's TLS settings towards its targets: a client
+// certificate for mutual TLS, as PEM certificate and key or a PKCS#12
+// bundle, extra CA certificates to trust, and whether server certificates
+// are verified, with hosts exempt from verification.
+type TLSProfile struct {
+	ClientCert      string   `json:"clientCert,omitempty"`
+	ClientKey       string   `json:"clientKey,omitempty"`
+	PKCS12          []byte   `json:"pkcs12,omitempty"`
+	PKCS12Password  string   `json:"pkcs12Password,omitempty"`
+	RootCAs         string   `json:"rootCAs,omitempty"`
+	Verify          bool     `json:"verify"`
+	SkipVerifyHosts []string `json:"skipVerifyHosts,omitempty"`
+}
+
+// ScopeRule limits which proxied traffic is stored for a project. Kind is
+// "host" (glob), "path" (regular expression), or "operation" (glob over the
+// operation name). Exclude rules take precedence over include rules.
+type ScopeRule struct {
+	ID        string    `json:"id"`
+	ProjectID string    `json:"projectId"`
+	Kind      string    `json:"kind"`
+	Pattern   string    `json:"pattern"`
+	Exclude   bool      `json:"exclude"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 // CapturedRequest holds proxy-captured GraphQL traffic.
@@ -237,6 +272,230 @@ type CapturedRequest struct {
 	ClusterID     *string           `json:"clusterId,omitempty"`
 	SchemaID      *string           `json:"schemaId,omitempty"`
 	ProjectID     *string           `json:"projectId,omitempty"`
+	// Transport is "websocket" or "sse" for streamed operations and
+	// "multipart" for @defer/@stream incremental responses, empty for plain
+	// HTTP. Streamed operations carry their messages or parts in Messages.
+	Transport string          `json:"transport,omitempty"`
+	Messages  []StreamMessage `json:"messages,omitempty"`
+	// BatchID groups the operations of one batched HTTP request; BatchIndex
+	// is the operation's position in the batch array.
+	BatchID    string `json:"batchId,omitempty"`
+	BatchIndex int    `json:"batchIndex,omitempty"`
+	// Protocol is the HTTP version the target answered with, e.g.
+	// "HTTP/1.1" or "HTTP/2.0", or the client's when the response has none.
+	Protocol string `json:"protocol,omitempty"`
+	// RewrittenBy lists the match-and-replace rules that changed the
+	// exchange. The capture holds the rewritten request and response;
+	// Original holds them as they were.
+	RewrittenBy []string          `json:"rewrittenBy,omitempty"`
+	Original    *OriginalExchange `json:"original,omitempty"`
+	// PersistedHash is the Apollo APQ sha256Hash the request carried.
+	PersistedHash string `json:"persistedHash,omitempty"`
+	// RequestBody is the raw body as sent, ResponseHeaders the response's
+	// headers, and DurationMs the time from sending the request to having
+	// read the response. Only Get and ListByProjectFull load them. The body
+	// is kept as bytes, base64 in JSON, since file uploads are binary.
+	RequestBody     []byte            `json:"requestBody,omitempty"`
+	ResponseHeaders map[string]string `json:"responseHeaders,omitempty"`
+	DurationMs      int64             `json:"durationMs,omitempty"`
+	// Role is the auth-role label of the listener that captured the request.
+	Role string `json:"role,omitempty"`
+	// TLSVersion and TLSCipher are what the proxy negotiated with the
+	// target, e.g. "TLS 1.3" and "TLS_AES_128_GCM_SHA256"; empty over
+	// plain HTTP.
+	TLSVersion string `json:"tlsVersion,omitempty"`
+	TLSCipher  string `json:"tlsCipher,omitempty"`
+	// Uploads are the files of a multipart file upload request. Their
+	// contents are only kept in RequestBody when upload retention allows.
+	Uploads []UploadedFile `json:"uploads,omitempty"`
+}
+
+// UploadedFile is a file part of a GraphQL multipart request
+// (graphql-multipart-request-spec). Paths are the variable paths the
+// request's map assigns it to, e.g. "variables.file", or
+// "0.variables.files.1" in a batch.
+type UploadedFile struct {
+	Field       string   `json:"field"`
+	Paths       []string `json:"paths,omitempty"`
+	FileName    string   `json:"fileName,omitempty"`
+	ContentType string   `json:"contentType,omitempty"`
+	Size        int64    `json:"size"`
+	SHA256      string   `json:"sha256"`
+}
+
+// PersistedQuery maps an Apollo APQ hash to the query text a client sent
+// with it to Host, in the project ProjectID ("" outside any project).
+// Verified reports whether the hash is the SHA-256 of the text.
+type PersistedQuery struct {
+	Hash          string    `json:"hash"`
+	Query         string    `json:"query"`
+	OperationName string    `json:"operationName,omitempty"`
+	Host          string    `json:"host,omitempty"`
+	ProjectID     string    `json:"projectId,omitempty"`
+	Verified      bool      `json:"verified"`
+	FirstSeen     time.Time `json:"firstSeen"`
+}
+
+// APQResult is the outcome of one step of the persisted query check.
+type APQResult struct {
+	Technique   string `json:"technique"`
+	Description string `json:"description"`
+	Payload     string `json:"payload"`
+	Vulnerable  bool   `json:"vulnerable"`
+	Finding     string `json:"finding"`
+	StatusCode  int    `json:"statusCode,omitempty"`
+	Response    string `json:"response,omitempty"`
+}
+
+// BundleOperation is a GraphQL operation or fragment found in a JavaScript
+// bundle. DocID is the persisted query ID (doc_id, query_hash, or APQ hash)
+// the bundle sends it under, when it says so; Text may be empty for
+// persisted builds that only ship the ID and name.
+type BundleOperation struct {
+	ID        string    `json:"id"`
+	ProjectID string    `json:"projectId,omitempty"`
+	Kind      string    `json:"kind"` // query, mutation, subscription, fragment, or "" if unknown
+	Name      string    `json:"name,omitempty"`
+	DocID     string    `json:"docId,omitempty"`
+	Text      string    `json:"text,omitempty"`
+	Source    string    `json:"source"` // bundle URL or uploaded file name
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+// OriginalExchange is a captured exchange before match-and-replace rules
+// changed it. Only the side a rule changed is set.
+type OriginalExchange struct {
+	Request  *OriginalMessage `json:"request,omitempty"`
+	Response *OriginalMessage `json:"response,omitempty"`
+}
+
+// OriginalMessage is an unmodified request or response. URL is empty for
+// responses.
+type OriginalMessage struct {
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// RewriteRule is a match-and-replace rule applied to proxied traffic. Phase
+// is "request" or "response"; Match and Replace are interpreted per Kind.
+// Host is an optional glob limiting the rule to matching hosts.
+type RewriteRule struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name,omitempty"`
+	Enabled   bool      `json:"enabled"`
+	Phase     string    `json:"phase"`
+	Kind      string    `json:"kind"`
+	Host      string    `json:"host,omitempty"`
+	Match     string    `json:"match"`
+	Replace   string    `json:"replace"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// InterceptSettings controls which proxied GraphQL requests, and optionally
+// their responses, are held for a manual decision. Hosts and Operations are
+// globs; an empty list matches everything. A message nobody decides on
+// within TimeoutSeconds is forwarded unchanged, or dropped when OnTimeout is
+// "drop".
+type InterceptSettings struct {
+	Requests       bool     `json:"requests"`
+	Responses      bool     `json:"responses"`
+	Hosts          []string `json:"hosts"`
+	Operations     []string `json:"operations"`
+	TimeoutSeconds int      `json:"timeoutSeconds"`
+	OnTimeout      string   `json:"onTimeout"`
+}
+
+// Proxy listener kinds.
+const (
+	ListenerMITM    = "mitm"    // HTTP proxy with CONNECT interception
+	ListenerReverse = "reverse" // reverse proxy pinned to Target
+)
+
+// ProxyListener is a listener run alongside the main proxy. Its traffic is
+// captured into ProjectID, or into the main proxy's linked project when
+// empty, and tagged with Role, e.g. the account a browser profile is
+// logged in as.
+type ProxyListener struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Addr      string    `json:"addr"`
+	Target    string    `json:"target,omitempty"`
+	ProjectID string    `json:"projectId,omitempty"`
+	Role      string    `json:"role,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// CAInfo describes the CA certificate the proxy signs host certificates
+// with. Leaves counts the host certificates stored for reuse.
+type CAInfo struct {
+	Subject   string    `json:"subject"`
+	SHA256    string    `json:"sha256"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	Path      string    `json:"path"`
+	Leaves    int       `json:"leaves"`
+}
+
+// InterceptedMessage is a request or response waiting for a decision. URL
+// and Method are set for requests, StatusCode for responses.
+type InterceptedMessage struct {
+	ID            string            `json:"id"`
+	Phase         string            `json:"phase"` // "request" or "response"
+	Timestamp     time.Time         `json:"timestamp"`
+	Deadline      time.Time         `json:"deadline"`
+	Method        string            `json:"method,omitempty"`
+	URL           string            `json:"url,omitempty"`
+	Host          string            `json:"host"`
+	OperationName string            `json:"operationName,omitempty"`
+	StatusCode    int               `json:"statusCode,omitempty"`
+	Headers       map[string]string `json:"headers"`
+	Body          string            `json:"body"`
+}
+
+// InterceptDecision resolves an intercepted message. Action is "forward" or
+// "drop". When Edit is set the message is forwarded with the given method,
+// URL, headers, and body (requests) or status code, headers, and body
+// (responses) instead of the original ones.
+type InterceptDecision struct {
+	Action     string            `json:"action"`
+	Edit       bool              `json:"edit"`
+	Method     string            `json:"method,omitempty"`
+	URL        string            `json:"url,omitempty"`
+	StatusCode int               `json:"statusCode,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+}
+
+// StreamMessage is one message of a streamed operation: a GraphQL-over-
+// WebSocket message, a server-sent event, or a part of an incremental
+// delivery response.
+type StreamMessage struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Direction string          `json:"direction"` // "client" or "server"
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+// ReplayAttempt is one request sent from the replay workbench. Every attempt
+// links back to the captured request it was derived from and, when it was
+// edited from an earlier attempt, to that attempt via ParentID.
+type ReplayAttempt struct {
+	ID              string            `json:"id"`
+	TrafficID       string            `json:"trafficId"`
+	ParentID        *string           `json:"parentId,omitempty"`
+	Timestamp       time.Time         `json:"timestamp"`
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	OperationName   string            `json:"operationName,omitempty"`
+	Query           string            `json:"query"`
+	Variables       json.RawMessage   `json:"variables,omitempty"`
+	ResponseCode    int               `json:"responseCode,omitempty"`
+	ResponseHeaders map[string]string `json:"responseHeaders,omitempty"`
+	ResponseBody    string            `json:"responseBody,omitempty"`
+	DurationMs      int64             `json:"durationMs"`
+	Error           string            `json:"error,omitempty"`
 }
 
 // DepthResult contains query depth analysis output.
@@ -255,6 +514,33 @@ type ComplexityResult struct {
 	Risk          string  `json:"risk"` // "low", "medium", "high", "critical"
 }
 
+// DoSResult is the outcome of one active DoS technique. Steps record each
+// payload size sent, smallest first, until the server refused one, slowed
+// down past the latency limit, failed, or the safe maximum was reached.
+type DoSResult struct {
+	Technique   string    `json:"technique"`
+	Description string    `json:"description"`
+	BaselineMs  int64     `json:"baselineMs"`
+	Steps       []DoSStep `json:"steps"`
+	MaxAccepted int       `json:"maxAccepted"`       // largest size the server executed
+	LimitAt     int       `json:"limitAt,omitempty"` // smallest size the server refused
+	StopReason  string    `json:"stopReason"`        // "limit", "rejected", "latency", "failure", "max_size", "payload_size", "budget"
+	Vulnerable  bool      `json:"vulnerable"`
+	Severity    string    `json:"severity,omitempty"` // "medium", "high", "critical"
+	Evidence    string    `json:"evidence,omitempty"`
+}
+
+// DoSStep is a single DoS probe of a given size (aliases, batch length,
+// depth, duplicates, directives, or fragments in the cycle).
+type DoSStep struct {
+	Size       int    `json:"size"`
+	Bytes      int    `json:"bytes"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Outcome    string `json:"outcome"` // "accepted", "limited", "rejected", "failed", "timeout"
+	Message    string `json:"message,omitempty"`
+}
+
 // IDORCandidate represents a potential IDOR vulnerability.
 type IDORCandidate struct {
 	FieldName      string   `json:"fieldName"`
@@ -307,12 +593,89 @@ type FieldChange struct {
 	Breaking bool   `json:"breaking"`
 }
 
+// AuthContext is a named set of credentials registered on a project, such
+// as "admin", "user", or "anonymous". Privilege ranks contexts against each
+// other; the highest is the reference the others are compared with.
+type AuthContext struct {
+	ID        string            `json:"id"`
+	ProjectID string            `json:"projectId"`
+	Name      string            `json:"name"`
+	Privilege int               `json:"privilege"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Cookies   map[string]string `json:"cookies,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
 // RBACEntry maps an auth context to its observed permissions.
+// Operations lists those that returned data; FlaggedOps lists those whose
+// result looks like broken access control compared with the
+// highest-privilege context.
 type RBACEntry struct {
-	AuthToken  string   `json:"authToken"`
-	Role       string   `json:"role,omitempty"`
-	Operations []string `json:"operations"`
-	DeniedOps  []string `json:"deniedOps,omitempty"`
+	ID         string    `json:"id,omitempty"`
+	RunID      string    `json:"runId,omitempty"`
+	ProjectID  string    `json:"projectId,omitempty"`
+	ContextID  string    `json:"contextId,omitempty"`
+	AuthToken  string    `json:"authToken"`
+	Role       string    `json:"role,omitempty"`
+	Privilege  int       `json:"privilege"`
+	Operations []string  `json:"operations"`
+	DeniedOps  []string  `json:"deniedOps,omitempty"`
+	ErroredOps []string  `json:"erroredOps,omitempty"`
+	FlaggedOps []string  `json:"flaggedOps,omitempty"`
+	CreatedAt  time.Time `json:"createdAt,omitempty"`
+}
+
+// InjectionFinding is an anomalous response to an injection payload placed
+// in one argument of a generated operation, measured against a benign
+// baseline request for the same operation.
+type InjectionFinding struct {
+	ID             string    `json:"id"`
+	SchemaID       string    `json:"schemaId"`
+	RunID          string    `json:"runId"`
+	Operation      string    `json:"operation"`
+	Kind           string    `json:"kind"`     // "query" or "mutation"
+	Argument       string    `json:"argument"` // dotted path, e.g. "input.email"
+	ArgType        string    `json:"argType"`
+	PayloadClass   string    `json:"payloadClass"`
+	Payload        string    `json:"payload"`
+	Anomalies      []string  `json:"anomalies"` // e.g. "signature:sql", "status", "timing", "size"
+	StatusCode     int       `json:"statusCode"`
+	DurationMs     int64     `json:"durationMs"`
+	ResponseSize   int       `json:"responseSize"`
+	BaselineStatus int       `json:"baselineStatus"`
+	BaselineMs     int64     `json:"baselineMs"`
+	BaselineSize   int       `json:"baselineSize"`
+	Evidence       string    `json:"evidence,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// UploadResult is one file the upload tester sent to an Upload argument.
+// Accepted means the operation returned data without errors; Vulnerable
+// that the file was accepted where it shouldn't be, caused a server error,
+// or came back unsanitized.
+type UploadResult struct {
+	Operation   string `json:"operation"`
+	Argument    string `json:"argument"` // dotted path, e.g. "input.avatar"
+	Case        string `json:"case"`     // "baseline", "filename", "content_type", "polyglot", "oversized"
+	Description string `json:"description"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType,omitempty"`
+	Size        int    `json:"size"`
+	StatusCode  int    `json:"statusCode,omitempty"`
+	DurationMs  int64  `json:"durationMs"`
+	Accepted    bool   `json:"accepted"`
+	Vulnerable  bool   `json:"vulnerable"`
+	Finding     string `json:"finding,omitempty"`
+	Response    string `json:"response,omitempty"`
+}
+
+// Wordlist is a named list of words stored in the database. Wordlists named
+// "injection:<class>" supply payloads to the injection fuzzer.
+type Wordlist struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Words     []string  `json:"words"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 // FuzzResult holds the result of a field fuzzing attempt.
@@ -0,0 +1,125 @@
+// Package scope decides which proxied traffic belongs to a project. Rules
+// only affect what is stored; out-of-scope requests are still forwarded.
+package scope
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// Rule kinds.
+const (
+	KindHost      = "host"      // glob over the host name, case-insensitive
+	KindPath      = "path"      // regular expression over the URL path
+	KindOperation = "operation" // glob over the operation name
+)
+
+// Scope is a compiled set of rules. A nil Scope allows everything.
+type Scope struct {
+	include map[string][]matcher
+	exclude map[string][]matcher
+}
+
+type matcher func(string) bool
+
+// Compile builds a Scope from rules, failing on the first invalid pattern.
+func Compile(rules []schema.ScopeRule) (*Scope, error) {
+	s := &Scope{
+		include: make(map[string][]matcher),
+		exclude: make(map[string][]matcher),
+	}
+	for _, rule := range rules {
+		m, err := compileRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		if rule.Exclude {
+			s.exclude[rule.Kind] = append(s.exclude[rule.Kind], m)
+		} else {
+			s.include[rule.Kind] = append(s.include[rule.Kind], m)
+		}
+	}
+	return s, nil
+}
+
+// Validate checks a single rule's kind and pattern.
+func Validate(rule schema.ScopeRule) error {
+	_, err := compileRule(rule)
+	return err
+}
+
+func compileRule(rule schema.ScopeRule) (matcher, error) {
+	if rule.Pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	switch rule.Kind {
+	case KindHost:
+		pattern := strings.ToLower(rule.Pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid host glob %q: %w", rule.Pattern, err)
+		}
+		return func(host string) bool {
+			ok, _ := path.Match(pattern, strings.ToLower(host))
+			return ok
+		}, nil
+	case KindPath:
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path regex %q: %w", rule.Pattern, err)
+		}
+		return re.MatchString, nil
+	case KindOperation:
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid operation glob %q: %w", rule.Pattern, err)
+		}
+		return func(name string) bool {
+			ok, _ := path.Match(rule.Pattern, name)
+			return ok
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown rule kind %q: must be host, path, or operation", rule.Kind)
+}
+
+// Allows reports whether a request to host and urlPath carrying the named
+// operation is in scope. Any matching exclude rule rejects it. For each kind
+// that has include rules, at least one of them must match; anonymous
+// operations never match an operation include rule.
+func (s *Scope) Allows(host, urlPath, operation string) bool {
+	if s == nil {
+		return true
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	values := map[string]string{
+		KindHost:      host,
+		KindPath:      urlPath,
+		KindOperation: operation,
+	}
+	for kind, value := range values {
+		if matchAny(s.exclude[kind], value) {
+			return false
+		}
+		if len(s.include[kind]) == 0 {
+			continue
+		}
+		if (kind == KindOperation && value == "") || !matchAny(s.include[kind], value) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(matchers []matcher, value string) bool {
+	for _, m := range matchers {
+		if m(value) {
+			return true
+		}
+	}
+	return false
+}
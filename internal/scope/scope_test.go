@@ -0,0 +1,104 @@
+package scope
+
+import (
+	"testing"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     []schema.ScopeRule
+		host      string
+		path      string
+		operation string
+		want      bool
+	}{
+		{name: "no rules", host: "api.example.com", path: "/graphql", want: true},
+		{
+			name:  "host include matches case-insensitively",
+			rules: []schema.ScopeRule{{Kind: KindHost, Pattern: "*.Example.com"}},
+			host:  "API.example.com:443", path: "/graphql", want: true,
+		},
+		{
+			name:  "host include rejects other hosts",
+			rules: []schema.ScopeRule{{Kind: KindHost, Pattern: "*.example.com"}},
+			host:  "stats.tracker.io", path: "/graphql", want: false,
+		},
+		{
+			name: "exclude wins over include",
+			rules: []schema.ScopeRule{
+				{Kind: KindHost, Pattern: "*.example.com"},
+				{Kind: KindHost, Pattern: "beacon.example.com", Exclude: true},
+			},
+			host: "beacon.example.com", path: "/graphql", want: false,
+		},
+		{
+			name:  "path regex",
+			rules: []schema.ScopeRule{{Kind: KindPath, Pattern: `^/(api/)?graphql$`}},
+			host:  "example.com", path: "/api/graphql", want: true,
+		},
+		{
+			name:  "path regex rejects REST calls",
+			rules: []schema.ScopeRule{{Kind: KindPath, Pattern: `^/(api/)?graphql$`}},
+			host:  "example.com", path: "/api/users", want: false,
+		},
+		{
+			name:  "operation exclude",
+			rules: []schema.ScopeRule{{Kind: KindOperation, Pattern: "Track*", Exclude: true}},
+			host:  "example.com", path: "/graphql", operation: "TrackEvent", want: false,
+		},
+		{
+			name:  "anonymous operation passes without operation includes",
+			rules: []schema.ScopeRule{{Kind: KindOperation, Pattern: "Track*", Exclude: true}},
+			host:  "example.com", path: "/graphql", want: true,
+		},
+		{
+			name:  "anonymous operation never matches an include",
+			rules: []schema.ScopeRule{{Kind: KindOperation, Pattern: "*"}},
+			host:  "example.com", path: "/graphql", want: false,
+		},
+		{
+			name: "every kind with includes must match",
+			rules: []schema.ScopeRule{
+				{Kind: KindHost, Pattern: "example.com"},
+				{Kind: KindOperation, Pattern: "Get*"},
+			},
+			host: "example.com", path: "/graphql", operation: "SetUser", want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Compile(tt.rules)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if got := s.Allows(tt.host, tt.path, tt.operation); got != tt.want {
+				t.Errorf("Allows(%q, %q, %q) = %v, want %v", tt.host, tt.path, tt.operation, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilScopeAllows(t *testing.T) {
+	var s *Scope
+	if !s.Allows("example.com", "/", "") {
+		t.Error("nil Scope rejected a request")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, rule := range []schema.ScopeRule{
+		{Kind: KindHost},
+		{Kind: KindHost, Pattern: "[a-"},
+		{Kind: KindPath, Pattern: "("},
+		{Kind: KindOperation, Pattern: "[x"},
+		{Kind: "cookie", Pattern: "a"},
+	} {
+		if err := Validate(rule); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want error", rule)
+		}
+	}
+}
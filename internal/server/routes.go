@@ -25,15 +25,23 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /projects", h.ProjectsList)
 	mux.HandleFunc("GET /projects/{id}", h.ProjectDetail)
 	mux.HandleFunc("GET /analysis/{id}", h.AnalysisView)
+	mux.HandleFunc("GET /replay/{id}", h.ReplayView)
+	mux.HandleFunc("GET /subscriptions", h.SubscriptionsView)
+	mux.HandleFunc("GET /rewrite", h.RewriteView)
 
 	// API — Introspection
 	mux.HandleFunc("POST /api/introspection", h.IntrospectionParse)
+	mux.HandleFunc("POST /api/introspection/fetch", h.IntrospectionFetch)
+
+	// API — SDL
+	mux.HandleFunc("POST /api/sdl", h.SDLImport)
 
 	// API — Schema
 	mux.HandleFunc("GET /api/schemas", h.SchemaList)
 	mux.HandleFunc("GET /api/schema/{id}", h.SchemaDetail)
 	mux.HandleFunc("GET /api/schema/{id}/graph-data", h.SchemaGraphData)
 	mux.HandleFunc("GET /api/schema/{id}/operations", h.SchemaOperations)
+	mux.HandleFunc("GET /api/schema/{id}/sdl", h.SchemaSDL)
 	mux.HandleFunc("DELETE /api/schema/{id}", h.SchemaDelete)
 
 	// API — Query Generator
@@ -46,13 +54,57 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/proxy/status", h.ProxyStatus)
 	mux.HandleFunc("DELETE /api/proxy/traffic", h.ProxyClearTraffic)
 	mux.HandleFunc("GET /api/proxy/sse", h.ProxySSE)
+	mux.HandleFunc("GET /api/proxy/traffic/{id}", h.TrafficGet)
+	mux.HandleFunc("GET /api/proxy/traffic/{id}/messages", h.TrafficMessages)
+
+	// API — Subscriptions
+	mux.HandleFunc("POST /api/subscription", h.SubscriptionRun)
+
+	// API — Replay
+	mux.HandleFunc("GET /api/replay/{id}", h.ReplayDetail)
+	mux.HandleFunc("POST /api/replay/{id}/send", h.ReplaySend)
+	mux.HandleFunc("GET /api/replay/{id}/attempts/{attemptID}", h.ReplayAttemptDiff)
 
 	// API — Projects
 	mux.HandleFunc("GET /api/projects", h.ProjectListAPI)
 	mux.HandleFunc("POST /api/projects", h.ProjectCreate)
 	mux.HandleFunc("DELETE /api/projects/{id}", h.ProjectDelete)
 	mux.HandleFunc("POST /api/projects/{id}/infer-schema", h.ProjectInferSchema)
+	mux.HandleFunc("POST /api/projects/{id}/idor", h.ProjectIDORTest)
+	mux.HandleFunc("GET /api/projects/{id}/auth-contexts", h.AuthContextList)
+	mux.HandleFunc("POST /api/projects/{id}/auth-contexts", h.AuthContextCreate)
+	mux.HandleFunc("DELETE /api/projects/{id}/auth-contexts/{ctxID}", h.AuthContextDelete)
+	mux.HandleFunc("GET /api/projects/{id}/rbac", h.RBACMatrix)
+	mux.HandleFunc("POST /api/projects/{id}/rbac/run", h.RBACRun)
+	mux.HandleFunc("GET /api/projects/{id}/scope", h.ScopeRuleList)
+	mux.HandleFunc("POST /api/projects/{id}/scope", h.ScopeRuleCreate)
+	mux.HandleFunc("DELETE /api/projects/{id}/scope/{ruleID}", h.ScopeRuleDelete)
+	mux.HandleFunc("GET /api/projects/{id}/bundles", h.BundleList)
+	mux.HandleFunc("POST /api/projects/{id}/bundles", h.BundleUpload)
+	mux.HandleFunc("GET /api/projects/{id}/har", h.HARExport)
+	mux.HandleFunc("POST /api/projects/{id}/har", h.HARImport)
+	mux.HandleFunc("GET /api/projects/{id}/tls", h.ProjectTLS)
+	mux.HandleFunc("POST /api/projects/{id}/tls", h.ProjectSetTLS)
+	mux.HandleFunc("DELETE /api/projects/{id}/tls", h.ProjectDeleteTLS)
 	mux.HandleFunc("POST /api/proxy/project", h.ProxySetProject)
+	mux.HandleFunc("GET /api/proxy/listeners", h.ListenerList)
+	mux.HandleFunc("POST /api/proxy/listeners", h.ListenerStart)
+	mux.HandleFunc("DELETE /api/proxy/listeners/{id}", h.ListenerStop)
+	mux.HandleFunc("GET /api/proxy/upstream", h.ProxyUpstream)
+	mux.HandleFunc("POST /api/proxy/upstream", h.ProxySetUpstream)
+	mux.HandleFunc("GET /api/proxy/ca", h.ProxyCA)
+	mux.HandleFunc("GET /api/proxy/ca/download", h.ProxyCADownload)
+	mux.HandleFunc("POST /api/proxy/ca/download", h.ProxyCADownload)
+	mux.HandleFunc("POST /api/proxy/ca/rotate", h.ProxyCARotate)
+	mux.HandleFunc("GET /api/proxy/intercept", h.InterceptState)
+	mux.HandleFunc("POST /api/proxy/intercept", h.InterceptSettingsSave)
+	mux.HandleFunc("POST /api/proxy/intercept/{id}", h.InterceptResolve)
+
+	// API — Match and replace
+	mux.HandleFunc("GET /api/rewrite", h.RewriteList)
+	mux.HandleFunc("POST /api/rewrite", h.RewriteCreate)
+	mux.HandleFunc("POST /api/rewrite/{id}/toggle", h.RewriteToggle)
+	mux.HandleFunc("DELETE /api/rewrite/{id}", h.RewriteDelete)
 
 	// API — Analysis
 	mux.HandleFunc("POST /api/analysis/run", h.RunAnalysis)
@@ -67,6 +119,29 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	// API — Bypass
 	mux.HandleFunc("POST /api/bypass", h.BypassIntrospection)
 
+	// API — Persisted queries
+	mux.HandleFunc("POST /api/apq", h.APQProbe)
+	mux.HandleFunc("GET /api/persisted-queries", h.PersistedQueries)
+
+	// API — DoS probes
+	mux.HandleFunc("POST /api/dos", h.DoSProbe)
+
+	// API — File upload tester
+	mux.HandleFunc("POST /api/uploads", h.UploadTest)
+
+	// API — Injection fuzzer
+	mux.HandleFunc("POST /api/injection", h.InjectionRun)
+	mux.HandleFunc("GET /api/injection/payloads", h.InjectionPayloads)
+	mux.HandleFunc("GET /api/injection/{schemaID}", h.InjectionFindings)
+
+	// API — Wordlists
+	mux.HandleFunc("GET /api/wordlists", h.WordlistList)
+	mux.HandleFunc("POST /api/wordlists", h.WordlistSave)
+	mux.HandleFunc("DELETE /api/wordlists/{id}", h.WordlistDelete)
+
+	// API — Schema recovery
+	mux.HandleFunc("POST /api/recovery", h.RecoverSchema)
+
 	// API — Diff
 	mux.HandleFunc("POST /api/diff", h.DiffSchemas)
 
@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	"sort"
 	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/parser"
 )
 
 // Fingerprint generates a structural hash of a GraphQL query string.
@@ -19,7 +21,84 @@ func Fingerprint(query string) string {
 }
 
 // normalizeQuery strips variable values and normalizes field ordering.
+// Queries that parse are normalized on the AST; anything else falls back to
+// a best-effort text normalization.
 func normalizeQuery(query string) string {
+	doc, err := parser.ParseDocument(query)
+	if err != nil {
+		return normalizeText(query)
+	}
+	for _, def := range doc.Definitions {
+		switch d := def.(type) {
+		case *parser.OperationDefinition:
+			for _, v := range d.VariableDefinitions {
+				if v.DefaultValue != nil {
+					v.DefaultValue = placeholderValue(v.DefaultValue)
+				}
+			}
+			d.SelectionSet = normalizeSelections(d.SelectionSet)
+		case *parser.FragmentDefinition:
+			d.SelectionSet = normalizeSelections(d.SelectionSet)
+		}
+	}
+	return normalizeWhitespace(parser.Print(doc))
+}
+
+// normalizeSelections drops aliases, blanks out literal arguments, and sorts
+// each selection set so that equivalent queries print identically.
+func normalizeSelections(sels []parser.Selection) []parser.Selection {
+	for _, sel := range sels {
+		switch s := sel.(type) {
+		case *parser.Field:
+			s.Alias = ""
+			for _, arg := range s.Arguments {
+				arg.Value = placeholderValue(arg.Value)
+			}
+			s.SelectionSet = normalizeSelections(s.SelectionSet)
+		case *parser.InlineFragment:
+			s.SelectionSet = normalizeSelections(s.SelectionSet)
+		}
+	}
+	sort.SliceStable(sels, func(i, j int) bool {
+		return selectionKey(sels[i]) < selectionKey(sels[j])
+	})
+	return sels
+}
+
+func selectionKey(sel parser.Selection) string {
+	switch s := sel.(type) {
+	case *parser.Field:
+		return s.Name
+	case *parser.FragmentSpread:
+		return "..." + s.Name
+	case *parser.InlineFragment:
+		return "... on " + s.TypeCondition
+	}
+	return ""
+}
+
+// placeholderValue replaces literal values with a fixed placeholder per kind,
+// keeping variables, enums, and the shape of lists and objects.
+func placeholderValue(v parser.Value) parser.Value {
+	switch v := v.(type) {
+	case *parser.StringValue:
+		return &parser.StringValue{Position: v.Position}
+	case *parser.IntValue, *parser.FloatValue:
+		return &parser.IntValue{Raw: "0", Position: v.Pos()}
+	case *parser.ListValue:
+		for i, item := range v.Values {
+			v.Values[i] = placeholderValue(item)
+		}
+	case *parser.ObjectValue:
+		for _, f := range v.Fields {
+			f.Value = placeholderValue(f.Value)
+		}
+	}
+	return v
+}
+
+// normalizeText is the fallback normalizer for queries that do not parse.
+func normalizeText(query string) string {
 	query = strings.TrimSpace(query)
 
 	// Remove comments
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// persistedPlaceholders are the query prefixes the proxy stores for
+// persisted queries whose text it doesn't know, followed by the ID.
+var persistedPlaceholders = []string{
+	"# persisted query doc_id=",
+	"# persisted query query_hash=",
+	"# persisted query sha256Hash=",
+}
+
+// BundleRepo handles persistence of operations found in JavaScript bundles.
+type BundleRepo struct {
+	db *DB
+}
+
+// NewBundleRepo creates a new bundle operation repository.
+func NewBundleRepo(db *DB) *BundleRepo {
+	return &BundleRepo{db: db}
+}
+
+// Save stores operations, one row per persisted query ID or per kind and
+// name within a project. An operation seen again keeps its first ID and
+// source, and gains a name or text it was missing. It returns the number of
+// operations not seen before.
+func (r *BundleRepo) Save(ops []schema.BundleOperation) (int, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	added := 0
+	for _, op := range ops {
+		res, err := tx.Exec(
+			`INSERT OR IGNORE INTO bundle_operations (id, project_id, op_key, kind, name, doc_id, text, source, first_seen)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			op.ID, op.ProjectID, bundleKey(op), op.Kind, op.Name, op.DocID, op.Text, op.Source, op.FirstSeen,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("insert bundle operation: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			added++
+			continue
+		}
+		if _, err := tx.Exec(
+			`UPDATE bundle_operations SET
+			  kind = CASE WHEN kind = '' THEN ? ELSE kind END,
+			  name = CASE WHEN name = '' THEN ? ELSE name END,
+			  text = CASE WHEN text = '' THEN ? ELSE text END
+			 WHERE project_id = ? AND op_key = ?`,
+			op.Kind, op.Name, op.Text, op.ProjectID, bundleKey(op),
+		); err != nil {
+			return 0, fmt.Errorf("update bundle operation: %w", err)
+		}
+	}
+	return added, tx.Commit()
+}
+
+// bundleKey identifies an operation within a project: by persisted query
+// ID when it has one, otherwise by kind and name (and text, if anonymous).
+func bundleKey(op schema.BundleOperation) string {
+	if op.DocID != "" {
+		return "id:" + op.DocID
+	}
+	key := op.Kind + ":" + op.Name
+	if op.Name == "" {
+		key += ":" + op.Text
+	}
+	return key
+}
+
+// ListByProject returns a project's bundle operations in the order they
+// were found.
+func (r *BundleRepo) ListByProject(projectID string) ([]schema.BundleOperation, error) {
+	rows, err := r.db.conn.Query(
+		`SELECT id, project_id, kind, name, doc_id, text, source, first_seen
+		 FROM bundle_operations WHERE project_id = ? ORDER BY first_seen, rowid`, projectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list bundle operations: %w", err)
+	}
+	defer rows.Close()
+
+	var ops []schema.BundleOperation
+	for rows.Next() {
+		var op schema.BundleOperation
+		if err := rows.Scan(&op.ID, &op.ProjectID, &op.Kind, &op.Name, &op.DocID, &op.Text, &op.Source, &op.FirstSeen); err != nil {
+			return nil, fmt.Errorf("scan bundle operation: %w", err)
+		}
+		ops = append(ops, op)
+	}
+	return ops, rows.Err()
+}
+
+// Resolve returns the name and text of the operation a project's bundles
+// send under a persisted query ID. When the mapping only names the
+// operation, the text comes from a document of that name found elsewhere.
+// Both are empty if the ID is unknown.
+func (r *BundleRepo) Resolve(projectID, docID string) (name, text string, err error) {
+	err = r.db.conn.QueryRow(
+		`SELECT b.name, COALESCE(NULLIF(b.text, ''),
+		   (SELECT t.text FROM bundle_operations t
+		    WHERE t.project_id = b.project_id AND t.name = b.name AND t.name != '' AND t.text != '' LIMIT 1), '')
+		 FROM bundle_operations b WHERE b.project_id = ? AND b.doc_id = ?`,
+		projectID, docID,
+	).Scan(&name, &text)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	return name, text, err
+}
+
+// ResolveTraffic fills in the operation name and query text of a project's
+// captured persisted queries that its bundles map. It returns the number of
+// captures changed.
+func (r *BundleRepo) ResolveTraffic(projectID string) (int64, error) {
+	rows, err := r.db.conn.Query(
+		`SELECT id, query, COALESCE(operation_name, '') FROM traffic
+		 WHERE COALESCE(project_id, '') = ? AND query LIKE '# persisted query %'`, projectID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("list persisted captures: %w", err)
+	}
+	type capture struct{ id, query, opName string }
+	var captures []capture
+	for rows.Next() {
+		var c capture
+		if err := rows.Scan(&c.id, &c.query, &c.opName); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan persisted capture: %w", err)
+		}
+		captures = append(captures, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var changed int64
+	for _, c := range captures {
+		docID := placeholderID(c.query)
+		if docID == "" {
+			continue
+		}
+		name, text, err := r.Resolve(projectID, docID)
+		if err != nil {
+			return changed, err
+		}
+		query, opName := c.query, c.opName
+		if text != "" {
+			query = text
+		}
+		if opName == "" {
+			opName = name
+		}
+		if query == c.query && opName == c.opName {
+			continue
+		}
+		if _, err := r.db.conn.Exec(
+			"UPDATE traffic SET query = ?, operation_name = ?, fingerprint = NULL WHERE id = ?",
+			query, opName, c.id,
+		); err != nil {
+			return changed, fmt.Errorf("resolve persisted capture: %w", err)
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// placeholderID returns the persisted query ID from a placeholder query, or
+// "" if query isn't one.
+func placeholderID(query string) string {
+	for _, prefix := range persistedPlaceholders {
+		if strings.HasPrefix(query, prefix) {
+			return strings.TrimSpace(strings.TrimPrefix(query, prefix))
+		}
+	}
+	return ""
+}
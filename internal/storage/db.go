@@ -60,6 +60,21 @@ func (db *DB) migrate() error {
 	migrations := []string{
 		migrationV1,
 		migrationV2,
+		migrationV3,
+		migrationV4,
+		migrationV5,
+		migrationV6,
+		migrationV7,
+		migrationV8,
+		migrationV9,
+		migrationV10,
+		migrationV11,
+		migrationV12,
+		migrationV13,
+		migrationV14,
+		migrationV15,
+		migrationV16,
+		migrationV17,
 	}
 
 	// Create migration tracking table
@@ -181,3 +196,194 @@ ALTER TABLE traffic ADD COLUMN project_id TEXT;
 
 CREATE INDEX IF NOT EXISTS idx_traffic_project ON traffic(project_id);
 `
+
+const migrationV3 = `
+CREATE TABLE IF NOT EXISTS replay_attempts (
+	id TEXT PRIMARY KEY,
+	traffic_id TEXT NOT NULL REFERENCES traffic(id) ON DELETE CASCADE,
+	parent_id TEXT REFERENCES replay_attempts(id) ON DELETE SET NULL,
+	timestamp DATETIME NOT NULL,
+	method TEXT NOT NULL,
+	url TEXT NOT NULL,
+	headers_json TEXT,
+	operation_name TEXT,
+	query TEXT,
+	variables_json TEXT,
+	response_code INTEGER,
+	response_headers_json TEXT,
+	response_body BLOB,
+	duration_ms INTEGER,
+	error TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_replay_traffic ON replay_attempts(traffic_id);
+`
+
+const migrationV4 = `
+CREATE TABLE IF NOT EXISTS auth_contexts (
+	id TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	privilege INTEGER NOT NULL DEFAULT 0,
+	headers_json TEXT,
+	cookies_json TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_contexts_project ON auth_contexts(project_id);
+
+CREATE TABLE IF NOT EXISTS rbac_entries (
+	id TEXT PRIMARY KEY,
+	run_id TEXT NOT NULL,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	context_id TEXT REFERENCES auth_contexts(id) ON DELETE SET NULL,
+	role TEXT NOT NULL,
+	privilege INTEGER NOT NULL DEFAULT 0,
+	auth_token TEXT,
+	operations_json TEXT NOT NULL,
+	denied_ops_json TEXT,
+	errored_ops_json TEXT,
+	flagged_ops_json TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_rbac_entries_project ON rbac_entries(project_id, created_at);
+`
+
+const migrationV5 = `
+CREATE TABLE IF NOT EXISTS injection_findings (
+	id TEXT PRIMARY KEY,
+	run_id TEXT NOT NULL,
+	schema_id TEXT NOT NULL REFERENCES schemas(id) ON DELETE CASCADE,
+	operation TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	argument TEXT NOT NULL,
+	arg_type TEXT,
+	payload_class TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	anomalies_json TEXT NOT NULL,
+	status_code INTEGER,
+	duration_ms INTEGER,
+	response_size INTEGER,
+	baseline_status INTEGER,
+	baseline_ms INTEGER,
+	baseline_size INTEGER,
+	evidence TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_injection_findings_schema ON injection_findings(schema_id, created_at);
+`
+
+const migrationV6 = `
+ALTER TABLE traffic ADD COLUMN transport TEXT;
+
+CREATE TABLE IF NOT EXISTS stream_messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	traffic_id TEXT NOT NULL REFERENCES traffic(id) ON DELETE CASCADE,
+	timestamp DATETIME NOT NULL,
+	direction TEXT NOT NULL,
+	type TEXT,
+	payload TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_stream_messages_traffic ON stream_messages(traffic_id, id);
+`
+
+const migrationV7 = `
+ALTER TABLE traffic ADD COLUMN batch_id TEXT;
+ALTER TABLE traffic ADD COLUMN batch_index INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_traffic_batch ON traffic(batch_id);
+`
+
+const migrationV8 = `
+ALTER TABLE traffic ADD COLUMN protocol TEXT;
+`
+
+const migrationV9 = `
+ALTER TABLE projects ADD COLUMN upstream_proxy TEXT;
+`
+
+const migrationV10 = `
+CREATE TABLE IF NOT EXISTS scope_rules (
+	id TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	kind TEXT NOT NULL,
+	pattern TEXT NOT NULL,
+	exclude INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_scope_rules_project ON scope_rules(project_id);
+`
+
+const migrationV11 = `
+ALTER TABLE traffic ADD COLUMN rewritten_by TEXT;
+ALTER TABLE traffic ADD COLUMN original_json TEXT;
+
+CREATE TABLE IF NOT EXISTS rewrite_rules (
+	id TEXT PRIMARY KEY,
+	name TEXT,
+	enabled INTEGER NOT NULL DEFAULT 1,
+	phase TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	host TEXT,
+	match_pattern TEXT NOT NULL DEFAULT '',
+	replace_with TEXT NOT NULL DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+const migrationV12 = `
+ALTER TABLE traffic ADD COLUMN persisted_hash TEXT;
+CREATE INDEX IF NOT EXISTS idx_traffic_persisted_hash ON traffic(persisted_hash);
+
+CREATE TABLE IF NOT EXISTS persisted_queries (
+	project_id TEXT NOT NULL DEFAULT '',
+	host TEXT NOT NULL DEFAULT '',
+	hash TEXT NOT NULL,
+	query TEXT NOT NULL,
+	operation_name TEXT,
+	verified INTEGER NOT NULL DEFAULT 0,
+	first_seen DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (project_id, host, hash)
+);
+`
+
+const migrationV13 = `
+CREATE TABLE IF NOT EXISTS bundle_operations (
+	id TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL DEFAULT '',
+	op_key TEXT NOT NULL,
+	kind TEXT NOT NULL DEFAULT '',
+	name TEXT NOT NULL DEFAULT '',
+	doc_id TEXT NOT NULL DEFAULT '',
+	text TEXT NOT NULL DEFAULT '',
+	source TEXT NOT NULL DEFAULT '',
+	first_seen DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(project_id, op_key)
+);
+CREATE INDEX IF NOT EXISTS idx_bundle_operations_doc_id ON bundle_operations(project_id, doc_id);
+`
+
+const migrationV14 = `
+ALTER TABLE traffic ADD COLUMN request_body TEXT;
+ALTER TABLE traffic ADD COLUMN response_headers_json TEXT;
+ALTER TABLE traffic ADD COLUMN duration_ms INTEGER;
+`
+
+const migrationV15 = `
+ALTER TABLE traffic ADD COLUMN role TEXT;
+`
+
+const migrationV16 = `
+ALTER TABLE projects ADD COLUMN upstream_tls_json TEXT;
+ALTER TABLE traffic ADD COLUMN tls_version TEXT;
+ALTER TABLE traffic ADD COLUMN tls_cipher TEXT;
+`
+
+const migrationV17 = `
+ALTER TABLE traffic ADD COLUMN uploads_json TEXT;
+`
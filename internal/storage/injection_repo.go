@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// InjectionRepo handles injection fuzzer findings persistence.
+type InjectionRepo struct {
+	db *DB
+}
+
+// NewInjectionRepo creates a new injection findings repository.
+func NewInjectionRepo(db *DB) *InjectionRepo {
+	return &InjectionRepo{db: db}
+}
+
+// SaveFindings stores the findings of one fuzzer run in a single transaction.
+func (r *InjectionRepo) SaveFindings(findings []schema.InjectionFinding) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("begin save injection findings tx: %w", err)
+	}
+	for _, f := range findings {
+		anomalies, _ := json.Marshal(f.Anomalies)
+		if _, err := tx.Exec(
+			`INSERT INTO injection_findings (id, run_id, schema_id, operation, kind, argument, arg_type,
+			  payload_class, payload, anomalies_json, status_code, duration_ms, response_size,
+			  baseline_status, baseline_ms, baseline_size, evidence, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			f.ID, f.RunID, f.SchemaID, f.Operation, f.Kind, f.Argument, f.ArgType,
+			f.PayloadClass, f.Payload, string(anomalies), f.StatusCode, f.DurationMs, f.ResponseSize,
+			f.BaselineStatus, f.BaselineMs, f.BaselineSize, f.Evidence, f.CreatedAt,
+		); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("insert injection finding: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListBySchema returns a schema's findings, newest first.
+func (r *InjectionRepo) ListBySchema(schemaID string, limit int) ([]schema.InjectionFinding, error) {
+	rows, err := r.db.conn.Query(
+		`SELECT id, run_id, schema_id, operation, kind, argument, arg_type, payload_class, payload,
+		        anomalies_json, status_code, duration_ms, response_size,
+		        baseline_status, baseline_ms, baseline_size, evidence, created_at
+		 FROM injection_findings WHERE schema_id = ? ORDER BY created_at DESC, operation, argument LIMIT ?`,
+		schemaID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list injection findings: %w", err)
+	}
+	defer rows.Close()
+
+	var findings []schema.InjectionFinding
+	for rows.Next() {
+		var f schema.InjectionFinding
+		var argType, evidence sql.NullString
+		var anomalies string
+		var status, duration, size, baseStatus, baseMs, baseSize sql.NullInt64
+		if err := rows.Scan(
+			&f.ID, &f.RunID, &f.SchemaID, &f.Operation, &f.Kind, &f.Argument, &argType, &f.PayloadClass, &f.Payload,
+			&anomalies, &status, &duration, &size, &baseStatus, &baseMs, &baseSize, &evidence, &f.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan injection finding: %w", err)
+		}
+		f.ArgType = argType.String
+		f.Evidence = evidence.String
+		f.StatusCode = int(status.Int64)
+		f.DurationMs = duration.Int64
+		f.ResponseSize = int(size.Int64)
+		f.BaselineStatus = int(baseStatus.Int64)
+		f.BaselineMs = baseMs.Int64
+		f.BaselineSize = int(baseSize.Int64)
+		json.Unmarshal([]byte(anomalies), &f.Anomalies) //nolint:errcheck
+		findings = append(findings, f)
+	}
+	return findings, rows.Err()
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -21,9 +22,9 @@ func NewProjectRepo(db *DB) *ProjectRepo {
 // Create stores a new project.
 func (r *ProjectRepo) Create(p *schema.Project) error {
 	_, err := r.db.conn.Exec(
-		`INSERT INTO projects (id, name, proxy_addr, schema_id, traffic_count, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		p.ID, p.Name, p.ProxyAddr, p.SchemaID, p.TrafficCount, p.CreatedAt, p.UpdatedAt,
+		`INSERT INTO projects (id, name, proxy_addr, upstream_proxy, schema_id, traffic_count, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.ID, p.Name, p.ProxyAddr, p.UpstreamProxy, p.SchemaID, p.TrafficCount, p.CreatedAt, p.UpdatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("create project: %w", err)
@@ -35,7 +36,7 @@ func (r *ProjectRepo) Create(p *schema.Project) error {
 // Traffic counts are computed live via a subquery.
 func (r *ProjectRepo) List() ([]schema.Project, error) {
 	rows, err := r.db.conn.Query(`
-		SELECT p.id, p.name, p.proxy_addr, p.schema_id,
+		SELECT p.id, p.name, p.proxy_addr, p.upstream_proxy, p.schema_id,
 		       (SELECT COUNT(*) FROM traffic WHERE project_id = p.id) AS traffic_count,
 		       p.created_at, p.updated_at
 		FROM projects p
@@ -48,13 +49,14 @@ func (r *ProjectRepo) List() ([]schema.Project, error) {
 	var projects []schema.Project
 	for rows.Next() {
 		var p schema.Project
-		var proxyAddr, schemaID sql.NullString
-		if err := rows.Scan(&p.ID, &p.Name, &proxyAddr, &schemaID, &p.TrafficCount, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		var proxyAddr, upstreamProxy, schemaID sql.NullString
+		if err := rows.Scan(&p.ID, &p.Name, &proxyAddr, &upstreamProxy, &schemaID, &p.TrafficCount, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("scan project: %w", err)
 		}
 		if proxyAddr.Valid {
 			p.ProxyAddr = proxyAddr.String
 		}
+		p.UpstreamProxy = upstreamProxy.String
 		if schemaID.Valid {
 			s := schemaID.String
 			p.SchemaID = &s
@@ -67,14 +69,14 @@ func (r *ProjectRepo) List() ([]schema.Project, error) {
 // Get retrieves a project by ID with live traffic count.
 func (r *ProjectRepo) Get(id string) (*schema.Project, error) {
 	var p schema.Project
-	var proxyAddr, schemaID sql.NullString
+	var proxyAddr, upstreamProxy, schemaID sql.NullString
 	err := r.db.conn.QueryRow(`
-		SELECT p.id, p.name, p.proxy_addr, p.schema_id,
+		SELECT p.id, p.name, p.proxy_addr, p.upstream_proxy, p.schema_id,
 		       (SELECT COUNT(*) FROM traffic WHERE project_id = p.id) AS traffic_count,
 		       p.created_at, p.updated_at
 		FROM projects p
 		WHERE p.id = ?`, id,
-	).Scan(&p.ID, &p.Name, &proxyAddr, &schemaID, &p.TrafficCount, &p.CreatedAt, &p.UpdatedAt)
+	).Scan(&p.ID, &p.Name, &proxyAddr, &upstreamProxy, &schemaID, &p.TrafficCount, &p.CreatedAt, &p.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -84,6 +86,7 @@ func (r *ProjectRepo) Get(id string) (*schema.Project, error) {
 	if proxyAddr.Valid {
 		p.ProxyAddr = proxyAddr.String
 	}
+	p.UpstreamProxy = upstreamProxy.String
 	if schemaID.Valid {
 		s := schemaID.String
 		p.SchemaID = &s
@@ -117,6 +120,68 @@ func (r *ProjectRepo) UpdateProxyAddr(projectID, addr string) error {
 	return err
 }
 
+// UpdateUpstreamProxy sets the upstream proxy URL for a project. An empty
+// URL means the project connects directly.
+func (r *ProjectRepo) UpdateUpstreamProxy(projectID, proxyURL string) error {
+	_, err := r.db.conn.Exec(
+		"UPDATE projects SET upstream_proxy = ?, updated_at = ? WHERE id = ?",
+		proxyURL, time.Now().UTC(), projectID,
+	)
+	return err
+}
+
+// TLSProfile returns a project's upstream TLS profile, or nil if it has
+// none.
+func (r *ProjectRepo) TLSProfile(projectID string) (*schema.TLSProfile, error) {
+	var raw sql.NullString
+	err := r.db.conn.QueryRow("SELECT upstream_tls_json FROM projects WHERE id = ?", projectID).Scan(&raw)
+	if err == sql.ErrNoRows || (err == nil && !raw.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get TLS profile: %w", err)
+	}
+	var p schema.TLSProfile
+	if err := json.Unmarshal([]byte(raw.String), &p); err != nil {
+		return nil, fmt.Errorf("decode TLS profile: %w", err)
+	}
+	return &p, nil
+}
+
+// UpdateTLSProfile sets a project's upstream TLS profile. A nil profile
+// clears it.
+func (r *ProjectRepo) UpdateTLSProfile(projectID string, p *schema.TLSProfile) error {
+	var raw any
+	if p != nil {
+		b, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		raw = string(b)
+	}
+	_, err := r.db.conn.Exec(
+		"UPDATE projects SET upstream_tls_json = ?, updated_at = ? WHERE id = ?",
+		raw, time.Now().UTC(), projectID,
+	)
+	return err
+}
+
+// ProjectForSchema returns the ID of the most recently updated project
+// whose schema is schemaID, or "" if none has it.
+func (r *ProjectRepo) ProjectForSchema(schemaID string) (string, error) {
+	var id string
+	err := r.db.conn.QueryRow(
+		"SELECT id FROM projects WHERE schema_id = ? ORDER BY updated_at DESC LIMIT 1", schemaID,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("project for schema: %w", err)
+	}
+	return id, nil
+}
+
 // UpdateSchema sets the inferred schema ID for a project.
 func (r *ProjectRepo) UpdateSchema(projectID, schemaID string) error {
 	_, err := r.db.conn.Exec(
@@ -125,3 +190,45 @@ func (r *ProjectRepo) UpdateSchema(projectID, schemaID string) error {
 	)
 	return err
 }
+
+// CreateScopeRule stores a new scope rule for a project.
+func (r *ProjectRepo) CreateScopeRule(rule *schema.ScopeRule) error {
+	_, err := r.db.conn.Exec(
+		`INSERT INTO scope_rules (id, project_id, kind, pattern, exclude, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		rule.ID, rule.ProjectID, rule.Kind, rule.Pattern, rule.Exclude, rule.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create scope rule: %w", err)
+	}
+	return nil
+}
+
+// ListScopeRules returns a project's scope rules in creation order.
+func (r *ProjectRepo) ListScopeRules(projectID string) ([]schema.ScopeRule, error) {
+	rows, err := r.db.conn.Query(
+		`SELECT id, project_id, kind, pattern, exclude, created_at
+		 FROM scope_rules WHERE project_id = ? ORDER BY created_at ASC`,
+		projectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list scope rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []schema.ScopeRule
+	for rows.Next() {
+		var rule schema.ScopeRule
+		if err := rows.Scan(&rule.ID, &rule.ProjectID, &rule.Kind, &rule.Pattern, &rule.Exclude, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan scope rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// DeleteScopeRule removes a scope rule from a project.
+func (r *ProjectRepo) DeleteScopeRule(projectID, id string) error {
+	_, err := r.db.conn.Exec("DELETE FROM scope_rules WHERE id = ? AND project_id = ?", id, projectID)
+	return err
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// RBACRepo handles auth context and authorization matrix persistence.
+type RBACRepo struct {
+	db *DB
+}
+
+// NewRBACRepo creates a new RBAC repository.
+func NewRBACRepo(db *DB) *RBACRepo {
+	return &RBACRepo{db: db}
+}
+
+// CreateContext stores a new auth context.
+func (r *RBACRepo) CreateContext(c *schema.AuthContext) error {
+	headers, _ := json.Marshal(c.Headers)
+	cookies, _ := json.Marshal(c.Cookies)
+	_, err := r.db.conn.Exec(
+		`INSERT INTO auth_contexts (id, project_id, name, privilege, headers_json, cookies_json, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		c.ID, c.ProjectID, c.Name, c.Privilege, string(headers), string(cookies), c.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create auth context: %w", err)
+	}
+	return nil
+}
+
+// ListContexts returns a project's auth contexts, highest privilege first.
+func (r *RBACRepo) ListContexts(projectID string) ([]schema.AuthContext, error) {
+	rows, err := r.db.conn.Query(
+		`SELECT id, project_id, name, privilege, headers_json, cookies_json, created_at
+		 FROM auth_contexts WHERE project_id = ? ORDER BY privilege DESC, created_at ASC`,
+		projectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list auth contexts: %w", err)
+	}
+	defer rows.Close()
+
+	var contexts []schema.AuthContext
+	for rows.Next() {
+		var c schema.AuthContext
+		var headersJSON, cookiesJSON sql.NullString
+		if err := rows.Scan(&c.ID, &c.ProjectID, &c.Name, &c.Privilege, &headersJSON, &cookiesJSON, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan auth context: %w", err)
+		}
+		if headersJSON.Valid {
+			json.Unmarshal([]byte(headersJSON.String), &c.Headers) //nolint:errcheck
+		}
+		if cookiesJSON.Valid {
+			json.Unmarshal([]byte(cookiesJSON.String), &c.Cookies) //nolint:errcheck
+		}
+		contexts = append(contexts, c)
+	}
+	return contexts, rows.Err()
+}
+
+// DeleteContext removes an auth context from a project.
+func (r *RBACRepo) DeleteContext(projectID, id string) error {
+	_, err := r.db.conn.Exec("DELETE FROM auth_contexts WHERE id = ? AND project_id = ?", id, projectID)
+	return err
+}
+
+// SaveEntries stores the entries of one matrix run in a single transaction.
+func (r *RBACRepo) SaveEntries(entries []schema.RBACEntry) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("begin save rbac entries tx: %w", err)
+	}
+	for _, e := range entries {
+		ops, _ := json.Marshal(e.Operations)
+		denied, _ := json.Marshal(e.DeniedOps)
+		errored, _ := json.Marshal(e.ErroredOps)
+		flagged, _ := json.Marshal(e.FlaggedOps)
+		var contextID any
+		if e.ContextID != "" {
+			contextID = e.ContextID
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO rbac_entries (id, run_id, project_id, context_id, role, privilege, auth_token,
+			  operations_json, denied_ops_json, errored_ops_json, flagged_ops_json, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			e.ID, e.RunID, e.ProjectID, contextID, e.Role, e.Privilege, e.AuthToken,
+			string(ops), string(denied), string(errored), string(flagged), e.CreatedAt,
+		); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("insert rbac entry: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// LatestEntries returns the entries of a project's most recent matrix run,
+// highest privilege first. Returns nil if the matrix was never run.
+func (r *RBACRepo) LatestEntries(projectID string) ([]schema.RBACEntry, error) {
+	rows, err := r.db.conn.Query(
+		`SELECT id, run_id, project_id, context_id, role, privilege, auth_token,
+		        operations_json, denied_ops_json, errored_ops_json, flagged_ops_json, created_at
+		 FROM rbac_entries
+		 WHERE run_id = (SELECT run_id FROM rbac_entries WHERE project_id = ? ORDER BY created_at DESC LIMIT 1)
+		 ORDER BY privilege DESC, role ASC`,
+		projectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list rbac entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []schema.RBACEntry
+	for rows.Next() {
+		var e schema.RBACEntry
+		var contextID, authToken, denied, errored, flagged sql.NullString
+		var ops string
+		if err := rows.Scan(
+			&e.ID, &e.RunID, &e.ProjectID, &contextID, &e.Role, &e.Privilege, &authToken,
+			&ops, &denied, &errored, &flagged, &e.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan rbac entry: %w", err)
+		}
+		e.ContextID = contextID.String
+		e.AuthToken = authToken.String
+		json.Unmarshal([]byte(ops), &e.Operations) //nolint:errcheck
+		if denied.Valid {
+			json.Unmarshal([]byte(denied.String), &e.DeniedOps) //nolint:errcheck
+		}
+		if errored.Valid {
+			json.Unmarshal([]byte(errored.String), &e.ErroredOps) //nolint:errcheck
+		}
+		if flagged.Valid {
+			json.Unmarshal([]byte(flagged.String), &e.FlaggedOps) //nolint:errcheck
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
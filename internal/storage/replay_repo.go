@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// ReplayRepo handles replay workbench history persistence.
+type ReplayRepo struct {
+	db *DB
+}
+
+// NewReplayRepo creates a new replay repository.
+func NewReplayRepo(db *DB) *ReplayRepo {
+	return &ReplayRepo{db: db}
+}
+
+const replayColumns = `id, traffic_id, parent_id, timestamp, method, url, headers_json,
+	operation_name, query, variables_json, response_code, response_headers_json,
+	response_body, duration_ms, error`
+
+// Save stores a replay attempt.
+func (r *ReplayRepo) Save(a *schema.ReplayAttempt) error {
+	headers, _ := json.Marshal(a.Headers)
+	respHeaders, _ := json.Marshal(a.ResponseHeaders)
+	var varsParam any
+	if len(a.Variables) > 0 {
+		varsParam = string(a.Variables)
+	}
+	_, err := r.db.conn.Exec(
+		`INSERT INTO replay_attempts (`+replayColumns+`)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.ID, a.TrafficID, a.ParentID, a.Timestamp, a.Method, a.URL, string(headers),
+		a.OperationName, a.Query, varsParam, a.ResponseCode, string(respHeaders),
+		[]byte(a.ResponseBody), a.DurationMs, a.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("insert replay attempt: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a replay attempt by ID. Returns nil, nil if not found.
+func (r *ReplayRepo) Get(id string) (*schema.ReplayAttempt, error) {
+	rows, err := r.db.conn.Query("SELECT "+replayColumns+" FROM replay_attempts WHERE id = ?", id)
+	attempts, err := scanReplayAttempts(rows, err)
+	if err != nil {
+		return nil, err
+	}
+	if len(attempts) == 0 {
+		return nil, nil
+	}
+	return &attempts[0], nil
+}
+
+// ListByTraffic returns every attempt replayed from a captured request,
+// oldest first.
+func (r *ReplayRepo) ListByTraffic(trafficID string) ([]schema.ReplayAttempt, error) {
+	return scanReplayAttempts(r.db.conn.Query(
+		"SELECT "+replayColumns+" FROM replay_attempts WHERE traffic_id = ? ORDER BY timestamp ASC", trafficID))
+}
+
+func scanReplayAttempts(rows *sql.Rows, err error) ([]schema.ReplayAttempt, error) {
+	if err != nil {
+		return nil, fmt.Errorf("list replay attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []schema.ReplayAttempt
+	for rows.Next() {
+		var a schema.ReplayAttempt
+		var parentID, headersJSON, opName, query, varsJSON, respHeadersJSON, errMsg sql.NullString
+		var respCode, duration sql.NullInt64
+		var respBody []byte
+
+		if err := rows.Scan(
+			&a.ID, &a.TrafficID, &parentID, &a.Timestamp, &a.Method, &a.URL, &headersJSON,
+			&opName, &query, &varsJSON, &respCode, &respHeadersJSON,
+			&respBody, &duration, &errMsg,
+		); err != nil {
+			return nil, fmt.Errorf("scan replay attempt: %w", err)
+		}
+
+		if parentID.Valid {
+			s := parentID.String
+			a.ParentID = &s
+		}
+		if headersJSON.Valid {
+			json.Unmarshal([]byte(headersJSON.String), &a.Headers) //nolint:errcheck
+		}
+		if respHeadersJSON.Valid {
+			json.Unmarshal([]byte(respHeadersJSON.String), &a.ResponseHeaders) //nolint:errcheck
+		}
+		a.OperationName = opName.String
+		a.Query = query.String
+		if varsJSON.Valid {
+			a.Variables = json.RawMessage(varsJSON.String)
+		}
+		a.ResponseCode = int(respCode.Int64)
+		a.ResponseBody = string(respBody)
+		a.DurationMs = duration.Int64
+		a.Error = errMsg.String
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
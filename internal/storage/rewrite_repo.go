@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// RewriteRepo handles match-and-replace rule persistence.
+type RewriteRepo struct {
+	db *DB
+}
+
+// NewRewriteRepo creates a new rewrite rule repository.
+func NewRewriteRepo(db *DB) *RewriteRepo {
+	return &RewriteRepo{db: db}
+}
+
+// Create stores a new rule.
+func (r *RewriteRepo) Create(rule *schema.RewriteRule) error {
+	_, err := r.db.conn.Exec(
+		`INSERT INTO rewrite_rules (id, name, enabled, phase, kind, host, match_pattern, replace_with, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rule.ID, rule.Name, rule.Enabled, rule.Phase, rule.Kind, rule.Host, rule.Match, rule.Replace, rule.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create rewrite rule: %w", err)
+	}
+	return nil
+}
+
+// List returns all rules in the order they are applied.
+func (r *RewriteRepo) List() ([]schema.RewriteRule, error) {
+	rows, err := r.db.conn.Query(
+		`SELECT id, name, enabled, phase, kind, host, match_pattern, replace_with, created_at
+		 FROM rewrite_rules ORDER BY created_at ASC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list rewrite rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []schema.RewriteRule
+	for rows.Next() {
+		var rule schema.RewriteRule
+		var name, host sql.NullString
+		if err := rows.Scan(&rule.ID, &name, &rule.Enabled, &rule.Phase, &rule.Kind, &host,
+			&rule.Match, &rule.Replace, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan rewrite rule: %w", err)
+		}
+		rule.Name, rule.Host = name.String, host.String
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// SetEnabled turns a rule on or off.
+func (r *RewriteRepo) SetEnabled(id string, enabled bool) error {
+	_, err := r.db.conn.Exec("UPDATE rewrite_rules SET enabled = ? WHERE id = ?", enabled, id)
+	return err
+}
+
+// Delete removes a rule.
+func (r *RewriteRepo) Delete(id string) error {
+	_, err := r.db.conn.Exec("DELETE FROM rewrite_rules WHERE id = ?", id)
+	return err
+}
@@ -146,15 +146,68 @@ func (r *TrafficRepo) Save(req *schema.CapturedRequest) error {
 	if len(req.Variables) > 0 {
 		varsParam = string(req.Variables)
 	}
+	var transport any
+	if req.Transport != "" {
+		transport = req.Transport
+	}
+	var batchID, batchIndex any
+	if req.BatchID != "" {
+		batchID, batchIndex = req.BatchID, req.BatchIndex
+	}
+	var protocol any
+	if req.Protocol != "" {
+		protocol = req.Protocol
+	}
+	var rewrittenBy, original any
+	if len(req.RewrittenBy) > 0 {
+		b, _ := json.Marshal(req.RewrittenBy)
+		rewrittenBy = string(b)
+	}
+	if req.Original != nil {
+		b, _ := json.Marshal(req.Original)
+		original = string(b)
+	}
+	var persistedHash any
+	if req.PersistedHash != "" {
+		persistedHash = req.PersistedHash
+	}
+	var requestBody, respHeaders, duration any
+	if len(req.RequestBody) > 0 {
+		// Stored as a BLOB so binary upload bodies come back unchanged.
+		requestBody = req.RequestBody
+	}
+	if len(req.ResponseHeaders) > 0 {
+		b, _ := json.Marshal(req.ResponseHeaders)
+		respHeaders = string(b)
+	}
+	if req.DurationMs > 0 {
+		duration = req.DurationMs
+	}
+	var role, tlsVersion, tlsCipher any
+	if req.Role != "" {
+		role = req.Role
+	}
+	if req.TLSVersion != "" {
+		tlsVersion, tlsCipher = req.TLSVersion, req.TLSCipher
+	}
+	var uploads any
+	if len(req.Uploads) > 0 {
+		b, _ := json.Marshal(req.Uploads)
+		uploads = string(b)
+	}
 	_, err := r.db.conn.Exec(
 		`INSERT INTO traffic (id, timestamp, method, url, host, headers_json,
 		  operation_name, query, variables_json, response_code, response_body,
-		  fingerprint, cluster_id, schema_id, project_id)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		  fingerprint, cluster_id, schema_id, project_id, transport, batch_id, batch_index,
+		  protocol, rewritten_by, original_json, persisted_hash,
+		  request_body, response_headers_json, duration_ms, role, tls_version, tls_cipher, uploads_json)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		req.ID, req.Timestamp, req.Method, req.URL, req.Host, string(headers),
 		req.OperationName, req.Query, varsParam,
 		req.ResponseCode, req.ResponseBody,
-		req.Fingerprint, req.ClusterID, req.SchemaID, req.ProjectID,
+		req.Fingerprint, req.ClusterID, req.SchemaID, req.ProjectID, transport,
+		batchID, batchIndex, protocol, rewrittenBy, original, persistedHash,
+		requestBody, respHeaders, duration, role, tlsVersion, tlsCipher, uploads,
 	)
 	if err != nil {
 		return fmt.Errorf("insert traffic: %w", err)
@@ -166,26 +219,33 @@ func (r *TrafficRepo) Save(req *schema.CapturedRequest) error {
 func (r *TrafficRepo) List(limit int) ([]schema.CapturedRequest, error) {
 	if limit > 0 {
 		return r.scanTraffic(r.db.conn.Query(
-			"SELECT id, timestamp, method, url, host, headers_json, operation_name, query, variables_json, response_code, fingerprint, cluster_id, project_id FROM traffic ORDER BY timestamp DESC LIMIT ?", limit))
+			"SELECT id, timestamp, method, url, host, headers_json, operation_name, query, variables_json, response_code, fingerprint, cluster_id, project_id, transport, batch_id, batch_index, protocol, rewritten_by, persisted_hash, role, tls_version, tls_cipher, uploads_json FROM traffic ORDER BY timestamp DESC LIMIT ?", limit))
 	}
 	return r.scanTraffic(r.db.conn.Query(
-		"SELECT id, timestamp, method, url, host, headers_json, operation_name, query, variables_json, response_code, fingerprint, cluster_id, project_id FROM traffic ORDER BY timestamp DESC"))
+		"SELECT id, timestamp, method, url, host, headers_json, operation_name, query, variables_json, response_code, fingerprint, cluster_id, project_id, transport, batch_id, batch_index, protocol, rewritten_by, persisted_hash, role, tls_version, tls_cipher, uploads_json FROM traffic ORDER BY timestamp DESC"))
 }
 
 // ListByProject returns captured traffic for a project, newest first. Limit 0 = no limit.
 func (r *TrafficRepo) ListByProject(projectID string, limit int) ([]schema.CapturedRequest, error) {
 	if limit > 0 {
 		return r.scanTraffic(r.db.conn.Query(
-			"SELECT id, timestamp, method, url, host, headers_json, operation_name, query, variables_json, response_code, fingerprint, cluster_id, project_id FROM traffic WHERE project_id = ? ORDER BY timestamp DESC LIMIT ?", projectID, limit))
+			"SELECT id, timestamp, method, url, host, headers_json, operation_name, query, variables_json, response_code, fingerprint, cluster_id, project_id, transport, batch_id, batch_index, protocol, rewritten_by, persisted_hash, role, tls_version, tls_cipher, uploads_json FROM traffic WHERE project_id = ? ORDER BY timestamp DESC LIMIT ?", projectID, limit))
 	}
 	return r.scanTraffic(r.db.conn.Query(
-		"SELECT id, timestamp, method, url, host, headers_json, operation_name, query, variables_json, response_code, fingerprint, cluster_id, project_id FROM traffic WHERE project_id = ? ORDER BY timestamp DESC", projectID))
+		"SELECT id, timestamp, method, url, host, headers_json, operation_name, query, variables_json, response_code, fingerprint, cluster_id, project_id, transport, batch_id, batch_index, protocol, rewritten_by, persisted_hash, role, tls_version, tls_cipher, uploads_json FROM traffic WHERE project_id = ? ORDER BY timestamp DESC", projectID))
 }
 
-// ListByProjectFull is like ListByProject but also loads response_body.
-// Used by schema inference so it can analyse response payloads.
+// ListByBatch returns the operations of one batched request in batch order.
+func (r *TrafficRepo) ListByBatch(batchID string) ([]schema.CapturedRequest, error) {
+	return r.scanTraffic(r.db.conn.Query(
+		"SELECT id, timestamp, method, url, host, headers_json, operation_name, query, variables_json, response_code, fingerprint, cluster_id, project_id, transport, batch_id, batch_index, protocol, rewritten_by, persisted_hash, role, tls_version, tls_cipher, uploads_json FROM traffic WHERE batch_id = ? ORDER BY batch_index", batchID))
+}
+
+// ListByProjectFull is like ListByProject but also loads response_body,
+// the raw request body, response headers, and duration. Used by schema
+// inference so it can analyse response payloads, and by HAR export.
 func (r *TrafficRepo) ListByProjectFull(projectID string, limit int) ([]schema.CapturedRequest, error) {
-	q := "SELECT id, timestamp, method, url, host, headers_json, operation_name, query, variables_json, response_code, response_body, fingerprint, cluster_id, project_id FROM traffic WHERE project_id = ? ORDER BY timestamp DESC"
+	q := "SELECT id, timestamp, method, url, host, headers_json, operation_name, query, variables_json, response_code, response_body, fingerprint, cluster_id, project_id, transport, batch_id, batch_index, protocol, rewritten_by, persisted_hash, request_body, response_headers_json, duration_ms, role, tls_version, tls_cipher, uploads_json FROM traffic WHERE project_id = ? ORDER BY timestamp DESC"
 	var args []any
 	args = append(args, projectID)
 	if limit > 0 {
@@ -202,16 +262,18 @@ func (r *TrafficRepo) ListByProjectFull(projectID string, limit int) ([]schema.C
 	for rows.Next() {
 		var req schema.CapturedRequest
 		var headersJSON, varsJSON sql.NullString
-		var clusterID, opName, query, fingerprint, projectIDval sql.NullString
-		var respCode sql.NullInt64
-		var responseBody []byte
+		var clusterID, opName, query, fingerprint, projectIDval, transport, batchID, protocol, rewrittenBy, persistedHash sql.NullString
+		var respHeadersJSON, role, tlsVersion, tlsCipher, uploadsJSON sql.NullString
+		var respCode, batchIndex, duration sql.NullInt64
+		var responseBody, requestBody []byte
 		var ts time.Time
 
 		if err := rows.Scan(
 			&req.ID, &ts, &req.Method, &req.URL, &req.Host,
 			&headersJSON, &opName, &query, &varsJSON, &respCode,
 			&responseBody,
-			&fingerprint, &clusterID, &projectIDval,
+			&fingerprint, &clusterID, &projectIDval, &transport, &batchID, &batchIndex, &protocol, &rewrittenBy, &persistedHash,
+			&requestBody, &respHeadersJSON, &duration, &role, &tlsVersion, &tlsCipher, &uploadsJSON,
 		); err != nil {
 			return nil, fmt.Errorf("scan traffic full: %w", err)
 		}
@@ -246,6 +308,23 @@ func (r *TrafficRepo) ListByProjectFull(projectID string, limit int) ([]schema.C
 			s := projectIDval.String
 			req.ProjectID = &s
 		}
+		req.Transport = transport.String
+		req.BatchID, req.BatchIndex = batchID.String, int(batchIndex.Int64)
+		req.Protocol = protocol.String
+		req.PersistedHash = persistedHash.String
+		if rewrittenBy.Valid {
+			json.Unmarshal([]byte(rewrittenBy.String), &req.RewrittenBy) //nolint:errcheck
+		}
+		req.RequestBody = requestBody
+		if respHeadersJSON.Valid {
+			json.Unmarshal([]byte(respHeadersJSON.String), &req.ResponseHeaders) //nolint:errcheck
+		}
+		req.DurationMs = duration.Int64
+		req.Role = role.String
+		req.TLSVersion, req.TLSCipher = tlsVersion.String, tlsCipher.String
+		if uploadsJSON.Valid {
+			json.Unmarshal([]byte(uploadsJSON.String), &req.Uploads) //nolint:errcheck
+		}
 		reqs = append(reqs, req)
 	}
 	return reqs, rows.Err()
@@ -261,14 +340,14 @@ func (r *TrafficRepo) scanTraffic(rows *sql.Rows, err error) ([]schema.CapturedR
 	for rows.Next() {
 		var req schema.CapturedRequest
 		var headersJSON, varsJSON sql.NullString
-		var clusterID, opName, query, fingerprint, projectID sql.NullString
-		var respCode sql.NullInt64
+		var clusterID, opName, query, fingerprint, projectID, transport, batchID, protocol, rewrittenBy, persistedHash, role, tlsVersion, tlsCipher, uploadsJSON sql.NullString
+		var respCode, batchIndex sql.NullInt64
 		var ts time.Time
 
 		if err := rows.Scan(
 			&req.ID, &ts, &req.Method, &req.URL, &req.Host,
 			&headersJSON, &opName, &query, &varsJSON, &respCode,
-			&fingerprint, &clusterID, &projectID,
+			&fingerprint, &clusterID, &projectID, &transport, &batchID, &batchIndex, &protocol, &rewrittenBy, &persistedHash, &role, &tlsVersion, &tlsCipher, &uploadsJSON,
 		); err != nil {
 			return nil, fmt.Errorf("scan traffic: %w", err)
 		}
@@ -300,11 +379,109 @@ func (r *TrafficRepo) scanTraffic(rows *sql.Rows, err error) ([]schema.CapturedR
 			s := projectID.String
 			req.ProjectID = &s
 		}
+		req.Transport = transport.String
+		req.BatchID, req.BatchIndex = batchID.String, int(batchIndex.Int64)
+		req.Protocol = protocol.String
+		req.PersistedHash = persistedHash.String
+		req.Role = role.String
+		req.TLSVersion, req.TLSCipher = tlsVersion.String, tlsCipher.String
+		if uploadsJSON.Valid {
+			json.Unmarshal([]byte(uploadsJSON.String), &req.Uploads) //nolint:errcheck
+		}
+		if rewrittenBy.Valid {
+			json.Unmarshal([]byte(rewrittenBy.String), &req.RewrittenBy) //nolint:errcheck
+		}
 		reqs = append(reqs, req)
 	}
 	return reqs, rows.Err()
 }
 
+// Get retrieves a captured request by ID, including its response body.
+// Returns nil, nil if not found.
+func (r *TrafficRepo) Get(id string) (*schema.CapturedRequest, error) {
+	var req schema.CapturedRequest
+	var headersJSON, varsJSON sql.NullString
+	var clusterID, opName, query, fingerprint, projectID, transport, batchID, protocol, rewrittenBy, persistedHash, originalJSON sql.NullString
+	var respHeadersJSON, role, tlsVersion, tlsCipher, uploadsJSON sql.NullString
+	var respCode, batchIndex, duration sql.NullInt64
+	var responseBody, requestBody []byte
+
+	err := r.db.conn.QueryRow(
+		"SELECT id, timestamp, method, url, host, headers_json, operation_name, query, variables_json, response_code, response_body, fingerprint, cluster_id, project_id, transport, batch_id, batch_index, protocol, rewritten_by, persisted_hash, original_json, request_body, response_headers_json, duration_ms, role, tls_version, tls_cipher, uploads_json FROM traffic WHERE id = ?",
+		id,
+	).Scan(
+		&req.ID, &req.Timestamp, &req.Method, &req.URL, &req.Host,
+		&headersJSON, &opName, &query, &varsJSON, &respCode,
+		&responseBody,
+		&fingerprint, &clusterID, &projectID, &transport, &batchID, &batchIndex, &protocol, &rewrittenBy, &persistedHash, &originalJSON,
+		&requestBody, &respHeadersJSON, &duration, &role, &tlsVersion, &tlsCipher, &uploadsJSON,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get traffic: %w", err)
+	}
+
+	if headersJSON.Valid {
+		json.Unmarshal([]byte(headersJSON.String), &req.Headers) //nolint:errcheck
+	}
+	if opName.Valid {
+		req.OperationName = opName.String
+	}
+	if query.Valid {
+		req.Query = query.String
+	}
+	if varsJSON.Valid {
+		req.Variables = json.RawMessage(varsJSON.String)
+	}
+	if respCode.Valid {
+		req.ResponseCode = int(respCode.Int64)
+	}
+	if len(responseBody) > 0 {
+		req.ResponseBody = json.RawMessage(responseBody)
+	}
+	if fingerprint.Valid {
+		req.Fingerprint = fingerprint.String
+	}
+	if clusterID.Valid {
+		s := clusterID.String
+		req.ClusterID = &s
+	}
+	if projectID.Valid {
+		s := projectID.String
+		req.ProjectID = &s
+	}
+	req.Transport = transport.String
+	req.BatchID, req.BatchIndex = batchID.String, int(batchIndex.Int64)
+	req.Protocol = protocol.String
+	req.PersistedHash = persistedHash.String
+	if rewrittenBy.Valid {
+		json.Unmarshal([]byte(rewrittenBy.String), &req.RewrittenBy) //nolint:errcheck
+	}
+	if originalJSON.Valid {
+		json.Unmarshal([]byte(originalJSON.String), &req.Original) //nolint:errcheck
+	}
+	req.RequestBody = requestBody
+	if respHeadersJSON.Valid {
+		json.Unmarshal([]byte(respHeadersJSON.String), &req.ResponseHeaders) //nolint:errcheck
+	}
+	req.DurationMs = duration.Int64
+	req.Role = role.String
+	req.TLSVersion, req.TLSCipher = tlsVersion.String, tlsCipher.String
+	if uploadsJSON.Valid {
+		json.Unmarshal([]byte(uploadsJSON.String), &req.Uploads) //nolint:errcheck
+	}
+	if req.Transport != "" {
+		msgs, err := r.Messages(id)
+		if err != nil {
+			return nil, err
+		}
+		req.Messages = msgs
+	}
+	return &req, nil
+}
+
 // ListByFingerprint returns all requests with the same structural fingerprint.
 func (r *TrafficRepo) ListByFingerprint(fp string) ([]schema.CapturedRequest, error) {
 	rows, err := r.db.conn.Query(
@@ -337,6 +514,134 @@ func (r *TrafficRepo) ListByFingerprint(fp string) ([]schema.CapturedRequest, er
 	return reqs, rows.Err()
 }
 
+// SetResponseBody records the response of a streamed request. Only the
+// first call has an effect, so the body holds the first result.
+func (r *TrafficRepo) SetResponseBody(id string, body json.RawMessage) error {
+	_, err := r.db.conn.Exec(
+		"UPDATE traffic SET response_body = ? WHERE id = ? AND (response_body IS NULL OR length(response_body) = 0)",
+		[]byte(body), id,
+	)
+	return err
+}
+
+// AppendMessage adds a message to a WebSocket or SSE request's stream.
+func (r *TrafficRepo) AppendMessage(trafficID string, msg schema.StreamMessage) error {
+	var payload any
+	if len(msg.Payload) > 0 {
+		payload = string(msg.Payload)
+	}
+	_, err := r.db.conn.Exec(
+		"INSERT INTO stream_messages (traffic_id, timestamp, direction, type, payload) VALUES (?, ?, ?, ?, ?)",
+		trafficID, msg.Timestamp, msg.Direction, msg.Type, payload,
+	)
+	if err != nil {
+		return fmt.Errorf("insert stream message: %w", err)
+	}
+	return nil
+}
+
+// Messages returns a request's stream messages in the order they were seen.
+func (r *TrafficRepo) Messages(trafficID string) ([]schema.StreamMessage, error) {
+	rows, err := r.db.conn.Query(
+		"SELECT timestamp, direction, type, payload FROM stream_messages WHERE traffic_id = ? ORDER BY id",
+		trafficID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list stream messages: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []schema.StreamMessage
+	for rows.Next() {
+		var m schema.StreamMessage
+		var msgType, payload sql.NullString
+		if err := rows.Scan(&m.Timestamp, &m.Direction, &msgType, &payload); err != nil {
+			return nil, fmt.Errorf("scan stream message: %w", err)
+		}
+		m.Type = msgType.String
+		if payload.Valid {
+			m.Payload = json.RawMessage(payload.String)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, rows.Err()
+}
+
+// SavePersistedQuery records the query text behind an APQ hash sent to a
+// host within a project, and fills it into that project's earlier captures
+// to the host that only carried the hash. The first text seen for a hash is
+// kept unless it isn't verified and pq is. It returns the number of
+// captures filled in.
+func (r *TrafficRepo) SavePersistedQuery(pq *schema.PersistedQuery) (int64, error) {
+	_, err := r.db.conn.Exec(
+		`INSERT INTO persisted_queries (project_id, host, hash, query, operation_name, verified, first_seen)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (project_id, host, hash) DO UPDATE SET
+		  query = excluded.query, operation_name = excluded.operation_name, verified = excluded.verified
+		 WHERE excluded.verified AND NOT persisted_queries.verified`,
+		pq.ProjectID, pq.Host, pq.Hash, pq.Query, pq.OperationName, pq.Verified, pq.FirstSeen,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("save persisted query: %w", err)
+	}
+	var query, opName string
+	err = r.db.conn.QueryRow(
+		"SELECT query, COALESCE(operation_name, '') FROM persisted_queries WHERE project_id = ? AND host = ? AND hash = ?",
+		pq.ProjectID, pq.Host, pq.Hash,
+	).Scan(&query, &opName)
+	if err != nil {
+		return 0, fmt.Errorf("load persisted query: %w", err)
+	}
+	res, err := r.db.conn.Exec(
+		`UPDATE traffic SET query = ?,
+		  operation_name = CASE WHEN operation_name IS NULL OR operation_name = '' THEN ? ELSE operation_name END,
+		  fingerprint = NULL
+		 WHERE persisted_hash = ? AND COALESCE(project_id, '') = ? AND host = ? AND query LIKE '# persisted query%'`,
+		query, opName, pq.Hash, pq.ProjectID, pq.Host,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("backfill persisted query: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// PersistedQuery returns the query text recorded for an APQ hash sent to
+// host within a project, or "" if it hasn't been seen there.
+func (r *TrafficRepo) PersistedQuery(projectID, host, hash string) (string, error) {
+	var query string
+	err := r.db.conn.QueryRow(
+		"SELECT query FROM persisted_queries WHERE project_id = ? AND host = ? AND hash = ?",
+		projectID, host, hash,
+	).Scan(&query)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return query, err
+}
+
+// ListPersistedQueries returns the recorded APQ hashes, newest first.
+func (r *TrafficRepo) ListPersistedQueries() ([]schema.PersistedQuery, error) {
+	rows, err := r.db.conn.Query(
+		"SELECT hash, query, operation_name, host, project_id, verified, first_seen FROM persisted_queries ORDER BY first_seen DESC",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list persisted queries: %w", err)
+	}
+	defer rows.Close()
+
+	var out []schema.PersistedQuery
+	for rows.Next() {
+		var pq schema.PersistedQuery
+		var opName sql.NullString
+		if err := rows.Scan(&pq.Hash, &pq.Query, &opName, &pq.Host, &pq.ProjectID, &pq.Verified, &pq.FirstSeen); err != nil {
+			return nil, fmt.Errorf("scan persisted query: %w", err)
+		}
+		pq.OperationName = opName.String
+		out = append(out, pq)
+	}
+	return out, rows.Err()
+}
+
 // Count returns total captured traffic entries.
 func (r *TrafficRepo) Count() (int, error) {
 	var count int
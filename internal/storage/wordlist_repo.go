@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/0xDTC/0xGQLForge/internal/schema"
+)
+
+// WordlistRepo handles wordlist persistence.
+type WordlistRepo struct {
+	db *DB
+}
+
+// NewWordlistRepo creates a new wordlist repository.
+func NewWordlistRepo(db *DB) *WordlistRepo {
+	return &WordlistRepo{db: db}
+}
+
+// Save stores a wordlist, replacing the words of an existing list with the
+// same name. w.ID is set to the stored list's ID.
+func (r *WordlistRepo) Save(w *schema.Wordlist) error {
+	words, _ := json.Marshal(w.Words)
+	_, err := r.db.conn.Exec(
+		`INSERT INTO wordlists (id, name, words_json, created_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(name) DO UPDATE SET words_json = excluded.words_json`,
+		w.ID, w.Name, string(words), w.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("save wordlist: %w", err)
+	}
+	// An existing list keeps its ID.
+	return r.db.conn.QueryRow("SELECT id FROM wordlists WHERE name = ?", w.Name).Scan(&w.ID)
+}
+
+// List returns all wordlists whose name starts with prefix, ordered by name.
+// An empty prefix returns every wordlist.
+func (r *WordlistRepo) List(prefix string) ([]schema.Wordlist, error) {
+	rows, err := r.db.conn.Query(
+		`SELECT id, name, words_json, created_at FROM wordlists
+		 WHERE substr(name, 1, ?) = ? ORDER BY name`,
+		len(prefix), prefix,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list wordlists: %w", err)
+	}
+	defer rows.Close()
+
+	var lists []schema.Wordlist
+	for rows.Next() {
+		var w schema.Wordlist
+		var words string
+		if err := rows.Scan(&w.ID, &w.Name, &words, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan wordlist: %w", err)
+		}
+		json.Unmarshal([]byte(words), &w.Words) //nolint:errcheck
+		lists = append(lists, w)
+	}
+	return lists, rows.Err()
+}
+
+// Delete removes a wordlist by ID.
+func (r *WordlistRepo) Delete(id string) error {
+	_, err := r.db.conn.Exec("DELETE FROM wordlists WHERE id = ?", id)
+	return err
+}
+
+// Payloads returns the "injection:<class>" wordlists keyed by class.
+func (r *WordlistRepo) Payloads() (map[string][]string, error) {
+	lists, err := r.List("injection:")
+	if err != nil {
+		return nil, err
+	}
+	payloads := make(map[string][]string, len(lists))
+	for _, w := range lists {
+		if class := strings.TrimPrefix(w.Name, "injection:"); class != "" {
+			payloads[class] = w.Words
+		}
+	}
+	return payloads, nil
+}
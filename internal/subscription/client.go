@@ -0,0 +1,193 @@
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+	"github.com/0xDTC/0xGQLForge/internal/ws"
+)
+
+// Options configures Run.
+type Options struct {
+	URL string
+	// Protocol is ProtocolTransportWS, ProtocolLegacyWS, or ProtocolSSE.
+	// Empty lets the server pick between the two WebSocket protocols.
+	Protocol string
+	Headers  map[string]string
+	// ConnectionParams is sent as the connection_init payload, where many
+	// servers expect auth tokens.
+	ConnectionParams json.RawMessage
+	Query            string
+	OperationName    string
+	Variables        json.RawMessage
+	Upstream         upstream.Config
+}
+
+// Event is something that happened on a subscription, in the order it was
+// observed.
+type Event struct {
+	Time    time.Time       `json:"time"`
+	Type    string          `json:"type"` // "connected", "next", "error", "complete", "closed"
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// Run executes a subscription and calls emit for every event until the
+// server completes it, the connection drops, or ctx is cancelled.
+func Run(ctx context.Context, opts Options, emit func(Event)) error {
+	if opts.Query == "" {
+		return errors.New("query is required")
+	}
+	if opts.Protocol == ProtocolSSE {
+		return runSSE(ctx, opts, emit)
+	}
+	return runWS(ctx, opts, emit)
+}
+
+func newEvent(typ string, payload json.RawMessage, msg string) Event {
+	return Event{Time: time.Now().UTC(), Type: typ, Payload: payload, Message: msg}
+}
+
+func runWS(ctx context.Context, opts Options, emit func(Event)) error {
+	header := http.Header{}
+	for k, v := range opts.Headers {
+		header.Set(k, v)
+	}
+	protocols := []string{ProtocolTransportWS, ProtocolLegacyWS}
+	if opts.Protocol != "" {
+		protocols = []string{opts.Protocol}
+	}
+
+	conn, err := ws.Dial(ctx, opts.URL, header, protocols, opts.Upstream)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	legacy := conn.Subprotocol == ProtocolLegacyWS
+	send := func(m Message) error {
+		data, _ := json.Marshal(m)
+		return conn.WriteText(data)
+	}
+
+	if err := send(Message{Type: "connection_init", Payload: opts.ConnectionParams}); err != nil {
+		return fmt.Errorf("connection_init: %w", err)
+	}
+	payload, _ := json.Marshal(OperationPayload{Query: opts.Query, OperationName: opts.OperationName, Variables: opts.Variables})
+	startType := "subscribe"
+	if legacy {
+		startType = "start"
+	}
+
+	acked := false
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				emit(newEvent("closed", nil, "stopped"))
+				return nil
+			}
+			if errors.Is(err, io.EOF) {
+				emit(newEvent("closed", nil, "server closed the connection"))
+				return nil
+			}
+			return err
+		}
+		m, ok := ParseMessage(data)
+		if !ok {
+			continue
+		}
+
+		switch m.Type {
+		case "connection_ack":
+			if acked {
+				continue
+			}
+			acked = true
+			emit(newEvent("connected", m.Payload, "subprotocol "+conn.Subprotocol))
+			if err := send(Message{ID: "1", Type: startType, Payload: payload}); err != nil {
+				return fmt.Errorf("%s: %w", startType, err)
+			}
+		case "ping":
+			send(Message{Type: "pong"}) //nolint:errcheck
+		case "next", "data":
+			emit(newEvent("next", m.Payload, ""))
+		case "error", "connection_error":
+			emit(newEvent("error", m.Payload, ""))
+			return nil
+		case "complete":
+			emit(newEvent("complete", nil, ""))
+			return nil
+		}
+	}
+}
+
+func runSSE(ctx context.Context, opts Options, emit func(Event)) error {
+	body, _ := json.Marshal(OperationPayload{Query: opts.Query, OperationName: opts.OperationName, Variables: opts.Variables})
+	req, err := http.NewRequestWithContext(ctx, "POST", opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	for k, v := range opts.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := upstream.NewStreamingClient(opts.Upstream).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if !strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream") {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return fmt.Errorf("server did not start an event stream: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	emit(newEvent("connected", nil, resp.Status))
+
+	var dec SSEDecoder
+	chunk := make([]byte, 32<<10)
+	for {
+		n, err := resp.Body.Read(chunk)
+		for _, e := range dec.Feed(chunk[:n]) {
+			switch e.Event {
+			case "complete":
+				emit(newEvent("complete", nil, ""))
+				return nil
+			case "error":
+				emit(newEvent("error", json.RawMessage(e.Data), ""))
+			default:
+				if json.Valid([]byte(e.Data)) {
+					emit(newEvent("next", json.RawMessage(e.Data), ""))
+				} else {
+					emit(newEvent("next", nil, e.Data))
+				}
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				emit(newEvent("closed", nil, "stopped"))
+				return nil
+			}
+			if errors.Is(err, io.EOF) {
+				emit(newEvent("closed", nil, "server closed the stream"))
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package subscription speaks the GraphQL subscription transports: the
+// graphql-transport-ws and legacy graphql-ws WebSocket protocols, and
+// server-sent events.
+package subscription
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Transports and WebSocket subprotocols.
+const (
+	// ProtocolTransportWS is the graphql-ws library's protocol.
+	ProtocolTransportWS = "graphql-transport-ws"
+	// ProtocolLegacyWS is subscriptions-transport-ws, which confusingly
+	// registered the "graphql-ws" subprotocol name.
+	ProtocolLegacyWS = "graphql-ws"
+	// ProtocolSSE is GraphQL over server-sent events.
+	ProtocolSSE = "sse"
+)
+
+// Message is a GraphQL-over-WebSocket message in either protocol.
+type Message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// OperationPayload is the payload of a subscribe (or legacy start) message.
+type OperationPayload struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName,omitempty"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+}
+
+// ParseMessage decodes a WebSocket text message. ok is false for anything
+// that isn't a GraphQL-over-WebSocket message.
+func ParseMessage(data []byte) (Message, bool) {
+	var m Message
+	if json.Unmarshal(data, &m) != nil || m.Type == "" {
+		return m, false
+	}
+	return m, true
+}
+
+// IsStart reports whether a client message type starts an operation.
+func IsStart(msgType string) bool {
+	return msgType == "subscribe" || msgType == "start"
+}
+
+// IsResult reports whether a server message type carries a result.
+func IsResult(msgType string) bool {
+	return msgType == "next" || msgType == "data"
+}
+
+// IsEnd reports whether a message type ends an operation, from either side.
+func IsEnd(msgType string) bool {
+	switch msgType {
+	case "complete", "stop", "error":
+		return true
+	}
+	return false
+}
+
+// SSEEvent is one server-sent event.
+type SSEEvent struct {
+	Event string // empty means "message"
+	Data  string
+	ID    string
+}
+
+// SSEDecoder parses a server-sent event stream incrementally, so it can be
+// fed chunks as they are relayed.
+type SSEDecoder struct {
+	buf   []byte
+	event SSEEvent
+	data  []string
+}
+
+// Feed adds a chunk of the stream and returns the events it completed.
+func (d *SSEDecoder) Feed(chunk []byte) []SSEEvent {
+	d.buf = append(d.buf, chunk...)
+	var events []SSEEvent
+	for {
+		i := bytes.IndexByte(d.buf, '\n')
+		if i < 0 {
+			return events
+		}
+		line := strings.TrimSuffix(string(d.buf[:i]), "\r")
+		d.buf = d.buf[i+1:]
+
+		if line == "" {
+			if len(d.data) > 0 {
+				d.event.Data = strings.Join(d.data, "\n")
+				events = append(events, d.event)
+			}
+			d.event, d.data = SSEEvent{}, nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment / keep-alive
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			d.event.Event = value
+		case "data":
+			d.data = append(d.data, value)
+		case "id":
+			d.event.ID = value
+		}
+	}
+}
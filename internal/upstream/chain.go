@@ -0,0 +1,245 @@
+package upstream
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ParseProxyURL validates an upstream proxy URL. An empty string means no
+// upstream proxy and returns nil. A missing port defaults to 8080 for HTTP,
+// 443 for HTTPS and 1080 for SOCKS5.
+func ParseProxyURL(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream proxy URL: %w", err)
+	}
+	var port string
+	switch u.Scheme {
+	case "http":
+		port = "8080"
+	case "https":
+		port = "443"
+	case "socks5", "socks5h":
+		port = "1080"
+	default:
+		return nil, fmt.Errorf("invalid upstream proxy URL: scheme must be http, https, socks5 or socks5h")
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid upstream proxy URL: missing host")
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u, nil
+}
+
+// dialVia opens a tunnel to addr through the upstream proxy at proxyURL.
+func dialVia(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, addr string, insecure bool) (net.Conn, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", proxyURL.Host)
+	if err != nil {
+		return nil, fmt.Errorf("dial upstream proxy: %w", err)
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{
+			ServerName:         proxyURL.Hostname(),
+			InsecureSkipVerify: insecure,
+		})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("upstream proxy TLS: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	// The handshake is bounded by ctx; the tunnel itself is not.
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline) //nolint:errcheck
+	}
+	if proxyURL.Scheme == "socks5" || proxyURL.Scheme == "socks5h" {
+		err = socks5Connect(conn, proxyURL.User, addr)
+	} else {
+		err = httpConnect(conn, proxyURL.User, addr)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{}) //nolint:errcheck
+	return conn, nil
+}
+
+// httpConnect asks an HTTP proxy to open a tunnel to addr.
+func httpConnect(conn net.Conn, user *url.Userinfo, addr string) error {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if user != nil {
+		password, _ := user.Password()
+		creds := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+creds)
+	}
+	if err := req.Write(conn); err != nil {
+		return fmt.Errorf("upstream proxy CONNECT: %w", err)
+	}
+	// Read byte by byte so nothing after the response head is swallowed by
+	// the buffer; the tunnel's first bytes belong to the caller.
+	resp, err := http.ReadResponse(bufio.NewReaderSize(oneByteReader{conn}, 1), req)
+	if err != nil {
+		return fmt.Errorf("upstream proxy CONNECT: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upstream proxy CONNECT: %s", resp.Status)
+	}
+	return nil
+}
+
+// oneByteReader limits reads to a single byte.
+type oneByteReader struct{ r io.Reader }
+
+func (o oneByteReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return o.r.Read(p)
+}
+
+// socks5Connect performs a SOCKS5 handshake (RFC 1928) on conn and asks the
+// proxy to connect to addr, authenticating with username and password
+// (RFC 1929) when credentials are given. The host name is always sent to the
+// proxy for resolution.
+func socks5Connect(conn net.Conn, user *url.Userinfo, addr string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("socks5: invalid port %q", portStr)
+	}
+	if len(host) > 255 {
+		return fmt.Errorf("socks5: host name too long")
+	}
+
+	method := byte(0x00) // no authentication
+	if user != nil {
+		method = 0x02 // username/password
+	}
+	if _, err := conn.Write([]byte{0x05, 0x01, method}); err != nil {
+		return fmt.Errorf("socks5: %w", err)
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("socks5: %w", err)
+	}
+	if reply[0] != 0x05 {
+		return errors.New("socks5: not a SOCKS5 proxy")
+	}
+	if reply[1] != method {
+		return errors.New("socks5: proxy rejected the authentication method")
+	}
+
+	if user != nil {
+		username := user.Username()
+		password, _ := user.Password()
+		if len(username) > 255 || len(password) > 255 {
+			return errors.New("socks5: credentials too long")
+		}
+		msg := []byte{0x01, byte(len(username))}
+		msg = append(msg, username...)
+		msg = append(msg, byte(len(password)))
+		msg = append(msg, password...)
+		if _, err := conn.Write(msg); err != nil {
+			return fmt.Errorf("socks5: %w", err)
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return fmt.Errorf("socks5: %w", err)
+		}
+		if reply[1] != 0x00 {
+			return errors.New("socks5: authentication failed")
+		}
+	}
+
+	msg := []byte{0x05, 0x01, 0x00}
+	if ip := net.ParseIP(host); ip != nil && ip.To4() != nil {
+		msg = append(msg, 0x01)
+		msg = append(msg, ip.To4()...)
+	} else if ip != nil {
+		msg = append(msg, 0x04)
+		msg = append(msg, ip.To16()...)
+	} else {
+		msg = append(msg, 0x03, byte(len(host)))
+		msg = append(msg, host...)
+	}
+	msg = append(msg, byte(port>>8), byte(port))
+	if _, err := conn.Write(msg); err != nil {
+		return fmt.Errorf("socks5: %w", err)
+	}
+
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return fmt.Errorf("socks5: %w", err)
+	}
+	if head[1] != 0x00 {
+		return fmt.Errorf("socks5: connect failed: %s", socks5Reply(head[1]))
+	}
+	// Skip the bound address the proxy reports.
+	var skip int
+	switch head[3] {
+	case 0x01:
+		skip = net.IPv4len + 2
+	case 0x04:
+		skip = net.IPv6len + 2
+	case 0x03:
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(conn, n); err != nil {
+			return fmt.Errorf("socks5: %w", err)
+		}
+		skip = int(n[0]) + 2
+	default:
+		return errors.New("socks5: invalid reply")
+	}
+	if _, err := io.ReadFull(conn, make([]byte, skip)); err != nil {
+		return fmt.Errorf("socks5: %w", err)
+	}
+	return nil
+}
+
+// socks5Reply describes a SOCKS5 reply code.
+func socks5Reply(code byte) string {
+	switch code {
+	case 0x01:
+		return "general failure"
+	case 0x02:
+		return "connection not allowed by ruleset"
+	case 0x03:
+		return "network unreachable"
+	case 0x04:
+		return "host unreachable"
+	case 0x05:
+		return "connection refused"
+	case 0x06:
+		return "TTL expired"
+	case 0x07:
+		return "command not supported"
+	case 0x08:
+		return "address type not supported"
+	}
+	return fmt.Sprintf("error %#02x", code)
+}
@@ -0,0 +1,174 @@
+package upstream
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// fakeSOCKS5 plays the proxy side of a handshake on conn: it expects the
+// client to send want and answers with replies, one after each expected
+// message.
+func fakeSOCKS5(t *testing.T, conn net.Conn, want, replies [][]byte) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		defer conn.Close()
+		for i, msg := range want {
+			got := make([]byte, len(msg))
+			if _, err := io.ReadFull(conn, got); err != nil {
+				done <- err
+				return
+			}
+			if !bytes.Equal(got, msg) {
+				t.Errorf("message %d = % x, want % x", i, got, msg)
+			}
+			if _, err := conn.Write(replies[i]); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	return done
+}
+
+func TestSOCKS5Connect(t *testing.T) {
+	ok4 := []byte{0x05, 0x00, 0x00, 0x01, 127, 0, 0, 1, 0x1F, 0x90}
+	tests := []struct {
+		name    string
+		user    *url.Userinfo
+		addr    string
+		want    [][]byte
+		replies [][]byte
+		err     string
+	}{
+		{
+			name:    "domain, no auth",
+			addr:    "example.com:443",
+			want:    [][]byte{{0x05, 0x01, 0x00}, append(append([]byte{0x05, 0x01, 0x00, 0x03, 11}, "example.com"...), 0x01, 0xBB)},
+			replies: [][]byte{{0x05, 0x00}, ok4},
+		},
+		{
+			name:    "IPv4",
+			addr:    "10.0.0.1:80",
+			want:    [][]byte{{0x05, 0x01, 0x00}, {0x05, 0x01, 0x00, 0x01, 10, 0, 0, 1, 0x00, 0x50}},
+			replies: [][]byte{{0x05, 0x00}, ok4},
+		},
+		{
+			name:    "IPv6, domain bound address",
+			addr:    "[::1]:8080",
+			want:    [][]byte{{0x05, 0x01, 0x00}, {0x05, 0x01, 0x00, 0x04, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0x1F, 0x90}},
+			replies: [][]byte{{0x05, 0x00}, {0x05, 0x00, 0x00, 0x03, 4, 'h', 'o', 's', 't', 0x00, 0x50}},
+		},
+		{
+			name: "username and password",
+			user: url.UserPassword("bob", "s3cret"),
+			addr: "example.com:443",
+			want: [][]byte{
+				{0x05, 0x01, 0x02},
+				append(append(append([]byte{0x01, 3}, "bob"...), 6), "s3cret"...),
+				append(append([]byte{0x05, 0x01, 0x00, 0x03, 11}, "example.com"...), 0x01, 0xBB),
+			},
+			replies: [][]byte{{0x05, 0x02}, {0x01, 0x00}, ok4},
+		},
+		{
+			name:    "bad credentials",
+			user:    url.UserPassword("bob", "wrong"),
+			addr:    "example.com:443",
+			want:    [][]byte{{0x05, 0x01, 0x02}, append(append(append([]byte{0x01, 3}, "bob"...), 5), "wrong"...)},
+			replies: [][]byte{{0x05, 0x02}, {0x01, 0x01}},
+			err:     "authentication failed",
+		},
+		{
+			name:    "method rejected",
+			addr:    "example.com:443",
+			want:    [][]byte{{0x05, 0x01, 0x00}},
+			replies: [][]byte{{0x05, 0xFF}},
+			err:     "rejected the authentication method",
+		},
+		{
+			name:    "not SOCKS5",
+			addr:    "example.com:443",
+			want:    [][]byte{{0x05, 0x01, 0x00}},
+			replies: [][]byte{{'H', 'T'}},
+			err:     "not a SOCKS5 proxy",
+		},
+		{
+			name:    "connection refused",
+			addr:    "10.0.0.1:80",
+			want:    [][]byte{{0x05, 0x01, 0x00}, {0x05, 0x01, 0x00, 0x01, 10, 0, 0, 1, 0x00, 0x50}},
+			replies: [][]byte{{0x05, 0x00}, {0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0}},
+			err:     "connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			done := fakeSOCKS5(t, server, tt.want, tt.replies)
+
+			err := socks5Connect(client, tt.user, tt.addr)
+			if tt.err == "" && err != nil {
+				t.Fatalf("socks5Connect: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("socks5Connect err = %v, want %q", err, tt.err)
+			}
+			client.Close()
+			if err := <-done; err != nil && tt.err == "" {
+				t.Errorf("proxy side: %v", err)
+			}
+		})
+	}
+}
+
+func TestSOCKS5ConnectInvalidAddr(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	for _, addr := range []string{"example.com", "example.com:0", "example.com:http", strings.Repeat("a", 256) + ":80"} {
+		if err := socks5Connect(client, nil, addr); err == nil {
+			t.Errorf("socks5Connect(%q) succeeded", addr)
+		}
+	}
+}
+
+func TestParseProxyURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		err  bool
+	}{
+		{raw: "", want: ""},
+		{raw: "http://proxy", want: "http://proxy:8080"},
+		{raw: "https://proxy", want: "https://proxy:443"},
+		{raw: "socks5://user:pw@proxy", want: "socks5://user:pw@proxy:1080"},
+		{raw: "socks5h://proxy:9050", want: "socks5h://proxy:9050"},
+		{raw: "ftp://proxy", err: true},
+		{raw: "http://", err: true},
+	}
+	for _, tt := range tests {
+		u, err := ParseProxyURL(tt.raw)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseProxyURL(%q) succeeded", tt.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseProxyURL(%q): %v", tt.raw, err)
+			continue
+		}
+		got := ""
+		if u != nil {
+			got = u.String()
+		}
+		if got != tt.want {
+			t.Errorf("ParseProxyURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
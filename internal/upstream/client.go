@@ -0,0 +1,146 @@
+// Package upstream builds the HTTP client used for requests sent to targets on
+// the user's behalf, so the proxy and the replay workbench behave the same way.
+package upstream
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Config controls how requests reach the upstream server.
+type Config struct {
+	// Timeout bounds the whole exchange, including reading the response body.
+	Timeout time.Duration
+	// InsecureSkipVerify disables certificate checks. Targets under test
+	// frequently use self-signed or internal certificates.
+	InsecureSkipVerify bool
+	// ProxyURL chains requests through another proxy: http://, https://,
+	// socks5:// or socks5h://, with optional user:pass credentials. Empty
+	// connects directly.
+	ProxyURL string
+	// ClientCert is presented to servers that ask for one (mutual TLS).
+	ClientCert *tls.Certificate
+	// RootCAs are trusted when certificates are verified; nil uses the
+	// system roots. LoadRootCAs adds a private CA to the system roots.
+	RootCAs *x509.CertPool
+	// SkipVerifyHosts lists host globs whose certificates aren't checked
+	// even when InsecureSkipVerify is off.
+	SkipVerifyHosts []string
+}
+
+// DefaultConfig returns the settings the proxy has always used.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:            30 * time.Second,
+		InsecureSkipVerify: true,
+	}
+}
+
+// NewClient creates an HTTP client for cfg. Redirects are never followed so
+// the caller sees exactly what the server returned.
+func NewClient(cfg Config) *http.Client {
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: newTransport(cfg, 0),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// NewStreamingClient is like NewClient but only bounds the wait for the
+// response headers, so long-lived streams such as SSE subscriptions are not
+// cut off by the timeout.
+func NewStreamingClient(cfg Config) *http.Client {
+	return &http.Client{
+		Transport: newTransport(cfg, cfg.Timeout),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Dial opens a raw connection to the host of u for protocols the HTTP client
+// cannot carry, such as WebSocket. https and wss URLs get a TLS connection
+// with the same certificates and verification settings as the client,
+// negotiating HTTP/1.1.
+// With a ProxyURL the connection is tunnelled through that proxy.
+func Dial(ctx context.Context, cfg Config, u *url.URL) (net.Conn, error) {
+	secure := u.Scheme == "https" || u.Scheme == "wss"
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if secure {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	proxyURL, err := ParseProxyURL(cfg.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	var conn net.Conn
+	if proxyURL != nil {
+		conn, err = dialVia(ctx, dialer, proxyURL, host, cfg.InsecureSkipVerify)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	}
+	if err != nil || !secure {
+		return conn, err
+	}
+
+	tc := tlsConfig(cfg, u.Hostname())
+	tc.ServerName = u.Hostname()
+	tc.NextProtos = []string{"http/1.1"}
+	tlsConn := tls.Client(conn, tc)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// newTransport builds the transport behind both clients. headerTimeout
+// bounds the wait for response headers; zero leaves it to the client.
+// Hosts exempt from verification get a transport of their own.
+func newTransport(cfg Config, headerTimeout time.Duration) http.RoundTripper {
+	verified := newHTTPTransport(cfg, tlsConfig(cfg, ""), headerTimeout)
+	if cfg.InsecureSkipVerify || len(cfg.SkipVerifyHosts) == 0 {
+		return verified
+	}
+	unverified := tlsConfig(cfg, "")
+	unverified.InsecureSkipVerify = true
+	return &hostTransport{
+		verified:   verified,
+		unverified: newHTTPTransport(cfg, unverified, headerTimeout),
+		skip:       cfg.SkipVerifyHosts,
+	}
+}
+
+// newHTTPTransport builds one transport. HTTP/2 is attempted with every TLS
+// server that offers it, as browsers would. An invalid ProxyURL fails every
+// request rather than silently connecting directly.
+func newHTTPTransport(cfg Config, tc *tls.Config, headerTimeout time.Duration) *http.Transport {
+	t := &http.Transport{
+		TLSClientConfig:       tc,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ResponseHeaderTimeout: headerTimeout,
+	}
+	proxyURL, err := ParseProxyURL(cfg.ProxyURL)
+	switch {
+	case err != nil:
+		t.Proxy = func(*http.Request) (*url.URL, error) { return nil, err }
+	case proxyURL != nil:
+		t.Proxy = http.ProxyURL(proxyURL)
+	}
+	return t
+}
@@ -0,0 +1,128 @@
+package upstream
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// LoadClientCert parses a client certificate for mutual TLS, either from a
+// PEM certificate chain and key or from a PKCS#12 (.p12/.pfx) bundle.
+func LoadClientCert(certPEM, keyPEM string, p12 []byte, password string) (*tls.Certificate, error) {
+	if len(p12) > 0 {
+		key, leaf, chain, err := pkcs12.DecodeChain(p12, password)
+		if err != nil {
+			return nil, fmt.Errorf("decode PKCS#12: %w", err)
+		}
+		cert := &tls.Certificate{PrivateKey: key, Leaf: leaf, Certificate: [][]byte{leaf.Raw}}
+		for _, c := range chain {
+			cert.Certificate = append(cert.Certificate, c.Raw)
+		}
+		return cert, nil
+	}
+	if certPEM == "" || keyPEM == "" {
+		return nil, errors.New("client certificate needs both a certificate and a key")
+	}
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("load client certificate: %w", err)
+	}
+	return &cert, nil
+}
+
+// LoadRootCAs returns the system roots plus every certificate in bundle, a
+// PEM file of one or more CA certificates.
+func LoadRootCAs(bundle string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	rest, n := []byte(bundle), 0
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse CA certificate: %w", err)
+		}
+		pool.AddCert(cert)
+		n++
+	}
+	if n == 0 {
+		return nil, errors.New("CA bundle holds no PEM certificates")
+	}
+	return pool, nil
+}
+
+// tlsConfig builds the client TLS settings for connections to host. An
+// empty host applies cfg without per-host exemptions.
+func tlsConfig(cfg Config, host string) *tls.Config {
+	tc := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify || matchHost(cfg.SkipVerifyHosts, host),
+		RootCAs:            cfg.RootCAs,
+	}
+	if cert := cfg.ClientCert; cert != nil {
+		tc.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert, nil
+		}
+	}
+	return tc
+}
+
+// hostTransport sends requests for hosts in skip through a transport that
+// doesn't verify certificates, and all others through one that does.
+// crypto/tls can only turn verification off for every host at once.
+type hostTransport struct {
+	verified, unverified *http.Transport
+	skip                 []string
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if matchHost(t.skip, req.URL.Hostname()) {
+		return t.unverified.RoundTrip(req)
+	}
+	return t.verified.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of both transports.
+func (t *hostTransport) CloseIdleConnections() {
+	t.verified.CloseIdleConnections()
+	t.unverified.CloseIdleConnections()
+}
+
+// matchHost reports whether host matches one of the globs, case-insensitively.
+func matchHost(globs []string, host string) bool {
+	if host == "" {
+		return false
+	}
+	host = strings.ToLower(host)
+	for _, g := range globs {
+		if ok, _ := path.Match(strings.ToLower(g), host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateHostGlobs checks SkipVerifyHosts patterns.
+func ValidateHostGlobs(globs []string) error {
+	for _, g := range globs {
+		if _, err := path.Match(g, ""); err != nil {
+			return fmt.Errorf("invalid host pattern %q: %w", g, err)
+		}
+	}
+	return nil
+}
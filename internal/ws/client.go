@@ -0,0 +1,151 @@
+package ws
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/0xDTC/0xGQLForge/internal/upstream"
+)
+
+// Conn is a client WebSocket connection.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	wmu         sync.Mutex
+	asm         *Assembler
+	Subprotocol string // negotiated subprotocol, if any
+}
+
+// Dial opens a WebSocket connection to rawURL (ws, wss, http, or https),
+// sending header with the upgrade request and offering subprotocols.
+func Dial(ctx context.Context, rawURL string, header http.Header, subprotocols []string, cfg upstream.Config) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse URL: %w", err)
+	}
+	switch u.Scheme {
+	case "ws", "http":
+		u.Scheme = "http"
+	case "wss", "https":
+		u.Scheme = "https"
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	conn, err := upstream.Dial(ctx, cfg, u)
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+	// Bound the handshake by ctx; the stream itself has no deadline.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var keyBytes [16]byte
+	if _, err := rand.Read(keyBytes[:]); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes[:])
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	for name, values := range header {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	if host := header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Del("Sec-WebSocket-Extensions") // no compression
+	if len(subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(subprotocols, ", "))
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("write handshake: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("read handshake: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		conn.Close()
+		return nil, fmt.Errorf("handshake failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != AcceptKey(key) {
+		conn.Close()
+		return nil, fmt.Errorf("handshake failed: bad Sec-WebSocket-Accept")
+	}
+
+	return &Conn{
+		conn:        conn,
+		br:          br,
+		asm:         NewAssembler(MaxFrameSize),
+		Subprotocol: resp.Header.Get("Sec-WebSocket-Protocol"),
+	}, nil
+}
+
+// WriteText sends a text message.
+func (c *Conn) WriteText(p []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return WriteFrame(c.conn, OpText, p, true)
+}
+
+// ReadMessage returns the next text or binary message. Pings are answered
+// automatically. A close frame from the server returns io.EOF.
+func (c *Conn) ReadMessage() (opcode byte, payload []byte, err error) {
+	for {
+		f, _, err := ReadFrame(c.br)
+		if err != nil {
+			return 0, nil, err
+		}
+		switch f.Opcode {
+		case OpPing:
+			c.wmu.Lock()
+			err := WriteFrame(c.conn, OpPong, f.Payload, true)
+			c.wmu.Unlock()
+			if err != nil {
+				return 0, nil, err
+			}
+		case OpPong:
+		case OpClose:
+			c.Close()
+			return 0, nil, io.EOF
+		default:
+			if op, msg, ok := c.asm.Add(f); ok {
+				return op, msg, nil
+			}
+		}
+	}
+}
+
+// Close sends a normal closure frame and closes the connection.
+func (c *Conn) Close() error {
+	c.wmu.Lock()
+	WriteFrame(c.conn, OpClose, binary.BigEndian.AppendUint16(nil, 1000), true) //nolint:errcheck
+	c.wmu.Unlock()
+	return c.conn.Close()
+}
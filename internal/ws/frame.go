@@ -0,0 +1,199 @@
+// Package ws implements the parts of the WebSocket protocol (RFC 6455) the
+// proxy and the subscription runner need: frame parsing for interception and
+// a minimal client.
+package ws
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Frame opcodes.
+const (
+	OpContinuation byte = 0x0
+	OpText         byte = 0x1
+	OpBinary       byte = 0x2
+	OpClose        byte = 0x8
+	OpPing         byte = 0x9
+	OpPong         byte = 0xA
+)
+
+// MaxFrameSize bounds the payload of a single frame.
+const MaxFrameSize = 16 << 20
+
+// ErrFrameTooLarge is returned for frames above MaxFrameSize.
+var ErrFrameTooLarge = errors.New("websocket frame too large")
+
+// acceptGUID is the fixed key suffix from RFC 6455 section 1.3.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Frame is a single WebSocket frame with its payload unmasked.
+type Frame struct {
+	Fin     bool
+	Opcode  byte
+	Payload []byte
+}
+
+// IsControl reports whether f is a close, ping, or pong frame.
+func (f Frame) IsControl() bool {
+	return f.Opcode&0x8 != 0
+}
+
+// ReadFrame reads one frame from r. raw holds the frame exactly as it was
+// on the wire, mask included, so a proxy can forward it unchanged.
+func ReadFrame(r *bufio.Reader) (f Frame, raw []byte, err error) {
+	var header [14]byte
+	if _, err := io.ReadFull(r, header[:2]); err != nil {
+		return f, nil, err
+	}
+	f.Fin = header[0]&0x80 != 0
+	f.Opcode = header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	n := 2
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		if _, err := io.ReadFull(r, header[n:n+2]); err != nil {
+			return f, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(header[n : n+2]))
+		n += 2
+	case 127:
+		if _, err := io.ReadFull(r, header[n:n+8]); err != nil {
+			return f, nil, err
+		}
+		length = binary.BigEndian.Uint64(header[n : n+8])
+		n += 8
+	}
+	if length > MaxFrameSize {
+		return f, nil, ErrFrameTooLarge
+	}
+
+	var mask []byte
+	if masked {
+		if _, err := io.ReadFull(r, header[n:n+4]); err != nil {
+			return f, nil, err
+		}
+		mask = header[n : n+4]
+		n += 4
+	}
+
+	raw = make([]byte, n+int(length))
+	copy(raw, header[:n])
+	if _, err := io.ReadFull(r, raw[n:]); err != nil {
+		return f, nil, err
+	}
+
+	f.Payload = make([]byte, length)
+	copy(f.Payload, raw[n:])
+	if masked {
+		for i := range f.Payload {
+			f.Payload[i] ^= mask[i%4]
+		}
+	}
+	return f, raw, nil
+}
+
+// WriteFrame writes a final frame. Clients must mask every frame they send;
+// servers must not.
+func WriteFrame(w io.Writer, opcode byte, payload []byte, mask bool) error {
+	header := []byte{0x80 | opcode, 0}
+	length := len(payload)
+	switch {
+	case length < 126:
+		header[1] = byte(length)
+	case length <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	body := payload
+	if mask {
+		header[1] |= 0x80
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return fmt.Errorf("mask key: %w", err)
+		}
+		header = append(header, key[:]...)
+		body = make([]byte, length)
+		for i := range payload {
+			body[i] = payload[i] ^ key[i%4]
+		}
+	}
+
+	if _, err := w.Write(append(header, body...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AcceptKey returns the Sec-WebSocket-Accept value for a client key.
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// IsUpgrade reports whether req asks to switch to the WebSocket protocol.
+func IsUpgrade(req *http.Request) bool {
+	return headerHasToken(req.Header, "Connection", "upgrade") &&
+		strings.EqualFold(req.Header.Get("Upgrade"), "websocket")
+}
+
+// headerHasToken reports whether a comma-separated header contains token.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Assembler joins fragmented messages. Feed it data frames in order;
+// it returns the complete message once the final fragment arrives.
+type Assembler struct {
+	opcode  byte
+	buf     []byte
+	dropped bool
+	limit   int
+}
+
+// NewAssembler creates an Assembler that drops messages over limit bytes.
+func NewAssembler(limit int) *Assembler {
+	return &Assembler{limit: limit}
+}
+
+// Add feeds a data frame. ok is false until a message is complete, and for
+// messages that exceeded the limit.
+func (a *Assembler) Add(f Frame) (opcode byte, payload []byte, ok bool) {
+	if f.Opcode != OpContinuation {
+		a.opcode, a.buf, a.dropped = f.Opcode, a.buf[:0], false
+	}
+	if !a.dropped {
+		if len(a.buf)+len(f.Payload) > a.limit {
+			a.dropped, a.buf = true, a.buf[:0]
+		} else {
+			a.buf = append(a.buf, f.Payload...)
+		}
+	}
+	if !f.Fin || a.dropped {
+		return 0, nil, false
+	}
+	msg := append([]byte(nil), a.buf...)
+	a.buf = a.buf[:0]
+	return a.opcode, msg, true
+}
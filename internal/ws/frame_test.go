@@ -0,0 +1,162 @@
+package ws
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		opcode  byte
+		payload []byte
+		header  int // header bytes before the mask key
+	}{
+		{name: "empty", opcode: OpText, payload: nil, header: 2},
+		{name: "short text", opcode: OpText, payload: []byte(`{"type":"connection_init"}`), header: 2},
+		{name: "125 bytes", opcode: OpBinary, payload: bytes.Repeat([]byte{0xAB}, 125), header: 2},
+		{name: "16-bit length", opcode: OpBinary, payload: bytes.Repeat([]byte{1}, 126), header: 4},
+		{name: "16-bit max", opcode: OpText, payload: bytes.Repeat([]byte("x"), 0xFFFF), header: 4},
+		{name: "64-bit length", opcode: OpBinary, payload: bytes.Repeat([]byte{2}, 0x10000), header: 10},
+		{name: "ping", opcode: OpPing, payload: []byte("hi"), header: 2},
+	}
+	for _, tt := range tests {
+		for _, mask := range []bool{false, true} {
+			var buf bytes.Buffer
+			if err := WriteFrame(&buf, tt.opcode, tt.payload, mask); err != nil {
+				t.Fatalf("%s: WriteFrame: %v", tt.name, err)
+			}
+			wire := append([]byte(nil), buf.Bytes()...)
+			wantLen := tt.header + len(tt.payload)
+			if mask {
+				wantLen += 4
+			}
+			if len(wire) != wantLen {
+				t.Errorf("%s (mask %v): %d bytes on the wire, want %d", tt.name, mask, len(wire), wantLen)
+			}
+			if masked := wire[1]&0x80 != 0; masked != mask {
+				t.Errorf("%s: mask bit %v, want %v", tt.name, masked, mask)
+			}
+
+			f, raw, err := ReadFrame(bufio.NewReader(&buf))
+			if err != nil {
+				t.Fatalf("%s (mask %v): ReadFrame: %v", tt.name, mask, err)
+			}
+			if !f.Fin || f.Opcode != tt.opcode || !bytes.Equal(f.Payload, tt.payload) {
+				t.Errorf("%s (mask %v): got fin=%v op=%x %d bytes", tt.name, mask, f.Fin, f.Opcode, len(f.Payload))
+			}
+			if !bytes.Equal(raw, wire) {
+				t.Errorf("%s (mask %v): raw frame differs from the bytes written", tt.name, mask)
+			}
+			if f.IsControl() != (tt.opcode == OpPing) {
+				t.Errorf("%s: IsControl = %v", tt.name, f.IsControl())
+			}
+		}
+	}
+}
+
+func TestReadFrameMasked(t *testing.T) {
+	// The masked "Hello" example from RFC 6455 section 5.7.
+	wire := []byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58}
+	f, raw, err := ReadFrame(bufio.NewReader(bytes.NewReader(wire)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(f.Payload) != "Hello" || f.Opcode != OpText || !f.Fin {
+		t.Errorf("got %+v", f)
+	}
+	if !bytes.Equal(raw, wire) {
+		t.Errorf("raw = % x, want % x", raw, wire)
+	}
+
+	// A fragment: FIN clear, unmasked "Hel".
+	f, _, err = ReadFrame(bufio.NewReader(bytes.NewReader([]byte{0x01, 0x03, 0x48, 0x65, 0x6c})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Fin || string(f.Payload) != "Hel" {
+		t.Errorf("fragment = %+v", f)
+	}
+}
+
+func TestReadFrameErrors(t *testing.T) {
+	tooLarge := []byte{0x82, 127}
+	tooLarge = binary.BigEndian.AppendUint64(tooLarge, MaxFrameSize+1)
+	tests := []struct {
+		name string
+		wire []byte
+		err  error
+	}{
+		{name: "empty", wire: nil, err: io.EOF},
+		{name: "truncated header", wire: []byte{0x81}, err: io.ErrUnexpectedEOF},
+		{name: "truncated length", wire: []byte{0x81, 126, 0x01}, err: io.ErrUnexpectedEOF},
+		{name: "truncated mask", wire: []byte{0x81, 0x81, 1, 2}, err: io.ErrUnexpectedEOF},
+		{name: "truncated payload", wire: []byte{0x81, 0x05, 'a', 'b'}, err: io.ErrUnexpectedEOF},
+		{name: "too large", wire: tooLarge, err: ErrFrameTooLarge},
+	}
+	for _, tt := range tests {
+		_, _, err := ReadFrame(bufio.NewReader(bytes.NewReader(tt.wire)))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestAssembler(t *testing.T) {
+	a := NewAssembler(8)
+	steps := []struct {
+		frame  Frame
+		ok     bool
+		opcode byte
+		msg    string
+	}{
+		{Frame{Fin: true, Opcode: OpText, Payload: []byte("one")}, true, OpText, "one"},
+		{Frame{Fin: false, Opcode: OpBinary, Payload: []byte("ab")}, false, 0, ""},
+		{Frame{Fin: false, Opcode: OpContinuation, Payload: []byte("cd")}, false, 0, ""},
+		{Frame{Fin: true, Opcode: OpContinuation, Payload: []byte("ef")}, true, OpBinary, "abcdef"},
+		// Over the limit: dropped, including its continuation.
+		{Frame{Fin: false, Opcode: OpText, Payload: []byte("12345")}, false, 0, ""},
+		{Frame{Fin: true, Opcode: OpContinuation, Payload: []byte("6789")}, false, 0, ""},
+		// The next message starts clean.
+		{Frame{Fin: true, Opcode: OpText, Payload: []byte("two")}, true, OpText, "two"},
+	}
+	for i, s := range steps {
+		op, msg, ok := a.Add(s.frame)
+		if ok != s.ok || op != s.opcode || string(msg) != s.msg {
+			t.Errorf("step %d: got (%x, %q, %v), want (%x, %q, %v)", i, op, msg, ok, s.opcode, s.msg, s.ok)
+		}
+	}
+}
+
+func TestAcceptKey(t *testing.T) {
+	// The example from RFC 6455 section 1.3.
+	if got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("AcceptKey = %q", got)
+	}
+}
+
+func TestIsUpgrade(t *testing.T) {
+	tests := []struct {
+		connection, upgrade string
+		want                bool
+	}{
+		{"Upgrade", "websocket", true},
+		{"keep-alive, Upgrade", "WebSocket", true},
+		{"keep-alive", "websocket", false},
+		{"Upgrade", "h2c", false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com/graphql", strings.NewReader(""))
+		req.Header.Set("Connection", tt.connection)
+		req.Header.Set("Upgrade", tt.upgrade)
+		if got := IsUpgrade(req); got != tt.want {
+			t.Errorf("IsUpgrade(%q, %q) = %v, want %v", tt.connection, tt.upgrade, got, tt.want)
+		}
+	}
+}